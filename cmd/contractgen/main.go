@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/xssnick/tonutils-go/ton/contractgen"
+)
+
+// contractgen - generates typed go client of the contract from json interface description.
+//
+// Usage:
+//
+//	contractgen -in counter.json -out counter/counter.go
+//
+// Or with go:generate directive:
+//
+//	//go:generate go run github.com/xssnick/tonutils-go/cmd/contractgen -in counter.json -out counter/counter.go
+func main() {
+	in := flag.String("in", "", "path to json contract description")
+	out := flag.String("out", "", "path to generated go file, stdout if not set")
+	flag.Parse()
+
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatalln("failed to read description:", err.Error())
+	}
+
+	contract, err := contractgen.ParseContract(data)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	src, err := contract.Generate(filepath.Base(*in))
+	if err != nil {
+		log.Fatalln("failed to generate:", err.Error())
+	}
+
+	if *out == "" {
+		_, _ = os.Stdout.Write(src)
+		return
+	}
+
+	if err = os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalln("failed to write result:", err.Error())
+	}
+}
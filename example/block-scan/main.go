@@ -74,7 +74,9 @@ func main() {
 	}
 
 	// TIP: you could save and store last trusted master block (master variable data)
-	// for faster initialization later using api.SetTrustedBlock
+	// for faster initialization later using api.SetTrustedBlock,
+	// or with ProofCheckPolicySecure use ton.NewFileLightClientStore and SetLightClientStore of the root client,
+	// then verified key blocks and the last trusted block will be kept between restarts
 
 	log.Println("master proofs chain successfully verified, all data is now safe and trusted!")
 
@@ -0,0 +1,205 @@
+// Code generated by contractgen from counter.json. DO NOT EDIT.
+
+package counter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+}
+
+var ErrUnknownOpcode = errors.New("unknown message opcode")
+
+// IncreasePayload - increases counter, can be sent only by owner
+type IncreasePayload struct {
+	_       tlb.Magic  `tlb:"#7e8764ef"`
+	QueryID uint64     `tlb:"## 64"`
+	Amount  uint32     `tlb:"## 32"`
+	Note    *cell.Cell `tlb:"maybe ^"`
+}
+
+type ChangeOwnerPayload struct {
+	_        tlb.Magic        `tlb:"#93b05b31"`
+	QueryID  uint64           `tlb:"## 64"`
+	NewOwner *address.Address `tlb:"addr"`
+}
+
+type GetCounterDataResult struct {
+	Value      uint64           `tvm:"int"`
+	Owner      *address.Address `tvm:"addr"`
+	LastAmount tlb.Coins        `tvm:"coins"`
+	Note       *cell.Cell       `tvm:"maybe cell"`
+}
+
+// Client - client of the simple counter contract, owner can increase counter value
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+func NewCounterClient(api TonApi, addr *address.Address) *Client {
+	return &Client{
+		addr: addr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// GetCounter - returns current counter value
+func (c *Client) GetCounter(ctx context.Context) (uint64, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetCounterAtBlock(ctx, b)
+}
+
+func (c *Client) GetCounterAtBlock(ctx context.Context, b *ton.BlockIDExt) (uint64, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_counter")
+	if err != nil {
+		return 0, fmt.Errorf("failed to run get_counter method: %w", err)
+	}
+
+	var result struct {
+		Value uint64 `tvm:"int"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode get_counter result: %w", err)
+	}
+	return result.Value, nil
+}
+
+// GetCounterData - returns counter value with its owner and the last increase amount
+func (c *Client) GetCounterData(ctx context.Context) (*GetCounterDataResult, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetCounterDataAtBlock(ctx, b)
+}
+
+func (c *Client) GetCounterDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*GetCounterDataResult, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_counter_data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_counter_data method: %w", err)
+	}
+
+	var result GetCounterDataResult
+	if err = res.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode get_counter_data result: %w", err)
+	}
+	return &result, nil
+}
+
+// GetValueFor - returns counter value owned by address
+func (c *Client) GetValueFor(ctx context.Context, owner *address.Address, shift *big.Int) (*big.Int, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetValueForAtBlock(ctx, owner, shift, b)
+}
+
+func (c *Client) GetValueForAtBlock(ctx context.Context, owner *address.Address, shift *big.Int, b *ton.BlockIDExt) (*big.Int, error) {
+	params, err := ton.EncodeParams(struct {
+		Owner *address.Address `tvm:"addr"`
+		Shift *big.Int         `tvm:"int"`
+	}{
+		Owner: owner,
+		Shift: shift,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode get_value_for params: %w", err)
+	}
+
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_value_for", params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_value_for method: %w", err)
+	}
+
+	var result struct {
+		Value *big.Int `tvm:"int"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode get_value_for result: %w", err)
+	}
+	return result.Value, nil
+}
+
+func (c *Client) BuildIncreasePayload(queryID uint64, amount uint32, note *cell.Cell) (*cell.Cell, error) {
+	body, err := tlb.ToCell(IncreasePayload{
+		QueryID: queryID,
+		Amount:  amount,
+		Note:    note,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert IncreasePayload to cell: %w", err)
+	}
+	return body, nil
+}
+
+func ParseIncreasePayload(body *cell.Cell) (*IncreasePayload, error) {
+	var p IncreasePayload
+	if err := tlb.LoadFromCell(&p, body.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse IncreasePayload: %w", err)
+	}
+	return &p, nil
+}
+
+func (c *Client) BuildChangeOwnerPayload(queryID uint64, newOwner *address.Address) (*cell.Cell, error) {
+	body, err := tlb.ToCell(ChangeOwnerPayload{
+		QueryID:  queryID,
+		NewOwner: newOwner,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert ChangeOwnerPayload to cell: %w", err)
+	}
+	return body, nil
+}
+
+func ParseChangeOwnerPayload(body *cell.Cell) (*ChangeOwnerPayload, error) {
+	var p ChangeOwnerPayload
+	if err := tlb.LoadFromCell(&p, body.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse ChangeOwnerPayload: %w", err)
+	}
+	return &p, nil
+}
+
+// ParseMessage - parses message body by its opcode, returns pointer to payload struct
+func ParseMessage(body *cell.Cell) (any, error) {
+	op, err := body.BeginParse().LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load opcode: %w", err)
+	}
+
+	switch op {
+	case 0x7e8764ef:
+		p, err := ParseIncreasePayload(body)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case 0x93b05b31:
+		p, err := ParseChangeOwnerPayload(body)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %x", ErrUnknownOpcode, op)
+}
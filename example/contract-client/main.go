@@ -0,0 +1,51 @@
+package main
+
+//go:generate go run ../../cmd/contractgen -in counter.json -out counter/counter.go
+
+import (
+	"context"
+	"log"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/example/contract-client/counter"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+func main() {
+	client := liteclient.NewConnectionPool()
+
+	// connect to testnet lite server
+	err := client.AddConnectionsFromConfigUrl(context.Background(), "https://ton-blockchain.github.io/testnet-global.config.json")
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := client.StickyContext(context.Background())
+
+	// initialize ton api lite connection wrapper
+	api := ton.NewAPIClient(client).WithRetry()
+
+	// client is generated from counter.json, run `go generate` after description changes
+	cnt := counter.NewCounterClient(api, address.MustParseAddr("EQBCFwW8uFUh-amdRmNY9NyeDEaeDYXd9ggJGsicpqVcHq7B"))
+
+	data, err := cnt.GetCounterData(ctx)
+	if err != nil {
+		log.Fatalln("get counter data err:", err.Error())
+		return
+	}
+	log.Println("value:", data.Value, "owner:", data.Owner.String(), "last amount:", data.LastAmount.String())
+
+	body, err := cnt.BuildIncreasePayload(1, 5, nil)
+	if err != nil {
+		log.Fatalln("build payload err:", err.Error())
+		return
+	}
+
+	msg, err := counter.ParseMessage(body)
+	if err != nil {
+		log.Fatalln("parse payload err:", err.Error())
+		return
+	}
+	log.Printf("message to send: %+v", msg)
+}
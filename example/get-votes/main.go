@@ -2,42 +2,49 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"github.com/xssnick/tonutils-go/address"
+	"log"
+
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/ton"
-	"log"
-	"os"
+	"github.com/xssnick/tonutils-go/ton/configcontract"
 )
 
 func main() {
 	client := liteclient.NewConnectionPool()
 
-	// connect to testnet lite server
+	// connect to mainnet lite servers
 	err := client.AddConnectionsFromConfigUrl(context.Background(), "https://ton.org/global.config.json")
 	if err != nil {
 		panic(err)
 	}
 
 	// initialize ton api lite connection wrapper
-	api := ton.NewAPIClient(client)
+	api := ton.NewAPIClient(client).WithRetry()
 
 	// if we want to route all requests to the same node, we can use it
 	ctx := client.StickyContext(context.Background())
 
-	b, err := api.LookupBlock(ctx, -1, -0x8000000000000000, 27531166)
+	addr, err := configcontract.ContractAddr(ctx, api)
 	if err != nil {
-		log.Fatalln("get block err:", err.Error())
+		log.Fatalln("get config contract address err:", err.Error())
 		return
 	}
 
-	addr := address.MustParseAddr("Ef9VVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVVbxn")
-
-	props, err := api.RunGetMethod(ctx, b, addr, "list_proposals")
+	proposals, err := configcontract.NewConfigClient(api, addr).GetProposals(ctx)
 	if err != nil {
 		log.Fatalln("get proposals err:", err.Error())
 		return
 	}
 
-	json.NewEncoder(os.Stdout).Encode(props.AsTuple())
+	for _, p := range proposals {
+		log.Printf("proposal %x: param %d, critical: %v, expires at: %d, rounds remaining: %d, wins: %d, losses: %d, voted weight: %d/%d",
+			p.Hash, p.ParamID, p.Critical, p.ExpiresAt, p.RoundsRemaining, p.Wins, p.Losses, p.WeightVoted, p.TotalWeight)
+
+		val, err := p.DecodedValue()
+		if err != nil {
+			log.Println("  failed to decode value:", err.Error())
+			continue
+		}
+		log.Printf("  new value: %+v", val)
+	}
 }
@@ -75,6 +75,10 @@ func main() {
 		// msgCell, _ := ext.ToCell()
 		// log.Println(base64.StdEncoding.EncodeToString(msgCell.ToBOC()))
 
+		// for the fully air-gapped flow, when private key is never on the online machine,
+		// use wallet.FromPublicKey with w.BuildUnsignedBundle, sign the bundle offline
+		// with bundle.Sign and broadcast it with signedBundle.Send
+
 		// send message to blockchain
 		err = api.SendExternalMessage(ctx, ext)
 		if err != nil {
@@ -45,6 +45,15 @@ type connection struct {
 
 	weight       int64
 	lastRespTime int64
+	inFlight     int64
+
+	health  nodeHealth
+	limiter rateLimiter
+
+	// closed when connection is dead, to reroute waiting requests
+	closed chan struct{}
+	// 1 when node was removed from pool by config sync
+	removed int32
 
 	pool *ConnectionPool
 }
@@ -139,6 +148,7 @@ func (c *ConnectionPool) AddConnection(ctx context.Context, addr, serverKey stri
 		serverKey:  serverKey,
 		connResult: make(chan error, 1),
 		reqs:       make(chan *ADNLRequest),
+		closed:     make(chan struct{}),
 		pool:       c,
 		id:         crc32.ChecksumIEEE([]byte(serverKey)),
 		weight:     1000,
@@ -150,7 +160,7 @@ func (c *ConnectionPool) AddConnection(ctx context.Context, addr, serverKey stri
 		till = time.Now().Add(60 * time.Second)
 	}
 
-	conn.tcp, err = net.DialTimeout("tcp", addr, till.Sub(time.Now()))
+	conn.tcp, err = (&net.Dialer{Deadline: till}).DialContext(ctx, "tcp", addr)
 	if err != nil {
 		return err
 	}
@@ -301,10 +311,19 @@ func (n *connection) listen(connResult chan<- error) {
 
 	// force close in case of error
 	_ = n.tcp.Close()
+	close(n.closed)
 
 	connResult <- err
 
 	if initialized {
+		n.pool.reqMx.RLock()
+		dis := n.pool.onDisconnect
+		n.pool.reqMx.RUnlock()
+
+		// node was removed from config or pool is stopped, no need to reconnect
+		reconnect := dis != nil && atomic.LoadInt32(&n.removed) == 0 && n.pool.globalCtx.Err() == nil
+		key := nodeKey(n.addr, n.serverKey)
+
 		// deactivate connection
 		n.pool.nodesMx.Lock()
 		for i := range n.pool.activeNodes {
@@ -314,19 +333,23 @@ func (n *connection) listen(connResult chan<- error) {
 				break
 			}
 		}
+		if reconnect {
+			// marked in the same lock, so config sync will not connect it in parallel
+			n.pool.reconnecting[key]++
+		}
 		n.pool.nodesMx.Unlock()
 
-		select {
-		case <-n.pool.globalCtx.Done():
-			return
-		default:
-			n.pool.reqMx.RLock()
-			dis := n.pool.onDisconnect
-			n.pool.reqMx.RUnlock()
-
-			if dis != nil {
-				go dis(n.addr, n.serverKey)
-			}
+		if reconnect {
+			go func() {
+				defer func() {
+					n.pool.nodesMx.Lock()
+					if n.pool.reconnecting[key]--; n.pool.reconnecting[key] <= 0 {
+						delete(n.pool.reconnecting, key)
+					}
+					n.pool.nodesMx.Unlock()
+				}()
+				dis(n.addr, n.serverKey)
+			}()
 		}
 	}
 }
@@ -521,6 +544,16 @@ func (n *connection) ping(qid int64) error {
 	return n.send(payload)
 }
 
+// sendQuery - sends request and accounts it as in flight, caller should decrement inFlight when done
+func (n *connection) sendQuery(req *ADNLRequest) error {
+	atomic.AddInt64(&n.inFlight, 1)
+	if _, err := n.queryAdnl(req.QueryID, req.Data); err != nil {
+		atomic.AddInt64(&n.inFlight, -1)
+		return err
+	}
+	return nil
+}
+
 func (n *connection) queryAdnl(qid []byte, req tl.Serializable) (string, error) {
 	payload, err := tl.Serialize(adnl.MessageQuery{
 		ID:   qid,
@@ -538,6 +571,11 @@ func (c *ConnectionPool) DefaultReconnect(waitBeforeReconnect time.Duration, max
 	var cb OnDisconnectCallback
 	cb = func(addr, key string) {
 		for {
+			if c.isRemoved(addr, key) {
+				// node is not in the config anymore
+				break
+			}
+
 			ctx, cancel := context.WithTimeout(context.Background(), 7*time.Second)
 			err := c.AddConnection(ctx, addr, key)
 			cancel()
@@ -0,0 +1,250 @@
+package liteclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+var ErrNoProbe = errors.New("health probe is not set")
+
+// QueryFunc - sends liteserver request to exact node
+type QueryFunc func(ctx context.Context, request tl.Serializable, result tl.Serializable) error
+
+// HealthProbe - should return the latest masterchain seqno known by node,
+// query is bound to the checked node. ton.MasterchainSeqnoProbe can be used as implementation.
+type HealthProbe func(ctx context.Context, query QueryFunc) (uint32, error)
+
+type HealthConfig struct {
+	// Probe - function to get the latest masterchain seqno of node, required
+	Probe HealthProbe
+	// Interval - how often nodes are checked, 5s by default
+	Interval time.Duration
+	// Timeout - timeout of one probe, 3s by default
+	Timeout time.Duration
+	// MaxLag - how many masterchain blocks node can be behind the best node to still receive traffic, 2 by default.
+	// Nodes are probed at different moments, so small lag is normal and should not exclude node.
+	MaxLag uint32
+	// MaxFailures - number of failed probes in a row after which node is considered unhealthy, 2 by default
+	MaxFailures int
+}
+
+// NodeStatus - health snapshot of the pool node, can be used for monitoring
+type NodeStatus struct {
+	ID        uint32 `json:"id"`
+	Addr      string `json:"addr"`
+	ServerKey string `json:"server_key"`
+
+	Healthy      bool          `json:"healthy"`
+	Weight       int64         `json:"weight"`
+	InFlight     int64         `json:"in_flight"`
+	LastRespTime time.Duration `json:"last_resp_time"`
+
+	MasterSeqno uint32    `json:"master_seqno"`
+	Lag         uint32    `json:"lag"`
+	CheckedAt   time.Time `json:"checked_at"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+type nodeHealth struct {
+	seqno     uint32
+	checkedAt time.Time
+	failures  int
+	err       error
+
+	// 1 when node is excluded from balancing
+	lagging int32
+
+	mx sync.RWMutex
+}
+
+type rateLimiter struct {
+	tokens  float64
+	updated time.Time
+	mx      sync.Mutex
+}
+
+// take - consumes one token if available, rate is requests per second
+func (l *rateLimiter) take(rate float64) bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := time.Now()
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+
+	if l.updated.IsZero() {
+		l.tokens = burst
+	} else {
+		l.tokens += now.Sub(l.updated).Seconds() * rate
+		if l.tokens > burst {
+			l.tokens = burst
+		}
+	}
+	l.updated = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// SetNodeLimits - limits concurrent requests and requests per second for each node,
+// 0 means no limit. When all nodes are at limit, requests wait for a free node until context deadline.
+func (c *ConnectionPool) SetNodeLimits(maxInFlight int, rateLimit float64) {
+	c.nodesMx.Lock()
+	c.maxInFlight = int64(maxInFlight)
+	c.rateLimit = rateLimit
+	c.nodesMx.Unlock()
+}
+
+// EnableHealthCheck - starts background probes of each node's masterchain seqno.
+// Nodes which are behind the best one by more than MaxLag blocks, or failing probes,
+// are excluded from balancing while there are healthy alternatives.
+func (c *ConnectionPool) EnableHealthCheck(cfg HealthConfig) error {
+	if cfg.Probe == nil {
+		return ErrNoProbe
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 3 * time.Second
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = 2
+	}
+	if cfg.MaxLag == 0 {
+		cfg.MaxLag = 2
+	}
+
+	c.nodesMx.Lock()
+	started := c.healthCfg != nil
+	c.healthCfg = &cfg
+	c.nodesMx.Unlock()
+
+	if !started {
+		go c.healthLoop()
+	}
+	return nil
+}
+
+// NodesStatus - returns health state of all active nodes
+func (c *ConnectionPool) NodesStatus() []NodeStatus {
+	c.nodesMx.RLock()
+	nodes := append([]*connection{}, c.activeNodes...)
+	c.nodesMx.RUnlock()
+
+	best := bestSeqno(nodes)
+
+	list := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		node.health.mx.RLock()
+		st := NodeStatus{
+			ID:           node.id,
+			Addr:         node.addr,
+			ServerKey:    node.serverKey,
+			Healthy:      atomic.LoadInt32(&node.health.lagging) == 0,
+			Weight:       atomic.LoadInt64(&node.weight),
+			InFlight:     atomic.LoadInt64(&node.inFlight),
+			LastRespTime: time.Duration(atomic.LoadInt64(&node.lastRespTime)),
+			MasterSeqno:  node.health.seqno,
+			CheckedAt:    node.health.checkedAt,
+			Failures:     node.health.failures,
+		}
+		if node.health.err != nil {
+			st.LastError = node.health.err.Error()
+		}
+		node.health.mx.RUnlock()
+
+		if best > st.MasterSeqno {
+			st.Lag = best - st.MasterSeqno
+		}
+		list = append(list, st)
+	}
+	return list
+}
+
+func (c *ConnectionPool) healthLoop() {
+	for {
+		c.nodesMx.RLock()
+		cfg := *c.healthCfg
+		nodes := append([]*connection{}, c.activeNodes...)
+		c.nodesMx.RUnlock()
+
+		c.checkNodes(nodes, cfg)
+
+		select {
+		case <-c.globalCtx.Done():
+			return
+		case <-time.After(cfg.Interval):
+		}
+	}
+}
+
+func (c *ConnectionPool) checkNodes(nodes []*connection, cfg HealthConfig) {
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *connection) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(c.globalCtx, cfg.Timeout)
+			defer cancel()
+
+			seqno, err := cfg.Probe(ctx, func(ctx context.Context, request tl.Serializable, result tl.Serializable) error {
+				return c.queryADNL(ctx, node, LiteServerQuery{Data: request}, result)
+			})
+
+			node.health.mx.Lock()
+			node.health.checkedAt = time.Now()
+			node.health.err = err
+			if err != nil {
+				node.health.failures++
+			} else {
+				node.health.failures = 0
+				node.health.seqno = seqno
+			}
+			node.health.mx.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	markLagging(nodes, cfg)
+}
+
+// markLagging - excludes nodes which are failing probes or behind the best one by more than MaxLag
+func markLagging(nodes []*connection, cfg HealthConfig) {
+	best := bestSeqno(nodes)
+	for _, node := range nodes {
+		node.health.mx.RLock()
+		healthy := node.health.failures < cfg.MaxFailures && node.health.seqno+cfg.MaxLag >= best
+		node.health.mx.RUnlock()
+
+		var lagging int32
+		if !healthy {
+			lagging = 1
+		}
+		atomic.StoreInt32(&node.health.lagging, lagging)
+	}
+}
+
+func bestSeqno(nodes []*connection) uint32 {
+	var best uint32
+	for _, node := range nodes {
+		node.health.mx.RLock()
+		if node.health.seqno > best {
+			best = node.health.seqno
+		}
+		node.health.mx.RUnlock()
+	}
+	return best
+}
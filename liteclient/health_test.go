@@ -0,0 +1,115 @@
+package liteclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConnectionPool_PickNodeHealth(t *testing.T) {
+	c := NewConnectionPool()
+	defer c.stop()
+
+	seqnos := map[uint32]uint32{1: 100, 2: 90, 3: 99}
+	nodes := []*connection{
+		{id: 1, weight: 1000},
+		{id: 2, weight: 2000},
+		{id: 3, weight: 1000},
+	}
+	c.activeNodes = nodes
+
+	node, ok := c.pickNode()
+	if !ok || node.id != 2 {
+		t.Fatal("heaviest node should be picked without health check")
+	}
+
+	failingProbe := func(ctx context.Context, query QueryFunc) (uint32, error) {
+		return 0, errors.New("failed")
+	}
+	c.checkNodes(nodes, HealthConfig{Probe: failingProbe, MaxFailures: 1})
+	for _, st := range c.NodesStatus() {
+		if st.Healthy {
+			t.Fatal("failed probes should mark node unhealthy")
+		}
+	}
+
+	for _, n := range nodes {
+		n.health.failures = 0
+		n.health.seqno = seqnos[n.id]
+	}
+	markLagging(nodes, HealthConfig{MaxLag: 2, MaxFailures: 1})
+
+	node, ok = c.pickNode()
+	if !ok || node.id == 2 {
+		t.Fatal("lagging node should not be picked")
+	}
+
+	for _, st := range c.NodesStatus() {
+		if st.ID == 2 && (st.Healthy || st.Lag != 10) {
+			t.Fatal("incorrect status of lagging node", st.Healthy, st.Lag)
+		}
+		if st.ID != 2 && !st.Healthy {
+			t.Fatal("node should be healthy", st.ID)
+		}
+	}
+
+	c.SetNodeLimits(1, 0)
+	nodes[0].inFlight = 1
+	node, _ = c.pickNode()
+	if node.id != 3 {
+		t.Fatal("node at concurrency limit should be skipped")
+	}
+
+	nodes[2].inFlight = 1
+	node, _ = c.pickNode()
+	if node.id != 2 {
+		t.Fatal("lagging node should be used when no other available")
+	}
+
+	nodes[1].inFlight = 1
+	node, ok = c.pickNode()
+	if !ok || node != nil {
+		t.Fatal("all nodes are busy, nothing should be picked")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var l rateLimiter
+	for i := 0; i < 5; i++ {
+		if !l.take(5) {
+			t.Fatal("burst should be allowed", i)
+		}
+	}
+	if l.take(5) {
+		t.Fatal("rate limit should be reached")
+	}
+}
+
+func TestConnectionPool_PickNodeRateLimited(t *testing.T) {
+	c := NewConnectionPool()
+	defer c.stop()
+
+	nodes := []*connection{
+		{id: 1, weight: 3000},
+		{id: 2, weight: 2000},
+		{id: 3, weight: 1000},
+	}
+	nodes[0].health.lagging = 1
+	c.activeNodes = nodes
+	c.SetNodeLimits(0, 1)
+
+	for _, id := range []uint32{2, 3, 1} {
+		node, ok := c.pickNode()
+		if !ok || node == nil || node.id != id {
+			t.Fatal("rate limited node should be replaced by the next best one", id)
+		}
+	}
+
+	if node, ok := c.pickNode(); !ok || node != nil {
+		t.Fatal("all nodes are out of rate, nothing should be picked")
+	}
+
+	if c.nodeAvailable(nodes[1]) {
+		t.Fatal("sticky node out of rate should not be available")
+	}
+}
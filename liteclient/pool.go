@@ -9,6 +9,7 @@ import (
 	"io"
 	mRand "math/rand"
 	"reflect"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -47,6 +48,15 @@ type ConnectionPool struct {
 
 	authKey ed25519.PrivateKey
 
+	healthCfg   *HealthConfig
+	maxInFlight int64
+	rateLimit   float64
+
+	// nodes of the last synced config, nil if sync was not used
+	syncedKeys map[string]bool
+	// nodes which are currently reconnected by disconnect callback
+	reconnecting map[string]int
+
 	globalCtx context.Context
 	stop      func()
 }
@@ -54,7 +64,8 @@ type ConnectionPool struct {
 // NewConnectionPool - ordinary pool to query liteserver
 func NewConnectionPool() *ConnectionPool {
 	c := &ConnectionPool{
-		activeReqs: map[string]*ADNLRequest{},
+		activeReqs:   map[string]*ADNLRequest{},
+		reconnecting: map[string]int{},
 	}
 
 	// default reconnect policy
@@ -88,9 +99,10 @@ func (c *ConnectionPool) StickyContext(ctx context.Context) context.Context {
 	var id uint32
 
 	c.nodesMx.RLock()
-	if len(c.activeNodes) > 0 {
+	nodes := healthyNodes(c.activeNodes)
+	if len(nodes) > 0 {
 		// pick random one
-		id = c.activeNodes[mRand.Uint32()%uint32(len(c.activeNodes))].id
+		id = nodes[mRand.Uint32()%uint32(len(nodes))].id
 	}
 	c.nodesMx.RUnlock()
 
@@ -148,6 +160,11 @@ func (c *ConnectionPool) QueryLiteserver(ctx context.Context, request tl.Seriali
 
 // QueryADNL - sends ADNL request to peer
 func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable, result tl.Serializable) error {
+	return c.queryADNL(ctx, nil, request, result)
+}
+
+// queryADNL - sends request to target node, or to balancer chosen one if target is nil
+func (c *ConnectionPool) queryADNL(ctx context.Context, target *connection, request tl.Serializable, result tl.Serializable) error {
 	id := make([]byte, 32)
 	_, err := io.ReadFull(rand.Reader, id)
 	if err != nil {
@@ -185,87 +202,205 @@ func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable,
 	tm := time.Now()
 
 	var node *connection
-	if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
-		node, err = c.querySticky(nodeID, req)
+	if target != nil {
+		// weight is returned on response, like for balanced requests
+		atomic.AddInt64(&target.weight, -1)
+		if err = target.sendQuery(req); err != nil {
+			return err
+		}
+		node = target
+	} else if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
+		node, err = c.querySticky(ctx, nodeID, req)
 		if err != nil {
 			return err
 		}
 	} else {
-		node, err = c.queryWithSmartBalancer(req)
+		node, err = c.queryWithSmartBalancer(ctx, req)
 		if err != nil {
 			return err
 		}
 	}
+	defer func() {
+		atomic.AddInt64(&node.inFlight, -1)
+	}()
 
-	// wait for response
-	select {
-	case resp := <-ch:
-		atomic.AddInt64(&node.weight, 1)
-		atomic.StoreInt64(&node.lastRespTime, int64(time.Since(tm)))
-
-		reflect.ValueOf(result).Elem().Set(reflect.ValueOf(resp.Data))
-		return nil
-	case <-ctx.Done():
-		if time.Since(tm) < 200*time.Millisecond {
-			// consider it as too short timeout to punish node
+	for {
+		// wait for response
+		select {
+		case resp := <-ch:
 			atomic.AddInt64(&node.weight, 1)
+			atomic.StoreInt64(&node.lastRespTime, int64(time.Since(tm)))
+
+			reflect.ValueOf(result).Elem().Set(reflect.ValueOf(resp.Data))
+			return nil
+		case <-node.closed:
+			if target != nil || len(ch) > 0 {
+				if target != nil {
+					return fmt.Errorf("connection to node %s was closed", node.addr)
+				}
+				// response is already received, it will be processed on next iteration
+				continue
+			}
+
+			// node is gone before answer, reroute request to another one
+			newNode, err := c.queryWithSmartBalancer(ctx, req)
+			if err != nil {
+				return fmt.Errorf("failed to reroute request from closed node %s: %w", node.addr, err)
+			}
+			atomic.AddInt64(&node.inFlight, -1)
+			node = newNode
+		case <-ctx.Done():
+			if time.Since(tm) < 200*time.Millisecond {
+				// consider it as too short timeout to punish node
+				atomic.AddInt64(&node.weight, 1)
+			}
+
+			if !hasDeadline {
+				return fmt.Errorf("%w, node %s", ErrADNLReqTimeout, node.addr)
+			}
+
+			return fmt.Errorf("deadline exceeded, node %s, err: %w", node.addr, ctx.Err())
+		}
+	}
+}
+
+func (c *ConnectionPool) querySticky(ctx context.Context, id uint32, req *ADNLRequest) (*connection, error) {
+	for {
+		var found, available bool
+
+		c.nodesMx.RLock()
+		for _, node := range c.activeNodes {
+			if node.id == id {
+				found = true
+				if available = c.nodeAvailable(node); !available {
+					break
+				}
+
+				atomic.AddInt64(&node.weight, -1)
+				err := node.sendQuery(req)
+				if err == nil {
+					c.nodesMx.RUnlock()
+					return node, nil
+				}
+				break
+			}
 		}
+		c.nodesMx.RUnlock()
 
-		if !hasDeadline {
-			return fmt.Errorf("%w, node %s", ErrADNLReqTimeout, node.addr)
+		if !found || available {
+			// fallback if bounded node is not available
+			return c.queryWithSmartBalancer(ctx, req)
 		}
 
-		return fmt.Errorf("deadline exceeded, node %s, err: %w", node.addr, ctx.Err())
+		// bounded node is at its limits, wait for it to keep the requests consistent
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("sticky node is busy: %w", ctx.Err())
+		case <-time.After(5 * time.Millisecond):
+		}
 	}
 }
 
-func (c *ConnectionPool) querySticky(id uint32, req *ADNLRequest) (*connection, error) {
-	c.nodesMx.RLock()
-	for _, node := range c.activeNodes {
-		if node.id == id {
-			atomic.AddInt64(&node.weight, -1)
-			_, err := node.queryAdnl(req.QueryID, req.Data)
-			if err == nil {
-				c.nodesMx.RUnlock()
-				return node, nil
+func (c *ConnectionPool) queryWithSmartBalancer(ctx context.Context, req *ADNLRequest) (*connection, error) {
+	for {
+		c.nodesMx.RLock()
+		reqNode, hasNodes := c.pickNode()
+		c.nodesMx.RUnlock()
+
+		if !hasNodes {
+			return nil, ErrNoActiveConnections
+		}
+
+		if reqNode == nil {
+			// all nodes are at their limits, wait for a free one
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("all nodes are busy: %w", ctx.Err())
+			case <-time.After(5 * time.Millisecond):
 			}
-			break
+			continue
 		}
-	}
-	c.nodesMx.RUnlock()
 
-	// fallback if bounded node is not available
-	return c.queryWithSmartBalancer(req)
+		atomic.AddInt64(&reqNode.weight, -1)
+
+		err := reqNode.sendQuery(req)
+		if err != nil {
+			return nil, err
+		}
+		return reqNode, nil
+	}
 }
 
-func (c *ConnectionPool) queryWithSmartBalancer(req *ADNLRequest) (*connection, error) {
-	var reqNode *connection
+// pickNode - chooses the best node which is not at its limits, healthy nodes are preferred.
+// When the best node is out of rate, the next one in the same order is tried.
+// Should be called under nodesMx lock.
+func (c *ConnectionPool) pickNode() (_ *connection, hasNodes bool) {
+	if len(c.activeNodes) == 0 {
+		return nil, false
+	}
+
+	type candidate struct {
+		node     *connection
+		healthy  bool
+		weight   int64
+		respTime int64
+	}
 
-	c.nodesMx.RLock()
+	list := make([]candidate, 0, len(c.activeNodes))
 	for _, node := range c.activeNodes {
-		if reqNode == nil {
-			reqNode = node
+		if c.maxInFlight > 0 && atomic.LoadInt64(&node.inFlight) >= c.maxInFlight {
 			continue
 		}
 
-		nw, old := atomic.LoadInt64(&node.weight), atomic.LoadInt64(&reqNode.weight)
-		if nw > old || (nw == old && atomic.LoadInt64(&node.lastRespTime) < atomic.LoadInt64(&reqNode.lastRespTime)) {
-			reqNode = node
+		list = append(list, candidate{
+			node:     node,
+			healthy:  atomic.LoadInt32(&node.health.lagging) == 0,
+			weight:   atomic.LoadInt64(&node.weight),
+			respTime: atomic.LoadInt64(&node.lastRespTime),
+		})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.healthy != b.healthy {
+			return a.healthy
+		}
+		if a.weight != b.weight {
+			return a.weight > b.weight
+		}
+		return a.respTime < b.respTime
+	})
+
+	for _, cand := range list {
+		if c.rateLimit <= 0 || cand.node.limiter.take(c.rateLimit) {
+			return cand.node, true
 		}
 	}
-	c.nodesMx.RUnlock()
+	return nil, true
+}
 
-	if reqNode == nil {
-		return nil, ErrNoActiveConnections
+// nodeAvailable - checks that node is not at its concurrency and rate limits, consumes rate token when available.
+// Should be called under nodesMx lock.
+func (c *ConnectionPool) nodeAvailable(node *connection) bool {
+	if c.maxInFlight > 0 && atomic.LoadInt64(&node.inFlight) >= c.maxInFlight {
+		return false
 	}
+	return c.rateLimit <= 0 || node.limiter.take(c.rateLimit)
+}
 
-	atomic.AddInt64(&reqNode.weight, -1)
+// healthyNodes - returns nodes which are not excluded by health check, or all nodes if there are no healthy ones
+func healthyNodes(nodes []*connection) []*connection {
+	var list []*connection
+	for _, node := range nodes {
+		if atomic.LoadInt32(&node.health.lagging) == 0 {
+			list = append(list, node)
+		}
+	}
 
-	_, err := reqNode.queryAdnl(req.QueryID, req.Data)
-	if err != nil {
-		return nil, err
+	if len(list) == 0 {
+		return nodes
 	}
-	return reqNode, nil
+	return list
 }
 
 func (c *ConnectionPool) SetOnDisconnect(cb OnDisconnectCallback) {
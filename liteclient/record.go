@@ -0,0 +1,240 @@
+package liteclient
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func init() {
+	tl.Register(RecordedQuery{}, "liteclient.recordedQuery request:bytes response:bytes = liteclient.RecordedQuery")
+}
+
+var ErrNotRecorded = errors.New("no recorded response for request")
+var ErrRecordsExhausted = errors.New("all recorded responses for request were already used")
+
+// waitMasterchainSeqno prefix id, its timeout depends on context deadline, so it is ignored on matching
+var waitMasterchainSeqnoID = tl.CRC("liteServer.waitMasterchainSeqno seqno:int timeout_ms:int = Object")
+
+type ReplayMode int
+
+const (
+	// ReplayStrict - request should be equal to recorded one, except timeout of waitMasterchainSeqno prefix,
+	// which depends on context deadline. Repeated requests are answered with recorded responses in the same order,
+	// ErrRecordsExhausted is returned when request is repeated more times than it was recorded.
+	ReplayStrict ReplayMode = iota
+	// ReplayLenient - when no exact match, the last recorded response for the same request type is used,
+	// waitMasterchainSeqno prefix is also ignored. When recorded responses are over, the last one is repeated.
+	ReplayLenient
+)
+
+// RecordedQuery - request and response pair, both are boxed TL-serialized
+type RecordedQuery struct {
+	Request  []byte `tl:"bytes"`
+	Response []byte `tl:"bytes"`
+}
+
+// Recorder - proxies queries to connection pool and writes every request and response pair to writer.
+// It implements the same interface as ConnectionPool, so it can be passed to ton.NewAPIClient.
+type Recorder struct {
+	pool *ConnectionPool
+	w    io.Writer
+	mx   sync.Mutex
+}
+
+// Replayer - answers queries with responses recorded by Recorder, without network access
+type Replayer struct {
+	mode     ReplayMode
+	exact    map[string][][]byte
+	stripped map[string][]byte
+	byType   map[uint32][]byte
+	pending  map[string]int
+	mx       sync.Mutex
+}
+
+// NewRecorder - creates recorder which writes queries of pool to w
+func NewRecorder(pool *ConnectionPool, w io.Writer) *Recorder {
+	return &Recorder{
+		pool: pool,
+		w:    w,
+	}
+}
+
+func (r *Recorder) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	req, err := tl.Serialize(payload, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	if err = r.pool.QueryLiteserver(ctx, payload, result); err != nil {
+		// transport errors are not recorded
+		return err
+	}
+
+	resp, err := tl.Serialize(reflect.ValueOf(result).Elem().Interface(), true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize response for record: %w", err)
+	}
+
+	data, err := tl.Serialize(RecordedQuery{Request: req, Response: resp}, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize record: %w", err)
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if _, err = r.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) StickyContext(ctx context.Context) context.Context {
+	return r.pool.StickyContext(ctx)
+}
+
+func (r *Recorder) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return r.pool.StickyContextNextNode(ctx)
+}
+
+func (r *Recorder) StickyNodeID(ctx context.Context) uint32 {
+	return r.pool.StickyNodeID(ctx)
+}
+
+// NewReplayer - loads records written by Recorder
+func NewReplayer(reader io.Reader, mode ReplayMode) (*Replayer, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+
+	r := &Replayer{
+		mode:     mode,
+		exact:    map[string][][]byte{},
+		stripped: map[string][]byte{},
+		byType:   map[uint32][]byte{},
+		pending:  map[string]int{},
+	}
+
+	for len(data) > 0 {
+		var rec RecordedQuery
+		data, err = tl.Parse(&rec, data, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse record: %w", err)
+		}
+
+		key := string(normalizeRequest(rec.Request))
+		r.exact[key] = append(r.exact[key], rec.Response)
+		r.stripped[string(stripWaitPrefix(rec.Request))] = rec.Response
+		if id, ok := requestTypeID(rec.Request); ok {
+			r.byType[id] = rec.Response
+		}
+	}
+	return r, nil
+}
+
+// NewReplayerFromFile - loads records from file written by Recorder
+func NewReplayerFromFile(path string, mode ReplayMode) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewReplayer(f, mode)
+}
+
+func (r *Replayer) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	req, err := tl.Serialize(payload, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	resp, err := r.find(req)
+	if err != nil {
+		return err
+	}
+
+	var v tl.Serializable
+	if _, err = tl.Parse(&v, resp, true); err != nil {
+		return fmt.Errorf("failed to parse recorded response: %w", err)
+	}
+
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(v))
+	return nil
+}
+
+func (r *Replayer) find(req []byte) ([]byte, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	key := string(normalizeRequest(req))
+	if list := r.exact[key]; len(list) > 0 {
+		i := r.pending[key]
+		if i >= len(list) {
+			if r.mode == ReplayStrict {
+				return nil, ErrRecordsExhausted
+			}
+			return list[len(list)-1], nil
+		}
+		r.pending[key] = i + 1
+		return list[i], nil
+	}
+
+	if r.mode == ReplayLenient {
+		if resp := r.stripped[string(stripWaitPrefix(req))]; resp != nil {
+			return resp, nil
+		}
+		if id, ok := requestTypeID(req); ok {
+			if resp := r.byType[id]; resp != nil {
+				return resp, nil
+			}
+		}
+	}
+	return nil, ErrNotRecorded
+}
+
+func (r *Replayer) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (r *Replayer) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (r *Replayer) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+// normalizeRequest - zeroes timeout of waitMasterchainSeqno prefix
+func normalizeRequest(req []byte) []byte {
+	if len(req) >= 12 && binary.LittleEndian.Uint32(req) == waitMasterchainSeqnoID {
+		req = append([]byte{}, req...)
+		binary.LittleEndian.PutUint32(req[8:], 0)
+	}
+	return req
+}
+
+func stripWaitPrefix(req []byte) []byte {
+	if len(req) >= 12 && binary.LittleEndian.Uint32(req) == waitMasterchainSeqnoID {
+		return req[12:]
+	}
+	return req
+}
+
+// requestTypeID - constructor id of the request, without waitMasterchainSeqno prefix
+func requestTypeID(req []byte) (uint32, bool) {
+	req = stripWaitPrefix(req)
+	if len(req) < 4 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(req), true
+}
@@ -0,0 +1,112 @@
+package liteclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func TestRecorder_Replay(t *testing.T) {
+	ls := startTestServer(t, 7)
+
+	pool := NewConnectionPool()
+	defer pool.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, fmt.Sprintf("127.0.0.1:%d", ls.Port), ls.ID.Key); err != nil {
+		t.Fatal("add connection err:", err)
+	}
+
+	buf := &bytes.Buffer{}
+	rec := NewRecorder(pool, buf)
+
+	for i := 0; i < 2; i++ {
+		var resp tl.Serializable
+		if err := rec.QueryLiteserver(ctx, GetMasterchainInf{}, &resp); err != nil {
+			t.Fatal("query err:", err)
+		}
+		if resp.(MasterchainInfo).Last.SeqNo != 7 {
+			t.Fatal("incorrect response")
+		}
+	}
+
+	// query with waitMasterchainSeqno prefix, timeout should be ignored on replay
+	waitReq := func(seqno, timeout uint32) tl.Serializable {
+		prefix := make([]byte, 12)
+		binary.LittleEndian.PutUint32(prefix, waitMasterchainSeqnoID)
+		binary.LittleEndian.PutUint32(prefix[4:], seqno)
+		binary.LittleEndian.PutUint32(prefix[8:], timeout)
+		data, _ := tl.Serialize(GetMasterchainInf{}, true)
+		return tl.Raw(append(prefix, data...))
+	}
+
+	req, _ := tl.Serialize(waitReq(5, 10000), true)
+	resp, _ := tl.Serialize(MasterchainInfo{
+		Last:          &BlockIDExt{Workchain: -1, SeqNo: 5, RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+		StateRootHash: make([]byte, 32),
+		Init:          &ZeroStateIDExt{RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+	}, true)
+	data, _ := tl.Serialize(RecordedQuery{Request: req, Response: resp}, true)
+	buf.Write(data)
+
+	strict, err := NewReplayer(bytes.NewReader(buf.Bytes()), ReplayStrict)
+	if err != nil {
+		t.Fatal("load err:", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		var res tl.Serializable
+		if err = strict.QueryLiteserver(ctx, GetMasterchainInf{}, &res); err != nil {
+			t.Fatal("replay err:", err)
+		}
+		if res.(MasterchainInfo).Last.SeqNo != 7 {
+			t.Fatal("incorrect replayed response")
+		}
+	}
+
+	var res tl.Serializable
+	if err = strict.QueryLiteserver(ctx, GetMasterchainInf{}, &res); !errors.Is(err, ErrRecordsExhausted) {
+		t.Fatal("strict replay should fail when records are over", err)
+	}
+
+	if err = strict.QueryLiteserver(ctx, waitReq(5, 3000), &res); err != nil {
+		t.Fatal("replay wait err:", err)
+	}
+	if res.(MasterchainInfo).Last.SeqNo != 5 {
+		t.Fatal("incorrect replayed wait response")
+	}
+
+	if err = strict.QueryLiteserver(ctx, waitReq(6, 3000), &res); !errors.Is(err, ErrNotRecorded) {
+		t.Fatal("should not be found in strict mode", err)
+	}
+
+	lenient, err := NewReplayer(bytes.NewReader(buf.Bytes()), ReplayLenient)
+	if err != nil {
+		t.Fatal("load err:", err)
+	}
+
+	if err = lenient.QueryLiteserver(ctx, waitReq(6, 3000), &res); err != nil {
+		t.Fatal("lenient replay err:", err)
+	}
+	if res.(MasterchainInfo).Last.SeqNo != 5 {
+		t.Fatal("incorrect lenient response")
+	}
+
+	for i := 0; i < 3; i++ {
+		if err = lenient.QueryLiteserver(ctx, GetMasterchainInf{}, &res); err != nil {
+			t.Fatal("lenient should repeat the last response", err)
+		}
+	}
+
+	if err = lenient.QueryLiteserver(ctx, TCPPing{RandomID: 1}, &res); !errors.Is(err, ErrNotRecorded) {
+		t.Fatal("unknown type should not be found", err)
+	}
+}
@@ -20,6 +20,7 @@ var Logger = log.Println
 type Server struct {
 	keys     map[string]ed25519.PrivateKey
 	listener net.Listener
+	mx       sync.Mutex
 
 	messageHandler func(ctx context.Context, client *ServerClient, msg tl.Serializable) error
 	disconnectHook func(client *ServerClient)
@@ -66,6 +67,9 @@ func (s *Server) SetConnectionHook(hook func(client *ServerClient) error) {
 }
 
 func (s *Server) Close() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
 	if s.listener != nil {
 		lis := s.listener
 		s.listener = nil
@@ -75,20 +79,28 @@ func (s *Server) Close() error {
 }
 
 func (s *Server) Listen(addr string) error {
+	s.mx.Lock()
 	if s.listener != nil {
+		s.mx.Unlock()
 		return fmt.Errorf("already started")
 	}
 
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
+		s.mx.Unlock()
 		return err
 	}
 	s.listener = listener
+	s.mx.Unlock()
 
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			if listener != s.listener {
+			s.mx.Lock()
+			closed := listener != s.listener
+			s.mx.Unlock()
+
+			if closed {
 				return nil
 			}
 
@@ -0,0 +1,223 @@
+package liteclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DrainTimeout - how long removed node waits for its in-flight requests before close,
+// requests which are still not answered after it will be rerouted to other nodes.
+var DrainTimeout = 10 * time.Second
+
+// ConfigLoader - returns fresh global config, used by WatchConfig
+type ConfigLoader func(ctx context.Context) (*GlobalConfig, error)
+
+// SyncResult - describes changes applied by SyncWithConfig
+type SyncResult struct {
+	Added   []string
+	Removed []string
+	Failed  map[string]error
+}
+
+func nodeKey(addr, serverKey string) string {
+	return addr + "/" + serverKey
+}
+
+// SyncWithConfig - makes pool nodes match liteservers list from config.
+// Nodes which are not in config anymore are gracefully drained and closed without reconnect,
+// new nodes are connected. Error is returned only when no active nodes left after sync.
+func (c *ConnectionPool) SyncWithConfig(ctx context.Context, config *GlobalConfig) (*SyncResult, error) {
+	select {
+	case <-c.globalCtx.Done():
+		return nil, ErrStopped
+	default:
+	}
+
+	if len(config.Liteservers) == 0 {
+		return nil, ErrNoConnections
+	}
+
+	wanted := map[string]LiteserverConfig{}
+	for _, ls := range config.Liteservers {
+		wanted[nodeKey(fmt.Sprintf("%s:%d", intToIP4(ls.IP), ls.Port), ls.ID.Key)] = ls
+	}
+
+	res := &SyncResult{
+		Failed: map[string]error{},
+	}
+
+	var toDrain []*connection
+	existing := map[string]bool{}
+
+	c.nodesMx.Lock()
+	c.syncedKeys = map[string]bool{}
+	for k := range wanted {
+		c.syncedKeys[k] = true
+	}
+
+	active := c.activeNodes[:0:0]
+	for _, node := range c.activeNodes {
+		key := nodeKey(node.addr, node.serverKey)
+		// duplicates can appear when node was reconnected in parallel with sync
+		if _, ok := wanted[key]; !ok || existing[key] {
+			toDrain = append(toDrain, node)
+			continue
+		}
+		existing[key] = true
+		active = append(active, node)
+	}
+	c.activeNodes = active
+
+	for key := range wanted {
+		if c.reconnecting[key] > 0 {
+			// disconnect callback is already connecting it
+			existing[key] = true
+		}
+	}
+	c.nodesMx.Unlock()
+
+	for _, node := range toDrain {
+		res.Removed = append(res.Removed, node.addr)
+		go node.drain()
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+	}
+
+	// connecting is stopped by both caller and pool stop
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.globalCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var mx sync.Mutex
+	for key, ls := range wanted {
+		if existing[key] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(ls LiteserverConfig) {
+			defer wg.Done()
+
+			addr := fmt.Sprintf("%s:%d", intToIP4(ls.IP), ls.Port)
+
+			err := c.AddConnection(ctx, addr, ls.ID.Key)
+
+			mx.Lock()
+			defer mx.Unlock()
+			if err != nil {
+				res.Failed[addr] = err
+				return
+			}
+			res.Added = append(res.Added, addr)
+		}(ls)
+	}
+	wg.Wait()
+
+	c.nodesMx.RLock()
+	num := len(c.activeNodes)
+	c.nodesMx.RUnlock()
+
+	if num == 0 {
+		return res, ErrNoActiveConnections
+	}
+	return res, nil
+}
+
+// WatchConfig - periodically loads config and syncs pool with it, until ctx or pool is stopped.
+// Config is applied only when its liteservers list was changed.
+func (c *ConnectionPool) WatchConfig(ctx context.Context, interval time.Duration, load ConfigLoader) {
+	go func() {
+		var last []LiteserverConfig
+		for {
+			cfg, err := load(ctx)
+			if err != nil {
+				Logger("failed to load liteservers config:", err.Error())
+			} else if !reflect.DeepEqual(last, cfg.Liteservers) || c.hasMissingNodes() {
+				syncCtx, cancel := context.WithTimeout(ctx, interval)
+				_, err = c.SyncWithConfig(syncCtx, cfg)
+				cancel()
+				if err != nil && !errors.Is(err, ErrStopped) {
+					Logger("failed to sync pool with liteservers config:", err.Error())
+				}
+				last = cfg.Liteservers
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.globalCtx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// WatchConfigFile - same as WatchConfig, but rereads config from file
+func (c *ConnectionPool) WatchConfigFile(ctx context.Context, interval time.Duration, configPath string) {
+	c.WatchConfig(ctx, interval, func(ctx context.Context) (*GlobalConfig, error) {
+		return GetConfigFromFile(configPath)
+	})
+}
+
+// WatchConfigUrl - same as WatchConfig, but downloads config from url
+func (c *ConnectionPool) WatchConfigUrl(ctx context.Context, interval time.Duration, configUrl string) {
+	c.WatchConfig(ctx, interval, func(ctx context.Context) (*GlobalConfig, error) {
+		return GetConfigFromUrl(ctx, configUrl)
+	})
+}
+
+// hasMissingNodes - true when some of synced config nodes are not connected
+func (c *ConnectionPool) hasMissingNodes() bool {
+	c.nodesMx.RLock()
+	defer c.nodesMx.RUnlock()
+
+	if c.syncedKeys == nil {
+		return false
+	}
+
+	connected := map[string]bool{}
+	for _, node := range c.activeNodes {
+		connected[nodeKey(node.addr, node.serverKey)] = true
+	}
+	return len(connected) < len(c.syncedKeys)
+}
+
+// isRemoved - true when node is not in the last synced config, so it should not be reconnected
+func (c *ConnectionPool) isRemoved(addr, serverKey string) bool {
+	c.nodesMx.RLock()
+	defer c.nodesMx.RUnlock()
+
+	return c.syncedKeys != nil && !c.syncedKeys[nodeKey(addr, serverKey)]
+}
+
+// drain - waits for in-flight requests to complete and closes connection without reconnect
+func (n *connection) drain() {
+	atomic.StoreInt32(&n.removed, 1)
+
+	till := time.Now().Add(DrainTimeout)
+	for atomic.LoadInt64(&n.inFlight) > 0 && time.Now().Before(till) {
+		select {
+		case <-n.pool.globalCtx.Done():
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	_ = n.tcp.Close()
+}
@@ -0,0 +1,177 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func startTestServer(t *testing.T, seqno uint32) LiteserverConfig {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	_ = lis.Close()
+
+	pub, key, _ := ed25519.GenerateKey(nil)
+	s := NewServer([]ed25519.PrivateKey{key})
+	s.SetMessageHandler(func(ctx context.Context, sc *ServerClient, msg tl.Serializable) error {
+		switch m := msg.(type) {
+		case adnl.MessageQuery:
+			if seqno == 0 {
+				// silent node
+				return nil
+			}
+			return sc.Send(adnl.MessageAnswer{ID: m.ID, Data: MasterchainInfo{
+				Last: &BlockIDExt{
+					Workchain: -1,
+					Shard:     -9223372036854775808,
+					SeqNo:     seqno,
+					RootHash:  make([]byte, 32),
+					FileHash:  make([]byte, 32),
+				},
+				StateRootHash: make([]byte, 32),
+				Init: &ZeroStateIDExt{
+					RootHash: make([]byte, 32),
+					FileHash: make([]byte, 32),
+				},
+			}})
+		case TCPPing:
+			return sc.Send(TCPPong{RandomID: m.RandomID})
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	go func() {
+		_ = s.Listen(lis.Addr().String())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	ls := LiteserverConfig{
+		IP:   2130706433, // 127.0.0.1
+		Port: port,
+	}
+	ls.ID.Key = base64.StdEncoding.EncodeToString(pub)
+	return ls
+}
+
+func querySeqno(ctx context.Context, c *ConnectionPool) (uint32, error) {
+	var resp tl.Serializable
+	if err := c.QueryLiteserver(ctx, GetMasterchainInf{}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.(MasterchainInfo).Last.SeqNo, nil
+}
+
+func TestConnectionPool_SyncWithConfig(t *testing.T) {
+	oldTimeout := DrainTimeout
+	DrainTimeout = 200 * time.Millisecond
+	defer func() {
+		DrainTimeout = oldTimeout
+	}()
+
+	silent := startTestServer(t, 0)
+	nodeB := startTestServer(t, 7)
+
+	c := NewConnectionPool()
+	defer c.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := c.SyncWithConfig(ctx, &GlobalConfig{Liteservers: []LiteserverConfig{silent}})
+	if err != nil {
+		t.Fatal("sync err:", err)
+	}
+	if len(res.Added) != 1 || len(res.Removed) != 0 {
+		t.Fatal("incorrect sync result", res.Added, res.Removed)
+	}
+
+	type result struct {
+		seqno uint32
+		err   error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		seqno, err := querySeqno(ctx, c)
+		waiter <- result{seqno, err}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	res, err = c.SyncWithConfig(ctx, &GlobalConfig{Liteservers: []LiteserverConfig{nodeB}})
+	if err != nil {
+		t.Fatal("sync err:", err)
+	}
+	if len(res.Added) != 1 || len(res.Removed) != 1 {
+		t.Fatal("incorrect sync result", res.Added, res.Removed)
+	}
+
+	// request to silent node should be rerouted after drain
+	r := <-waiter
+	if r.err != nil {
+		t.Fatal("rerouted query err:", r.err)
+	}
+	if r.seqno != 7 {
+		t.Fatal("incorrect seqno", r.seqno)
+	}
+
+	// no reconnects to removed node expected
+	time.Sleep(500 * time.Millisecond)
+	for _, st := range c.NodesStatus() {
+		if st.ServerKey != nodeB.ID.Key {
+			t.Fatal("removed node is still active")
+		}
+	}
+
+	res, err = c.SyncWithConfig(ctx, &GlobalConfig{Liteservers: []LiteserverConfig{nodeB}})
+	if err != nil {
+		t.Fatal("sync err:", err)
+	}
+	if len(res.Added) != 0 || len(res.Removed) != 0 {
+		t.Fatal("nothing should be changed", res.Added, res.Removed)
+	}
+}
+
+func TestConnectionPool_SyncWithConfigSkip(t *testing.T) {
+	nodeA := startTestServer(t, 7)
+	nodeB := startTestServer(t, 8)
+
+	c := NewConnectionPool()
+	defer c.Stop()
+
+	// node is reconnected by disconnect callback, sync should not connect it twice
+	keyA := nodeKey("127.0.0.1:"+strconv.Itoa(nodeA.Port), nodeA.ID.Key)
+	c.reconnecting[keyA] = 1
+
+	res, err := c.SyncWithConfig(context.Background(), &GlobalConfig{Liteservers: []LiteserverConfig{nodeA, nodeB}})
+	if err != nil {
+		t.Fatal("sync err:", err)
+	}
+	if len(res.Added) != 1 || res.Added[0] != "127.0.0.1:"+strconv.Itoa(nodeB.Port) {
+		t.Fatal("reconnecting node should be skipped", res.Added)
+	}
+
+	// cancelled caller context should stop connecting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	delete(c.reconnecting, keyA)
+	res, err = c.SyncWithConfig(ctx, &GlobalConfig{Liteservers: []LiteserverConfig{nodeA, nodeB}})
+	if err != nil {
+		t.Fatal("sync err:", err)
+	}
+	if len(res.Added) != 0 || len(res.Failed) != 1 {
+		t.Fatal("node should not be connected with cancelled context", res.Added, res.Failed)
+	}
+}
@@ -235,31 +235,44 @@ func loadBlkPrevInfo(loader *cell.Slice, afterMerge bool) (*BlkPrevInfo, error)
 }
 
 func ConvertShardIdentToShard(si ShardIdent) (workchain int32, shard uint64) {
-	shard = si.ShardPrefix
-	pow2 := uint64(1) << (63 - si.PrefixBits)
-	shard |= pow2
-	return si.WorkchainID, shard
+	return si.WorkchainID, ShardFromPrefix(si.ShardPrefix, uint8(si.PrefixBits))
 }
 
-func shardChild(shard uint64, left bool) uint64 {
-	x := lowerBit64(shard) >> 1
+// ShardFromPrefix - builds shard id from the prefixLen high bits of prefix:
+// prefix bits, then a single 1 bit tag, then zeroes. Other bits of prefix are ignored.
+func ShardFromPrefix(prefix uint64, prefixLen uint8) uint64 {
+	tag := uint64(1) << (63 - prefixLen)
+	return prefix&^(tag|(tag-1)) | tag
+}
+
+// ShardChild - left or right child of the shard after split
+func ShardChild(shard uint64, left bool) uint64 {
+	x := shardLowerBit(shard) >> 1
 	if left {
 		return shard - x
 	}
 	return shard + x
 }
 
-func shardParent(shard uint64) uint64 {
-	x := lowerBit64(shard)
+// ShardParent - shard which was split into this one
+func ShardParent(shard uint64) uint64 {
+	x := shardLowerBit(shard)
 	return (shard - x) | (x << 1)
 }
 
-func lowerBit64(x uint64) uint64 {
-	return x & bitsNegate64(x)
+// ShardContains - checks that bits of id above the shard tag bit are equal to the shard prefix,
+// id can be the first 64 bits of account id, or id of the other shard
+func ShardContains(shard, id uint64) bool {
+	return (shard^id)&(-shardLowerBit(shard)<<1) == 0
+}
+
+// ShardIsAncestor - checks that shard is the same as other or contains it, so other was split from it
+func ShardIsAncestor(shard, other uint64) bool {
+	return shardLowerBit(shard) >= shardLowerBit(other) && ShardContains(shard, other)
 }
 
-func bitsNegate64(x uint64) uint64 {
-	return ^x + 1
+func shardLowerBit(x uint64) uint64 {
+	return x & -x
 }
 
 func (h *BlockHeader) GetParentBlocks() ([]*BlockInfo, error) {
@@ -280,7 +293,7 @@ func (h *BlockHeader) GetParentBlocks() ([]*BlockInfo, error) {
 			SeqNo:     h.PrevRef.Prev1.SeqNo,
 			RootHash:  h.PrevRef.Prev1.RootHash,
 			FileHash:  h.PrevRef.Prev1.FileHash,
-			Shard:     int64(shardParent(shard)),
+			Shard:     int64(ShardParent(shard)),
 		}}, nil
 	}
 
@@ -292,14 +305,14 @@ func (h *BlockHeader) GetParentBlocks() ([]*BlockInfo, error) {
 		SeqNo:     h.PrevRef.Prev1.SeqNo,
 		RootHash:  h.PrevRef.Prev1.RootHash,
 		FileHash:  h.PrevRef.Prev1.FileHash,
-		Shard:     int64(shardChild(shard, true)),
+		Shard:     int64(ShardChild(shard, true)),
 	})
 	parents = append(parents, &BlockInfo{
 		Workchain: workchain,
 		SeqNo:     h.PrevRef.Prev2.SeqNo,
 		RootHash:  h.PrevRef.Prev2.RootHash,
 		FileHash:  h.PrevRef.Prev2.FileHash,
-		Shard:     int64(shardChild(shard, false)),
+		Shard:     int64(ShardChild(shard, false)),
 	})
 	return parents, nil
 }
@@ -39,3 +39,27 @@ func TestBlockNotMaster(t *testing.T) {
 
 	println(len(parents))
 }
+
+func TestShardMath(t *testing.T) {
+	const full = uint64(1) << 63
+
+	left, right := ShardChild(full, true), ShardChild(full, false)
+	if left != 0x4000000000000000 || right != 0xc000000000000000 {
+		t.Fatalf("incorrect children %x %x", left, right)
+	}
+	if ShardParent(left) != full || ShardParent(ShardChild(right, true)) != right {
+		t.Fatal("incorrect parent")
+	}
+
+	if ShardFromPrefix(0xffffffffffffffff, 2) != 0xe000000000000000 || ShardFromPrefix(0x1234, 0) != full {
+		t.Fatal("incorrect shard from prefix")
+	}
+
+	if !ShardContains(right, 0x8000000000000001) || ShardContains(right, 0x7fffffffffffffff) || !ShardContains(full, 0) {
+		t.Fatal("incorrect contains")
+	}
+
+	if !ShardIsAncestor(full, right) || !ShardIsAncestor(right, right) || ShardIsAncestor(right, full) || ShardIsAncestor(left, right) {
+		t.Fatal("incorrect ancestor")
+	}
+}
@@ -1,6 +1,10 @@
 package tlb
 
-import "github.com/xssnick/tonutils-go/tvm/cell"
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
 
 func init() {
 	Register(ValidatorSet{})
@@ -56,6 +60,92 @@ type SigPubKeyED25519 struct {
 	Key []byte `tlb:"bits 256"`
 }
 
+// ValidatorDescr - validator of the set, ADNLAddr is nil when set has no addresses
+type ValidatorDescr struct {
+	Index     uint16
+	PublicKey []byte
+	Weight    uint64
+	ADNLAddr  []byte
+}
+
+// ValidatorsList - returns all validators of the set ordered by index, and their total weight
+func (s *ValidatorSetAny) ValidatorsList() ([]*ValidatorDescr, uint64, error) {
+	var list *cell.Dictionary
+	var definedWeight *uint64
+	switch t := s.Validators.(type) {
+	case ValidatorSet:
+		list = t.List
+	case ValidatorSetExt:
+		list = t.List
+		definedWeight = &t.TotalWeight
+	default:
+		return nil, 0, fmt.Errorf("unknown validator set type")
+	}
+
+	kvs, err := list.LoadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load validators list dict: %w", err)
+	}
+
+	var totalWeight uint64
+	res := make([]*ValidatorDescr, 0, len(kvs))
+	for _, kv := range kvs {
+		idx, err := kv.Key.LoadUInt(16)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse validator index: %w", err)
+		}
+
+		tag, err := kv.Value.Copy().LoadUInt(8)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse validator %d: %w", idx, err)
+		}
+
+		v := &ValidatorDescr{Index: uint16(idx)}
+		switch tag {
+		case 0x53:
+			var val Validator
+			if err = LoadFromCell(&val, kv.Value); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse validator %d: %w", idx, err)
+			}
+			v.PublicKey, v.Weight = val.PublicKey.Key, val.Weight
+		default:
+			var val ValidatorAddr
+			if err = LoadFromCell(&val, kv.Value); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse validator %d: %w", idx, err)
+			}
+			v.PublicKey, v.Weight, v.ADNLAddr = val.PublicKey.Key, val.Weight, val.ADNLAddr
+		}
+
+		totalWeight += v.Weight
+		res = append(res, v)
+	}
+
+	if definedWeight != nil && totalWeight != *definedWeight {
+		return nil, 0, fmt.Errorf("incorrect sum of weights")
+	}
+
+	// dict keys are ordered as bit strings, so it is the same as numeric order
+	return res, totalWeight, nil
+}
+
+type ConfigVotingSetup struct {
+	_              Magic               `tlb:"#91"`
+	NormalParams   ConfigProposalSetup `tlb:"^"`
+	CriticalParams ConfigProposalSetup `tlb:"^"`
+}
+
+type ConfigProposalSetup struct {
+	_            Magic  `tlb:"#36"`
+	MinTotRounds uint8  `tlb:"## 8"`
+	MaxTotRounds uint8  `tlb:"## 8"`
+	MinWins      uint8  `tlb:"## 8"`
+	MaxLosses    uint8  `tlb:"## 8"`
+	MinStoreSec  uint32 `tlb:"## 32"`
+	MaxStoreSec  uint32 `tlb:"## 32"`
+	BitPrice     uint32 `tlb:"## 32"`
+	CellPrice    uint32 `tlb:"## 32"`
+}
+
 type CatchainConfig struct {
 	Config any `tlb:"[CatchainConfigV1,CatchainConfigV2]"`
 }
@@ -103,6 +103,26 @@ func (m *ExternalMessage) Payload() *cell.Cell {
 	return m.Body
 }
 
+// NormalizedHash - hash of the message with empty source address, zero import fee, without state init
+// and with body stored in ref. It does not depend on the way message was serialized by sender,
+// so it can be used to identify message and to find it in transactions.
+func (m *ExternalMessage) NormalizedHash() []byte {
+	body := m.Body
+	if body == nil {
+		body = cell.BeginCell().EndCell()
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(0b10, 2). // ext_in_msg_info
+		MustStoreUInt(0b00, 2). // addr_none
+		MustStoreAddr(m.DstAddr).
+		MustStoreCoins(0).
+		MustStoreBoolBit(false). // no state init
+		MustStoreBoolBit(true).  // body in ref
+		MustStoreRef(body).
+		EndCell().Hash()
+}
+
 func (m *ExternalMessage) SenderAddr() *address.Address {
 	return m.SrcAddr
 }
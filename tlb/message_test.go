@@ -150,3 +150,42 @@ func TestMessage_LoadFromCell(t *testing.T) {
 		}
 	})
 }
+
+func TestExternalMessage_NormalizedHash(t *testing.T) {
+	dst := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	body := cell.BeginCell().MustStoreUInt(777, 64).EndCell()
+
+	msg := &ExternalMessage{
+		DstAddr: dst,
+		Body:    body,
+	}
+
+	other := &ExternalMessage{
+		SrcAddr:   address.NewAddressExt(0, 8, []byte{0xAA}),
+		DstAddr:   dst,
+		ImportFee: MustFromTON("0.1"),
+		StateInit: &StateInit{Code: cell.BeginCell().EndCell(), Data: cell.BeginCell().EndCell()},
+		Body:      body,
+	}
+
+	if !bytes.Equal(msg.NormalizedHash(), other.NormalizedHash()) {
+		t.Fatal("normalized hash should not depend on src, import fee and state init")
+	}
+
+	// body stored inline is loaded as the same cell
+	c := cell.BeginCell().MustStoreUInt(0b10, 2).MustStoreUInt(0, 2).MustStoreAddr(dst).MustStoreCoins(0).
+		MustStoreBoolBit(false).MustStoreBoolBit(false).MustStoreBuilder(body.ToBuilder()).EndCell()
+
+	var inline ExternalMessage
+	if err := LoadFromCell(&inline, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msg.NormalizedHash(), inline.NormalizedHash()) {
+		t.Fatal("normalized hash should not depend on body layout")
+	}
+
+	other.Body = cell.BeginCell().MustStoreUInt(778, 64).EndCell()
+	if bytes.Equal(msg.NormalizedHash(), other.NormalizedHash()) {
+		t.Fatal("normalized hash should depend on body")
+	}
+}
@@ -59,8 +59,73 @@ type McStateExtraBlockInfo struct {
 	Flags            uint16           `tlb:"## 16"`
 	ValidatorInfo    ValidatorInfo    `tlb:"."`
 	PrevBlocks       *cell.Dictionary `tlb:"dict 32"`
+	PrevBlocksMaxLt  KeyMaxLt         `tlb:"."`
+	AfterKeyBlock    bool             `tlb:"bool"`
 	LastKeyBlock     *ExtBlkRef       `tlb:"maybe ."`
-	BlockCreateStats *cell.Cell       `tlb:"."`
+	BlockCreateStats *cell.Cell       `tlb:"."` // present when Flags & 1, BlockCreateStats or BlockCreateStatsExt
+}
+
+// BlockCreateStats - counters of created blocks by validator public key, values are CreatorStats
+type BlockCreateStats struct {
+	_        Magic            `tlb:"#17"`
+	Counters *cell.Dictionary `tlb:"dict 256"`
+}
+
+// BlockCreateStatsExt - augmented version of BlockCreateStats, every value is prefixed with uint32 extra
+type BlockCreateStatsExt struct {
+	_        Magic            `tlb:"#34"`
+	Counters *cell.Dictionary `tlb:"dict 256"`
+	Count    uint32           `tlb:"## 32"`
+}
+
+type CreatorStats struct {
+	_           Magic    `tlb:"#4"`
+	MCBlocks    Counters `tlb:"."`
+	ShardBlocks Counters `tlb:"."`
+}
+
+type Counters struct {
+	LastUpdated uint32 `tlb:"## 32"`
+	Total       uint64 `tlb:"## 64"`
+	Cnt2048     uint64 `tlb:"## 64"`
+	Cnt65536    uint64 `tlb:"## 64"`
+}
+
+// ShardStateStats - additional info of the shard state, stored in ref of ShardStateUnsplit
+type ShardStateStats struct {
+	OverloadHistory    uint64             `tlb:"## 64"`
+	UnderloadHistory   uint64             `tlb:"## 64"`
+	TotalBalance       CurrencyCollection `tlb:"."`
+	TotalValidatorFees CurrencyCollection `tlb:"."`
+	Libraries          *cell.Dictionary   `tlb:"dict 256"`
+	MasterRef          *ExtBlkRef         `tlb:"maybe ."`
+}
+
+type LibDescr struct {
+	_          Magic            `tlb:"$00"`
+	Lib        *cell.Cell       `tlb:"^"`
+	Publishers *cell.Dictionary `tlb:"dict inline 256"`
+}
+
+type OutMsgQueueInfo struct {
+	OutQueue      *cell.Dictionary  `tlb:"dict 352"`
+	OutQueueExtra uint64            `tlb:"## 64"`
+	ProcInfo      *cell.Dictionary  `tlb:"dict 96"`
+	Extra         *OutMsgQueueExtra `tlb:"maybe ."`
+}
+
+type OutMsgQueueExtra struct {
+	_                  Magic            `tlb:"#0"`
+	DispatchQueue      *cell.Dictionary `tlb:"dict 256"`
+	DispatchQueueExtra uint64           `tlb:"## 64"`
+	OutQueueSize       *uint64          `tlb:"maybe ## 48"`
+}
+
+// AccountDispatchQueue - deferred messages of account, value of OutMsgQueueExtra.DispatchQueue
+// (prefixed with uint64 extra)
+type AccountDispatchQueue struct {
+	Messages *cell.Dictionary `tlb:"dict 64"`
+	Count    uint64           `tlb:"## 48"`
 }
 
 type ConfigParams struct {
@@ -0,0 +1,95 @@
+package ton
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrPrunedField - requested part of the account state was pruned by liteserver, use GetAccount to get it
+var ErrPrunedField = errors.New("field is pruned in account state, request full state")
+
+// AccountMeta - account state without code, data and libraries trees.
+// Parts which can be pruned are accessible only with methods,
+// they return ErrPrunedField when liteserver has not sent them.
+type AccountMeta struct {
+	IsActive    bool
+	Status      tlb.AccountStatus
+	Balance     tlb.Coins
+	StorageInfo tlb.StorageInfo
+	LastTxLT    uint64
+	LastTxHash  []byte
+
+	state *tlb.AccountState
+}
+
+// GetAccountMeta - same as GetAccount, but requests pruned account state, so big code and data
+// are not transferred. Proof is checked the same way, pruned branches are kept in place.
+func (c *APIClient) GetAccountMeta(ctx context.Context, block *BlockIDExt, addr *address.Address) (*AccountMeta, error) {
+	st, shardAcc, err := c.getAccountState(ctx, GetAccountStatePruned{
+		ID: block,
+		Account: AccountID{
+			Workchain: addr.Workchain(),
+			ID:        addr.Data(),
+		},
+	}, block, addr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if st == nil {
+		return &AccountMeta{
+			IsActive: false,
+			Status:   tlb.AccountStatusNonExist,
+			Balance:  tlb.ZeroCoins,
+		}, nil
+	}
+
+	return &AccountMeta{
+		IsActive:    true,
+		Status:      st.Status,
+		Balance:     st.Balance,
+		StorageInfo: st.StorageInfo,
+		LastTxLT:    shardAcc.LastTransLT,
+		LastTxHash:  shardAcc.LastTransHash,
+		state:       st,
+	}, nil
+}
+
+// Code - returns code of the active account, nil if account is not active
+func (a *AccountMeta) Code() (*cell.Cell, error) {
+	if a.state == nil || a.Status != tlb.AccountStatusActive {
+		return nil, nil
+	}
+	return notPruned(a.state.StateInit.Code)
+}
+
+// Data - returns data of the active account, nil if account is not active
+func (a *AccountMeta) Data() (*cell.Cell, error) {
+	if a.state == nil || a.Status != tlb.AccountStatusActive {
+		return nil, nil
+	}
+	return notPruned(a.state.StateInit.Data)
+}
+
+// ExtraCurrencies - returns extra currencies of the account balance
+func (a *AccountMeta) ExtraCurrencies() (*cell.Dictionary, error) {
+	if a.state == nil || a.state.ExtraCurrencies == nil {
+		return nil, nil
+	}
+
+	if _, err := notPruned(a.state.ExtraCurrencies.AsCell()); err != nil {
+		return nil, err
+	}
+	return a.state.ExtraCurrencies, nil
+}
+
+func notPruned(c *cell.Cell) (*cell.Cell, error) {
+	if c != nil && c.GetType() == cell.PrunedCellType {
+		return nil, ErrPrunedField
+	}
+	return c, nil
+}
@@ -54,16 +54,34 @@ type APIClientWrapped interface {
 	Client() LiteClient
 	GetTime(ctx context.Context) (uint32, error)
 	GetLibraries(ctx context.Context, list ...[]byte) ([]*cell.Cell, error)
+	GetLibrariesWithProof(ctx context.Context, master *BlockIDExt, hashes ...[]byte) ([]*cell.Cell, error)
 	LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error)
+	LookupBlockWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error)
+	LookupBlockByLTWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, lt uint64) (*BlockIDExt, error)
+	LookupBlockByUTimeWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, utime uint32) (*BlockIDExt, error)
 	GetBlockData(ctx context.Context, block *BlockIDExt) (*tlb.Block, error)
+	VerifyBlockID(ctx context.Context, master, block *BlockIDExt) error
 	GetBlockTransactionsV2(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]TransactionShortInfo, bool, error)
+	GetBlockTransactionsWithMetadata(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]TransactionShortInfo, bool, error)
+	GetBlockTransactionsExt(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]*tlb.Transaction, bool, error)
 	GetBlockShardsInfo(ctx context.Context, master *BlockIDExt) ([]*BlockIDExt, error)
+	GetShardInfo(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, exact bool) (*BlockIDExt, error)
+	GetShardBlockProof(ctx context.Context, block *BlockIDExt) (*ShardBlockProof, error)
+	GetOutMsgQueueSizes(ctx context.Context) (*OutMsgQueueSizes, error)
+	GetShardOutMsgQueueSizes(ctx context.Context, workchain int32, shard int64) (*OutMsgQueueSizes, error)
+	GetBlockOutMsgQueueSize(ctx context.Context, block *BlockIDExt) (uint64, error)
+	GetDispatchQueueInfo(ctx context.Context, block *BlockIDExt, afterAddr []byte, maxAccounts int32) ([]AccountDispatchQueueInfo, bool, error)
+	GetDispatchQueueMessages(ctx context.Context, block *BlockIDExt, addr *address.Address, afterLT uint64, maxMessages int32, oneAccount bool) ([]DispatchQueueMessage, bool, error)
+	GetValidatorStats(ctx context.Context, master *BlockIDExt, limit int32, startAfter []byte, modifiedAfter uint32) ([]*CreatorStats, bool, error)
 	GetBlockchainConfig(ctx context.Context, block *BlockIDExt, onlyParams ...int32) (*BlockchainConfig, error)
 	GetMasterchainInfo(ctx context.Context) (*BlockIDExt, error)
 	GetAccount(ctx context.Context, block *BlockIDExt, addr *address.Address) (*tlb.Account, error)
+	GetAccountMeta(ctx context.Context, block *BlockIDExt, addr *address.Address) (*AccountMeta, error)
+	GetShardState(ctx context.Context, block *BlockIDExt) (*ShardState, error)
 	SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error
 	RunGetMethod(ctx context.Context, blockInfo *BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ExecutionResult, error)
 	ListTransactions(ctx context.Context, addr *address.Address, num uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error)
+	ListTransactionsVerified(ctx context.Context, master *BlockIDExt, addr *address.Address, limit uint32, from *tlb.Transaction) ([]*tlb.Transaction, error)
 	GetTransaction(ctx context.Context, block *BlockIDExt, addr *address.Address, lt uint64) (*tlb.Transaction, error)
 	GetBlockProof(ctx context.Context, known, target *BlockIDExt) (*PartialBlockProof, error)
 	CurrentMasterchainInfo(ctx context.Context) (_ *BlockIDExt, err error)
@@ -84,6 +102,9 @@ type APIClient struct {
 	curMasters       map[uint32]*masterInfo
 	curMastersLock   sync.RWMutex
 	proofCheckPolicy ProofCheckPolicy
+	lightStore       LightClientStore
+	// maxShardStateSize - limit of the state BoC size for GetShardState, used atomically
+	maxShardStateSize int64
 
 	trustedLock sync.RWMutex
 }
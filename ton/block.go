@@ -10,6 +10,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tl"
 
 	"github.com/xssnick/tonutils-go/tlb"
@@ -39,7 +40,8 @@ func init() {
 	tl.Register(Object{}, "object ? = Object")
 	tl.Register(True{}, "true = True")
 	tl.Register(TransactionID3{}, "liteServer.transactionId3 account:int256 lt:long = liteServer.TransactionId3")
-	tl.Register(TransactionID{}, "liteServer.transactionId mode:# account:mode.0?int256 lt:mode.1?long hash:mode.2?int256 = liteServer.TransactionId")
+	tl.Register(TransactionID{}, "liteServer.transactionId mode:# account:mode.0?int256 lt:mode.1?long hash:mode.2?int256 metadata:mode.8?liteServer.transactionMetadata = liteServer.TransactionId")
+	tl.Register(TransactionMetadata{}, "liteServer.transactionMetadata mode:# depth:int initiator:liteServer.accountId initiator_lt:long = liteServer.TransactionMetadata")
 
 	tl.Register(GetState{}, "liteServer.getState id:tonNode.blockIdExt = liteServer.BlockState")
 	tl.Register(BlockState{}, "liteServer.blockState id:tonNode.blockIdExt root_hash:int256 file_hash:int256 data:bytes = liteServer.BlockState")
@@ -72,14 +74,14 @@ type Version struct {
 }
 
 type GetState struct {
-	ID       *BlockIDExt `tl:"struct"`
-	RootHash []byte      `tl:"int256"`
-	FileHash []byte      `tl:"int256"`
-	Data     *cell.Cell  `tl:"cell"`
+	ID *BlockIDExt `tl:"struct"`
 }
 
 type BlockState struct {
-	ID *BlockIDExt `tl:"struct"`
+	ID       *BlockIDExt `tl:"struct"`
+	RootHash []byte      `tl:"int256"`
+	FileHash []byte      `tl:"int256"`
+	Data     []byte      `tl:"bytes"`
 }
 
 type GetShardBlockProof struct {
@@ -182,7 +184,7 @@ type ShardInfo struct {
 	ID               *BlockIDExt  `tl:"struct"`
 	ShardBlock       *BlockIDExt  `tl:"struct"`
 	ShardProof       []*cell.Cell `tl:"cell optional 2"`
-	ShardDescription *cell.Cell   `tl:"bytes"`
+	ShardDescription *cell.Cell   `tl:"cell"`
 }
 
 type BlockTransactions struct {
@@ -193,12 +195,16 @@ type BlockTransactions struct {
 	Proof          *cell.Cell      `tl:"cell optional"`
 }
 
+// BlockTransactionsExt - response of ListBlockTransactionsExt.
+// Transactions and Proof were *cell.Cell and []byte before, so only the first transaction of the response
+// was decoded and proof was left as raw BoC. It is a breaking change for code which used these fields directly,
+// APIClient.GetBlockTransactionsExt can be used instead to get parsed and proof checked transactions.
 type BlockTransactionsExt struct {
-	ID           *BlockIDExt `tl:"struct"`
-	ReqCount     int32       `tl:"int"`
-	Incomplete   bool        `tl:"bool"`
-	Transactions *cell.Cell  `tl:"cell optional"`
-	Proof        []byte      `tl:"bytes"`
+	ID           *BlockIDExt  `tl:"struct"`
+	ReqCount     int32        `tl:"int"`
+	Incomplete   bool         `tl:"bool"`
+	Transactions []*cell.Cell `tl:"cell optional"`
+	Proof        *cell.Cell   `tl:"cell optional"`
 }
 
 type BlockData struct {
@@ -268,6 +274,8 @@ type TransactionShortInfo struct {
 	Account []byte
 	LT      uint64
 	Hash    []byte
+	// Metadata - filled only when requested, not covered by proof
+	Metadata *TransactionMetadata
 }
 
 type GetBlockProof struct {
@@ -284,10 +292,19 @@ func (t *TransactionShortInfo) ID3() *TransactionID3 {
 }
 
 type TransactionID struct {
-	Flags   uint32 `tl:"flags"`
-	Account []byte `tl:"?0 int256"`
-	LT      uint64 `tl:"?1 long"`
-	Hash    []byte `tl:"?2 int256"`
+	Flags    uint32               `tl:"flags"`
+	Account  []byte               `tl:"?0 int256"`
+	LT       uint64               `tl:"?1 long"`
+	Hash     []byte               `tl:"?2 int256"`
+	Metadata *TransactionMetadata `tl:"?8 struct"`
+}
+
+// TransactionMetadata - position of transaction in the chain of messages, started by external message of initiator
+type TransactionMetadata struct {
+	Mode        uint32    `tl:"flags"`
+	Depth       int32     `tl:"int"`
+	Initiator   AccountID `tl:"struct"`
+	InitiatorLT uint64    `tl:"long"`
 }
 
 type TransactionID3 struct {
@@ -363,11 +380,17 @@ func (c *APIClient) GetMasterchainInfo(ctx context.Context) (*BlockIDExt, error)
 						"For better security you should use SetTrustedBlock(block) method and pass there init block from config on start")
 				}
 			} else {
-				if err := c.VerifyProofChain(ctx, root.trustedBlock, t.Last); err != nil {
+				keyBlocks, err := c.verifyProofChain(ctx, root.trustedBlock, t.Last)
+				if err != nil {
 					return nil, fmt.Errorf("failed to verify proof chain: %w", err)
 				}
 
 				if t.Last.SeqNo > root.trustedBlock.SeqNo {
+					if root.lightStore != nil {
+						if err = c.saveLightClientState(ctx, root.lightStore, keyBlocks, t.Last); err != nil {
+							return nil, fmt.Errorf("failed to save light client state: %w", err)
+						}
+					}
 					root.trustedBlock = t.Last.Copy()
 				}
 			}
@@ -379,6 +402,23 @@ func (c *APIClient) GetMasterchainInfo(ctx context.Context) (*BlockIDExt, error)
 	return nil, errUnexpectedResponse(resp)
 }
 
+// MasterchainSeqnoProbe - health probe for liteclient.ConnectionPool, returns the latest master block seqno of node
+func MasterchainSeqnoProbe(ctx context.Context, query liteclient.QueryFunc) (uint32, error) {
+	var resp tl.Serializable
+	err := query(ctx, GetMasterchainInf{}, &resp)
+	if err != nil {
+		return 0, err
+	}
+
+	switch t := resp.(type) {
+	case MasterchainInfo:
+		return t.Last.SeqNo, nil
+	case LSError:
+		return 0, t
+	}
+	return 0, errUnexpectedResponse(resp)
+}
+
 // LookupBlock - find block information by seqno, shard and chain
 func (c *APIClient) LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error) {
 	var resp tl.Serializable
@@ -407,7 +447,33 @@ func (c *APIClient) LookupBlock(ctx context.Context, workchain int32, shard int6
 	return nil, errUnexpectedResponse(resp)
 }
 
-// GetBlockData - get block detailed information
+// GetBlockHeader - returns header of the block, it is checked by proof against block id
+func (c *APIClient) GetBlockHeader(ctx context.Context, block *BlockIDExt) (*tlb.BlockHeader, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetBlockHeader{ID: block}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case BlockHeader:
+		if !t.ID.Equals(block) {
+			return nil, fmt.Errorf("response with incorrect block")
+		}
+
+		header, err := checkBlockHeaderProof(t.HeaderProof, block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check header proof: %w", err)
+		}
+		return header, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// GetBlockData - get block detailed information,
+// block id should be taken from a trusted source or checked with VerifyBlockID
 func (c *APIClient) GetBlockData(ctx context.Context, block *BlockIDExt) (*tlb.Block, error) {
 	var resp tl.Serializable
 	err := c.client.QueryLiteserver(ctx, GetBlockData{ID: block}, &resp)
@@ -439,6 +505,16 @@ func (c *APIClient) GetBlockData(ctx context.Context, block *BlockIDExt) (*tlb.B
 
 // GetBlockTransactionsV2 - list of block transactions
 func (c *APIClient) GetBlockTransactionsV2(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]TransactionShortInfo, bool, error) {
+	return c.getBlockTransactions(ctx, block, count, false, after...)
+}
+
+// GetBlockTransactionsWithMetadata - same as GetBlockTransactionsV2, but also requests metadata of transactions:
+// depth and initiator of the messages chain. Liteserver should support it, metadata is not covered by proof.
+func (c *APIClient) GetBlockTransactionsWithMetadata(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]TransactionShortInfo, bool, error) {
+	return c.getBlockTransactions(ctx, block, count, true, after...)
+}
+
+func (c *APIClient) getBlockTransactions(ctx context.Context, block *BlockIDExt, count uint32, withMetadata bool, after ...*TransactionID3) ([]TransactionShortInfo, bool, error) {
 	withAfter := uint32(0)
 	var afterTx *TransactionID3
 	if len(after) > 0 && after[0] != nil {
@@ -450,6 +526,9 @@ func (c *APIClient) GetBlockTransactionsV2(ctx context.Context, block *BlockIDEx
 	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
 		mode |= 1 << 5
 	}
+	if withMetadata {
+		mode |= 1 << 8
+	}
 
 	var resp tl.Serializable
 	err := c.client.QueryLiteserver(ctx, ListBlockTransactions{
@@ -495,9 +574,10 @@ func (c *APIClient) GetBlockTransactionsV2(ctx context.Context, block *BlockIDEx
 			}
 
 			txIds = append(txIds, TransactionShortInfo{
-				Account: id.Account,
-				LT:      id.LT,
-				Hash:    id.Hash,
+				Account:  id.Account,
+				LT:       id.LT,
+				Hash:     id.Hash,
+				Metadata: id.Metadata,
 			})
 		}
 		return txIds, t.Incomplete, nil
@@ -507,6 +587,73 @@ func (c *APIClient) GetBlockTransactionsV2(ctx context.Context, block *BlockIDEx
 	return nil, false, errUnexpectedResponse(resp)
 }
 
+// GetBlockTransactionsExt - list of block transactions with their full data, every transaction is checked by block proof
+func (c *APIClient) GetBlockTransactionsExt(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]*tlb.Transaction, bool, error) {
+	withAfter := uint32(0)
+	var afterTx *TransactionID3
+	if len(after) > 0 && after[0] != nil {
+		afterTx = after[0]
+		withAfter = 1
+	}
+
+	mode := 0b111 | (withAfter << 7)
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		mode |= 1 << 5
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, ListBlockTransactionsExt{
+		Mode:      mode,
+		ID:        block,
+		Count:     count,
+		After:     afterTx,
+		WantProof: &True{},
+	}, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case BlockTransactionsExt:
+		var shardAccounts tlb.ShardAccountBlocks
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			if t.Proof == nil {
+				return nil, false, fmt.Errorf("no proof passed by ls")
+			}
+
+			blockProof, err := CheckBlockProof(t.Proof, block.RootHash)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to check block proof: %w", err)
+			}
+
+			if err = tlb.LoadFromCellAsProof(&shardAccounts, blockProof.Extra.ShardAccountBlocks.BeginParse()); err != nil {
+				return nil, false, fmt.Errorf("failed to load shard accounts from proof: %w", err)
+			}
+		}
+
+		txList := make([]*tlb.Transaction, 0, len(t.Transactions))
+		for _, txCell := range t.Transactions {
+			var tx tlb.Transaction
+			if err = tlb.LoadFromCell(&tx, txCell.BeginParse()); err != nil {
+				return nil, false, fmt.Errorf("failed to parse transaction: %w", err)
+			}
+			tx.Hash = txCell.Hash()
+
+			if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+				if err = CheckTransactionProof(tx.Hash, tx.LT, tx.AccountAddr, &shardAccounts); err != nil {
+					return nil, false, fmt.Errorf("incorrect tx %s proof: %w", hex.EncodeToString(tx.Hash), err)
+				}
+			}
+			txList = append(txList, &tx)
+		}
+		return txList, t.Incomplete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
 // GetBlockShardsInfo - gets the information about workchains and its shards at given masterchain state
 func (c *APIClient) GetBlockShardsInfo(ctx context.Context, master *BlockIDExt) ([]*BlockIDExt, error) {
 	var resp tl.Serializable
@@ -607,6 +754,14 @@ func LoadShardsFromHashes(shardHashes *cell.Dictionary, skipPruned bool) (shards
 				continue
 			}
 
+			// shard is defined by the path in the tree, next validator shard can differ from it
+			pathLen := bk.Key.BitsSize()
+			path, err := bk.Key.BeginParse().LoadUInt(pathLen)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load shard path: %w", err)
+			}
+			shard := int64(tlb.ShardFromPrefix(path<<(64-pathLen), uint8(pathLen)))
+
 			loader := bk.Value.BeginParse()
 
 			ab, err := loader.LoadUInt(4)
@@ -622,7 +777,7 @@ func LoadShardsFromHashes(shardHashes *cell.Dictionary, skipPruned bool) (shards
 				}
 				shards = append(shards, &BlockIDExt{
 					Workchain: int32(workchain),
-					Shard:     shardDesc.NextValidatorShard,
+					Shard:     shard,
 					SeqNo:     shardDesc.SeqNo,
 					RootHash:  shardDesc.RootHash,
 					FileHash:  shardDesc.FileHash,
@@ -634,7 +789,7 @@ func LoadShardsFromHashes(shardHashes *cell.Dictionary, skipPruned bool) (shards
 				}
 				shards = append(shards, &BlockIDExt{
 					Workchain: int32(workchain),
-					Shard:     shardDesc.NextValidatorShard,
+					Shard:     shard,
 					SeqNo:     shardDesc.SeqNo,
 					RootHash:  shardDesc.RootHash,
 					FileHash:  shardDesc.FileHash,
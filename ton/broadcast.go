@@ -0,0 +1,337 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+// BroadcastStatus - final status of the broadcasted external message
+type BroadcastStatus int
+
+const (
+	// BroadcastStatusIncluded - message was processed in transaction
+	BroadcastStatusIncluded BroadcastStatus = iota + 1
+	// BroadcastStatusExpired - message was not included till its valid until time
+	BroadcastStatusExpired
+	// BroadcastStatusRejected - all liteservers rejected message, usually because contract has not accepted it
+	BroadcastStatusRejected
+)
+
+// shards can be committed to masterchain with a delay,
+// so we wait a bit more after valid until to not miss the transaction
+const broadcastExpireMargin = 30
+
+func (s BroadcastStatus) String() string {
+	switch s {
+	case BroadcastStatusIncluded:
+		return "included"
+	case BroadcastStatusExpired:
+		return "expired"
+	case BroadcastStatusRejected:
+		return "rejected"
+	}
+	return "unknown"
+}
+
+// BroadcastResult - result of the external message broadcast
+type BroadcastResult struct {
+	// Hash - normalized hash of the message
+	Hash   []byte
+	Status BroadcastStatus
+	// Transaction - transaction where message was processed, when included
+	Transaction *tlb.Transaction
+	// Block - masterchain block where transaction was found, or the last checked block
+	Block *BlockIDExt
+	// Accepted - number of sends accepted by liteservers, including rebroadcasts
+	Accepted int
+	// Err - reason of rejection
+	Err error
+}
+
+// Broadcaster - sends external messages to multiple liteservers in parallel
+// and rebroadcasts them until inclusion or expiration.
+type Broadcaster struct {
+	api      APIClientWrapped
+	nodes    int
+	interval time.Duration
+
+	mx      sync.Mutex
+	pending map[string]*broadcastJob
+}
+
+type broadcastJob struct {
+	done   chan struct{}
+	result *BroadcastResult
+	err    error
+
+	// number of Send calls waiting for the job, guarded by Broadcaster mx
+	waiters int
+	cancel  func()
+}
+
+// blockHeaderClient - optional api method, used to get time of the checked masterchain block
+type blockHeaderClient interface {
+	GetBlockHeader(ctx context.Context, block *BlockIDExt) (*tlb.BlockHeader, error)
+}
+
+// NewBroadcaster - creates broadcaster which sends every message to up to nodes different liteservers.
+// Api should be connected to multiple liteservers, otherwise it works as a single sender with rebroadcasts.
+func NewBroadcaster(api APIClientWrapped, nodes int) *Broadcaster {
+	if nodes <= 0 {
+		nodes = 1
+	}
+
+	return &Broadcaster{
+		api:      api,
+		nodes:    nodes,
+		interval: 5 * time.Second,
+		pending:  map[string]*broadcastJob{},
+	}
+}
+
+// SetRebroadcastInterval - sets interval of inclusion checks and rebroadcasts, default is 5 seconds.
+// Already running broadcasts keep the interval they were started with.
+func (b *Broadcaster) SetRebroadcastInterval(interval time.Duration) {
+	b.mx.Lock()
+	b.interval = interval
+	b.mx.Unlock()
+}
+
+// Send - broadcasts message and waits for its final status.
+// validUntil is a unix time after which contract will not accept message, wallets store it in the message body.
+//
+// Messages are deduplicated by normalized hash: concurrent calls with the same message
+// are waiting for the single broadcast. Broadcast is not bound to the context of any call,
+// each call stops waiting when its own context is done, and broadcast is stopped when no one waits for it.
+// Error is returned only when status cannot be determined, for example when context is done.
+func (b *Broadcaster) Send(ctx context.Context, msg *tlb.ExternalMessage, validUntil uint32) (*BroadcastResult, error) {
+	hash := msg.NormalizedHash()
+
+	b.mx.Lock()
+	job, ok := b.pending[string(hash)]
+	if !ok {
+		jobCtx, cancel := context.WithCancel(context.Background())
+		job = &broadcastJob{done: make(chan struct{}), cancel: cancel}
+		b.pending[string(hash)] = job
+
+		interval := b.interval
+		go func() {
+			defer cancel()
+			job.result, job.err = b.track(jobCtx, msg, hash, validUntil, interval)
+
+			b.mx.Lock()
+			if b.pending[string(hash)] == job {
+				delete(b.pending, string(hash))
+			}
+			b.mx.Unlock()
+			close(job.done)
+		}()
+	}
+	job.waiters++
+	b.mx.Unlock()
+
+	select {
+	case <-ctx.Done():
+		b.mx.Lock()
+		if job.waiters--; job.waiters == 0 {
+			// nobody waits for result anymore, next Send will start a new broadcast
+			if b.pending[string(hash)] == job {
+				delete(b.pending, string(hash))
+			}
+			job.cancel()
+		}
+		b.mx.Unlock()
+		return nil, ctx.Err()
+	case <-job.done:
+		if job.err != nil {
+			return nil, job.err
+		}
+
+		res := *job.result
+		return &res, nil
+	}
+}
+
+func (b *Broadcaster) track(ctx context.Context, msg *tlb.ExternalMessage, hash []byte, validUntil uint32, interval time.Duration) (*BroadcastResult, error) {
+	master, err := b.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	acc, err := b.api.WaitForBlock(master.SeqNo).GetAccount(ctx, master, msg.DstAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+	lastLT := acc.LastTxLT
+
+	res := &BroadcastResult{
+		Hash:  hash,
+		Block: master,
+	}
+
+	accepted, err := b.broadcast(ctx, msg)
+	if accepted == 0 {
+		var lsErr LSError
+		if !errors.As(err, &lsErr) {
+			// liteservers are not reachable, so we cannot say anything about message
+			return nil, fmt.Errorf("failed to send message: %w", err)
+		}
+
+		res.Status = BroadcastStatusRejected
+		res.Err = err
+		return res, nil
+	}
+	res.Accepted += accepted
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		master, err = b.api.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			continue
+		}
+
+		acc, err = b.api.WaitForBlock(master.SeqNo).GetAccount(ctx, master, msg.DstAddr)
+		if err != nil {
+			continue
+		}
+
+		if acc.LastTxLT != lastLT {
+			tx, err := b.findTransaction(ctx, msg.DstAddr, hash, acc.LastTxLT, acc.LastTxHash, lastLT)
+			if err != nil {
+				continue
+			}
+			res.Block = master
+
+			if tx != nil {
+				res.Status = BroadcastStatusIncluded
+				res.Transaction = tx
+				return res, nil
+			}
+			lastLT = acc.LastTxLT
+		}
+		res.Block = master
+
+		now, err := b.blockTime(ctx, master)
+		if err != nil {
+			continue
+		}
+
+		if now > validUntil+broadcastExpireMargin {
+			res.Status = BroadcastStatusExpired
+			return res, nil
+		}
+
+		if now <= validUntil {
+			// liteservers could lose message, so we send it again
+			n, _ := b.broadcast(ctx, msg)
+			res.Accepted += n
+		}
+	}
+}
+
+// blockTime - generation time of the masterchain block, checked by proof when api supports block headers,
+// otherwise liteserver time is used
+func (b *Broadcaster) blockTime(ctx context.Context, master *BlockIDExt) (uint32, error) {
+	if hc, ok := b.api.(blockHeaderClient); ok {
+		header, err := hc.GetBlockHeader(ctx, master)
+		if err != nil {
+			return 0, err
+		}
+		return header.GenUtime, nil
+	}
+	return b.api.GetTime(ctx)
+}
+
+// broadcast - sends message to multiple liteservers in parallel, returns number of accepted sends and the last error
+func (b *Broadcaster) broadcast(ctx context.Context, msg *tlb.ExternalMessage) (int, error) {
+	var contexts []context.Context
+	nodeCtx := ctx
+	for i := 0; i < b.nodes; i++ {
+		var err error
+		if nodeCtx, err = b.api.Client().StickyContextNextNode(nodeCtx); err != nil {
+			break
+		}
+		contexts = append(contexts, nodeCtx)
+	}
+
+	if len(contexts) == 0 {
+		// fallback to balancer
+		contexts = append(contexts, ctx)
+	}
+
+	var wg sync.WaitGroup
+	var mx sync.Mutex
+	var lastErr error
+	accepted := 0
+
+	for _, c := range contexts {
+		wg.Add(1)
+		go func(c context.Context) {
+			defer wg.Done()
+
+			err := b.api.SendExternalMessage(c, msg)
+
+			mx.Lock()
+			defer mx.Unlock()
+			if err != nil {
+				var lsErr LSError
+				// keep liteserver error, it is more informative than network one
+				if lastErr == nil || errors.As(err, &lsErr) {
+					lastErr = err
+				}
+				return
+			}
+			accepted++
+		}(c)
+	}
+	wg.Wait()
+
+	return accepted, lastErr
+}
+
+// findTransaction - scans account transactions from the passed one till the transaction with stopLT,
+// and returns the one with external inbound message with matching normalized hash
+func (b *Broadcaster) findTransaction(ctx context.Context, addr *address.Address, msgHash []byte, lt uint64, txHash []byte, stopLT uint64) (*tlb.Transaction, error) {
+	for lt > stopLT {
+		list, err := b.api.ListTransactions(ctx, addr, 10, lt, txHash)
+		if err != nil {
+			if errors.Is(err, ErrNoTransactionsWereFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		for i := len(list) - 1; i >= 0; i-- {
+			tx := list[i]
+			if tx.LT <= stopLT {
+				return nil, nil
+			}
+
+			if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeExternalIn {
+				continue
+			}
+
+			if bytes.Equal(tx.IO.In.AsExternalIn().NormalizedHash(), msgHash) {
+				return tx, nil
+			}
+		}
+
+		lt, txHash = list[0].PrevTxLT, list[0].PrevTxHash
+	}
+	return nil, nil
+}
@@ -0,0 +1,304 @@
+package configcontract
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrNoConfigData - config account is not active, so it has no data
+var ErrNoConfigData = errors.New("config contract is not active")
+
+// ErrNotValidator - key is not in the current validator set
+var ErrNotValidator = errors.New("key is not in the current validator set")
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	GetBlockchainConfig(ctx context.Context, block *ton.BlockIDExt, onlyParams ...int32) (*ton.BlockchainConfig, error)
+	GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error)
+	SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error
+}
+
+// State - decoded data of the config contract
+type State struct {
+	// Seqno - seqno of the next external message, it is used in votes
+	Seqno     uint32
+	PublicKey []byte
+	Proposals []*Proposal
+}
+
+// Proposal - config param change which is voted by validators
+type Proposal struct {
+	// Hash - hash of the proposal cell, it is used for voting
+	Hash      []byte
+	ExpiresAt uint32
+	Critical  bool
+	ParamID   int32
+	// ParamValue - new value of the param, nil means that param will be removed
+	ParamValue *cell.Cell
+	// IfHashEqual - proposal is applied only when hash of the current param value is equal to it, nil if not checked
+	IfHashEqual []byte
+	// Voters - indexes of validators voted in the current round
+	Voters          []uint16
+	WeightRemaining int64
+	// ValidatorSetID - hash of the validator set of the current round,
+	// when it differs from the current set, voting starts from the beginning on the next vote
+	ValidatorSetID  []byte
+	RoundsRemaining uint8
+	Wins            uint8
+	Losses          uint8
+
+	// WeightVoted - total weight of voters, 0 when validator set was changed since the last vote
+	WeightVoted uint64
+	// TotalWeight - total weight of the current validator set
+	TotalWeight uint64
+}
+
+type configData struct {
+	Config    *cell.Cell       `tlb:"^"`
+	Seqno     uint32           `tlb:"## 32"`
+	PublicKey []byte           `tlb:"bits 256"`
+	Proposals *cell.Dictionary `tlb:"dict 256"`
+}
+
+type proposalStatus struct {
+	_               tlb.Magic        `tlb:"#ce"`
+	Expires         uint32           `tlb:"## 32"`
+	Proposal        configProposal   `tlb:"^"`
+	Critical        bool             `tlb:"bool"`
+	Voters          *cell.Dictionary `tlb:"dict 16"`
+	WeightRemaining int64            `tlb:"## 64"`
+	VsetID          []byte           `tlb:"bits 256"`
+	RoundsRemaining uint8            `tlb:"## 8"`
+	Wins            uint8            `tlb:"## 8"`
+	Losses          uint8            `tlb:"## 8"`
+}
+
+type configProposal struct {
+	_           tlb.Magic  `tlb:"#f3"`
+	ParamID     int32      `tlb:"## 32"`
+	ParamValue  *cell.Cell `tlb:"maybe ^"`
+	IfHashEqual []byte     `tlb:"maybe bits 256"`
+}
+
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+// ContractAddr - gets config contract address from the config param 0
+func ContractAddr(ctx context.Context, api TonApi) (*address.Address, error) {
+	b, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	cfg, err := api.GetBlockchainConfig(ctx, b, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config address from network config: %w", err)
+	}
+
+	data := cfg.Get(0)
+	if data == nil {
+		return nil, fmt.Errorf("failed to get config address from network config")
+	}
+
+	hash, err := data.BeginParse().LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config address from network config 0, failed to load hash: %w", err)
+	}
+	return address.NewAddress(0, 255, hash), nil
+}
+
+func NewConfigClient(api TonApi, configAddr *address.Address) *Client {
+	return &Client{
+		addr: configAddr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// GetState - loads config account with proof, decodes proposals and calculates their voted weight using current validator set
+func (c *Client) GetState(ctx context.Context) (*State, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetStateAtBlock(ctx, b)
+}
+
+func (c *Client) GetStateAtBlock(ctx context.Context, b *ton.BlockIDExt) (*State, error) {
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config account: %w", err)
+	}
+
+	if !acc.IsActive || acc.Data == nil {
+		return nil, ErrNoConfigData
+	}
+
+	st, err := ParseState(acc.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := c.api.WaitForBlock(b.SeqNo).GetBlockchainConfig(ctx, b, 34)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current validator set: %w", err)
+	}
+
+	if err = st.calcWeights(cfg.Get(34)); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// GetProposals - returns active proposals ordered by expiration time
+func (c *Client) GetProposals(ctx context.Context) ([]*Proposal, error) {
+	st, err := c.GetState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return st.Proposals, nil
+}
+
+// ParseState - decodes config contract data, weights of proposals are not calculated
+func ParseState(data *cell.Cell) (*State, error) {
+	var d configData
+	if err := tlb.LoadFromCell(&d, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse config contract data: %w", err)
+	}
+
+	st := &State{
+		Seqno:     d.Seqno,
+		PublicKey: d.PublicKey,
+	}
+
+	if d.Proposals != nil {
+		err := d.Proposals.ForEach(func(key, value *cell.Slice) error {
+			var ps proposalStatus
+			if err := tlb.LoadFromCell(&ps, value); err != nil {
+				return fmt.Errorf("failed to parse proposal: %w", err)
+			}
+
+			p := &Proposal{
+				Hash:            key.MustLoadSlice(256),
+				ExpiresAt:       ps.Expires,
+				Critical:        ps.Critical,
+				ParamID:         ps.Proposal.ParamID,
+				ParamValue:      ps.Proposal.ParamValue,
+				IfHashEqual:     ps.Proposal.IfHashEqual,
+				WeightRemaining: ps.WeightRemaining,
+				ValidatorSetID:  ps.VsetID,
+				RoundsRemaining: ps.RoundsRemaining,
+				Wins:            ps.Wins,
+				Losses:          ps.Losses,
+			}
+
+			if ps.Voters != nil {
+				err := ps.Voters.ForEach(func(key, _ *cell.Slice) error {
+					p.Voters = append(p.Voters, uint16(key.MustLoadUInt(16)))
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			st.Proposals = append(st.Proposals, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(st.Proposals, func(i, j int) bool {
+		return st.Proposals[i].ExpiresAt < st.Proposals[j].ExpiresAt
+	})
+	return st, nil
+}
+
+// Proposal - returns proposal by hash, nil if there is no such
+func (s *State) Proposal(hash []byte) *Proposal {
+	for _, p := range s.Proposals {
+		if bytes.Equal(p.Hash, hash) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *State) calcWeights(vsetCell *cell.Cell) error {
+	if vsetCell == nil {
+		return fmt.Errorf("no current validator set in config")
+	}
+
+	var vset tlb.ValidatorSetAny
+	if err := tlb.LoadFromCell(&vset, vsetCell.BeginParse()); err != nil {
+		return fmt.Errorf("failed to parse current validator set: %w", err)
+	}
+
+	list, total, err := vset.ValidatorsList()
+	if err != nil {
+		return fmt.Errorf("failed to parse current validator set: %w", err)
+	}
+
+	weights := make(map[uint16]uint64, len(list))
+	for _, v := range list {
+		weights[v.Index] = v.Weight
+	}
+
+	for _, p := range s.Proposals {
+		p.TotalWeight = total
+		if !bytes.Equal(p.ValidatorSetID, vsetCell.Hash()) {
+			continue
+		}
+
+		for _, idx := range p.Voters {
+			p.WeightVoted += weights[idx]
+		}
+	}
+	return nil
+}
+
+// DecodedValue - returns param value proposed to set, decoded into the tlb type of the param.
+// Returns nil when proposal removes the param, and the cell as is when param type is unknown.
+func (p *Proposal) DecodedValue() (any, error) {
+	if p.ParamValue == nil {
+		return nil, nil
+	}
+	return DecodeParam(p.ParamID, p.ParamValue)
+}
+
+// validatorIndex - finds index of the key in the current validator set
+func validatorIndex(vsetCell *cell.Cell, key ed25519.PublicKey) (uint16, error) {
+	var vset tlb.ValidatorSetAny
+	if err := tlb.LoadFromCell(&vset, vsetCell.BeginParse()); err != nil {
+		return 0, fmt.Errorf("failed to parse current validator set: %w", err)
+	}
+
+	list, _, err := vset.ValidatorsList()
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse current validator set: %w", err)
+	}
+
+	for _, v := range list {
+		if bytes.Equal(v.PublicKey, key) {
+			return v.Index, nil
+		}
+	}
+	return 0, ErrNotValidator
+}
@@ -0,0 +1,296 @@
+package configcontract
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/liteservertest"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var votingSetup = tlb.ConfigVotingSetup{
+	NormalParams: tlb.ConfigProposalSetup{
+		MinTotRounds: 2, MaxTotRounds: 3, MinWins: 2, MaxLosses: 2,
+		MinStoreSec: 1000000, MaxStoreSec: 10000000, BitPrice: 1, CellPrice: 500,
+	},
+	CriticalParams: tlb.ConfigProposalSetup{
+		MinTotRounds: 4, MaxTotRounds: 7, MinWins: 4, MaxLosses: 2,
+		MinStoreSec: 5000000, MaxStoreSec: 16000000, BitPrice: 2, CellPrice: 1000,
+	},
+}
+
+func TestProposalFee(t *testing.T) {
+	value := cell.BeginCell().MustStoreUInt(0xC4, 8).MustStoreUInt(10, 32).EndCell()
+	proposal, err := BuildProposal(8, value, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 cells: proposal (8 + 32 + 1 + 1 bits) and value (40 bits)
+	fee, err := ProposalFee(votingSetup, proposal, false, 2000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (1*(42+40+1024) + 500*(2+2)) * 2000000; fee.Nano().Int64() != int64(exp) {
+		t.Fatal("incorrect fee", fee.Nano(), exp)
+	}
+
+	fee, err = ProposalFee(votingSetup, proposal, true, 20000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (2*(42+40+1024) + 1000*(2+2)) * 16000000; fee.Nano().Int64() != int64(exp) {
+		t.Fatal("store time should be reduced to max", fee.Nano(), exp)
+	}
+
+	if _, err = ProposalFee(votingSetup, proposal, true, 1000000); err == nil {
+		t.Fatal("store time should be checked")
+	}
+
+	body, err := BuildNewProposalPayload(proposal, true, 1800000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var p NewProposalPayload
+	if err = tlb.LoadFromCell(&p, body.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Critical || p.ExpireAt != 1800000000 || !bytes.Equal(p.Proposal.Hash(), proposal.Hash()) {
+		t.Fatal("incorrect new proposal payload")
+	}
+}
+
+func TestDecodeParam(t *testing.T) {
+	setup, err := tlb.ToCell(votingSetup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := DecodeParam(11, setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := v.(*tlb.ConfigVotingSetup); !ok || s.CriticalParams.MaxStoreSec != 16000000 {
+		t.Fatal("incorrect voting setup", v)
+	}
+
+	v, err = DecodeParam(1, cell.BeginCell().MustStoreSlice(bytes.Repeat([]byte{0x33}, 32), 256).EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := v.(*address.Address); !ok || a.Workchain() != -1 {
+		t.Fatal("incorrect address", v)
+	}
+
+	raw := cell.BeginCell().MustStoreUInt(1, 8).EndCell()
+	if v, err = DecodeParam(100, raw); err != nil || v != raw {
+		t.Fatal("unknown param should be returned as is")
+	}
+}
+
+func TestClient_Proposals(t *testing.T) {
+	chain := liteservertest.NewChain()
+
+	_, k1, _ := ed25519.GenerateKey(nil)
+	_, k2, _ := ed25519.GenerateKey(nil)
+	_, k3, _ := ed25519.GenerateKey(nil)
+	if err := chain.SetValidators(k1, k2, k3); err != nil {
+		t.Fatal(err)
+	}
+	// key block with validators
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	srv := liteservertest.NewServer(chain)
+	if err := srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	defer srv.Close()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	defer pool.Stop()
+	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+
+	master, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := api.GetBlockchainConfig(ctx, master, 34)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vsetHash := cfg.Get(34).Hash()
+
+	newValue := cell.BeginCell().MustStoreUInt(0xC4, 8).MustStoreUInt(10, 32).MustStoreUInt(0, 64).EndCell()
+	proposal, err := BuildProposal(8, newValue, bytes.Repeat([]byte{0xF0}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	removal, err := BuildProposal(100, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	voters := cell.NewDict(16)
+	for _, idx := range []uint64{0, 2} {
+		if err = voters.Set(cell.BeginCell().MustStoreUInt(idx, 16).EndCell(), cell.BeginCell().EndCell()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	proposals := cell.NewDict(256)
+	for _, p := range []struct {
+		cell    *cell.Cell
+		expires uint32
+		vset    []byte
+	}{{proposal, 1800000000, vsetHash}, {removal, 1700000000, bytes.Repeat([]byte{1}, 32)}} {
+		var ps proposalStatus
+		if err = tlb.LoadFromCell(&ps.Proposal, p.cell.BeginParse()); err != nil {
+			t.Fatal(err)
+		}
+		ps.Expires = p.expires
+		ps.Critical = true
+		ps.Voters = voters
+		ps.WeightRemaining = 1
+		ps.VsetID = p.vset
+		ps.RoundsRemaining = 3
+		ps.Wins = 1
+		ps.Losses = 1
+
+		val, err := tlb.ToCell(ps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = proposals.Set(cell.BeginCell().MustStoreSlice(p.cell.Hash(), 256).EndCell(), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := tlb.ToCell(configData{
+		Config:    cell.BeginCell().EndCell(),
+		Seqno:     17,
+		PublicKey: bytes.Repeat([]byte{0xAB}, 32),
+		Proposals: proposals,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configAddr := address.MustParseRawAddr("-1:" + "5555555555555555555555555555555555555555555555555555555555555555")
+	chain.SetConfigParam(0, cell.BeginCell().MustStoreSlice(configAddr.Data(), 256).EndCell())
+	chain.SetConfigParam(11, cell.BeginCell().MustStoreBuilder(mustToCell(t, votingSetup).ToBuilder()).EndCell())
+	chain.SetAccount(liteservertest.Account{
+		Address: configAddr,
+		Balance: tlb.MustFromTON("10"),
+		Code:    cell.BeginCell().MustStoreUInt(1, 8).EndCell(),
+		Data:    data,
+	})
+
+	var votes []*tlb.ExternalMessage
+	chain.SetExternalMessageHandler(func(chain *liteservertest.Chain, msg *tlb.ExternalMessage) error {
+		votes = append(votes, msg)
+		return nil
+	})
+	if _, err = chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+	// new client to not use cached masterchain info
+	api = ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+
+	addr, err := ContractAddr(ctx, api)
+	if err != nil {
+		t.Fatal("get config addr err:", err)
+	}
+	if addr.String() != configAddr.String() {
+		t.Fatal("incorrect config address")
+	}
+
+	client := NewConfigClient(api, addr)
+	list, err := client.GetProposals(ctx)
+	if err != nil {
+		t.Fatal("get proposals err:", err)
+	}
+
+	if len(list) != 2 || list[0].ParamID != 100 || list[1].ParamID != 8 {
+		t.Fatal("proposals should be ordered by expiration")
+	}
+
+	if list[0].ParamValue != nil || list[0].WeightVoted != 0 || list[0].TotalWeight != 3 {
+		t.Fatal("votes of the old validator set should not be counted")
+	}
+	if v, err := list[0].DecodedValue(); err != nil || v != nil {
+		t.Fatal("removal should have no value")
+	}
+
+	p := list[1]
+	if !bytes.Equal(p.Hash, proposal.Hash()) || !p.Critical || p.ExpiresAt != 1800000000 || p.RoundsRemaining != 3 ||
+		p.Wins != 1 || p.Losses != 1 || !bytes.Equal(p.IfHashEqual, bytes.Repeat([]byte{0xF0}, 32)) {
+		t.Fatal("incorrect proposal")
+	}
+	if fmt.Sprint(p.Voters) != "[0 2]" || p.WeightVoted != 2 || p.TotalWeight != 3 {
+		t.Fatal("incorrect votes", p.Voters, p.WeightVoted)
+	}
+
+	v, err := p.DecodedValue()
+	if err != nil || v.(*cell.Cell).BeginParse().MustLoadUInt(8) != 0xC4 {
+		t.Fatal("incorrect value")
+	}
+
+	fee, err := client.EstimateProposalFee(ctx, proposal, true, 6000000)
+	if err != nil {
+		t.Fatal("estimate fee err:", err)
+	}
+	if exp, _ := ProposalFee(votingSetup, proposal, true, 6000000); fee.Nano().Cmp(exp.Nano()) != 0 {
+		t.Fatal("incorrect fee estimate")
+	}
+
+	if err = client.Vote(ctx, k2, proposal.Hash()); err != nil {
+		t.Fatal("vote err:", err)
+	}
+
+	if len(votes) != 1 {
+		t.Fatal("vote is not sent")
+	}
+
+	s := votes[0].Body.BeginParse()
+	sig := s.MustLoadSlice(512)
+	signed := s.Copy().MustLoadSlice(s.BitsLeft())
+	if !ed25519.Verify(k2.Public().(ed25519.PublicKey), signed, sig) {
+		t.Fatal("incorrect vote signature")
+	}
+	if s.MustLoadUInt(32) != voteTag || s.MustLoadUInt(32) != 17 {
+		t.Fatal("incorrect vote tag or seqno")
+	}
+	s.MustLoadUInt(32)
+	if s.MustLoadUInt(16) != 1 || !bytes.Equal(s.MustLoadSlice(256), proposal.Hash()) {
+		t.Fatal("incorrect vote index or hash")
+	}
+
+	_, stranger, _ := ed25519.GenerateKey(nil)
+	if err = client.Vote(ctx, stranger, proposal.Hash()); err != ErrNotValidator {
+		t.Fatal("vote of not validator should fail", err)
+	}
+}
+
+func mustToCell(t *testing.T, v any) *cell.Cell {
+	c, err := tlb.ToCell(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
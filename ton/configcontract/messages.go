@@ -0,0 +1,204 @@
+package configcontract
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const voteTag = 0x566f7465
+
+// NewProposalPayload - internal message which creates proposal, message value should cover proposal fee and gas
+type NewProposalPayload struct {
+	_        tlb.Magic  `tlb:"#6e565052"`
+	QueryID  uint64     `tlb:"## 64"`
+	ExpireAt uint32     `tlb:"## 32"`
+	Proposal *cell.Cell `tlb:"^"`
+	Critical bool       `tlb:"bool"`
+}
+
+// BuildProposal - builds proposal to set param, nil value removes param.
+// When ifHashEqual is set, proposal is applied only if hash of the current value is equal to it.
+// Hash of the returned cell is used to vote for the proposal.
+func BuildProposal(paramID int32, value *cell.Cell, ifHashEqual []byte) (*cell.Cell, error) {
+	if ifHashEqual != nil && len(ifHashEqual) != 32 {
+		return nil, fmt.Errorf("incorrect hash size")
+	}
+
+	c, err := tlb.ToCell(configProposal{
+		ParamID:     paramID,
+		ParamValue:  value,
+		IfHashEqual: ifHashEqual,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert proposal to cell: %w", err)
+	}
+	return c, nil
+}
+
+// BuildNewProposalPayload - builds message to submit proposal, expireAt is a unix time until which proposal is stored
+func BuildNewProposalPayload(proposal *cell.Cell, critical bool, expireAt uint32) (*cell.Cell, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	body, err := tlb.ToCell(NewProposalPayload{
+		QueryID:  binary.LittleEndian.Uint64(buf),
+		ExpireAt: expireAt,
+		Proposal: proposal,
+		Critical: critical,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert NewProposalPayload to cell: %w", err)
+	}
+	return body, nil
+}
+
+// ProposalFee - calculates storage fee of the proposal the same way as config contract does.
+// Store time longer than max allowed is reduced to max, and shorter than min is an error.
+func ProposalFee(setup tlb.ConfigVotingSetup, proposal *cell.Cell, critical bool, storeSeconds uint32) (tlb.Coins, error) {
+	params := setup.NormalParams
+	if critical {
+		params = setup.CriticalParams
+	}
+
+	if storeSeconds < params.MinStoreSec {
+		return tlb.Coins{}, fmt.Errorf("store time should be at least %d seconds", params.MinStoreSec)
+	}
+	if storeSeconds > params.MaxStoreSec {
+		storeSeconds = params.MaxStoreSec
+	}
+
+	cells, bits := dataSize(proposal, map[string]bool{})
+
+	price := new(big.Int).Mul(big.NewInt(int64(params.BitPrice)), big.NewInt(int64(bits+1024)))
+	price.Add(price, new(big.Int).Mul(big.NewInt(int64(params.CellPrice)), big.NewInt(int64(cells+2))))
+	price.Mul(price, big.NewInt(int64(storeSeconds)))
+	return tlb.FromNanoTON(price), nil
+}
+
+// dataSize - number of unique cells and their bits
+func dataSize(c *cell.Cell, seen map[string]bool) (cells, bits uint64) {
+	if seen[string(c.Hash())] {
+		return 0, 0
+	}
+	seen[string(c.Hash())] = true
+
+	cells, bits = 1, uint64(c.BitsSize())
+	for i := 0; i < int(c.RefsNum()); i++ {
+		rc, rb := dataSize(c.MustPeekRef(i), seen)
+		cells += rc
+		bits += rb
+	}
+	return cells, bits
+}
+
+// EstimateProposalFee - calculates proposal fee using voting setup from the current config (param 11)
+func (c *Client) EstimateProposalFee(ctx context.Context, proposal *cell.Cell, critical bool, storeSeconds uint32) (tlb.Coins, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.EstimateProposalFeeAtBlock(ctx, proposal, critical, storeSeconds, b)
+}
+
+func (c *Client) EstimateProposalFeeAtBlock(ctx context.Context, proposal *cell.Cell, critical bool, storeSeconds uint32, b *ton.BlockIDExt) (tlb.Coins, error) {
+	cfg, err := c.api.WaitForBlock(b.SeqNo).GetBlockchainConfig(ctx, b, 11)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to get voting setup: %w", err)
+	}
+
+	var setup tlb.ConfigVotingSetup
+	if err = tlb.LoadFromCell(&setup, cfg.Get(11).BeginParse()); err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to parse voting setup: %w", err)
+	}
+	return ProposalFee(setup, proposal, critical, storeSeconds)
+}
+
+// VoteSignData - data which should be signed by the validator key to vote for the proposal,
+// seqno is the current seqno of the config contract.
+func VoteSignData(seqno, validUntil uint32, validatorIndex uint16, proposalHash []byte) ([]byte, error) {
+	if len(proposalHash) != 32 {
+		return nil, fmt.Errorf("incorrect proposal hash size")
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(voteTag, 32).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(uint64(validUntil), 32).
+		MustStoreUInt(uint64(validatorIndex), 16).
+		MustStoreSlice(proposalHash, 256).
+		EndCell().BeginParse().LoadSlice(32 + 32 + 32 + 16 + 256)
+}
+
+// BuildVoteMessage - builds external message with vote for the proposal signed by validator key,
+// validatorIndex is the index of the validator in the current validator set (config param 34).
+func (c *Client) BuildVoteMessage(key ed25519.PrivateKey, seqno, validUntil uint32, validatorIndex uint16, proposalHash []byte) (*tlb.ExternalMessage, error) {
+	data, err := VoteSignData(seqno, validUntil, validatorIndex, proposalHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tlb.ExternalMessage{
+		DstAddr: c.addr,
+		Body: cell.BeginCell().
+			MustStoreSlice(ed25519.Sign(key, data), 512).
+			MustStoreSlice(data, uint(len(data))*8).
+			EndCell(),
+	}, nil
+}
+
+// Vote - finds validator index of the key in the current set, and sends vote for the proposal.
+// Vote is valid for 1 minute, seqno is taken from the current state, so concurrent votes can fail.
+func (c *Client) Vote(ctx context.Context, key ed25519.PrivateKey, proposalHash []byte) error {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, c.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get config account: %w", err)
+	}
+	if !acc.IsActive || acc.Data == nil {
+		return ErrNoConfigData
+	}
+
+	st, err := ParseState(acc.Data)
+	if err != nil {
+		return err
+	}
+
+	if st.Proposal(proposalHash) == nil {
+		return fmt.Errorf("proposal %x not found", proposalHash)
+	}
+
+	cfg, err := c.api.WaitForBlock(b.SeqNo).GetBlockchainConfig(ctx, b, 34)
+	if err != nil {
+		return fmt.Errorf("failed to get current validator set: %w", err)
+	}
+
+	idx, err := validatorIndex(cfg.Get(34), key.Public().(ed25519.PublicKey))
+	if err != nil {
+		return err
+	}
+
+	msg, err := c.BuildVoteMessage(key, st.Seqno, uint32(time.Now().Add(time.Minute).Unix()), idx, proposalHash)
+	if err != nil {
+		return err
+	}
+
+	if err = c.api.SendExternalMessage(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send vote: %w", err)
+	}
+	return nil
+}
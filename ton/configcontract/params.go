@@ -0,0 +1,45 @@
+package configcontract
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// DecodeParam - parses config param value into its tlb type:
+//
+//	0-4   - *address.Address of the system contracts
+//	11    - tlb.ConfigVotingSetup
+//	28    - tlb.CatchainConfig
+//	29    - tlb.ConsensusConfig
+//	32-37 - tlb.ValidatorSetAny
+//
+// Cell is returned as is for other params.
+func DecodeParam(id int32, value *cell.Cell) (any, error) {
+	var dst any
+	switch id {
+	case 0, 1, 2, 3, 4:
+		hash, err := value.BeginParse().LoadSlice(256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load address of param %d: %w", id, err)
+		}
+		return address.NewAddress(0, 255, hash), nil
+	case 11:
+		dst = &tlb.ConfigVotingSetup{}
+	case 28:
+		dst = &tlb.CatchainConfig{}
+	case 29:
+		dst = &tlb.ConsensusConfig{}
+	case 32, 33, 34, 35, 36, 37:
+		dst = &tlb.ValidatorSetAny{}
+	default:
+		return value, nil
+	}
+
+	if err := tlb.LoadFromCell(dst, value.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse param %d: %w", id, err)
+	}
+	return dst, nil
+}
@@ -0,0 +1,329 @@
+package contractgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Contract - description of the contract interface, client is generated from it
+type Contract struct {
+	// Package - name of the generated go package
+	Package string `json:"package"`
+	// Name - contract name, client constructor will be New<Name>Client
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	GetMethods  []*GetMethod `json:"get_methods,omitempty"`
+	Messages    []*Message   `json:"messages,omitempty"`
+}
+
+// GetMethod - contract get method, arguments and results are stack values in order
+type GetMethod struct {
+	// Name - get method name in contract, for example get_jetton_data
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Args        []*Field `json:"args,omitempty"`
+	Results     []*Field `json:"results"`
+}
+
+// Message - internal message body with 32 bits opcode
+type Message struct {
+	// Name - message name, for example transfer, payload type will be TransferPayload
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Opcode      string   `json:"opcode"`
+	Fields      []*Field `json:"fields,omitempty"`
+}
+
+// Field - typed value.
+//
+// Stack types (get methods args and results):
+// int, int8-int64, uint8-uint64, bool, coins, address, cell, slice, tuple, any.
+//
+// Message body types: uint1-uint64, int1-int256, bool, coins, address, cell (stored in ref),
+// either_cell (stored inline or in ref), bits<N>, dict<N>.
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Optional - value can be null on stack, or for message cell, stored as Maybe ^Cell
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ParseContract - parses json description of the contract and validates it
+func ParseContract(data []byte) (*Contract, error) {
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse contract description: %w", err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate - checks names and types of the description
+func (c *Contract) Validate() error {
+	if !token.IsIdentifier(c.Package) || strings.ToLower(c.Package) != c.Package {
+		return fmt.Errorf("incorrect package name %q", c.Package)
+	}
+
+	if goName(c.Name) == "" {
+		return fmt.Errorf("contract name is not set")
+	}
+
+	// identifiers of generated code, package level and client methods are checked together,
+	// so description names cannot produce duplicate declarations
+	names := map[string]string{
+		"TonApi":                          "generated api interface",
+		"Client":                          "generated client",
+		"New" + goName(c.Name) + "Client": "generated constructor",
+		"Address":                         "generated client method",
+	}
+	if len(c.Messages) > 0 {
+		names["ErrUnknownOpcode"] = "generated error"
+		names["ParseMessage"] = "generated message parser"
+	}
+
+	addName := func(name, what string) error {
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("%s %s conflicts with %s", what, name, prev)
+		}
+		names[name] = what
+		return nil
+	}
+
+	for _, m := range c.GetMethods {
+		name := goName(m.Name)
+		if name == "" {
+			return fmt.Errorf("get method without name")
+		}
+
+		generated := []string{name, name + "AtBlock"}
+		if len(m.Results) > 1 {
+			generated = append(generated, name+"Result")
+		}
+		for _, n := range generated {
+			if err := addName(n, "get method "+m.Name); err != nil {
+				return err
+			}
+		}
+
+		if len(m.Results) == 0 {
+			return fmt.Errorf("get method %s has no results", m.Name)
+		}
+
+		if err := validateFields(m.Args, stackTypeOf, "argument of "+m.Name); err != nil {
+			return err
+		}
+		if err := validateFields(m.Results, stackTypeOf, "result of "+m.Name); err != nil {
+			return err
+		}
+	}
+
+	opcodes := map[uint32]string{}
+	for _, m := range c.Messages {
+		name := goName(m.Name)
+		if name == "" {
+			return fmt.Errorf("message without name")
+		}
+		for _, n := range []string{name + "Payload", "Build" + name + "Payload", "Parse" + name + "Payload"} {
+			if err := addName(n, "message "+m.Name); err != nil {
+				return err
+			}
+		}
+
+		op, err := m.opcode()
+		if err != nil {
+			return err
+		}
+		if prev, ok := opcodes[op]; ok {
+			return fmt.Errorf("message %s has the same opcode as %s", m.Name, prev)
+		}
+		opcodes[op] = m.Name
+
+		if err = validateFields(m.Fields, tlbTypeOf, "field of "+m.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Message) opcode() (uint32, error) {
+	op, err := strconv.ParseUint(m.Opcode, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("incorrect opcode %q of message %s", m.Opcode, m.Name)
+	}
+	return uint32(op), nil
+}
+
+func validateFields(fields []*Field, typeOf func(f *Field) (goType, tag string, err error), what string) error {
+	names := map[string]bool{}
+	for _, f := range fields {
+		name := goName(f.Name)
+		if name == "" {
+			return fmt.Errorf("%s without name", what)
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate %s %s", what, f.Name)
+		}
+		names[name] = true
+
+		if _, _, err := typeOf(f); err != nil {
+			return fmt.Errorf("%s %s: %w", what, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// stackTypeOf - go type and tvm tag of the stack value
+func stackTypeOf(f *Field) (goType, tag string, err error) {
+	switch f.Type {
+	case "int":
+		goType, tag = "*big.Int", "int"
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		goType, tag = f.Type, "int"
+	case "bool":
+		goType, tag = "bool", "bool"
+	case "coins":
+		goType, tag = "tlb.Coins", "coins"
+	case "address":
+		goType, tag = "*address.Address", "addr"
+	case "cell":
+		goType, tag = "*cell.Cell", "cell"
+	case "slice":
+		goType, tag = "*cell.Slice", "slice"
+	case "tuple":
+		goType, tag = "[]any", "tuple"
+	case "any":
+		goType, tag = "any", "any"
+	default:
+		return "", "", fmt.Errorf("unknown stack type %q", f.Type)
+	}
+
+	if f.Optional {
+		tag = "maybe " + tag
+	}
+	return goType, tag, nil
+}
+
+// tlbTypeOf - go type and tlb tag of the message field
+func tlbTypeOf(f *Field) (goType, tag string, err error) {
+	if f.Optional && f.Type != "cell" {
+		return "", "", fmt.Errorf("only cell can be optional in message")
+	}
+
+	switch f.Type {
+	case "bool":
+		return "bool", "bool", nil
+	case "coins":
+		return "tlb.Coins", ".", nil
+	case "address":
+		return "*address.Address", "addr", nil
+	case "cell":
+		if f.Optional {
+			return "*cell.Cell", "maybe ^", nil
+		}
+		return "*cell.Cell", "^", nil
+	case "either_cell":
+		return "*cell.Cell", "either . ^", nil
+	}
+
+	for _, p := range []struct {
+		prefix string
+		max    uint64
+	}{{"uint", 64}, {"int", 256}, {"bits", 1023}, {"dict", 1023}} {
+		if !strings.HasPrefix(f.Type, p.prefix) {
+			continue
+		}
+
+		n, err := strconv.ParseUint(f.Type[len(p.prefix):], 10, 16)
+		if err != nil || n == 0 || n > p.max {
+			return "", "", fmt.Errorf("incorrect size of %q", f.Type)
+		}
+
+		switch p.prefix {
+		case "uint":
+			return intGoType("uint", n), "## " + strconv.FormatUint(n, 10), nil
+		case "int":
+			if n > 64 {
+				return "*big.Int", "## " + strconv.FormatUint(n, 10), nil
+			}
+			return intGoType("int", n), "## " + strconv.FormatUint(n, 10), nil
+		case "bits":
+			return "[]byte", "bits " + strconv.FormatUint(n, 10), nil
+		case "dict":
+			return "*cell.Dictionary", "dict " + strconv.FormatUint(n, 10), nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown message field type %q", f.Type)
+}
+
+func intGoType(prefix string, bits uint64) string {
+	for _, sz := range []uint64{8, 16, 32} {
+		if bits <= sz {
+			return prefix + strconv.FormatUint(sz, 10)
+		}
+	}
+	return prefix + "64"
+}
+
+var initialisms = map[string]string{
+	"id":   "ID",
+	"url":  "URL",
+	"lt":   "LT",
+	"ton":  "TON",
+	"api":  "API",
+	"addr": "Addr",
+}
+
+// goName - converts snake_case name to exported go identifier
+func goName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	}) {
+		if v, ok := initialisms[strings.ToLower(part)]; ok {
+			sb.WriteString(v)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	res := sb.String()
+	if !token.IsIdentifier(res) {
+		return ""
+	}
+	return res
+}
+
+// argName - converts snake_case name to unexported go identifier, which is not conflicts with generated code
+func argName(name string) string {
+	n := goName(name)
+	for i, r := range n {
+		if r < 'A' || r > 'Z' {
+			if i > 1 {
+				// keep last upper letter of initialism, for example IDValue -> idValue
+				i--
+			}
+			n = strings.ToLower(n[:i]) + n[i:]
+			break
+		}
+		if i == len(n)-1 {
+			n = strings.ToLower(n)
+		}
+	}
+
+	switch n {
+	case "ctx", "b", "c", "err", "res", "result", "params", "body",
+		// imported packages, argument would shadow them in method body
+		"address", "big", "cell", "context", "errors", "fmt", "tlb", "ton":
+		return n + "Arg"
+	}
+	if token.IsKeyword(n) {
+		return n + "Arg"
+	}
+	return n
+}
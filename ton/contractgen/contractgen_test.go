@@ -0,0 +1,174 @@
+package contractgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/example/contract-client/counter"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/liteservertest"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestContract_GenerateUpToDate(t *testing.T) {
+	data, err := os.ReadFile("../../example/contract-client/counter.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ParseContract(data)
+	if err != nil {
+		t.Fatal("parse err:", err)
+	}
+
+	src, err := c.Generate("counter.json")
+	if err != nil {
+		t.Fatal("generate err:", err)
+	}
+
+	committed, err := os.ReadFile("../../example/contract-client/counter/counter.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(src, committed) {
+		t.Fatal("generated example is outdated, run go generate in example/contract-client")
+	}
+}
+
+func TestParseContract_Invalid(t *testing.T) {
+	for name, desc := range map[string]string{
+		"package":      `{"package": "Counter", "name": "counter"}`,
+		"no results":   `{"package": "c", "name": "c", "get_methods": [{"name": "get_x"}]}`,
+		"stack type":   `{"package": "c", "name": "c", "get_methods": [{"name": "get_x", "results": [{"name": "x", "type": "uint256"}]}]}`,
+		"field size":   `{"package": "c", "name": "c", "messages": [{"name": "m", "opcode": "1", "fields": [{"name": "x", "type": "uint65"}]}]}`,
+		"opcode":       `{"package": "c", "name": "c", "messages": [{"name": "m", "opcode": "0x1ffffffff"}]}`,
+		"same opcode":  `{"package": "c", "name": "c", "messages": [{"name": "a", "opcode": "1"}, {"name": "b", "opcode": "0x1"}]}`,
+		"dup field":    `{"package": "c", "name": "c", "messages": [{"name": "m", "opcode": "1", "fields": [{"name": "x", "type": "bool"}, {"name": "x", "type": "bool"}]}]}`,
+		"optional int": `{"package": "c", "name": "c", "messages": [{"name": "m", "opcode": "1", "fields": [{"name": "x", "type": "uint8", "optional": true}]}]}`,
+		"address":      `{"package": "c", "name": "c", "get_methods": [{"name": "address", "results": [{"name": "x", "type": "int"}]}]}`,
+		"constructor":  `{"package": "c", "name": "counter", "get_methods": [{"name": "new_counter_client", "results": [{"name": "x", "type": "int"}]}]}`,
+		"build":        `{"package": "c", "name": "c", "get_methods": [{"name": "build_m_payload", "results": [{"name": "x", "type": "int"}]}], "messages": [{"name": "m", "opcode": "1"}]}`,
+		"parse":        `{"package": "c", "name": "c", "get_methods": [{"name": "parse_message", "results": [{"name": "x", "type": "int"}]}], "messages": [{"name": "m", "opcode": "1"}]}`,
+		"result":       `{"package": "c", "name": "c", "get_methods": [{"name": "x", "results": [{"name": "a", "type": "int"}, {"name": "b", "type": "int"}]}, {"name": "x_result", "results": [{"name": "a", "type": "int"}]}]}`,
+	} {
+		if _, err := ParseContract([]byte(desc)); err == nil {
+			t.Fatal("description should be rejected:", name)
+		}
+	}
+}
+
+func TestArgName(t *testing.T) {
+	for in, want := range map[string]string{
+		"query_id":  "queryID",
+		"id":        "id",
+		"id_value":  "idValue",
+		"new_owner": "newOwner",
+		"type":      "typeArg",
+		"ctx":       "ctxArg",
+		"address":   "addressArg",
+	} {
+		if got := argName(in); got != want {
+			t.Fatal("incorrect arg name for", in, got)
+		}
+	}
+}
+
+func TestGeneratedClient(t *testing.T) {
+	chain := liteservertest.NewChain()
+
+	owner := address.MustParseRawAddr("0:" + "13dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(liteservertest.Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+	chain.SetGetMethod(addr, "get_counter", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		return []any{big.NewInt(7)}, 0
+	})
+	chain.SetGetMethod(addr, "get_counter_data", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		return []any{big.NewInt(7), cell.BeginCell().MustStoreAddr(owner).EndCell().BeginParse(), tlb.MustFromTON("0.5").Nano(), nil}, 0
+	})
+	chain.SetGetMethod(addr, "get_value_for", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		a, err := params[0].(*cell.Slice).LoadAddr()
+		if err != nil || a.String() != owner.String() {
+			return nil, 100
+		}
+		return []any{new(big.Int).Add(big.NewInt(7), params[1].(*big.Int))}, 0
+	})
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	srv := liteservertest.NewServer(chain)
+	if err := srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	defer pool.Stop()
+
+	client := counter.NewCounterClient(ton.NewAPIClient(pool), addr)
+
+	v, err := client.GetCounter(ctx)
+	if err != nil {
+		t.Fatal("get counter err:", err)
+	}
+	if v != 7 {
+		t.Fatal("incorrect counter", v)
+	}
+
+	data, err := client.GetCounterData(ctx)
+	if err != nil {
+		t.Fatal("get counter data err:", err)
+	}
+	if data.Value != 7 || data.Owner.String() != owner.String() || data.LastAmount.String() != "0.5" || data.Note != nil {
+		t.Fatal("incorrect counter data")
+	}
+
+	val, err := client.GetValueFor(ctx, owner, big.NewInt(3))
+	if err != nil {
+		t.Fatal("get value for err:", err)
+	}
+	if val.Int64() != 10 {
+		t.Fatal("incorrect value", val.String())
+	}
+
+	body, err := client.BuildIncreasePayload(11, 5, cell.BeginCell().MustStoreUInt(1, 8).EndCell())
+	if err != nil {
+		t.Fatal("build payload err:", err)
+	}
+
+	msg, err := counter.ParseMessage(body)
+	if err != nil {
+		t.Fatal("parse message err:", err)
+	}
+	inc, ok := msg.(*counter.IncreasePayload)
+	if !ok || inc.QueryID != 11 || inc.Amount != 5 || inc.Note == nil {
+		t.Fatal("incorrect parsed payload")
+	}
+
+	if _, err = counter.ParseChangeOwnerPayload(body); err == nil {
+		t.Fatal("payload with another opcode should not be parsed")
+	}
+
+	if _, err = counter.ParseMessage(cell.BeginCell().MustStoreUInt(1, 32).EndCell()); !errors.Is(err, counter.ErrUnknownOpcode) {
+		t.Fatal("unknown opcode should be rejected", err)
+	}
+}
@@ -0,0 +1,317 @@
+package contractgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+type genField struct {
+	Name   string
+	Arg    string
+	GoType string
+	Tag    string
+}
+
+type genGetMethod struct {
+	Name        string
+	Method      string
+	Description string
+	Args        []genField
+	Results     []genField
+	// ResultType - type returned by method, single result is returned as is
+	ResultType string
+	ZeroResult string
+}
+
+type genMessage struct {
+	Name        string
+	Description string
+	Opcode      string
+	Fields      []genField
+}
+
+type genContract struct {
+	Source      string
+	Package     string
+	Name        string
+	Description string
+	StdImports  []string
+	Imports     []string
+	GetMethods  []genGetMethod
+	Messages    []genMessage
+}
+
+// Generate - generates go source of the contract client, source is the description file name for the header comment
+func (c *Contract) Generate(source string) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	imports := map[string]bool{
+		"context":                                true,
+		"fmt":                                    true,
+		"github.com/xssnick/tonutils-go/address": true,
+		"github.com/xssnick/tonutils-go/ton":     true,
+	}
+	useType := func(goType string) {
+		switch {
+		case strings.Contains(goType, "big."):
+			imports["math/big"] = true
+		case strings.Contains(goType, "tlb."):
+			imports["github.com/xssnick/tonutils-go/tlb"] = true
+		case strings.Contains(goType, "cell."):
+			imports["github.com/xssnick/tonutils-go/tvm/cell"] = true
+		}
+	}
+
+	gc := genContract{
+		Source:      source,
+		Package:     c.Package,
+		Name:        goName(c.Name),
+		Description: c.Description,
+	}
+
+	for _, m := range c.GetMethods {
+		gm := genGetMethod{
+			Name:        goName(m.Name),
+			Method:      m.Name,
+			Description: m.Description,
+		}
+
+		for _, f := range m.Args {
+			goType, tag, _ := stackTypeOf(f)
+			useType(goType)
+			gm.Args = append(gm.Args, genField{Name: goName(f.Name), Arg: argName(f.Name), GoType: goType, Tag: tag})
+		}
+		for _, f := range m.Results {
+			goType, tag, _ := stackTypeOf(f)
+			useType(goType)
+			gm.Results = append(gm.Results, genField{Name: goName(f.Name), GoType: goType, Tag: tag})
+		}
+
+		if len(gm.Results) == 1 {
+			gm.ResultType = gm.Results[0].GoType
+			gm.ZeroResult = zeroValue(gm.ResultType)
+		} else {
+			gm.ResultType = "*" + gm.Name + "Result"
+			gm.ZeroResult = "nil"
+		}
+		gc.GetMethods = append(gc.GetMethods, gm)
+	}
+
+	if len(c.Messages) > 0 {
+		imports["errors"] = true
+		imports["github.com/xssnick/tonutils-go/tlb"] = true
+		imports["github.com/xssnick/tonutils-go/tvm/cell"] = true
+	}
+
+	for _, m := range c.Messages {
+		op, _ := m.opcode()
+		gm := genMessage{
+			Name:        goName(m.Name),
+			Description: m.Description,
+			Opcode:      fmt.Sprintf("%08x", op),
+		}
+
+		for _, f := range m.Fields {
+			goType, tag, _ := tlbTypeOf(f)
+			useType(goType)
+			gm.Fields = append(gm.Fields, genField{Name: goName(f.Name), Arg: argName(f.Name), GoType: goType, Tag: tag})
+		}
+		gc.Messages = append(gc.Messages, gm)
+	}
+
+	for imp := range imports {
+		if strings.Contains(imp, ".") {
+			gc.Imports = append(gc.Imports, imp)
+		} else {
+			gc.StdImports = append(gc.StdImports, imp)
+		}
+	}
+	sort.Strings(gc.StdImports)
+	sort.Strings(gc.Imports)
+
+	var buf bytes.Buffer
+	if err := clientTemplate.Execute(&buf, gc); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+func zeroValue(goType string) string {
+	switch goType {
+	case "bool":
+		return "false"
+	case "tlb.Coins":
+		return "tlb.Coins{}"
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		return "0"
+	}
+	return "nil"
+}
+
+var clientTemplate = template.Must(template.New("client").Funcs(template.FuncMap{
+	"backquote": func(s string) string {
+		return "`" + s + "`"
+	},
+}).Parse(`// Code generated by contractgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	"{{.}}"
+{{- end}}
+{{range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+}
+{{- if .Messages}}
+
+var ErrUnknownOpcode = errors.New("unknown message opcode")
+{{- end}}
+{{range .Messages}}
+{{- if .Description}}
+// {{.Name}}Payload - {{.Description}}
+{{- end}}
+type {{.Name}}Payload struct {
+	_ tlb.Magic {{backquote (printf "tlb:\"#%s\"" .Opcode)}}
+{{- range .Fields}}
+	{{.Name}} {{.GoType}} {{backquote (printf "tlb:\"%s\"" .Tag)}}
+{{- end}}
+}
+{{end}}
+{{- range .GetMethods}}{{if gt (len .Results) 1}}
+type {{.Name}}Result struct {
+{{- range .Results}}
+	{{.Name}} {{.GoType}} {{backquote (printf "tvm:\"%s\"" .Tag)}}
+{{- end}}
+}
+{{end}}{{end}}
+{{- if .Description}}
+// Client - {{.Description}}
+{{- end}}
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+func New{{.Name}}Client(api TonApi, addr *address.Address) *Client {
+	return &Client{
+		addr: addr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+{{range .GetMethods}}
+{{- if .Description}}
+// {{.Name}} - {{.Description}}
+{{- end}}
+func (c *Client) {{.Name}}(ctx context.Context{{range .Args}}, {{.Arg}} {{.GoType}}{{end}}) ({{.ResultType}}, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return {{.ZeroResult}}, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.{{.Name}}AtBlock(ctx{{range .Args}}, {{.Arg}}{{end}}, b)
+}
+
+func (c *Client) {{.Name}}AtBlock(ctx context.Context{{range .Args}}, {{.Arg}} {{.GoType}}{{end}}, b *ton.BlockIDExt) ({{.ResultType}}, error) {
+{{- if .Args}}
+	params, err := ton.EncodeParams(struct {
+{{- range .Args}}
+		{{.Name}} {{.GoType}} {{backquote (printf "tvm:\"%s\"" .Tag)}}
+{{- end}}
+	}{
+{{- range .Args}}
+		{{.Name}}: {{.Arg}},
+{{- end}}
+	})
+	if err != nil {
+		return {{.ZeroResult}}, fmt.Errorf("failed to encode {{.Method}} params: %w", err)
+	}
+
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "{{.Method}}", params...)
+{{- else}}
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "{{.Method}}")
+{{- end}}
+	if err != nil {
+		return {{.ZeroResult}}, fmt.Errorf("failed to run {{.Method}} method: %w", err)
+	}
+{{if gt (len .Results) 1}}
+	var result {{.Name}}Result
+	if err = res.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode {{.Method}} result: %w", err)
+	}
+	return &result, nil
+{{- else}}{{$m := .}}{{with index .Results 0}}
+	var result struct {
+		{{.Name}} {{.GoType}} {{backquote (printf "tvm:\"%s\"" .Tag)}}
+	}
+	if err = res.Decode(&result); err != nil {
+		return {{$m.ZeroResult}}, fmt.Errorf("failed to decode {{$m.Method}} result: %w", err)
+	}
+	return result.{{.Name}}, nil
+{{- end}}{{end}}
+}
+{{end}}
+{{- range .Messages}}
+func (c *Client) Build{{.Name}}Payload({{range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.Arg}} {{$f.GoType}}{{end}}) (*cell.Cell, error) {
+	body, err := tlb.ToCell({{.Name}}Payload{
+{{- range .Fields}}
+		{{.Name}}: {{.Arg}},
+{{- end}}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert {{.Name}}Payload to cell: %w", err)
+	}
+	return body, nil
+}
+
+func Parse{{.Name}}Payload(body *cell.Cell) (*{{.Name}}Payload, error) {
+	var p {{.Name}}Payload
+	if err := tlb.LoadFromCell(&p, body.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse {{.Name}}Payload: %w", err)
+	}
+	return &p, nil
+}
+{{end}}
+{{- if .Messages}}
+// ParseMessage - parses message body by its opcode, returns pointer to payload struct
+func ParseMessage(body *cell.Cell) (any, error) {
+	op, err := body.BeginParse().LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load opcode: %w", err)
+	}
+
+	switch op {
+{{- range .Messages}}
+	case 0x{{.Opcode}}:
+		p, err := Parse{{.Name}}Payload(body)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+{{- end}}
+	}
+	return nil, fmt.Errorf("%w: %x", ErrUnknownOpcode, op)
+}
+{{- end}}
+`))
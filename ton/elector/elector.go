@@ -0,0 +1,492 @@
+package elector
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrNoElectorData - elector account is not active, so it has no data
+var ErrNoElectorData = errors.New("elector contract is not active")
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+	GetBlockchainConfig(ctx context.Context, block *ton.BlockIDExt, onlyParams ...int32) (*ton.BlockchainConfig, error)
+	GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error)
+}
+
+// State - decoded data of the elector contract
+type State struct {
+	// Election - current elections, nil when elections are not announced
+	Election *Election
+	// Credits - stakes and rewards which can be recovered with recover_stake message
+	Credits       []*Credit
+	PastElections []*PastElection
+	// Grams - funds which are not owned by anyone, they are distributed as bonuses
+	Grams tlb.Coins
+	// ActiveID - id of the elections of the current validator set
+	ActiveID   uint32
+	ActiveHash []byte
+}
+
+// Election - state of the current elections
+type Election struct {
+	// ID - elections id, it is the unix time when the new validator set will be active
+	ID           uint32
+	CloseAt      uint32
+	MinStake     tlb.Coins
+	TotalStake   tlb.Coins
+	Participants []*Participant
+	Failed       bool
+	Finished     bool
+}
+
+// Participant - stake application of the validator in the current elections
+type Participant struct {
+	PubKey ed25519.PublicKey
+	Stake  tlb.Coins
+	// Time - unix time of the last stake
+	Time uint32
+	// MaxFactor - max ratio of the validator stake to the minimal stake in the set, 16.16 fixed point
+	MaxFactor uint32
+	// Address - masterchain wallet which sent the stake
+	Address  *address.Address
+	ADNLAddr []byte
+}
+
+// Credit - funds of the wallet which can be recovered
+type Credit struct {
+	Address *address.Address
+	Amount  tlb.Coins
+}
+
+// PastElection - elections of the active or previous validator sets, their stakes are still frozen
+type PastElection struct {
+	ID         uint32
+	UnfreezeAt uint32
+	// StakeHeld - period in seconds after validation end, when stakes are frozen
+	StakeHeld        uint32
+	ValidatorSetHash []byte
+	Frozen           []*FrozenStake
+	TotalStake       tlb.Coins
+	Bonuses          tlb.Coins
+	Complaints       []*Complaint
+}
+
+// FrozenStake - stake of the elected validator
+type FrozenStake struct {
+	PubKey  ed25519.PublicKey
+	Address *address.Address
+	Weight  uint64
+	Stake   tlb.Coins
+	Banned  bool
+}
+
+// Complaint - complaint about the validator of the past elections
+type Complaint struct {
+	// Hash - hash of the complaint, it is used for voting
+	Hash              []byte
+	ValidatorPubKey   ed25519.PublicKey
+	Description       *cell.Cell
+	CreatedAt         uint32
+	Severity          uint8
+	RewardAddress     *address.Address
+	Paid              tlb.Coins
+	SuggestedFine     tlb.Coins
+	SuggestedFinePart uint32
+	// Voters - indexes of validators voted for the complaint
+	Voters []uint16
+	// ValidatorSetID - hash of the validator set where complaint is voted
+	ValidatorSetID  []byte
+	WeightRemaining int64
+}
+
+type electorData struct {
+	Elect         *electData       `tlb:"maybe ^"`
+	Credits       *cell.Dictionary `tlb:"dict 256"`
+	PastElections *cell.Dictionary `tlb:"dict 32"`
+	Grams         tlb.Coins        `tlb:"."`
+	ActiveID      uint32           `tlb:"## 32"`
+	ActiveHash    []byte           `tlb:"bits 256"`
+}
+
+type electData struct {
+	ElectAt    uint32           `tlb:"## 32"`
+	ElectClose uint32           `tlb:"## 32"`
+	MinStake   tlb.Coins        `tlb:"."`
+	TotalStake tlb.Coins        `tlb:"."`
+	Members    *cell.Dictionary `tlb:"dict 256"`
+	Failed     bool             `tlb:"bool"`
+	Finished   bool             `tlb:"bool"`
+}
+
+type electMember struct {
+	Stake     tlb.Coins `tlb:"."`
+	Time      uint32    `tlb:"## 32"`
+	MaxFactor uint32    `tlb:"## 32"`
+	SrcAddr   []byte    `tlb:"bits 256"`
+	ADNLAddr  []byte    `tlb:"bits 256"`
+}
+
+type pastElection struct {
+	UnfreezeAt uint32           `tlb:"## 32"`
+	StakeHeld  uint32           `tlb:"## 32"`
+	VsetHash   []byte           `tlb:"bits 256"`
+	Frozen     *cell.Dictionary `tlb:"dict 256"`
+	TotalStake tlb.Coins        `tlb:"."`
+	Bonuses    tlb.Coins        `tlb:"."`
+	Complaints *cell.Dictionary `tlb:"dict 256"`
+}
+
+type frozenStake struct {
+	Addr   []byte    `tlb:"bits 256"`
+	Weight uint64    `tlb:"## 64"`
+	Stake  tlb.Coins `tlb:"."`
+	Banned bool      `tlb:"bool"`
+}
+
+type complaintStatus struct {
+	_               tlb.Magic          `tlb:"#2d"`
+	Complaint       validatorComplaint `tlb:"^"`
+	Voters          *cell.Dictionary   `tlb:"dict 16"`
+	VsetID          []byte             `tlb:"bits 256"`
+	WeightRemaining int64              `tlb:"## 64"`
+}
+
+type validatorComplaint struct {
+	_                 tlb.Magic  `tlb:"#bc"`
+	ValidatorPubKey   []byte     `tlb:"bits 256"`
+	Description       *cell.Cell `tlb:"^"`
+	CreatedAt         uint32     `tlb:"## 32"`
+	Severity          uint8      `tlb:"## 8"`
+	RewardAddr        []byte     `tlb:"bits 256"`
+	Paid              tlb.Coins  `tlb:"."`
+	SuggestedFine     tlb.Coins  `tlb:"."`
+	SuggestedFinePart uint32     `tlb:"## 32"`
+}
+
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+// ContractAddr - gets elector address from the config param 1
+func ContractAddr(ctx context.Context, api TonApi) (*address.Address, error) {
+	b, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	cfg, err := api.GetBlockchainConfig(ctx, b, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elector address from network config: %w", err)
+	}
+
+	data := cfg.Get(1)
+	if data == nil {
+		return nil, fmt.Errorf("failed to get elector address from network config")
+	}
+
+	hash, err := data.BeginParse().LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elector address from network config 1, failed to load hash: %w", err)
+	}
+	return masterAddr(hash), nil
+}
+
+func NewElectorClient(api TonApi, electorAddr *address.Address) *Client {
+	return &Client{
+		addr: electorAddr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// GetState - loads elector account with proof and decodes its data
+func (c *Client) GetState(ctx context.Context) (*State, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetStateAtBlock(ctx, b)
+}
+
+func (c *Client) GetStateAtBlock(ctx context.Context, b *ton.BlockIDExt) (*State, error) {
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elector account: %w", err)
+	}
+
+	if !acc.IsActive || acc.Data == nil {
+		return nil, ErrNoElectorData
+	}
+	return ParseState(acc.Data)
+}
+
+// GetActiveElectionID - returns id of the current elections, 0 when elections are not announced
+func (c *Client) GetActiveElectionID(ctx context.Context) (uint32, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetActiveElectionIDAtBlock(ctx, b)
+}
+
+func (c *Client) GetActiveElectionIDAtBlock(ctx context.Context, b *ton.BlockIDExt) (uint32, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "active_election_id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to run active_election_id method: %w", err)
+	}
+
+	var result struct {
+		ID uint32 `tvm:"int"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode active_election_id result: %w", err)
+	}
+	return result.ID, nil
+}
+
+// GetReturnedStake - returns amount which can be recovered by the masterchain wallet with recover_stake message
+func (c *Client) GetReturnedStake(ctx context.Context, wallet *address.Address) (tlb.Coins, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetReturnedStakeAtBlock(ctx, wallet, b)
+}
+
+func (c *Client) GetReturnedStakeAtBlock(ctx context.Context, wallet *address.Address, b *ton.BlockIDExt) (tlb.Coins, error) {
+	if wallet.Workchain() != -1 {
+		return tlb.ZeroCoins, nil
+	}
+
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "compute_returned_stake", new(big.Int).SetBytes(wallet.Data()))
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to run compute_returned_stake method: %w", err)
+	}
+
+	var result struct {
+		Amount tlb.Coins `tvm:"coins"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to decode compute_returned_stake result: %w", err)
+	}
+	return result.Amount, nil
+}
+
+// ParseState - decodes elector contract data
+func ParseState(data *cell.Cell) (*State, error) {
+	var d electorData
+	if err := tlb.LoadFromCell(&d, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse elector data: %w", err)
+	}
+
+	st := &State{
+		Grams:      d.Grams,
+		ActiveID:   d.ActiveID,
+		ActiveHash: d.ActiveHash,
+	}
+
+	if d.Elect != nil {
+		el, err := parseElection(d.Elect)
+		if err != nil {
+			return nil, err
+		}
+		st.Election = el
+	}
+
+	err := forEach(d.Credits, func(key, value *cell.Slice) error {
+		var amount tlb.Coins
+		if err := tlb.LoadFromCell(&amount, value); err != nil {
+			return fmt.Errorf("failed to parse credit: %w", err)
+		}
+
+		st.Credits = append(st.Credits, &Credit{
+			Address: masterAddr(key.MustLoadSlice(256)),
+			Amount:  amount,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEach(d.PastElections, func(key, value *cell.Slice) error {
+		pe, err := parsePastElection(uint32(key.MustLoadUInt(32)), value)
+		if err != nil {
+			return err
+		}
+		st.PastElections = append(st.PastElections, pe)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(st.PastElections, func(i, j int) bool {
+		return st.PastElections[i].ID < st.PastElections[j].ID
+	})
+	return st, nil
+}
+
+// Participant - returns stake application of the validator in the current elections, nil if there is no such
+func (s *State) Participant(pubKey ed25519.PublicKey) *Participant {
+	if s.Election == nil {
+		return nil
+	}
+
+	for _, p := range s.Election.Participants {
+		if p.PubKey.Equal(pubKey) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Credit - returns amount which can be recovered by the wallet
+func (s *State) Credit(wallet *address.Address) tlb.Coins {
+	for _, c := range s.Credits {
+		if c.Address.Workchain() == wallet.Workchain() && string(c.Address.Data()) == string(wallet.Data()) {
+			return c.Amount
+		}
+	}
+	return tlb.ZeroCoins
+}
+
+// PastElection - returns past elections by id, nil if there is no such
+func (s *State) PastElection(id uint32) *PastElection {
+	for _, pe := range s.PastElections {
+		if pe.ID == id {
+			return pe
+		}
+	}
+	return nil
+}
+
+func parseElection(d *electData) (*Election, error) {
+	el := &Election{
+		ID:         d.ElectAt,
+		CloseAt:    d.ElectClose,
+		MinStake:   d.MinStake,
+		TotalStake: d.TotalStake,
+		Failed:     d.Failed,
+		Finished:   d.Finished,
+	}
+
+	err := forEach(d.Members, func(key, value *cell.Slice) error {
+		var m electMember
+		if err := tlb.LoadFromCell(&m, value); err != nil {
+			return fmt.Errorf("failed to parse election participant: %w", err)
+		}
+
+		el.Participants = append(el.Participants, &Participant{
+			PubKey:    key.MustLoadSlice(256),
+			Stake:     m.Stake,
+			Time:      m.Time,
+			MaxFactor: m.MaxFactor,
+			Address:   masterAddr(m.SrcAddr),
+			ADNLAddr:  m.ADNLAddr,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return el, nil
+}
+
+func parsePastElection(id uint32, value *cell.Slice) (*PastElection, error) {
+	var d pastElection
+	if err := tlb.LoadFromCell(&d, value); err != nil {
+		return nil, fmt.Errorf("failed to parse past elections %d: %w", id, err)
+	}
+
+	pe := &PastElection{
+		ID:               id,
+		UnfreezeAt:       d.UnfreezeAt,
+		StakeHeld:        d.StakeHeld,
+		ValidatorSetHash: d.VsetHash,
+		TotalStake:       d.TotalStake,
+		Bonuses:          d.Bonuses,
+	}
+
+	err := forEach(d.Frozen, func(key, value *cell.Slice) error {
+		var f frozenStake
+		if err := tlb.LoadFromCell(&f, value); err != nil {
+			return fmt.Errorf("failed to parse frozen stake of elections %d: %w", id, err)
+		}
+
+		pe.Frozen = append(pe.Frozen, &FrozenStake{
+			PubKey:  key.MustLoadSlice(256),
+			Address: masterAddr(f.Addr),
+			Weight:  f.Weight,
+			Stake:   f.Stake,
+			Banned:  f.Banned,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEach(d.Complaints, func(key, value *cell.Slice) error {
+		var cs complaintStatus
+		if err := tlb.LoadFromCell(&cs, value); err != nil {
+			return fmt.Errorf("failed to parse complaint of elections %d: %w", id, err)
+		}
+
+		c := &Complaint{
+			Hash:              key.MustLoadSlice(256),
+			ValidatorPubKey:   cs.Complaint.ValidatorPubKey,
+			Description:       cs.Complaint.Description,
+			CreatedAt:         cs.Complaint.CreatedAt,
+			Severity:          cs.Complaint.Severity,
+			RewardAddress:     masterAddr(cs.Complaint.RewardAddr),
+			Paid:              cs.Complaint.Paid,
+			SuggestedFine:     cs.Complaint.SuggestedFine,
+			SuggestedFinePart: cs.Complaint.SuggestedFinePart,
+			ValidatorSetID:    cs.VsetID,
+			WeightRemaining:   cs.WeightRemaining,
+		}
+
+		err := forEach(cs.Voters, func(key, _ *cell.Slice) error {
+			c.Voters = append(c.Voters, uint16(key.MustLoadUInt(16)))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		pe.Complaints = append(pe.Complaints, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pe, nil
+}
+
+func forEach(dict *cell.Dictionary, fn func(key, value *cell.Slice) error) error {
+	if dict == nil {
+		return nil
+	}
+	return dict.ForEach(fn)
+}
+
+func masterAddr(hash []byte) *address.Address {
+	return address.NewAddress(0, 255, hash)
+}
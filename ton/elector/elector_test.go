@@ -0,0 +1,287 @@
+package elector
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/liteservertest"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func key256(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func mustDictSet(t *testing.T, dict *cell.Dictionary, key *cell.Cell, value any) {
+	var v *cell.Cell
+	switch x := value.(type) {
+	case *cell.Cell:
+		v = x
+	default:
+		var err error
+		if v, err = tlb.ToCell(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := dict.Set(key, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testElectorData(t *testing.T) *cell.Cell {
+	members := cell.NewDict(256)
+	mustDictSet(t, members, cell.BeginCell().MustStoreSlice(key256(1), 256).EndCell(), electMember{
+		Stake:     tlb.MustFromTON("300000"),
+		Time:      1700000100,
+		MaxFactor: 3 * MaxFactorOne,
+		SrcAddr:   key256(0xAA),
+		ADNLAddr:  key256(0xAD),
+	})
+
+	credits := cell.NewDict(256)
+	mustDictSet(t, credits, cell.BeginCell().MustStoreSlice(key256(0xBB), 256).EndCell(), tlb.MustFromTON("12.5"))
+
+	frozen := cell.NewDict(256)
+	mustDictSet(t, frozen, cell.BeginCell().MustStoreSlice(key256(2), 256).EndCell(), frozenStake{
+		Addr:   key256(0xBB),
+		Weight: 1 << 50,
+		Stake:  tlb.MustFromTON("500000"),
+		Banned: true,
+	})
+
+	voters := cell.NewDict(16)
+	mustDictSet(t, voters, cell.BeginCell().MustStoreUInt(3, 16).EndCell(), cell.BeginCell().EndCell())
+	mustDictSet(t, voters, cell.BeginCell().MustStoreUInt(7, 16).EndCell(), cell.BeginCell().EndCell())
+
+	complaints := cell.NewDict(256)
+	mustDictSet(t, complaints, cell.BeginCell().MustStoreSlice(key256(0xCC), 256).EndCell(), complaintStatus{
+		Complaint: validatorComplaint{
+			ValidatorPubKey:   key256(2),
+			Description:       cell.BeginCell().MustStoreUInt(0x123, 32).EndCell(),
+			CreatedAt:         1700000200,
+			Severity:          2,
+			RewardAddr:        key256(0xDD),
+			Paid:              tlb.MustFromTON("10"),
+			SuggestedFine:     tlb.MustFromTON("101"),
+			SuggestedFinePart: 0,
+		},
+		Voters:          voters,
+		VsetID:          key256(0xEE),
+		WeightRemaining: -5,
+	})
+
+	past := cell.NewDict(32)
+	mustDictSet(t, past, cell.BeginCell().MustStoreUInt(1699990000, 32).EndCell(), pastElection{
+		UnfreezeAt: 1700100000,
+		StakeHeld:  32768,
+		VsetHash:   key256(0xEE),
+		Frozen:     frozen,
+		TotalStake: tlb.MustFromTON("500000"),
+		Bonuses:    tlb.MustFromTON("77"),
+		Complaints: complaints,
+	})
+	mustDictSet(t, past, cell.BeginCell().MustStoreUInt(1699900000, 32).EndCell(), pastElection{
+		UnfreezeAt: 1700000000,
+		StakeHeld:  32768,
+		VsetHash:   key256(0xEF),
+		TotalStake: tlb.MustFromTON("1"),
+		Bonuses:    tlb.ZeroCoins,
+	})
+
+	data, err := tlb.ToCell(electorData{
+		Elect: &electData{
+			ElectAt:    1700065536,
+			ElectClose: 1700057344,
+			MinStake:   tlb.MustFromTON("10000"),
+			TotalStake: tlb.MustFromTON("300000"),
+			Members:    members,
+		},
+		Credits:       credits,
+		PastElections: past,
+		Grams:         tlb.MustFromTON("3"),
+		ActiveID:      1699990000,
+		ActiveHash:    key256(0xEE),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestClient_GetState(t *testing.T) {
+	chain := liteservertest.NewChain()
+
+	electorAddr := address.MustParseRawAddr("-1:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	chain.SetConfigParam(1, cell.BeginCell().MustStoreSlice(electorAddr.Data(), 256).EndCell())
+	chain.SetAccount(liteservertest.Account{
+		Address: electorAddr,
+		Balance: tlb.MustFromTON("1000000"),
+		Code:    cell.BeginCell().MustStoreUInt(1, 8).EndCell(),
+		Data:    testElectorData(t),
+	})
+	chain.SetGetMethod(electorAddr, "active_election_id", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		return []any{big.NewInt(1700065536)}, 0
+	})
+	chain.SetGetMethod(electorAddr, "compute_returned_stake", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		if params[0].(*big.Int).Cmp(new(big.Int).SetBytes(key256(0xBB))) != 0 {
+			return []any{big.NewInt(0)}, 0
+		}
+		return []any{tlb.MustFromTON("12.5").Nano()}, 0
+	})
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	srv := liteservertest.NewServer(chain)
+	if err := srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	defer pool.Stop()
+	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+
+	addr, err := ContractAddr(ctx, api)
+	if err != nil {
+		t.Fatal("get elector addr err:", err)
+	}
+	if addr.String() != electorAddr.String() {
+		t.Fatal("incorrect elector address", addr.String())
+	}
+
+	client := NewElectorClient(api, addr)
+
+	id, err := client.GetActiveElectionID(ctx)
+	if err != nil || id != 1700065536 {
+		t.Fatal("incorrect active election id", id, err)
+	}
+
+	wallet := masterAddr(key256(0xBB))
+	returned, err := client.GetReturnedStake(ctx, wallet)
+	if err != nil || returned.String() != "12.5" {
+		t.Fatal("incorrect returned stake", returned.String(), err)
+	}
+
+	st, err := client.GetState(ctx)
+	if err != nil {
+		t.Fatal("get state err:", err)
+	}
+
+	if st.Election == nil || st.Election.ID != 1700065536 || st.Election.CloseAt != 1700057344 ||
+		st.Election.MinStake.String() != "10000" || st.Election.Failed || st.Election.Finished {
+		t.Fatal("incorrect election")
+	}
+
+	p := st.Participant(key256(1))
+	if p == nil || p.Stake.String() != "300000" || p.MaxFactor != 3*MaxFactorOne ||
+		p.Address.String() != masterAddr(key256(0xAA)).String() || !bytes.Equal(p.ADNLAddr, key256(0xAD)) {
+		t.Fatal("incorrect participant", p)
+	}
+	if st.Participant(key256(2)) != nil {
+		t.Fatal("should be no participant")
+	}
+
+	if st.Credit(wallet).String() != "12.5" || st.Credit(masterAddr(key256(1))).String() != "0" {
+		t.Fatal("incorrect credits")
+	}
+
+	if st.Grams.String() != "3" || st.ActiveID != 1699990000 || !bytes.Equal(st.ActiveHash, key256(0xEE)) {
+		t.Fatal("incorrect state")
+	}
+
+	if len(st.PastElections) != 2 || st.PastElections[0].ID != 1699900000 {
+		t.Fatal("past elections should be sorted")
+	}
+
+	pe := st.PastElection(st.ActiveID)
+	if pe == nil || pe.UnfreezeAt != 1700100000 || pe.Bonuses.String() != "77" || len(pe.Frozen) != 1 || len(pe.Complaints) != 1 {
+		t.Fatal("incorrect past elections")
+	}
+
+	f := pe.Frozen[0]
+	if !bytes.Equal(f.PubKey, key256(2)) || !f.Banned || f.Weight != 1<<50 || f.Address.String() != wallet.String() {
+		t.Fatal("incorrect frozen stake")
+	}
+
+	c := pe.Complaints[0]
+	if !bytes.Equal(c.Hash, key256(0xCC)) || c.Severity != 2 || c.WeightRemaining != -5 ||
+		c.SuggestedFine.String() != "101" || len(c.Voters) != 2 || c.Voters[0] != 3 || c.Voters[1] != 7 ||
+		c.Description.BeginParse().MustLoadUInt(32) != 0x123 {
+		t.Fatal("incorrect complaint")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	wallet := masterAddr(key256(0xBB))
+
+	body, err := BuildNewStakePayload(key, wallet, 1700065536, 3*MaxFactorOne, key256(0xAD))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stake NewStakePayload
+	if err = tlb.LoadFromCell(&stake, body.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewStakeSignData(wallet, stake.ElectionID, stake.MaxFactor, stake.ADNLAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 76 || !bytes.Equal(stake.ValidatorPubKey, pub) || !ed25519.Verify(pub, data, stake.Signature.Value) {
+		t.Fatal("incorrect stake signature")
+	}
+
+	if _, err = BuildNewStakePayload(key, wallet, 1, MaxFactorOne-1, key256(0xAD)); err == nil {
+		t.Fatal("max factor below 1 should be rejected")
+	}
+
+	body, err = BuildRecoverStakePayload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.BeginParse().MustLoadUInt(32) != 0x47657424 || body.BitsSize() != 96 {
+		t.Fatal("incorrect recover stake payload")
+	}
+
+	body, err = BuildComplaintVotePayload(key, 7, 1699990000, key256(0xCC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := body.BeginParse()
+	if s.MustLoadUInt(32) != 0x56744370 {
+		t.Fatal("incorrect vote opcode")
+	}
+	s.MustLoadUInt(64)
+	sig := s.MustLoadSlice(512)
+
+	signed := s.MustLoadSlice(s.BitsLeft())
+	if !ed25519.Verify(pub, signed, sig) {
+		t.Fatal("incorrect vote signature")
+	}
+
+	var vote ComplaintVotePayload
+	if err = tlb.LoadFromCell(&vote, body.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if vote.SignTag != 0x56744350 || vote.ValidatorIndex != 7 || vote.ElectionID != 1699990000 || !bytes.Equal(vote.ComplaintHash, key256(0xCC)) {
+		t.Fatal("incorrect vote payload")
+	}
+}
@@ -0,0 +1,183 @@
+package elector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Opcodes of elector responses, query id of the request is returned in response
+const (
+	OpNewStakeConfirmation = 0xf374484c
+	OpNewStakeRejected     = 0xee6f454c
+	OpRecoverStakeOK       = 0xf96f7324
+	OpRecoverStakeError    = 0xfffffffe
+)
+
+// MaxFactorOne - max factor 1.0 in 16.16 fixed point format, elector accepts values from 1.0
+const MaxFactorOne = 1 << 16
+
+const (
+	newStakeSignTag      = 0x654c5074
+	complaintVoteSignTag = 0x56744350
+)
+
+// NewStakePayload - stake application, should be sent from the masterchain wallet with value of stake + 1 TON for fees
+type NewStakePayload struct {
+	_               tlb.Magic `tlb:"#4e73744b"`
+	QueryID         uint64    `tlb:"## 64"`
+	ValidatorPubKey []byte    `tlb:"bits 256"`
+	ElectionID      uint32    `tlb:"## 32"`
+	MaxFactor       uint32    `tlb:"## 32"`
+	ADNLAddr        []byte    `tlb:"bits 256"`
+	Signature       struct {
+		Value []byte `tlb:"bits 512"`
+	} `tlb:"^"`
+}
+
+// RecoverStakePayload - requests credits of the wallet, should be sent from the masterchain wallet with 1 TON for fees
+type RecoverStakePayload struct {
+	_       tlb.Magic `tlb:"#47657424"`
+	QueryID uint64    `tlb:"## 64"`
+}
+
+// ComplaintVotePayload - vote of the validator for the complaint, should be sent from the masterchain wallet
+type ComplaintVotePayload struct {
+	_         tlb.Magic `tlb:"#56744370"`
+	QueryID   uint64    `tlb:"## 64"`
+	Signature []byte    `tlb:"bits 512"`
+	SignTag   uint32    `tlb:"## 32"`
+	// ValidatorIndex - index of the voting validator in the current validator set
+	ValidatorIndex uint16 `tlb:"## 16"`
+	ElectionID     uint32 `tlb:"## 32"`
+	ComplaintHash  []byte `tlb:"bits 256"`
+}
+
+// NewStakeSignData - data which should be signed by the validator key for stake application,
+// it can be used when key is stored in validator node or external signer.
+func NewStakeSignData(wallet *address.Address, electionID, maxFactor uint32, adnlAddr []byte) ([]byte, error) {
+	if len(adnlAddr) != 32 {
+		return nil, fmt.Errorf("incorrect adnl address size")
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(newStakeSignTag, 32).
+		MustStoreUInt(uint64(electionID), 32).
+		MustStoreUInt(uint64(maxFactor), 32).
+		MustStoreSlice(wallet.Data(), 256).
+		MustStoreSlice(adnlAddr, 256).
+		EndCell().BeginParse().LoadSlice(32 + 32 + 32 + 256 + 256)
+}
+
+// BuildNewStakePayload - builds stake application signed by validator key.
+// Wallet is a masterchain address which will send the stake, maxFactor is 16.16 fixed point, see MaxFactorOne.
+func BuildNewStakePayload(key ed25519.PrivateKey, wallet *address.Address, electionID, maxFactor uint32, adnlAddr []byte) (*cell.Cell, error) {
+	data, err := NewStakeSignData(wallet, electionID, maxFactor, adnlAddr)
+	if err != nil {
+		return nil, err
+	}
+	return BuildNewStakePayloadSigned(key.Public().(ed25519.PublicKey), ed25519.Sign(key, data), electionID, maxFactor, adnlAddr)
+}
+
+// BuildNewStakePayloadSigned - same as BuildNewStakePayload, but with signature of NewStakeSignData made externally
+func BuildNewStakePayloadSigned(pubKey ed25519.PublicKey, signature []byte, electionID, maxFactor uint32, adnlAddr []byte) (*cell.Cell, error) {
+	if maxFactor < MaxFactorOne {
+		return nil, fmt.Errorf("max factor should be at least 1.0")
+	}
+	if len(pubKey) != ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("incorrect public key or signature size")
+	}
+	if len(adnlAddr) != 32 {
+		return nil, fmt.Errorf("incorrect adnl address size")
+	}
+
+	queryID, err := randomQueryID()
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewStakePayload{
+		QueryID:         queryID,
+		ValidatorPubKey: pubKey,
+		ElectionID:      electionID,
+		MaxFactor:       maxFactor,
+		ADNLAddr:        adnlAddr,
+	}
+	p.Signature.Value = signature
+
+	body, err := tlb.ToCell(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert NewStakePayload to cell: %w", err)
+	}
+	return body, nil
+}
+
+// BuildRecoverStakePayload - builds request to return credits to the sender wallet
+func BuildRecoverStakePayload() (*cell.Cell, error) {
+	queryID, err := randomQueryID()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := tlb.ToCell(RecoverStakePayload{
+		QueryID: queryID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert RecoverStakePayload to cell: %w", err)
+	}
+	return body, nil
+}
+
+// ComplaintVoteSignData - data which should be signed by the validator key to vote for the complaint
+func ComplaintVoteSignData(validatorIndex uint16, electionID uint32, complaintHash []byte) ([]byte, error) {
+	if len(complaintHash) != 32 {
+		return nil, fmt.Errorf("incorrect complaint hash size")
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(complaintVoteSignTag, 32).
+		MustStoreUInt(uint64(validatorIndex), 16).
+		MustStoreUInt(uint64(electionID), 32).
+		MustStoreSlice(complaintHash, 256).
+		EndCell().BeginParse().LoadSlice(32 + 16 + 32 + 256)
+}
+
+// BuildComplaintVotePayload - builds vote for the complaint signed by validator key,
+// validatorIndex is the index of the validator in the current validator set (config param 34).
+func BuildComplaintVotePayload(key ed25519.PrivateKey, validatorIndex uint16, electionID uint32, complaintHash []byte) (*cell.Cell, error) {
+	data, err := ComplaintVoteSignData(validatorIndex, electionID, complaintHash)
+	if err != nil {
+		return nil, err
+	}
+
+	queryID, err := randomQueryID()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := tlb.ToCell(ComplaintVotePayload{
+		QueryID:        queryID,
+		Signature:      ed25519.Sign(key, data),
+		SignTag:        complaintVoteSignTag,
+		ValidatorIndex: validatorIndex,
+		ElectionID:     electionID,
+		ComplaintHash:  complaintHash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert ComplaintVotePayload to cell: %w", err)
+	}
+	return body, nil
+}
+
+func randomQueryID() (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -18,6 +19,8 @@ func init() {
 	tl.Register(GetLibraries{}, "liteServer.getLibraries library_list:(vector int256) = liteServer.LibraryResult")
 	tl.Register(LibraryEntry{}, "liteServer.libraryEntry hash:int256 data:bytes = liteServer.LibraryEntry")
 	tl.Register(LibraryResult{}, "liteServer.libraryResult result:(vector liteServer.libraryEntry) = liteServer.LibraryResult")
+	tl.Register(GetLibrariesWithProof{}, "liteServer.getLibrariesWithProof id:tonNode.blockIdExt mode:# library_list:(vector int256) = liteServer.LibraryResultWithProof")
+	tl.Register(LibraryResultWithProof{}, "liteServer.libraryResultWithProof id:tonNode.blockIdExt mode:# result:(vector liteServer.libraryEntry) state_proof:bytes data_proof:bytes = liteServer.LibraryResultWithProof")
 }
 
 type GetLibraries struct {
@@ -33,6 +36,20 @@ type LibraryResult struct {
 	Result []*LibraryEntry `tl:"vector struct"`
 }
 
+type GetLibrariesWithProof struct {
+	ID          *BlockIDExt `tl:"struct"`
+	Mode        uint32      `tl:"flags"`
+	LibraryList [][]byte    `tl:"vector int256"`
+}
+
+type LibraryResultWithProof struct {
+	ID         *BlockIDExt     `tl:"struct"`
+	Mode       uint32          `tl:"flags"`
+	Result     []*LibraryEntry `tl:"vector struct"`
+	StateProof *cell.Cell      `tl:"cell"`
+	DataProof  *cell.Cell      `tl:"cell"`
+}
+
 type ConfigAll struct {
 	Mode        int         `tl:"int"`
 	ID          *BlockIDExt `tl:"struct"`
@@ -87,6 +104,70 @@ func (c *APIClient) GetLibraries(ctx context.Context, hashes ...[]byte) ([]*cell
 	return nil, errUnexpectedResponse(resp)
 }
 
+// GetLibrariesWithProof - same as GetLibraries, but also checks that libraries are published in the masterchain state of the block.
+// Libraries which are not found are returned as nil.
+func (c *APIClient) GetLibrariesWithProof(ctx context.Context, master *BlockIDExt, hashes ...[]byte) ([]*cell.Cell, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetLibrariesWithProof{
+		ID:          master,
+		LibraryList: hashes,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case LibraryResultWithProof:
+		var libs *cell.Dictionary
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			state, err := CheckBlockShardStateProof([]*cell.Cell{t.StateProof, t.DataProof}, master.RootHash)
+			if err != nil {
+				return nil, fmt.Errorf("incorrect proof: %w", err)
+			}
+
+			var stats tlb.ShardStateStats
+			if err = tlb.LoadFromCellAsProof(&stats, state.Stats.BeginParse()); err != nil {
+				return nil, fmt.Errorf("failed to load shard state stats: %w", err)
+			}
+			libs = stats.Libraries
+		}
+
+		libList := make([]*cell.Cell, len(hashes))
+		for i := 0; i < len(hashes); i++ {
+			for _, e := range t.Result {
+				if !bytes.Equal(hashes[i], e.Data.Hash()) {
+					continue
+				}
+
+				if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+					if libs == nil {
+						return nil, fmt.Errorf("library %x is not found in proof", hashes[i])
+					}
+
+					val, err := libs.LoadValue(cell.BeginCell().MustStoreSlice(hashes[i], 256).EndCell())
+					if err != nil {
+						return nil, fmt.Errorf("library %x is not found in proof: %w", hashes[i], err)
+					}
+
+					var descr tlb.LibDescr
+					if err = tlb.LoadFromCellAsProof(&descr, val); err != nil {
+						return nil, fmt.Errorf("failed to load library %x description: %w", hashes[i], err)
+					}
+
+					if !bytes.Equal(descr.Lib.Hash(0), hashes[i]) {
+						return nil, fmt.Errorf("incorrect library %x in proof", hashes[i])
+					}
+				}
+				libList[i] = e.Data
+			}
+		}
+		return libList, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
 func (c *APIClient) GetBlockchainConfig(ctx context.Context, block *BlockIDExt, onlyParams ...int32) (*BlockchainConfig, error) {
 	var resp tl.Serializable
 	var err error
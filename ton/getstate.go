@@ -40,46 +40,69 @@ type AccountID struct {
 }
 
 func (c *APIClient) GetAccount(ctx context.Context, block *BlockIDExt, addr *address.Address) (*tlb.Account, error) {
-	var resp tl.Serializable
-	err := c.client.QueryLiteserver(ctx, GetAccountState{
+	st, shardAcc, err := c.getAccountState(ctx, GetAccountState{
 		ID: block,
 		Account: AccountID{
 			Workchain: addr.Workchain(),
 			ID:        addr.Data(),
 		},
-	}, &resp)
+	}, block, addr, false)
 	if err != nil {
 		return nil, err
 	}
 
+	if st == nil {
+		return &tlb.Account{
+			IsActive: false,
+		}, nil
+	}
+
+	acc := &tlb.Account{
+		IsActive:   true,
+		State:      st,
+		LastTxHash: shardAcc.LastTransHash,
+		LastTxLT:   shardAcc.LastTransLT,
+	}
+
+	if st.Status == tlb.AccountStatusActive {
+		acc.Code = st.StateInit.Code
+		acc.Data = st.StateInit.Data
+	}
+
+	return acc, nil
+}
+
+// getAccountState - queries account state and checks its proof, nil state is returned when account not exists.
+// State with pruned branches is accepted only when allowPruned is true, otherwise it should be complete.
+func (c *APIClient) getAccountState(ctx context.Context, query tl.Serializable, block *BlockIDExt, addr *address.Address, allowPruned bool) (*tlb.AccountState, *tlb.ShardAccount, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, query, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	switch t := resp.(type) {
 	case AccountState:
 		if !t.ID.Equals(block) {
-			return nil, fmt.Errorf("response with incorrect master block")
+			return nil, nil, fmt.Errorf("response with incorrect master block")
 		}
 
 		if t.State == nil {
-			return &tlb.Account{
-				IsActive: false,
-			}, nil
+			return nil, nil, nil
 		}
 
 		if t.Proof == nil {
-			return nil, fmt.Errorf("no proof")
-		}
-
-		acc := &tlb.Account{
-			IsActive: true,
+			return nil, nil, fmt.Errorf("no proof")
 		}
 
 		var shardHash []byte
 		if c.proofCheckPolicy != ProofCheckPolicyUnsafe && addr.Workchain() != address.MasterchainID {
 			if len(t.ShardProof) == 0 {
-				return nil, ErrNoProof
+				return nil, nil, ErrNoProof
 			}
 
 			if t.Shard == nil || len(t.Shard.RootHash) != 32 {
-				return nil, fmt.Errorf("shard block not passed")
+				return nil, nil, fmt.Errorf("shard block not passed")
 			}
 
 			shardHash = t.Shard.RootHash
@@ -87,35 +110,31 @@ func (c *APIClient) GetAccount(ctx context.Context, block *BlockIDExt, addr *add
 
 		shardAcc, balanceInfo, err := CheckAccountStateProof(addr, block, t.Proof, t.ShardProof, shardHash, c.proofCheckPolicy == ProofCheckPolicyUnsafe)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check acc state proof: %w", err)
+			return nil, nil, fmt.Errorf("failed to check acc state proof: %w", err)
+		}
+
+		stateHash := t.State.Hash()
+		if allowPruned {
+			// state can contain pruned branches, so we compare hashes of the original cells
+			stateHash = t.State.Hash(0)
 		}
 
-		if !bytes.Equal(shardAcc.Account.Hash(0), t.State.Hash()) {
-			return nil, fmt.Errorf("proof hash not match state account hash")
+		if !bytes.Equal(shardAcc.Account.Hash(0), stateHash) {
+			return nil, nil, fmt.Errorf("proof hash not match state account hash")
 		}
 
 		var st tlb.AccountState
 		if err = st.LoadFromCell(t.State.BeginParse()); err != nil {
-			return nil, fmt.Errorf("failed to load account state: %w", err)
+			return nil, nil, fmt.Errorf("failed to load account state: %w", err)
 		}
 
 		if st.Balance.Nano().Cmp(balanceInfo.Currencies.Coins.Nano()) != 0 {
-			return nil, fmt.Errorf("proof balance not match state balance")
+			return nil, nil, fmt.Errorf("proof balance not match state balance")
 		}
 
-		acc.LastTxHash = shardAcc.LastTransHash
-		acc.LastTxLT = shardAcc.LastTransLT
-
-		if st.Status == tlb.AccountStatusActive {
-			acc.Code = st.StateInit.Code
-			acc.Data = st.StateInit.Data
-		}
-
-		acc.State = &st
-
-		return acc, nil
+		return &st, shardAcc, nil
 	case LSError:
-		return nil, t
+		return nil, nil, t
 	}
-	return nil, errUnexpectedResponse(resp)
+	return nil, nil, errUnexpectedResponse(resp)
 }
@@ -99,45 +99,27 @@ func (c *Client) GetJettonDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*
 		return nil, fmt.Errorf("failed to run get_jetton_data method: %w", err)
 	}
 
-	supply, err := res.Int(0)
-	if err != nil {
-		return nil, fmt.Errorf("supply get err: %w", err)
-	}
-
-	mintable, err := res.Int(1)
-	if err != nil {
-		return nil, fmt.Errorf("mintable get err: %w", err)
-	}
-
-	adminAddr, err := res.Slice(2)
-	if err != nil {
-		return nil, fmt.Errorf("admin addr get err: %w", err)
+	var data struct {
+		TotalSupply *big.Int         `tvm:"int"`
+		Mintable    bool             `tvm:"bool"`
+		Admin       *address.Address `tvm:"addr"`
+		Content     *cell.Cell       `tvm:"cell"`
+		WalletCode  *cell.Cell       `tvm:"cell"`
 	}
-	addr, err := adminAddr.LoadAddr()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load address from adminAddr slice: %w", err)
-	}
-
-	contentCell, err := res.Cell(3)
-	if err != nil {
-		return nil, fmt.Errorf("content cell get err: %w", err)
+	if err = res.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode get_jetton_data result: %w", err)
 	}
 
-	content, err := nft.ContentFromCell(contentCell)
+	content, err := nft.ContentFromCell(data.Content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load content from contentCell: %w", err)
 	}
 
-	walletCode, err := res.Cell(4)
-	if err != nil {
-		return nil, fmt.Errorf("wallet code get err: %w", err)
-	}
-
 	return &Data{
-		TotalSupply: supply,
-		Mintable:    mintable.Cmp(big.NewInt(0)) != 0,
-		AdminAddr:   addr,
+		TotalSupply: data.TotalSupply,
+		Mintable:    data.Mintable,
+		AdminAddr:   data.Admin,
 		Content:     content,
-		WalletCode:  walletCode,
+		WalletCode:  data.WalletCode,
 	}, nil
 }
@@ -0,0 +1,300 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// KeyBlock - verified masterchain key block
+type KeyBlock struct {
+	ID       *BlockIDExt
+	GenUTime uint32
+	// Validators - current validators set (config param 34) from the key block state, nil if it was not loaded
+	Validators *cell.Cell
+}
+
+// LightClientState - verified chain state which can be used to resume proof checks after restart
+type LightClientState struct {
+	// TrustedBlock - the latest verified masterchain block
+	TrustedBlock *BlockIDExt
+	// KeyBlocks - verified key blocks sorted by seqno
+	KeyBlocks []*KeyBlock
+}
+
+// LightClientStore - storage of the light client state, it is filled by APIClient
+// when master blocks are verified with ProofCheckPolicySecure
+type LightClientStore interface {
+	// LoadState - returns stored state, empty state if nothing was stored yet
+	LoadState() (*LightClientState, error)
+	// SetTrustedBlock - stores the latest verified masterchain block
+	SetTrustedBlock(block *BlockIDExt) error
+	// AddKeyBlocks - stores verified key blocks, already known blocks should be updated
+	AddKeyBlocks(blocks ...*KeyBlock) error
+}
+
+// FileLightClientStore - LightClientStore which keeps state in json file
+type FileLightClientStore struct {
+	path  string
+	state *LightClientState
+	mx    sync.Mutex
+}
+
+type lightClientStateJSON struct {
+	TrustedBlock *BlockIDExt    `json:"trusted_block,omitempty"`
+	KeyBlocks    []keyBlockJSON `json:"key_blocks"`
+}
+
+type keyBlockJSON struct {
+	ID         *BlockIDExt `json:"id"`
+	GenUTime   uint32      `json:"gen_utime"`
+	Validators []byte      `json:"validators,omitempty"`
+}
+
+// ValidatorSet - parses validators set of the key block
+func (k *KeyBlock) ValidatorSet() (*tlb.ValidatorSetAny, error) {
+	if k.Validators == nil {
+		return nil, fmt.Errorf("validators set is not loaded")
+	}
+
+	var set tlb.ValidatorSetAny
+	if err := tlb.LoadFromCell(&set, k.Validators.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse validators set: %w", err)
+	}
+	return &set, nil
+}
+
+// NewFileLightClientStore - opens store at path, file will be created on the first write
+func NewFileLightClientStore(path string) (*FileLightClientStore, error) {
+	s := &FileLightClientStore{
+		path:  path,
+		state: &LightClientState{},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var st lightClientStateJSON
+	if err = json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	s.state.TrustedBlock = st.TrustedBlock
+	for _, kb := range st.KeyBlocks {
+		if kb.ID == nil {
+			return nil, fmt.Errorf("key block without id in state file")
+		}
+
+		b := &KeyBlock{ID: kb.ID, GenUTime: kb.GenUTime}
+		if len(kb.Validators) > 0 {
+			if b.Validators, err = cell.FromBOC(kb.Validators); err != nil {
+				return nil, fmt.Errorf("failed to parse validators of key block %d: %w", kb.ID.SeqNo, err)
+			}
+		}
+		s.state.KeyBlocks = append(s.state.KeyBlocks, b)
+	}
+	sort.Slice(s.state.KeyBlocks, func(i, j int) bool {
+		return s.state.KeyBlocks[i].ID.SeqNo < s.state.KeyBlocks[j].ID.SeqNo
+	})
+	return s, nil
+}
+
+func (s *FileLightClientStore) LoadState() (*LightClientState, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	st := &LightClientState{
+		KeyBlocks: make([]*KeyBlock, 0, len(s.state.KeyBlocks)),
+	}
+	if s.state.TrustedBlock != nil {
+		st.TrustedBlock = s.state.TrustedBlock.Copy()
+	}
+	for _, b := range s.state.KeyBlocks {
+		cp := *b
+		cp.ID = b.ID.Copy()
+		st.KeyBlocks = append(st.KeyBlocks, &cp)
+	}
+	return st, nil
+}
+
+func (s *FileLightClientStore) SetTrustedBlock(block *BlockIDExt) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	prev := s.state.TrustedBlock
+	s.state.TrustedBlock = block.Copy()
+	if err := s.save(); err != nil {
+		s.state.TrustedBlock = prev
+		return err
+	}
+	return nil
+}
+
+func (s *FileLightClientStore) AddKeyBlocks(blocks ...*KeyBlock) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	list := append([]*KeyBlock{}, s.state.KeyBlocks...)
+	for _, b := range blocks {
+		i := sort.Search(len(list), func(i int) bool {
+			return list[i].ID.SeqNo >= b.ID.SeqNo
+		})
+
+		cp := *b
+		cp.ID = b.ID.Copy()
+
+		if i < len(list) && list[i].ID.SeqNo == b.ID.SeqNo {
+			if !bytes.Equal(list[i].ID.RootHash, b.ID.RootHash) {
+				return fmt.Errorf("key block %d conflicts with stored one", b.ID.SeqNo)
+			}
+			if cp.Validators == nil {
+				cp.Validators = list[i].Validators
+			}
+			list[i] = &cp
+			continue
+		}
+
+		list = append(list, nil)
+		copy(list[i+1:], list[i:])
+		list[i] = &cp
+	}
+
+	prev := s.state.KeyBlocks
+	s.state.KeyBlocks = list
+	if err := s.save(); err != nil {
+		s.state.KeyBlocks = prev
+		return err
+	}
+	return nil
+}
+
+func (s *FileLightClientStore) save() error {
+	st := lightClientStateJSON{
+		TrustedBlock: s.state.TrustedBlock,
+		KeyBlocks:    make([]keyBlockJSON, 0, len(s.state.KeyBlocks)),
+	}
+	for _, b := range s.state.KeyBlocks {
+		kb := keyBlockJSON{ID: b.ID, GenUTime: b.GenUTime}
+		if b.Validators != nil {
+			kb.Validators = b.Validators.ToBOC()
+		}
+		st.KeyBlocks = append(st.KeyBlocks, kb)
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+
+	// write to temp file first, to not corrupt state on crash
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// SetLightClientStore - sets storage for verified chain state and resumes from it:
+// the newest stored block (trusted or key one) becomes the trusted block if it is newer than the current one.
+// Store should belong to the same network as the client, it is not checked.
+// Newly verified key blocks with their validators and the latest trusted block will be saved to the store.
+func (c *APIClient) SetLightClientStore(store LightClientStore) error {
+	state, err := store.LoadState()
+	if err != nil {
+		return fmt.Errorf("failed to load light client state: %w", err)
+	}
+
+	start := state.TrustedBlock
+	if n := len(state.KeyBlocks); n > 0 && (start == nil || state.KeyBlocks[n-1].ID.SeqNo > start.SeqNo) {
+		start = state.KeyBlocks[n-1].ID
+	}
+
+	root := c.root()
+	root.trustedLock.Lock()
+	defer root.trustedLock.Unlock()
+
+	if start != nil && (root.trustedBlock == nil || start.SeqNo > root.trustedBlock.SeqNo) {
+		root.trustedBlock = start.Copy()
+	}
+	root.lightStore = store
+	return nil
+}
+
+// KeyBlocksHistory - verified key blocks from the light client store, sorted by seqno
+func (c *APIClient) KeyBlocksHistory() ([]*KeyBlock, error) {
+	root := c.root()
+	root.trustedLock.RLock()
+	store := root.lightStore
+	root.trustedLock.RUnlock()
+
+	if store == nil {
+		return nil, fmt.Errorf("light client store is not set")
+	}
+
+	state, err := store.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	return state.KeyBlocks, nil
+}
+
+// ValidatorSetHistory - key blocks from the light client store where validators set was changed, sorted by seqno
+func (c *APIClient) ValidatorSetHistory() ([]*KeyBlock, error) {
+	blocks, err := c.KeyBlocksHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*KeyBlock
+	var last []byte
+	for _, b := range blocks {
+		if b.Validators == nil {
+			continue
+		}
+
+		if hash := b.Validators.Hash(); !bytes.Equal(hash, last) {
+			res = append(res, b)
+			last = hash
+		}
+	}
+	return res, nil
+}
+
+// saveLightClientState - stores verified key blocks with their validators and the new trusted block
+func (c *APIClient) saveLightClientState(ctx context.Context, store LightClientStore, keyBlocks []*KeyBlock, trusted *BlockIDExt) error {
+	for _, b := range keyBlocks {
+		// config is checked against key block hash which is already verified
+		cfg, err := c.GetBlockchainConfig(ctx, b.ID, 34)
+		if err != nil {
+			return fmt.Errorf("failed to get validators of key block %d: %w", b.ID.SeqNo, err)
+		}
+		b.Validators = cfg.Get(34)
+	}
+
+	if len(keyBlocks) > 0 {
+		if err := store.AddKeyBlocks(keyBlocks...); err != nil {
+			return fmt.Errorf("failed to store key blocks: %w", err)
+		}
+	}
+
+	if err := store.SetTrustedBlock(trusted); err != nil {
+		return fmt.Errorf("failed to store trusted block: %w", err)
+	}
+	return nil
+}
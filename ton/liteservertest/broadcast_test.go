@@ -0,0 +1,241 @@
+package liteservertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// startBroadcastServers - starts 2 liteservers of the same chain and returns api connected to both
+func startBroadcastServers(t *testing.T, chain *Chain) *ton.APIClient {
+	pool := liteclient.NewConnectionPool()
+	t.Cleanup(pool.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		srv := NewServer(chain)
+		if err := srv.Start(); err != nil {
+			t.Fatal("start err:", err)
+		}
+		t.Cleanup(func() {
+			_ = srv.Close()
+		})
+
+		if err := pool.AddConnection(ctx, srv.Addr(), srv.Key()); err != nil {
+			t.Fatal("connect err:", err)
+		}
+	}
+	return ton.NewAPIClient(pool)
+}
+
+// commitInBackground - commits blocks periodically until test end, before each commit fn is called
+func commitInBackground(t *testing.T, chain *Chain, fn func(i int)) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			case <-time.After(30 * time.Millisecond):
+			}
+
+			if fn != nil {
+				fn(i)
+			}
+			if _, err := chain.Commit(); err != nil {
+				t.Error("commit err:", err)
+				return
+			}
+		}
+	}()
+}
+
+func TestBroadcaster_Included(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+
+	var calls int32
+	var once sync.Once
+	chain.SetExternalMessageHandler(func(chain *Chain, msg *tlb.ExternalMessage) error {
+		atomic.AddInt32(&calls, 1)
+
+		var err error
+		// message is processed once, copies from other liteservers are ignored by validators
+		once.Do(func() {
+			_, err = chain.AddTransaction(msg.DstAddr, &tlb.Transaction{
+				IO: struct {
+					In  *tlb.Message      `tlb:"maybe ^"`
+					Out *tlb.MessagesList `tlb:"maybe ^"`
+				}{
+					In: &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: msg},
+				},
+			})
+		})
+		return err
+	})
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startBroadcastServers(t, chain)
+	commitInBackground(t, chain, nil)
+
+	b := ton.NewBroadcaster(api, 3)
+	b.SetRebroadcastInterval(20 * time.Millisecond)
+
+	msg := &tlb.ExternalMessage{
+		DstAddr: addr,
+		Body:    cell.BeginCell().MustStoreUInt(0xBEEF, 32).EndCell(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// first caller gives up before inclusion, it should not stop broadcast for others
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+
+	shortDone := make(chan error, 1)
+	go func() {
+		_, err := b.Send(shortCtx, msg, uint32(time.Now().Unix())+60)
+		shortDone <- err
+	}()
+	time.Sleep(2 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	results := make([]*ton.BroadcastResult, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := b.Send(ctx, msg, uint32(time.Now().Unix())+60)
+			if err != nil {
+				t.Error("send err:", err)
+				return
+			}
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if err := <-shortDone; err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("unexpected error of cancelled send:", err)
+	}
+
+	for _, res := range results {
+		if res.Status != ton.BroadcastStatusIncluded || res.Transaction == nil {
+			t.Fatal("message should be included, got", res.Status)
+		}
+		if !bytes.Equal(res.Transaction.IO.In.AsExternalIn().NormalizedHash(), msg.NormalizedHash()) {
+			t.Fatal("incorrect transaction")
+		}
+	}
+
+	if results[0].Transaction.LT != results[1].Transaction.LT || results[0].Accepted < 2 {
+		t.Fatal("same message should be broadcasted once to both liteservers", results[0].Accepted)
+	}
+
+	if n := atomic.LoadInt32(&calls); n < 2 || int(n) != results[0].Accepted {
+		t.Fatal("incorrect number of sends", n)
+	}
+}
+
+func TestBroadcaster_ExpiredAndRejected(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+
+	var reject int32
+	chain.SetExternalMessageHandler(func(chain *Chain, msg *tlb.ExternalMessage) error {
+		if atomic.LoadInt32(&reject) == 1 {
+			return errors.New("bad signature")
+		}
+		// accepted by liteserver, but never included
+		return nil
+	})
+
+	start := chain.Now() + 1000
+	chain.SetTime(start)
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startBroadcastServers(t, chain)
+	commitInBackground(t, chain, func(i int) {
+		chain.SetTime(start + uint32(i)*10)
+	})
+
+	b := ton.NewBroadcaster(api, 2)
+	b.SetRebroadcastInterval(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	msg := &tlb.ExternalMessage{
+		DstAddr: addr,
+		Body:    cell.BeginCell().MustStoreUInt(1, 32).EndCell(),
+	}
+
+	res, err := b.Send(ctx, msg, start+20)
+	if err != nil {
+		t.Fatal("send err:", err)
+	}
+	if res.Status != ton.BroadcastStatusExpired || res.Accepted < 2 {
+		t.Fatal("message should be expired, got", res.Status, res.Accepted)
+	}
+
+	header, err := api.GetBlockHeader(ctx, res.Block)
+	if err != nil {
+		t.Fatal("get header err:", err)
+	}
+	if header.GenUtime <= start+20 {
+		t.Fatal("message expired too early")
+	}
+
+	atomic.StoreInt32(&reject, 1)
+	res, err = b.Send(ctx, msg, chain.Now()+60)
+	if err != nil {
+		t.Fatal("send err:", err)
+	}
+
+	var lsErr ton.LSError
+	if res.Status != ton.BroadcastStatusRejected || !errors.As(res.Err, &lsErr) {
+		t.Fatal("message should be rejected, got", res.Status)
+	}
+}
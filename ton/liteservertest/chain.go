@@ -0,0 +1,1101 @@
+package liteservertest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	// GlobalID - network id of the fake chain
+	GlobalID = -239
+
+	shardFull = int64(-9223372036854775808)
+)
+
+var ErrAccountNotFound = errors.New("account not found")
+
+// GetMethod - emulates contract get method, receives arguments in the same order as they were passed to RunGetMethod,
+// returns result stack values and exit code
+type GetMethod func(acc *Account, params []any) (result []any, exitCode int32)
+
+// ExternalMessageHandler - processes external message sent to the chain
+type ExternalMessageHandler func(chain *Chain, msg *tlb.ExternalMessage) error
+
+// Account - state of the account in the fake chain
+type Account struct {
+	Address *address.Address
+	Status  tlb.AccountStatus
+	Balance tlb.Coins
+	Code    *cell.Cell
+	Data    *cell.Cell
+
+	lastTxLT   uint64
+	lastTxHash []byte
+	// raw state cell, when account was loaded from fixture
+	raw *cell.Cell
+}
+
+type txRecord struct {
+	cell *cell.Cell
+	tx   *tlb.Transaction
+	// block where transaction was included, nil if not yet committed
+	block *ton.BlockIDExt
+}
+
+type blockData struct {
+	id      *ton.BlockIDExt
+	block   *cell.Cell
+	state   *cell.Cell
+	utime   uint32
+	startLT uint64
+	endLT   uint64
+
+	accounts    *cell.Dictionary
+	shardHashes *cell.Dictionary
+	txs         []*txRecord
+
+	// masterchain only
+	keyBlock     bool
+	prevKeyBlock uint32
+	signers      []ed25519.PrivateKey
+	ccSeqno      uint32
+}
+
+type masterRecord struct {
+	master *blockData
+	shard  *blockData
+}
+
+// Chain - in-memory model of masterchain and single basechain shard.
+// Every Commit produces new masterchain block and new shard block with all changes made since previous commit,
+// blocks and states are real cells with valid hashes, so proofs for them can be verified by the client.
+//
+// Masterchain blocks are signed by fake validators, block becomes a key block when config or validators were changed,
+// so proof chains between masterchain blocks can be verified too.
+//
+// Augmented dictionaries are stored without extra values in forks, which is enough for proof checks of this library.
+type Chain struct {
+	accounts     map[string]*Account
+	txs          map[string][]*txRecord
+	pending      []*txRecord
+	getMethods   map[string]GetMethod
+	configParams map[int32]*cell.Cell
+	libraries    map[string]*cell.Cell
+	creatorStats map[string]tlb.CreatorStats
+	sent         []*tlb.ExternalMessage
+
+	blocks    []*masterRecord
+	zeroState *ton.ZeroStateIDExt
+	lastState *cell.Cell
+	lt        uint64
+	fixedTime uint32
+
+	// validators from config, they will sign blocks after the next key block
+	validators []ed25519.PrivateKey
+	// validators which are signing new blocks
+	signers      []ed25519.PrivateKey
+	ccSeqno      uint32
+	keyPending   bool
+	lastKeyBlock uint32
+
+	onMessage ExternalMessageHandler
+	newBlock  chan struct{}
+
+	mx sync.RWMutex
+}
+
+// NewChain - creates chain with zero state and first committed block
+func NewChain() *Chain {
+	c := &Chain{
+		accounts:     map[string]*Account{},
+		txs:          map[string][]*txRecord{},
+		getMethods:   map[string]GetMethod{},
+		configParams: map[int32]*cell.Cell{},
+		libraries:    map[string]*cell.Cell{},
+		creatorStats: map[string]tlb.CreatorStats{},
+		lt:           1000000,
+		newBlock:     make(chan struct{}),
+	}
+	c.onMessage = DefaultExternalMessageHandler
+
+	// config address, to have at least one param
+	c.configParams[0] = cell.BeginCell().MustStoreSlice(make([]byte, 32), 256).EndCell()
+
+	catchainConfig, err := tlb.ToCell(tlb.CatchainConfig{Config: tlb.CatchainConfigV1{
+		McCatchainLifetime:      3600,
+		ShardCatchainLifetime:   3600,
+		ShardValidatorsLifetime: 3600,
+		ShardValidatorsNum:      1,
+	}})
+	if err != nil {
+		panic("failed to build catchain config: " + err.Error())
+	}
+	c.configParams[28] = catchainConfig
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		panic("failed to generate validator key: " + err.Error())
+	}
+	if err = c.setValidators([]ed25519.PrivateKey{key}); err != nil {
+		panic("failed to set validators: " + err.Error())
+	}
+	c.signers = c.validators
+
+	zero, err := c.buildState(-1, 0, 0, cell.NewDict(256), nil)
+	if err != nil {
+		panic("failed to build zero state: " + err.Error())
+	}
+	boc := zero.ToBOC()
+	fileHash := sha256.Sum256(boc)
+	c.zeroState = &ton.ZeroStateIDExt{
+		Workchain: -1,
+		RootHash:  zero.Hash(),
+		FileHash:  fileHash[:],
+	}
+	c.lastState = zero
+
+	if _, err = c.Commit(); err != nil {
+		panic("failed to commit first block: " + err.Error())
+	}
+	return c
+}
+
+// DefaultExternalMessageHandler - adds transaction with inbound message to destination account and commits a block
+func DefaultExternalMessageHandler(chain *Chain, msg *tlb.ExternalMessage) error {
+	_, err := chain.AddTransaction(msg.DstAddr, &tlb.Transaction{
+		IO: struct {
+			In  *tlb.Message      `tlb:"maybe ^"`
+			Out *tlb.MessagesList `tlb:"maybe ^"`
+		}{
+			In: &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: msg},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = chain.Commit()
+	return err
+}
+
+// SetExternalMessageHandler - replaces default handler of sent external messages
+func (c *Chain) SetExternalMessageHandler(handler ExternalMessageHandler) {
+	c.mx.Lock()
+	c.onMessage = handler
+	c.mx.Unlock()
+}
+
+// SetTime - fixes time of the chain, 0 means current time
+func (c *Chain) SetTime(unix uint32) {
+	c.mx.Lock()
+	c.fixedTime = unix
+	c.mx.Unlock()
+}
+
+// Now - current chain time
+func (c *Chain) Now() uint32 {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.now()
+}
+
+func (c *Chain) now() uint32 {
+	if c.fixedTime > 0 {
+		return c.fixedTime
+	}
+	return uint32(time.Now().Unix())
+}
+
+// SetAccount - creates or replaces account state, it will be visible after next Commit
+func (c *Chain) SetAccount(acc Account) {
+	if acc.Status == "" {
+		acc.Status = tlb.AccountStatusActive
+		if acc.Code == nil {
+			acc.Status = tlb.AccountStatusUninit
+		}
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if old := c.accounts[acc.Address.String()]; old != nil {
+		acc.lastTxLT, acc.lastTxHash = old.lastTxLT, old.lastTxHash
+	}
+	c.accounts[acc.Address.String()] = &acc
+}
+
+// LoadAccountBOC - sets account state from serialized tlb Account cell, for example taken from liteServer.accountState
+func (c *Chain) LoadAccountBOC(boc []byte, lastTxLT uint64, lastTxHash []byte) error {
+	root, err := cell.FromBOC(boc)
+	if err != nil {
+		return fmt.Errorf("failed to parse boc: %w", err)
+	}
+
+	var st tlb.AccountState
+	if err = st.LoadFromCell(root.BeginParse()); err != nil {
+		return fmt.Errorf("failed to parse account state: %w", err)
+	}
+	if !st.IsValid {
+		return fmt.Errorf("account state is empty")
+	}
+
+	acc := &Account{
+		Address:    st.Address,
+		Status:     st.Status,
+		Balance:    st.Balance,
+		lastTxLT:   lastTxLT,
+		lastTxHash: lastTxHash,
+		raw:        root,
+	}
+	if st.StateInit != nil {
+		acc.Code, acc.Data = st.StateInit.Code, st.StateInit.Data
+	}
+
+	c.mx.Lock()
+	c.accounts[acc.Address.String()] = acc
+	c.mx.Unlock()
+	return nil
+}
+
+// GetAccount - returns copy of the current account state
+func (c *Chain) GetAccount(addr *address.Address) (*Account, error) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	acc := c.accounts[addr.String()]
+	if acc == nil {
+		return nil, ErrAccountNotFound
+	}
+	cp := *acc
+	return &cp, nil
+}
+
+// SetGetMethod - registers get method emulation for the account
+func (c *Chain) SetGetMethod(addr *address.Address, method string, fn GetMethod) {
+	c.mx.Lock()
+	c.getMethods[getMethodKey(addr, tlb.MethodNameHash(method))] = fn
+	c.mx.Unlock()
+}
+
+// SetConfigParam - sets blockchain config param, it will be visible after next Commit, which will create a key block
+func (c *Chain) SetConfigParam(id int32, value *cell.Cell) {
+	c.mx.Lock()
+	c.configParams[id] = value
+	c.keyPending = true
+	c.mx.Unlock()
+}
+
+// SetValidators - replaces masterchain validators, next Commit will create a key block with the new set in config,
+// and blocks after it will be signed by the new validators
+func (c *Chain) SetValidators(keys ...ed25519.PrivateKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("at least one validator is required")
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.setValidators(keys)
+}
+
+func (c *Chain) setValidators(keys []ed25519.PrivateKey) error {
+	list := cell.NewDict(16)
+	for i, v := range validatorAddrs(keys) {
+		val, err := tlb.ToCell(v)
+		if err != nil {
+			return err
+		}
+		if err = list.SetIntKey(big.NewInt(int64(i)), val); err != nil {
+			return err
+		}
+	}
+
+	now := c.now()
+	set, err := tlb.ToCell(tlb.ValidatorSetAny{Validators: tlb.ValidatorSet{
+		UTimeSince: now,
+		UTimeUntil: now + 365*24*3600,
+		Total:      uint16(len(keys)),
+		Main:       uint16(len(keys)),
+		List:       list,
+	}})
+	if err != nil {
+		return err
+	}
+
+	c.validators = keys
+	c.configParams[34] = set
+	c.keyPending = true
+	return nil
+}
+
+// AddLibrary - adds library cell which can be requested by hash, it will be published in masterchain state after next Commit
+func (c *Chain) AddLibrary(lib *cell.Cell) {
+	c.mx.Lock()
+	c.libraries[string(lib.Hash())] = lib
+	c.mx.Unlock()
+}
+
+// SetCreatorStats - sets block creation stats of validator, it will be visible after next Commit
+func (c *Chain) SetCreatorStats(pubKey []byte, stats tlb.CreatorStats) {
+	c.mx.Lock()
+	c.creatorStats[string(pubKey)] = stats
+	c.mx.Unlock()
+}
+
+// SentMessages - external messages which were sent to the chain
+func (c *Chain) SentMessages() []*tlb.ExternalMessage {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return append([]*tlb.ExternalMessage{}, c.sent...)
+}
+
+// AddTransaction - appends transaction to the account, it will be included in the next block.
+// Account address, LT, previous transaction, time, statuses and empty phases are filled automatically.
+// Account is created as uninit if not exists.
+func (c *Chain) AddTransaction(addr *address.Address, tx *tlb.Transaction) (*tlb.Transaction, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	acc := c.accounts[addr.String()]
+	if acc == nil {
+		acc = &Account{
+			Address: addr,
+			Status:  tlb.AccountStatusUninit,
+			Balance: tlb.ZeroCoins,
+		}
+		c.accounts[addr.String()] = acc
+	}
+
+	c.lt += 1000
+	tx.AccountAddr = addr.Data()
+	tx.LT = c.lt
+	tx.PrevTxLT = acc.lastTxLT
+	tx.PrevTxHash = acc.lastTxHash
+	if tx.PrevTxHash == nil {
+		tx.PrevTxHash = make([]byte, 32)
+	}
+	if tx.Now == 0 {
+		tx.Now = c.now()
+	}
+	if tx.OrigStatus == "" {
+		tx.OrigStatus = acc.Status
+	}
+	if tx.EndStatus == "" {
+		tx.EndStatus = acc.Status
+	}
+	if tx.StateUpdate.OldHash == nil {
+		tx.StateUpdate.OldHash = make([]byte, 32)
+	}
+	if tx.StateUpdate.NewHash == nil {
+		tx.StateUpdate.NewHash = make([]byte, 32)
+	}
+	if tx.Description.Description == nil {
+		tx.Description.Description = tlb.TransactionDescriptionOrdinary{
+			ComputePhase: tlb.ComputePhase{
+				Phase: tlb.ComputePhaseSkipped{
+					Reason: tlb.ComputeSkipReason{Type: tlb.ComputeSkipReasonNoState},
+				},
+			},
+		}
+	}
+	if tx.IO.Out != nil && tx.OutMsgCount == 0 {
+		if list, err := tx.IO.Out.ToSlice(); err == nil {
+			tx.OutMsgCount = uint16(len(list))
+		}
+	}
+
+	txCell, err := tlb.ToCell(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	tx.Hash = txCell.Hash()
+
+	c.addTx(acc, txCell, tx)
+	return tx, nil
+}
+
+// LoadTransactionsBOC - adds transactions from serialized cells (single or multi root boc) as is,
+// accounts must exist, their last transaction will be updated.
+func (c *Chain) LoadTransactionsBOC(boc []byte) error {
+	roots, err := cell.FromBOCMultiRoot(boc)
+	if err != nil {
+		return fmt.Errorf("failed to parse boc: %w", err)
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for _, root := range roots {
+		var tx tlb.Transaction
+		if err = tlb.LoadFromCell(&tx, root.BeginParse()); err != nil {
+			return fmt.Errorf("failed to parse transaction: %w", err)
+		}
+		tx.Hash = root.Hash()
+
+		var acc *Account
+		for _, a := range c.accounts {
+			if string(a.Address.Data()) == string(tx.AccountAddr) {
+				acc = a
+				break
+			}
+		}
+		if acc == nil {
+			return fmt.Errorf("account of transaction %x: %w", tx.Hash, ErrAccountNotFound)
+		}
+
+		if tx.LT > c.lt {
+			c.lt = tx.LT
+		}
+		c.addTx(acc, root, &tx)
+	}
+	return nil
+}
+
+func (c *Chain) addTx(acc *Account, txCell *cell.Cell, tx *tlb.Transaction) {
+	if tx.LT > acc.lastTxLT {
+		acc.lastTxLT = tx.LT
+		acc.lastTxHash = tx.Hash
+	}
+
+	rec := &txRecord{cell: txCell, tx: tx}
+	c.txs[acc.Address.String()] = append(c.txs[acc.Address.String()], rec)
+	c.pending = append(c.pending, rec)
+}
+
+// LastBlock - the latest committed masterchain block
+func (c *Chain) LastBlock() *ton.BlockIDExt {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.blocks[len(c.blocks)-1].master.id.Copy()
+}
+
+// Commit - creates new masterchain and shard blocks with current state and pending transactions
+func (c *Chain) Commit() (*ton.BlockIDExt, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	seqno := uint32(len(c.blocks) + 1)
+	utime := c.now()
+
+	var prevMaster, prevShard *blockData
+	if len(c.blocks) > 0 {
+		prevMaster = c.blocks[len(c.blocks)-1].master
+		prevShard = c.blocks[len(c.blocks)-1].shard
+	}
+
+	var masterTxs, shardTxs []*txRecord
+	for _, tx := range c.pending {
+		if c.txWorkchain(tx) == address.MasterchainID {
+			masterTxs = append(masterTxs, tx)
+		} else {
+			shardTxs = append(shardTxs, tx)
+		}
+	}
+
+	// pending transactions are in range of the new block
+	startLT := c.lt + 1
+	if prevMaster != nil {
+		startLT = prevMaster.endLT + 1
+	}
+	c.lt += 1000
+
+	shard, err := c.buildBlock(0, seqno, utime, startLT, c.lt, prevShard, prevMaster, shardTxs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build shard block: %w", err)
+	}
+
+	shardHashes, err := buildShardHashes(shard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build shard hashes: %w", err)
+	}
+
+	master, err := c.buildBlock(-1, seqno, utime, startLT, c.lt, prevMaster, nil, masterTxs, shardHashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build master block: %w", err)
+	}
+
+	if master.keyBlock {
+		c.keyPending = false
+		c.lastKeyBlock = seqno
+		if !sameKeys(c.signers, c.validators) {
+			// new validators are taking their place after key block
+			c.signers = c.validators
+			c.ccSeqno++
+		}
+	}
+
+	for _, tx := range shardTxs {
+		tx.block = shard.id
+	}
+	for _, tx := range masterTxs {
+		tx.block = master.id
+	}
+	c.pending = nil
+
+	c.blocks = append(c.blocks, &masterRecord{master: master, shard: shard})
+	c.lastState = master.state
+
+	close(c.newBlock)
+	c.newBlock = make(chan struct{})
+
+	return master.id.Copy(), nil
+}
+
+func (c *Chain) txWorkchain(tx *txRecord) int32 {
+	for _, a := range c.accounts {
+		if string(a.Address.Data()) == string(tx.tx.AccountAddr) {
+			return a.Address.Workchain()
+		}
+	}
+	return 0
+}
+
+// waitBlock - returns channel which will be closed when next block is committed
+func (c *Chain) waitBlock() <-chan struct{} {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.newBlock
+}
+
+func (c *Chain) masterBySeqno(seqno uint32) *masterRecord {
+	if seqno == 0 || int(seqno) > len(c.blocks) {
+		return nil
+	}
+	return c.blocks[seqno-1]
+}
+
+// findBlock - finds block data by full id
+func (c *Chain) findBlock(id *ton.BlockIDExt) *blockData {
+	if id == nil {
+		return nil
+	}
+
+	rec := c.masterBySeqno(id.SeqNo)
+	if rec == nil {
+		return nil
+	}
+
+	for _, b := range []*blockData{rec.master, rec.shard} {
+		if b.id.Equals(id) {
+			return b
+		}
+	}
+	return nil
+}
+
+func (c *Chain) accountState(acc *Account) (*cell.Cell, error) {
+	if acc.raw != nil {
+		return acc.raw, nil
+	}
+
+	storage := cell.BeginCell().
+		MustStoreUInt(acc.lastTxLT, 64).
+		MustStoreBigCoins(acc.Balance.Nano()).
+		MustStoreDict(nil)
+
+	switch acc.Status {
+	case tlb.AccountStatusActive:
+		si, err := tlb.ToCell(tlb.StateInit{Code: acc.Code, Data: acc.Data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize state init: %w", err)
+		}
+		storage.MustStoreBoolBit(true).MustStoreBuilder(si.ToBuilder())
+	case tlb.AccountStatusUninit:
+		storage.MustStoreUInt(0b00, 2)
+	default:
+		return nil, fmt.Errorf("account status %s is not supported", acc.Status)
+	}
+
+	info, err := tlb.ToCell(tlb.StorageInfo{
+		StorageUsed: tlb.StorageUsed{
+			BitsUsed:        big.NewInt(0),
+			CellsUsed:       big.NewInt(0),
+			PublicCellsUsed: big.NewInt(0),
+		},
+		LastPaid: c.now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize storage info: %w", err)
+	}
+
+	return cell.BeginCell().
+		MustStoreBoolBit(true).
+		MustStoreAddr(acc.Address).
+		MustStoreBuilder(info.ToBuilder()).
+		MustStoreBuilder(storage).
+		EndCell(), nil
+}
+
+func (c *Chain) buildAccounts(workchain int32) (*cell.Dictionary, error) {
+	dict := cell.NewDict(256)
+	for _, acc := range c.accounts {
+		if acc.Address.Workchain() != workchain {
+			continue
+		}
+
+		st, err := c.accountState(acc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build state of %s: %w", acc.Address.String(), err)
+		}
+
+		hash := acc.lastTxHash
+		if hash == nil {
+			hash = make([]byte, 32)
+		}
+
+		dbi, err := tlb.ToCell(tlb.DepthBalanceInfo{
+			Currencies: tlb.CurrencyCollection{Coins: acc.Balance},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sa, err := tlb.ToCell(tlb.ShardAccount{
+			Account:       st,
+			LastTransHash: hash,
+			LastTransLT:   acc.lastTxLT,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		val := cell.BeginCell().MustStoreBuilder(dbi.ToBuilder()).MustStoreBuilder(sa.ToBuilder()).EndCell()
+		if err = dict.Set(cell.BeginCell().MustStoreSlice(acc.Address.Data(), 256).EndCell(), val); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
+}
+
+func (c *Chain) buildState(workchain int32, seqno, utime uint32, accounts *cell.Dictionary, shardHashes *cell.Dictionary) (*cell.Cell, error) {
+	var mcExtra *cell.Cell
+	if workchain == address.MasterchainID {
+		params, err := c.buildConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := c.buildMcStateInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build mc state info: %w", err)
+		}
+
+		extra := tlb.McStateExtra{
+			ShardHashes:   shardHashes,
+			Info:          info,
+			GlobalBalance: tlb.CurrencyCollection{Coins: c.totalBalance(nil)},
+		}
+		extra.ConfigParams.ConfigAddr = make([]byte, 32)
+		extra.ConfigParams.Config.Params = params
+
+		mcExtra, err = tlb.ToCell(extra)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize mc state extra: %w", err)
+		}
+	}
+
+	stats, err := c.buildStateStats(workchain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build state stats: %w", err)
+	}
+
+	queueSize := uint64(0)
+	queue, err := tlb.ToCell(tlb.OutMsgQueueInfo{
+		Extra: &tlb.OutMsgQueueExtra{
+			OutQueueSize: &queueSize,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize out msg queue info: %w", err)
+	}
+
+	st := tlb.ShardStateUnsplit{
+		GlobalID: GlobalID,
+		ShardIdent: tlb.ShardIdent{
+			WorkchainID: workchain,
+		},
+		Seqno:           seqno,
+		GenUTime:        utime,
+		GenLT:           c.lt,
+		OutMsgQueueInfo: queue,
+		Stats:           stats,
+		McStateExtra:    mcExtra,
+	}
+	st.Accounts.ShardAccounts = accounts
+
+	return tlb.ToCell(st)
+}
+
+func (c *Chain) buildConfig() (*cell.Dictionary, error) {
+	params := cell.NewDict(32)
+	for id, v := range c.configParams {
+		if err := params.SetIntKey(big.NewInt(int64(id)), cell.BeginCell().MustStoreRef(v).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to set config param %d: %w", id, err)
+		}
+	}
+	return params, nil
+}
+
+// buildMcStateInfo - previous masterchain blocks and creator stats
+func (c *Chain) buildMcStateInfo() (*cell.Cell, error) {
+	prevBlocks := cell.NewDict(32)
+	var maxLT uint64
+	var hasKey bool
+	for _, rec := range c.blocks {
+		m := rec.master
+		val := cell.BeginCell().
+			MustStoreBoolBit(m.keyBlock).MustStoreUInt(m.endLT, 64).                 // KeyMaxLt
+			MustStoreBoolBit(m.keyBlock).MustStoreBuilder(extBlkRef(m).ToBuilder()). // KeyExtBlkRef
+			EndCell()
+		if err := prevBlocks.SetIntKey(big.NewInt(int64(m.id.SeqNo)), val); err != nil {
+			return nil, err
+		}
+		maxLT = m.endLT
+		hasKey = hasKey || m.keyBlock
+	}
+
+	counters := cell.NewDict(256)
+	for key, st := range c.creatorStats {
+		val, err := tlb.ToCell(st)
+		if err != nil {
+			return nil, err
+		}
+		if err = counters.Set(cell.BeginCell().MustStoreSlice([]byte(key), 256).EndCell(), val); err != nil {
+			return nil, err
+		}
+	}
+
+	createStats, err := tlb.ToCell(tlb.BlockCreateStats{Counters: counters})
+	if err != nil {
+		return nil, err
+	}
+
+	return tlb.ToCell(tlb.McStateExtraBlockInfo{
+		Flags:            1,
+		PrevBlocks:       prevBlocks,
+		PrevBlocksMaxLt:  tlb.KeyMaxLt{IsKey: hasKey, MaxEndLT: maxLT},
+		BlockCreateStats: createStats,
+	})
+}
+
+// totalBalance - sum of accounts balances in workchain, or in all workchains when it is nil
+func (c *Chain) totalBalance(workchain *int32) tlb.Coins {
+	sum := new(big.Int)
+	for _, acc := range c.accounts {
+		if workchain != nil && acc.Address.Workchain() != *workchain {
+			continue
+		}
+		sum.Add(sum, acc.Balance.Nano())
+	}
+	return tlb.FromNanoTON(sum)
+}
+
+// buildStateStats - state stats with published libraries, libraries are stored only in masterchain
+func (c *Chain) buildStateStats(workchain int32) (*cell.Cell, error) {
+	libs := cell.NewDict(256)
+	if workchain == address.MasterchainID {
+		for hash, lib := range c.libraries {
+			publishers := cell.NewDict(256)
+			if err := publishers.Set(cell.BeginCell().MustStoreSlice(make([]byte, 32), 256).EndCell(), cell.BeginCell().EndCell()); err != nil {
+				return nil, err
+			}
+
+			descr, err := tlb.ToCell(tlb.LibDescr{Lib: lib, Publishers: publishers})
+			if err != nil {
+				return nil, err
+			}
+
+			if err = libs.Set(cell.BeginCell().MustStoreSlice([]byte(hash), 256).EndCell(), descr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return tlb.ToCell(tlb.ShardStateStats{
+		TotalBalance:       tlb.CurrencyCollection{Coins: c.totalBalance(&workchain)},
+		TotalValidatorFees: tlb.CurrencyCollection{Coins: tlb.ZeroCoins},
+		Libraries:          libs,
+	})
+}
+
+func (c *Chain) buildBlock(workchain int32, seqno, utime uint32, startLT, endLT uint64, prev, master *blockData, txs []*txRecord, shardHashes *cell.Dictionary) (*blockData, error) {
+	accounts, err := c.buildAccounts(workchain)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.buildState(workchain, seqno, utime, accounts, shardHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	oldState := c.lastState
+	if prev != nil {
+		oldState = prev.state
+	}
+
+	accountBlocks, err := buildAccountBlocks(txs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build account blocks: %w", err)
+	}
+
+	var custom *tlb.McBlockExtra
+	var keyBlock bool
+	var validatorsHash uint32
+	if workchain == address.MasterchainID {
+		keyBlock = c.keyPending
+
+		custom = &tlb.McBlockExtra{
+			KeyBlock:    keyBlock,
+			ShardHashes: shardHashes,
+		}
+		if keyBlock {
+			cfg, err := c.buildConfig()
+			if err != nil {
+				return nil, err
+			}
+			custom.ConfigParams = &tlb.ConfigParams{ConfigAddr: make([]byte, 32)}
+			custom.ConfigParams.Config.Params = cfg
+		}
+
+		if validatorsHash, err = validatorSetHash(c.ccSeqno, c.signers); err != nil {
+			return nil, fmt.Errorf("failed to calc validator set hash: %w", err)
+		}
+	}
+
+	extra, err := tlb.ToCell(tlb.BlockExtra{
+		InMsgDesc:          cell.BeginCell().EndCell(),
+		OutMsgDesc:         cell.BeginCell().EndCell(),
+		ShardAccountBlocks: cell.BeginCell().MustStoreDict(accountBlocks).EndCell(),
+		RandSeed:           make([]byte, 32),
+		CreatedBy:          make([]byte, 32),
+		Custom:             custom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize block extra: %w", err)
+	}
+
+	info := cell.BeginCell().
+		MustStoreUInt(0x9bc7a987, 32).
+		MustStoreUInt(0, 32). // version
+		MustStoreBoolBit(workchain != address.MasterchainID).
+		MustStoreBoolBit(false). // after merge
+		MustStoreBoolBit(false). // before split
+		MustStoreBoolBit(false). // after split
+		MustStoreBoolBit(false). // want split
+		MustStoreBoolBit(false). // want merge
+		MustStoreBoolBit(keyBlock).
+		MustStoreBoolBit(false). // vert seqno incr
+		MustStoreUInt(0, 8).     // flags
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32). // vert seqno
+		MustStoreUInt(0, 2).MustStoreUInt(0, 6).MustStoreInt(int64(workchain), 32).MustStoreUInt(0, 64).
+		MustStoreUInt(uint64(utime), 32).
+		MustStoreUInt(startLT, 64).
+		MustStoreUInt(endLT, 64).
+		MustStoreUInt(uint64(validatorsHash), 32).
+		MustStoreUInt(uint64(c.ccSeqno), 32).
+		MustStoreUInt(0, 32). // min ref mc seqno
+		MustStoreUInt(uint64(c.lastKeyBlock), 32)
+
+	if workchain != address.MasterchainID {
+		info.MustStoreRef(extBlkRef(master))
+	}
+	info.MustStoreRef(extBlkRef(prev))
+
+	block := cell.BeginCell().
+		MustStoreUInt(0x11ef55aa, 32).
+		MustStoreInt(GlobalID, 32).
+		MustStoreRef(info.EndCell()).
+		MustStoreRef(cell.BeginCell().EndCell()). // value flow
+		MustStoreRef(cell.BeginCell().MustStoreRef(oldState).MustStoreRef(state).EndCell()).
+		MustStoreRef(extra).
+		EndCell()
+
+	fileHash := sha256.Sum256(block.ToBOC())
+
+	return &blockData{
+		id: &ton.BlockIDExt{
+			Workchain: workchain,
+			Shard:     shardFull,
+			SeqNo:     seqno,
+			RootHash:  block.Hash(),
+			FileHash:  fileHash[:],
+		},
+		block:        block,
+		state:        state,
+		utime:        utime,
+		startLT:      startLT,
+		endLT:        endLT,
+		accounts:     accounts,
+		shardHashes:  shardHashes,
+		txs:          txs,
+		keyBlock:     keyBlock,
+		prevKeyBlock: c.lastKeyBlock,
+		signers:      c.signers,
+		ccSeqno:      c.ccSeqno,
+	}, nil
+}
+
+func extBlkRef(b *blockData) *cell.Cell {
+	ref := tlb.ExtBlkRef{
+		RootHash: make([]byte, 32),
+		FileHash: make([]byte, 32),
+	}
+	if b != nil {
+		ref = tlb.ExtBlkRef{
+			EndLt:    b.endLT,
+			SeqNo:    b.id.SeqNo,
+			RootHash: b.id.RootHash,
+			FileHash: b.id.FileHash,
+		}
+	}
+	c, err := tlb.ToCell(ref)
+	if err != nil {
+		panic(err.Error())
+	}
+	return c
+}
+
+func buildAccountBlocks(txs []*txRecord) (*cell.Dictionary, error) {
+	byAccount := map[string][]*txRecord{}
+	for _, tx := range txs {
+		byAccount[string(tx.tx.AccountAddr)] = append(byAccount[string(tx.tx.AccountAddr)], tx)
+	}
+
+	emptyCC, err := tlb.ToCell(tlb.CurrencyCollection{Coins: tlb.ZeroCoins})
+	if err != nil {
+		return nil, err
+	}
+
+	dict := cell.NewDict(256)
+	for addr, list := range byAccount {
+		txDict := cell.NewDict(64)
+		for _, tx := range list {
+			val := cell.BeginCell().MustStoreBuilder(emptyCC.ToBuilder()).MustStoreRef(tx.cell).EndCell()
+			if err = txDict.SetIntKey(new(big.Int).SetUint64(tx.tx.LT), val); err != nil {
+				return nil, err
+			}
+		}
+
+		hashUpd, err := tlb.ToCell(tlb.HashUpdate{OldHash: make([]byte, 32), NewHash: make([]byte, 32)})
+		if err != nil {
+			return nil, err
+		}
+
+		ab, err := tlb.ToCell(tlb.AccountBlock{
+			Addr:         []byte(addr),
+			Transactions: txDict,
+			StateUpdate:  hashUpd,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		val := cell.BeginCell().MustStoreBuilder(emptyCC.ToBuilder()).MustStoreBuilder(ab.ToBuilder()).EndCell()
+		if err = dict.Set(cell.BeginCell().MustStoreSlice([]byte(addr), 256).EndCell(), val); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
+}
+
+func buildShardHashes(shard *blockData) (*cell.Dictionary, error) {
+	desc, err := shardDesc(shard)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := cell.BeginCell().MustStoreUInt(0, 1).MustStoreBuilder(desc.ToBuilder()).EndCell()
+
+	dict := cell.NewDict(32)
+	if err = dict.SetIntKey(big.NewInt(int64(shard.id.Workchain)), cell.BeginCell().MustStoreRef(leaf).EndCell()); err != nil {
+		return nil, err
+	}
+	return dict, nil
+}
+
+func shardDesc(shard *blockData) (*cell.Cell, error) {
+	return tlb.ToCell(tlb.ShardDesc{
+		SeqNo:              shard.id.SeqNo,
+		StartLT:            shard.startLT,
+		EndLT:              shard.endLT,
+		RootHash:           shard.id.RootHash,
+		FileHash:           shard.id.FileHash,
+		NextValidatorShard: shard.id.Shard,
+		GenUTime:           shard.utime,
+		SplitMergeAt:       tlb.FutureSplitMergeNone{},
+	})
+}
+
+// sortedTxs - transactions of block ordered by account and lt
+func validatorAddrs(keys []ed25519.PrivateKey) []*tlb.ValidatorAddr {
+	list := make([]*tlb.ValidatorAddr, 0, len(keys))
+	for _, key := range keys {
+		pub := key.Public().(ed25519.PublicKey)
+		list = append(list, &tlb.ValidatorAddr{
+			PublicKey: tlb.SigPubKeyED25519{Key: pub},
+			Weight:    1,
+			ADNLAddr:  pub,
+		})
+	}
+	return list
+}
+
+// validatorSetHash - short hash of validators list, same as in block header
+func validatorSetHash(ccSeqno uint32, keys []ed25519.PrivateKey) (uint32, error) {
+	h := ton.ValidatorSetHashable{CCSeqno: ccSeqno}
+	for _, v := range validatorAddrs(keys) {
+		h.Validators = append(h.Validators, ton.ValidatorItemHashable{
+			Key:    v.PublicKey.Key,
+			Weight: v.Weight,
+			Addr:   v.ADNLAddr,
+		})
+	}
+
+	b, err := tl.Serialize(h, true)
+	if err != nil {
+		return 0, err
+	}
+	return crc32.Checksum(b, crc32.MakeTable(crc32.Castagnoli)), nil
+}
+
+func sameKeys(a, b []ed25519.PrivateKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedTxs(list []*txRecord) []*txRecord {
+	res := append([]*txRecord{}, list...)
+	sort.Slice(res, func(i, j int) bool {
+		ai, aj := string(res[i].tx.AccountAddr), string(res[j].tx.AccountAddr)
+		if ai != aj {
+			return ai < aj
+		}
+		return res[i].tx.LT < res[j].tx.LT
+	})
+	return res
+}
+
+func getMethodKey(addr *address.Address, id uint64) string {
+	return fmt.Sprintf("%d:%x:%d", addr.Workchain(), addr.Data(), id)
+}
@@ -0,0 +1,166 @@
+package liteservertest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestServer_LightClientStore(t *testing.T) {
+	chain := NewChain()
+	genesis := chain.LastBlock()
+
+	pub, key, _ := ed25519.GenerateKey(nil)
+
+	commit := func(n int) {
+		for i := 0; i < n; i++ {
+			if _, err := chain.Commit(); err != nil {
+				t.Fatal("commit err:", err)
+			}
+		}
+	}
+
+	commit(2)
+	if err := chain.SetValidators(key); err != nil {
+		t.Fatal("set validators err:", err)
+	}
+	commit(2) // key block 4
+	chain.SetConfigParam(8, cell.BeginCell().MustStoreUInt(0xC4, 8).MustStoreUInt(4, 32).EndCell())
+	commit(2) // key block 6
+
+	path := filepath.Join(t.TempDir(), "light.json")
+	store, err := ton.NewFileLightClientStore(path)
+	if err != nil {
+		t.Fatal("open store err:", err)
+	}
+
+	api := startServer(t, chain, ton.ProofCheckPolicySecure)
+	api.SetTrustedBlock(genesis)
+	if err = api.SetLightClientStore(store); err != nil {
+		t.Fatal("set store err:", err)
+	}
+
+	ctx := context.Background()
+	last, err := api.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("get master err:", err)
+	}
+	if last.SeqNo != 7 {
+		t.Fatal("incorrect last block", last.SeqNo)
+	}
+
+	keyBlocks, err := api.KeyBlocksHistory()
+	if err != nil {
+		t.Fatal("key blocks err:", err)
+	}
+	if len(keyBlocks) != 2 || keyBlocks[0].ID.SeqNo != 4 || keyBlocks[1].ID.SeqNo != 6 {
+		t.Fatal("incorrect key blocks", len(keyBlocks))
+	}
+
+	changes, err := api.ValidatorSetHistory()
+	if err != nil {
+		t.Fatal("validators history err:", err)
+	}
+	if len(changes) != 1 || changes[0].ID.SeqNo != 4 {
+		t.Fatal("incorrect validators history", len(changes))
+	}
+
+	set, err := changes[0].ValidatorSet()
+	if err != nil {
+		t.Fatal("validator set err:", err)
+	}
+	list, err := set.Validators.(tlb.ValidatorSet).List.LoadAll()
+	if err != nil {
+		t.Fatal("load validators err:", err)
+	}
+	var val tlb.ValidatorAddr
+	if err = tlb.LoadFromCell(&val, list[0].Value); err != nil {
+		t.Fatal("parse validator err:", err)
+	}
+	if len(list) != 1 || !bytes.Equal(val.PublicKey.Key, pub) {
+		t.Fatal("incorrect validators in history")
+	}
+
+	commit(1)
+
+	// new client resumes from the stored state without trusted block from config
+	store, err = ton.NewFileLightClientStore(path)
+	if err != nil {
+		t.Fatal("reopen store err:", err)
+	}
+
+	resumed := startServer(t, chain, ton.ProofCheckPolicySecure)
+	if err = resumed.SetLightClientStore(store); err != nil {
+		t.Fatal("set store err:", err)
+	}
+
+	last, err = resumed.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("get master after resume err:", err)
+	}
+
+	state, err := store.LoadState()
+	if err != nil {
+		t.Fatal("load state err:", err)
+	}
+	// trusted block is updated only after verification from the stored one
+	if !state.TrustedBlock.Equals(last) || last.SeqNo != 8 || len(state.KeyBlocks) != 2 {
+		t.Fatal("incorrect state after resume")
+	}
+
+	if err = resumed.VerifyProofChain(ctx, last, keyBlocks[0].ID); err != nil {
+		t.Fatal("backward proof chain err:", err)
+	}
+
+	conflict := &ton.KeyBlock{ID: keyBlocks[0].ID.Copy()}
+	conflict.ID.RootHash = make([]byte, 32)
+	if err = store.AddKeyBlocks(conflict); err == nil {
+		t.Fatal("conflicting key block should not be stored")
+	}
+}
+
+func TestServer_ForwardProofSignatures(t *testing.T) {
+	chain := NewChain()
+	genesis := chain.LastBlock()
+	for i := 0; i < 2; i++ {
+		if _, err := chain.Commit(); err != nil {
+			t.Fatal("commit err:", err)
+		}
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+
+	part, err := api.GetBlockProof(ctx, genesis, chain.LastBlock())
+	if err != nil {
+		t.Fatal("get block proof err:", err)
+	}
+	if len(part.Steps) != 1 {
+		t.Fatal("incorrect steps num", len(part.Steps))
+	}
+
+	fwd := part.Steps[0].(ton.BlockLinkForward)
+	configProof, err := cell.FromBOC(fwd.ConfigProof)
+	if err != nil {
+		t.Fatal("parse config proof err:", err)
+	}
+	destProof, err := cell.FromBOC(fwd.DestProof)
+	if err != nil {
+		t.Fatal("parse dest proof err:", err)
+	}
+
+	if err = ton.CheckForwardBlockProof(fwd.From, fwd.To, fwd.ToKeyBlock, configProof, destProof, fwd.SignatureSet); err != nil {
+		t.Fatal("forward proof check err:", err)
+	}
+
+	fwd.SignatureSet.Signatures[0].Signature[0] ^= 0xFF
+	if err = ton.CheckForwardBlockProof(fwd.From, fwd.To, fwd.ToKeyBlock, configProof, destProof, fwd.SignatureSet); err == nil {
+		t.Fatal("tampered signature should not pass")
+	}
+}
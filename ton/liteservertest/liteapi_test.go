@@ -0,0 +1,706 @@
+package liteservertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestServer_LookupBlockWithProof(t *testing.T) {
+	chain := NewChain()
+
+	// genesis block uses current time, so next blocks should be later
+	start := chain.Now() + 1000
+	for i := 0; i < 4; i++ {
+		chain.SetTime(start + uint32(i*10))
+		if _, err := chain.Commit(); err != nil {
+			t.Fatal("commit err:", err)
+		}
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+
+	master := chain.LastBlock()
+
+	for _, wc := range []int32{address.MasterchainID, 0} {
+		expected, err := api.LookupBlock(ctx, wc, shardFull, 3)
+		if err != nil {
+			t.Fatal("lookup err:", err)
+		}
+
+		block, err := api.LookupBlockWithProof(ctx, master, wc, shardFull, 3)
+		if err != nil {
+			t.Fatal("lookup with proof err:", err)
+		}
+		if !block.Equals(expected) {
+			t.Fatal("incorrect block by seqno", wc)
+		}
+
+		// block 3 was generated at start+10
+		block, err = api.LookupBlockByUTimeWithProof(ctx, master, wc, shardFull, start+5)
+		if err != nil {
+			t.Fatal("lookup by utime err:", err)
+		}
+		if !block.Equals(expected) {
+			t.Fatal("incorrect block by utime", wc, block.SeqNo)
+		}
+
+		prev, err := api.GetBlockData(ctx, mustLookup(t, api, wc, 2))
+		if err != nil {
+			t.Fatal("get block data err:", err)
+		}
+
+		block, err = api.LookupBlockByLTWithProof(ctx, master, wc, shardFull, prev.BlockInfo.EndLt+1)
+		if err != nil {
+			t.Fatal("lookup by lt err:", err)
+		}
+		if !block.Equals(expected) {
+			t.Fatal("incorrect block by lt", wc, block.SeqNo)
+		}
+	}
+
+	// block after the client's one should not be returned
+	old := mustLookup(t, api, address.MasterchainID, 2)
+	if _, err := api.LookupBlockWithProof(ctx, old, 0, shardFull, 4); err != ton.ErrBlockNotFound {
+		t.Fatal("block should not be found, got", err)
+	}
+}
+
+func TestServer_ShardBlockProof(t *testing.T) {
+	chain := NewChain()
+	for i := 0; i < 3; i++ {
+		if _, err := chain.Commit(); err != nil {
+			t.Fatal("commit err:", err)
+		}
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+
+	master := chain.LastBlock()
+
+	shard, err := api.GetShardInfo(ctx, master, 0, shardFull, true)
+	if err != nil {
+		t.Fatal("get shard info err:", err)
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		t.Fatal("get shards err:", err)
+	}
+	if !shard.Equals(shards[0]) {
+		t.Fatal("incorrect shard block")
+	}
+
+	// not exact shard contains requested one
+	if _, err = api.GetShardInfo(ctx, master, 0, 0x6000000000000000, false); err != nil {
+		t.Fatal("get child shard info err:", err)
+	}
+
+	block := mustLookup(t, api, 0, 2)
+
+	proof, err := api.GetShardBlockProof(ctx, block)
+	if err != nil {
+		t.Fatal("get shard block proof err:", err)
+	}
+	if !proof.MasterchainID.Equals(master) || len(proof.Links) != 1+int(shard.SeqNo-block.SeqNo) {
+		t.Fatal("incorrect shard block proof")
+	}
+
+	proof, err = api.GetShardBlockProof(ctx, master)
+	if err != nil {
+		t.Fatal("get master block proof err:", err)
+	}
+	if !proof.MasterchainID.Equals(master) || len(proof.Links) != 0 {
+		t.Fatal("incorrect master block proof")
+	}
+
+	// tampered link should be rejected
+	proof, _ = api.GetShardBlockProof(ctx, block)
+	proof.Links[1].ID = shard
+	if err = ton.CheckShardBlockProof(block, proof); err == nil {
+		t.Fatal("tampered proof should not pass")
+	}
+}
+
+func TestServer_BlockTransactionsMetadata(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+
+	api := startServer(t, chain)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		err := api.SendExternalMessage(ctx, &tlb.ExternalMessage{
+			DstAddr: addr,
+			Body:    cell.BeginCell().MustStoreUInt(uint64(i), 32).EndCell(),
+		})
+		if err != nil {
+			t.Fatal("send err:", err)
+		}
+	}
+
+	block, err := api.WaitForBlock(chain.LastBlock().SeqNo).CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("master info err:", err)
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, block)
+	if err != nil {
+		t.Fatal("get shards err:", err)
+	}
+
+	var list []ton.TransactionShortInfo
+	for seqno := shards[0].SeqNo; seqno > 1 && len(list) == 0; seqno-- {
+		list, _, err = api.GetBlockTransactionsWithMetadata(ctx, mustLookup(t, api, 0, seqno), 10)
+		if err != nil {
+			t.Fatal("get block txs err:", err)
+		}
+	}
+	if len(list) == 0 {
+		t.Fatal("no transactions found")
+	}
+
+	for _, tx := range list {
+		if tx.Metadata == nil || !bytes.Equal(tx.Metadata.Initiator.ID, addr.Data()) || tx.Metadata.InitiatorLT != tx.LT {
+			t.Fatal("incorrect metadata")
+		}
+	}
+
+	txBlock, err := api.LookupBlockByLTWithProof(ctx, block, 0, shardFull, list[0].LT)
+	if err != nil {
+		t.Fatal("lookup block by lt err:", err)
+	}
+
+	txs, incomplete, err := api.GetBlockTransactionsExt(ctx, txBlock, 1)
+	if err != nil {
+		t.Fatal("get block txs ext err:", err)
+	}
+	if len(txs) != 1 || !bytes.Equal(txs[0].Hash, list[0].Hash) || incomplete != (len(list) > 1) {
+		t.Fatal("incorrect block transactions ext")
+	}
+}
+
+func TestServer_ValidatorStats(t *testing.T) {
+	chain := NewChain()
+
+	keys := [][]byte{
+		bytes.Repeat([]byte{0x33}, 32),
+		bytes.Repeat([]byte{0x11}, 32),
+		bytes.Repeat([]byte{0x22}, 32),
+	}
+	for i, key := range keys {
+		chain.SetCreatorStats(key, tlb.CreatorStats{
+			MCBlocks:    tlb.Counters{LastUpdated: uint32(100 * (i + 1)), Total: uint64(i + 1)},
+			ShardBlocks: tlb.Counters{LastUpdated: uint32(100 * (i + 1)), Total: uint64(10 * (i + 1))},
+		})
+	}
+
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+	master := chain.LastBlock()
+
+	stats, complete, err := api.GetValidatorStats(ctx, master, 2, nil, 0)
+	if err != nil {
+		t.Fatal("get stats err:", err)
+	}
+	if complete || len(stats) != 2 || !bytes.Equal(stats[0].PublicKey, keys[1]) || !bytes.Equal(stats[1].PublicKey, keys[2]) {
+		t.Fatal("incorrect first page")
+	}
+	if stats[0].MCBlocks.Total != 2 || stats[0].ShardBlocks.Total != 20 {
+		t.Fatal("incorrect counters")
+	}
+
+	stats, complete, err = api.GetValidatorStats(ctx, master, 2, stats[1].PublicKey, 0)
+	if err != nil {
+		t.Fatal("get stats err:", err)
+	}
+	if !complete || len(stats) != 1 || !bytes.Equal(stats[0].PublicKey, keys[0]) {
+		t.Fatal("incorrect second page")
+	}
+
+	stats, _, err = api.GetValidatorStats(ctx, master, 10, nil, 150)
+	if err != nil {
+		t.Fatal("get stats err:", err)
+	}
+	if len(stats) != 2 {
+		t.Fatal("incorrect modified after filter", len(stats))
+	}
+}
+
+func TestServer_LibrariesAndQueues(t *testing.T) {
+	chain := NewChain()
+
+	lib := cell.BeginCell().MustStoreUInt(0xC0DE, 16).EndCell()
+	chain.AddLibrary(lib)
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+	master := chain.LastBlock()
+
+	missing := bytes.Repeat([]byte{0xAA}, 32)
+	libs, err := api.GetLibrariesWithProof(ctx, master, lib.Hash(), missing)
+	if err != nil {
+		t.Fatal("get libraries err:", err)
+	}
+	if len(libs) != 2 || libs[0] == nil || !bytes.Equal(libs[0].Hash(), lib.Hash()) || libs[1] != nil {
+		t.Fatal("incorrect libraries")
+	}
+
+	sizes, err := api.GetOutMsgQueueSizes(ctx)
+	if err != nil {
+		t.Fatal("get queue sizes err:", err)
+	}
+	if len(sizes.Shards) != 2 || sizes.ExtMsgQueueSizeLimit == 0 {
+		t.Fatal("incorrect queue sizes")
+	}
+
+	sizes, err = api.GetShardOutMsgQueueSizes(ctx, 0, shardFull)
+	if err != nil {
+		t.Fatal("get shard queue sizes err:", err)
+	}
+	if len(sizes.Shards) != 1 || sizes.Shards[0].ID.Workchain != 0 {
+		t.Fatal("incorrect shard queue sizes")
+	}
+
+	size, err := api.GetBlockOutMsgQueueSize(ctx, master)
+	if err != nil {
+		t.Fatal("get block queue size err:", err)
+	}
+	if size != 0 {
+		t.Fatal("incorrect block queue size")
+	}
+
+	accounts, complete, err := api.GetDispatchQueueInfo(ctx, master, nil, 10)
+	if err != nil {
+		t.Fatal("get dispatch queue info err:", err)
+	}
+	if !complete || len(accounts) != 0 {
+		t.Fatal("incorrect dispatch queue info")
+	}
+
+	addr := address.MustParseRawAddr("-1:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	msgs, complete, err := api.GetDispatchQueueMessages(ctx, master, addr, 0, 10, true)
+	if err != nil {
+		t.Fatal("get dispatch queue messages err:", err)
+	}
+	if !complete || len(msgs) != 0 {
+		t.Fatal("incorrect dispatch queue messages")
+	}
+}
+
+func mustLookup(t *testing.T, api *ton.APIClient, workchain int32, seqno uint32) *ton.BlockIDExt {
+	block, err := api.LookupBlock(context.Background(), workchain, shardFull, seqno)
+	if err != nil {
+		t.Fatal("lookup err:", err)
+	}
+	return block
+}
+
+func TestServer_ListTransactionsVerified(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+
+	api := startServer(t, chain)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		err := api.SendExternalMessage(ctx, &tlb.ExternalMessage{
+			DstAddr: addr,
+			Body:    cell.BeginCell().MustStoreUInt(uint64(i), 32).EndCell(),
+		})
+		if err != nil {
+			t.Fatal("send err:", err)
+		}
+	}
+	master := chain.LastBlock()
+
+	// shard block proofs will be built from newer blocks, so they are linked by forward proofs
+	for i := 0; i < 2; i++ {
+		if _, err := chain.Commit(); err != nil {
+			t.Fatal("commit err:", err)
+		}
+	}
+
+	list, err := api.ListTransactionsVerified(ctx, master, addr, 2, nil)
+	if err != nil {
+		t.Fatal("list verified err:", err)
+	}
+	if len(list) != 2 || list[0].IO.In.AsExternalIn().Body.BeginParse().MustLoadUInt(32) != 1 {
+		t.Fatal("incorrect first page")
+	}
+
+	list, err = api.ListTransactionsVerified(ctx, master, addr, 2, list[0])
+	if err != nil {
+		t.Fatal("list verified next page err:", err)
+	}
+	if len(list) != 1 || list[0].IO.In.AsExternalIn().Body.BeginParse().MustLoadUInt(32) != 0 {
+		t.Fatal("incorrect second page")
+	}
+
+	if _, err = api.ListTransactionsVerified(ctx, master, addr, 2, list[0]); err != ton.ErrNoTransactionsWereFound {
+		t.Fatal("should be no more transactions, got", err)
+	}
+
+	shard := mustLookup(t, api, 0, 3)
+	if err = api.VerifyBlockID(ctx, master, shard); err != nil {
+		t.Fatal("verify shard block err:", err)
+	}
+	if err = api.VerifyBlockID(ctx, master, mustLookup(t, api, address.MasterchainID, 2)); err != nil {
+		t.Fatal("verify master block err:", err)
+	}
+
+	fake := shard.Copy()
+	fake.RootHash = make([]byte, 32)
+	if err = api.VerifyBlockID(ctx, master, fake); err == nil {
+		t.Fatal("unknown block should not be verified")
+	}
+
+	unsafe := ton.NewAPIClient(api.Client(), ton.ProofCheckPolicyUnsafe)
+	if _, err = unsafe.ListTransactionsVerified(ctx, master, addr, 2, nil); err != ton.ErrUnsafeProofCheckPolicy {
+		t.Fatal("verification should be refused with unsafe policy, got", err)
+	}
+}
+
+func TestServer_GetShardState(t *testing.T) {
+	chain := NewChain()
+
+	addrs := []*address.Address{
+		address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"),
+		address.MustParseRawAddr("0:" + "13dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"),
+	}
+	for i, addr := range addrs {
+		chain.SetAccount(Account{
+			Address: addr,
+			Balance: tlb.MustFromTON(fmt.Sprint(i + 1)),
+			Code:    cell.BeginCell().EndCell(),
+			Data:    cell.BeginCell().MustStoreUInt(uint64(i), 8).EndCell(),
+		})
+	}
+
+	master, err := chain.Commit()
+	if err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startServer(t, chain, ton.ProofCheckPolicySecure)
+	api.SetTrustedBlock(master)
+	ctx := context.Background()
+
+	block := mustLookup(t, api, 0, master.SeqNo)
+	state, err := api.GetShardState(ctx, block)
+	if err != nil {
+		t.Fatal("get state err:", err)
+	}
+
+	acc, err := state.GetAccount(addrs[1])
+	if err != nil {
+		t.Fatal("get account err:", err)
+	}
+	if !acc.IsActive || acc.State.Balance.Nano().Cmp(tlb.MustFromTON("2").Nano()) != 0 || acc.Data.BeginParse().MustLoadUInt(8) != 1 {
+		t.Fatal("incorrect account state")
+	}
+
+	// state of the left half of the workchain contains only accounts which are starting from 0 bit
+	half := &ton.ShardState{Block: &ton.BlockIDExt{Workchain: 0, Shard: 0x4000000000000000}, State: state.State}
+	if _, err = half.GetAccount(addrs[0]); err == nil {
+		t.Fatal("account from another shard should be rejected")
+	}
+	if _, err = half.GetAccount(addrs[1]); err != nil {
+		t.Fatal("account from the shard should be accepted", err)
+	}
+
+	missing, err := state.GetAccount(address.MustParseRawAddr("0:" + "0000000000000000000000000000000000000000000000000000000000000001"))
+	if err != nil {
+		t.Fatal("get missing account err:", err)
+	}
+	if missing.IsActive {
+		t.Fatal("missing account should not be active")
+	}
+
+	var list []*ton.ShardStateAccount
+	if err = state.ForEachAccount(func(acc *ton.ShardStateAccount) error {
+		list = append(list, acc)
+		return nil
+	}); err != nil {
+		t.Fatal("iterate accounts err:", err)
+	}
+	// ordered by address
+	if len(list) != 2 || list[0].Address.String() != addrs[1].String() || list[1].Address.String() != addrs[0].String() {
+		t.Fatal("incorrect accounts list")
+	}
+	if list[1].Balance.Coins.Nano().Cmp(tlb.MustFromTON("1").Nano()) != 0 {
+		t.Fatal("incorrect account balance")
+	}
+
+	stopErr := fmt.Errorf("stop")
+	calls := 0
+	if err = state.ForEachAccount(func(acc *ton.ShardStateAccount) error {
+		calls++
+		return stopErr
+	}); err != stopErr || calls != 1 {
+		t.Fatal("iteration should stop on error")
+	}
+
+	stats, err := state.Stats()
+	if err != nil {
+		t.Fatal("stats err:", err)
+	}
+	if stats.TotalBalance.Coins.Nano().Cmp(tlb.MustFromTON("3").Nano()) != 0 {
+		t.Fatal("incorrect total balance", stats.TotalBalance.Coins.String())
+	}
+
+	mcState, err := api.GetShardState(ctx, master)
+	if err != nil {
+		t.Fatal("get master state err:", err)
+	}
+	supply, err := mcState.GlobalBalance()
+	if err != nil {
+		t.Fatal("global balance err:", err)
+	}
+	if supply.Coins.Nano().Cmp(tlb.MustFromTON("3").Nano()) != 0 {
+		t.Fatal("incorrect global balance", supply.Coins.String())
+	}
+
+	// block id with another root hash should not be accepted
+	fake := block.Copy()
+	fake.RootHash = make([]byte, 32)
+	if _, err = api.GetShardState(ctx, fake); err == nil {
+		t.Fatal("state of unknown block should not be returned")
+	}
+
+	// limit is applied to the clients wrapping the api too
+	api.SetMaxShardStateSize(16)
+	if _, err = api.WithRetry().GetShardState(ctx, block); !errors.Is(err, ton.ErrShardStateTooBig) {
+		t.Fatal("state over the limit should not be decoded", err)
+	}
+}
+
+func TestServer_GetAccountMeta(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	code := cell.BeginCell().MustStoreUInt(1, 8).MustStoreRef(cell.BeginCell().MustStoreUInt(2, 8).EndCell()).EndCell()
+	data := cell.BeginCell().MustStoreUInt(3, 8).EndCell()
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1.5"),
+		Code:    code,
+		Data:    data,
+	})
+
+	master, err := chain.Commit()
+	if err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+
+	meta, err := api.GetAccountMeta(ctx, master, addr)
+	if err != nil {
+		t.Fatal("get account meta err:", err)
+	}
+
+	full, err := api.GetAccount(ctx, master, addr)
+	if err != nil {
+		t.Fatal("get account err:", err)
+	}
+
+	if !meta.IsActive || meta.Status != tlb.AccountStatusActive || meta.Balance.Nano().Cmp(full.State.Balance.Nano()) != 0 {
+		t.Fatal("incorrect account meta")
+	}
+	if meta.LastTxLT != full.LastTxLT || !bytes.Equal(meta.LastTxHash, full.LastTxHash) {
+		t.Fatal("incorrect last tx")
+	}
+
+	// code has refs, so it is pruned
+	if _, err = meta.Code(); err != ton.ErrPrunedField {
+		t.Fatal("pruned code should not be returned", err)
+	}
+
+	// data has no refs, so it is sent as is
+	d, err := meta.Data()
+	if err != nil {
+		t.Fatal("get data err:", err)
+	}
+	if !bytes.Equal(d.Hash(), data.Hash()) {
+		t.Fatal("incorrect data")
+	}
+
+	// liteserver which answers full state request with pruned one should not be trusted
+	pruning := ton.NewAPIClient(prunedStateClient{api.Client()}, ton.ProofCheckPolicyFast)
+	if _, err = pruning.GetAccount(ctx, master, addr); err == nil {
+		t.Fatal("pruned state should be rejected by GetAccount")
+	}
+
+	missing, err := api.GetAccountMeta(ctx, master, address.MustParseRawAddr("0:"+"0000000000000000000000000000000000000000000000000000000000000001"))
+	if err != nil {
+		t.Fatal("get missing account meta err:", err)
+	}
+	if missing.IsActive || missing.Status != tlb.AccountStatusNonExist {
+		t.Fatal("missing account should not be active")
+	}
+}
+
+type testPoint struct {
+	X int16 `tvm:"int"`
+	Y int16 `tvm:"int"`
+}
+
+type testMethodData struct {
+	Amount  *big.Int         `tvm:"int"`
+	Seqno   uint32           `tvm:"int"`
+	Enabled bool             `tvm:"bool"`
+	Balance tlb.Coins        `tvm:"coins"`
+	Owner   *address.Address `tvm:"addr"`
+	Code    *cell.Cell       `tvm:"cell"`
+	Note    *cell.Cell       `tvm:"maybe cell"`
+	Point   *testPoint       `tvm:"tuple"`
+	Raw     []any            `tvm:"tuple"`
+	Init    *tlb.StateInit   `tvm:"tlb"`
+}
+
+func TestServer_RunGetMethodDecode(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+	chain.SetGetMethod(addr, "echo", func(acc *Account, params []any) ([]any, int32) {
+		return params, 0
+	})
+
+	master, err := chain.Commit()
+	if err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+
+	src := testMethodData{
+		Amount:  big.NewInt(-7),
+		Seqno:   42,
+		Enabled: true,
+		Balance: tlb.MustFromTON("2.5"),
+		Owner:   addr,
+		Code:    cell.BeginCell().MustStoreUInt(0xAA, 8).EndCell(),
+		Point:   &testPoint{X: 1, Y: -2},
+		Raw:     []any{big.NewInt(5)},
+		Init:    &tlb.StateInit{Code: cell.BeginCell().EndCell(), Data: cell.BeginCell().MustStoreUInt(1, 8).EndCell()},
+	}
+
+	params, err := ton.EncodeParams(src)
+	if err != nil {
+		t.Fatal("encode err:", err)
+	}
+
+	res, err := api.RunGetMethod(ctx, master, addr, "echo", params...)
+	if err != nil {
+		t.Fatal("run get method err:", err)
+	}
+
+	var dst testMethodData
+	if err = res.Decode(&dst); err != nil {
+		t.Fatal("decode err:", err)
+	}
+
+	if dst.Amount.Int64() != -7 || dst.Seqno != 42 || !dst.Enabled || dst.Balance.String() != "2.5" {
+		t.Fatal("incorrect decoded numbers")
+	}
+	if dst.Owner.String() != addr.String() || !bytes.Equal(dst.Code.Hash(), src.Code.Hash()) || dst.Note != nil {
+		t.Fatal("incorrect decoded cells")
+	}
+	if dst.Point == nil || dst.Point.X != 1 || dst.Point.Y != -2 || len(dst.Raw) != 1 {
+		t.Fatal("incorrect decoded tuples")
+	}
+	if dst.Init == nil || !bytes.Equal(dst.Init.Data.Hash(), src.Init.Data.Hash()) {
+		t.Fatal("incorrect decoded tlb")
+	}
+
+	var overflow struct {
+		Amount *big.Int `tvm:"int"`
+		Seqno  uint8    `tvm:"int"`
+		Flag   uint8    `tvm:"int"`
+	}
+	// -1 of bool does not fit uint8
+	if err = res.Decode(&overflow); err == nil {
+		t.Fatal("overflow should be detected")
+	}
+
+	var wrongType struct {
+		Amount *cell.Cell `tvm:"cell"`
+	}
+	if err = res.Decode(&wrongType); !errors.Is(err, ton.ErrIncorrectResultType) {
+		t.Fatal("type mismatch should be detected", err)
+	}
+
+	var tooLong struct {
+		_    any   `tvm:"any"`
+		List []any `tvm:"any"`
+	}
+	if err = ton.NewExecutionResult([]any{big.NewInt(1)}).Decode(&tooLong); !errors.Is(err, ton.ErrResultIndexOutOfRange) {
+		t.Fatal("missing value should be detected", err)
+	}
+
+	var unexported struct {
+		amount *big.Int `tvm:"int"`
+	}
+	if err = ton.NewExecutionResult([]any{big.NewInt(1)}).Decode(&unexported); err == nil {
+		t.Fatal("unexported field should be rejected")
+	}
+	if _, err = ton.EncodeParams(unexported); err == nil {
+		t.Fatal("unexported field should not be encoded")
+	}
+}
+
+// prunedStateClient - replaces full account state requests with pruned ones
+type prunedStateClient struct {
+	ton.LiteClient
+}
+
+func (c prunedStateClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if q, ok := payload.(ton.GetAccountState); ok {
+		payload = ton.GetAccountStatePruned(q)
+	}
+	return c.LiteClient.QueryLiteserver(ctx, payload, result)
+}
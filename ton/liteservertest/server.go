@@ -0,0 +1,1302 @@
+package liteservertest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Server - liteserver which answers queries from in-memory Chain, for hermetic tests.
+// Responses contain proofs built from the chain cells, so clients can be used with proof checks enabled.
+type Server struct {
+	chain  *Chain
+	key    ed25519.PrivateKey
+	server *liteclient.Server
+	addr   string
+}
+
+// NewServer - creates liteserver for chain, call Start to listen on random local port
+func NewServer(chain *Chain) *Server {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	s := &Server{
+		chain:  chain,
+		key:    key,
+		server: liteclient.NewServer([]ed25519.PrivateKey{key}),
+	}
+	s.server.SetMessageHandler(s.handle)
+	return s
+}
+
+// Start - starts listening on random local port
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to find free port: %w", err)
+	}
+	s.addr = lis.Addr().String()
+	_ = lis.Close()
+
+	started := make(chan error, 1)
+	go func() {
+		started <- s.server.Listen(s.addr)
+	}()
+
+	// wait for listener to be ready
+	for i := 0; i < 100; i++ {
+		select {
+		case err = <-started:
+			return fmt.Errorf("failed to listen: %w", err)
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", s.addr, 100*time.Millisecond)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("server was not started in time")
+}
+
+// Close - stops listening
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// Chain - chain served by this server
+func (s *Server) Chain() *Chain {
+	return s.chain
+}
+
+// Addr - listen address in ip:port format
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Key - server public key in base64
+func (s *Server) Key() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Config - global config with this server, its zero state is used as init block
+func (s *Server) Config() *liteclient.GlobalConfig {
+	host, _, _ := net.SplitHostPort(s.addr)
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", s.addr)
+
+	ls := liteclient.LiteserverConfig{
+		IP:   int64(binary.BigEndian.Uint32(net.ParseIP(host).To4())),
+		Port: tcpAddr.Port,
+	}
+	ls.ID.Type = "pub.ed25519"
+	ls.ID.Key = s.Key()
+
+	zero := s.chain.zeroState
+	block := liteclient.ConfigBlock{
+		Workchain: zero.Workchain,
+		Shard:     shardFull,
+		RootHash:  zero.RootHash,
+		FileHash:  zero.FileHash,
+	}
+
+	return &liteclient.GlobalConfig{
+		Type:        "config.global",
+		Liteservers: []liteclient.LiteserverConfig{ls},
+		Validator: liteclient.ValidatorConfig{
+			Type:      "validator.config.global",
+			ZeroState: block,
+			InitBlock: block,
+		},
+	}
+}
+
+// NewConnectionPool - creates pool connected to this server
+func (s *Server) NewConnectionPool(ctx context.Context) (*liteclient.ConnectionPool, error) {
+	pool := liteclient.NewConnectionPool()
+	if err := pool.AddConnection(ctx, s.addr, s.Key()); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (s *Server) handle(ctx context.Context, sc *liteclient.ServerClient, msg tl.Serializable) error {
+	switch m := msg.(type) {
+	case adnl.MessageQuery:
+		q, ok := m.Data.(liteclient.LiteServerQuery)
+		if !ok {
+			return fmt.Errorf("unexpected query type %s", reflect.TypeOf(m.Data).String())
+		}
+
+		// process in parallel, because some queries can wait for new blocks
+		go func() {
+			resp := s.process(ctx, q.Data)
+			if err := sc.Send(adnl.MessageAnswer{ID: m.ID, Data: resp}); err != nil {
+				liteclient.Logger("failed to send liteserver response:", err.Error())
+			}
+		}()
+		return nil
+	case liteclient.TCPPing:
+		return sc.Send(liteclient.TCPPong{RandomID: m.RandomID})
+	}
+	return fmt.Errorf("unexpected message type %s", reflect.TypeOf(msg).String())
+}
+
+func (s *Server) process(ctx context.Context, query any) tl.Serializable {
+	if list, ok := query.([]tl.Serializable); ok && len(list) == 2 {
+		// waitMasterchainSeqno prefix
+		if w, ok := list[0].(ton.WaitMasterchainSeqno); ok {
+			if err := s.waitSeqno(ctx, uint32(w.Seqno), time.Duration(w.Timeout)*time.Millisecond); err != nil {
+				return ton.LSError{Code: 652, Text: err.Error()}
+			}
+			query = list[1]
+		}
+	}
+
+	resp, err := s.query(query)
+	if err != nil {
+		if lsErr, ok := err.(ton.LSError); ok {
+			return lsErr
+		}
+		return ton.LSError{Code: -400, Text: err.Error()}
+	}
+	return resp
+}
+
+func (s *Server) waitSeqno(ctx context.Context, seqno uint32, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		wait := s.chain.waitBlock()
+		if s.chain.LastBlock().SeqNo >= seqno {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for masterchain seqno %d", seqno)
+		case <-wait:
+		}
+	}
+}
+
+var errBlockNotFound = ton.LSError{Code: 651, Text: "block not found"}
+
+func (s *Server) query(query any) (tl.Serializable, error) {
+	c := s.chain
+
+	switch q := query.(type) {
+	case ton.GetTime:
+		return ton.CurrentTime{Now: c.Now()}, nil
+	case ton.GetVersion:
+		return ton.Version{Version: 0x101, Capabilities: 7, Now: c.Now()}, nil
+	case ton.GetMasterchainInf:
+		c.mx.RLock()
+		defer c.mx.RUnlock()
+
+		last := c.blocks[len(c.blocks)-1].master
+		return ton.MasterchainInfo{
+			Last:          last.id,
+			StateRootHash: last.state.Hash(),
+			Init:          c.zeroState,
+		}, nil
+	case ton.GetMasterchainInfoExt:
+		c.mx.RLock()
+		defer c.mx.RUnlock()
+
+		last := c.blocks[len(c.blocks)-1].master
+		return ton.MasterchainInfoExt{
+			Version:       0x101,
+			Capabilities:  7,
+			Last:          last.id,
+			LastUTime:     last.utime,
+			Now:           c.now(),
+			StateRootHash: last.state.Hash(),
+			Init:          c.zeroState,
+		}, nil
+	case ton.LookupBlock:
+		return s.lookupBlock(q)
+	case ton.GetBlockHeader:
+		c.mx.RLock()
+		defer c.mx.RUnlock()
+
+		b := c.findBlock(q.ID)
+		if b == nil {
+			return nil, errBlockNotFound
+		}
+		return blockHeader(b)
+	case ton.GetBlockData:
+		c.mx.RLock()
+		defer c.mx.RUnlock()
+
+		b := c.findBlock(q.ID)
+		if b == nil {
+			return nil, errBlockNotFound
+		}
+		return ton.BlockData{ID: b.id, Payload: b.block.ToBOCWithFlags(false)}, nil
+	case ton.GetState:
+		c.mx.RLock()
+		defer c.mx.RUnlock()
+
+		b := c.findBlock(q.ID)
+		if b == nil {
+			return nil, errBlockNotFound
+		}
+
+		data := b.state.ToBOCWithFlags(false)
+		fileHash := sha256.Sum256(data)
+		return ton.BlockState{ID: b.id, RootHash: b.state.Hash(), FileHash: fileHash[:], Data: data}, nil
+	case ton.GetAllShardsInfo:
+		return s.allShardsInfo(q)
+	case ton.GetAccountState:
+		return s.accountState(q, false)
+	case ton.GetAccountStatePruned:
+		return s.accountState(ton.GetAccountState(q), true)
+	case ton.RunSmcMethod:
+		return s.runMethod(q)
+	case ton.GetTransactions:
+		return s.transactions(q)
+	case ton.GetOneTransaction:
+		return s.oneTransaction(q)
+	case ton.ListBlockTransactions:
+		return s.blockTransactions(q)
+	case ton.ListBlockTransactionsExt:
+		return s.blockTransactionsExt(q)
+	case ton.GetBlockProof:
+		return s.blockProof(q)
+	case ton.LookupBlockWithProof:
+		return s.lookupBlockWithProof(q)
+	case ton.GetShardInfo:
+		return s.shardInfo(q)
+	case ton.GetShardBlockProof:
+		return s.shardBlockProof(q)
+	case ton.GetOutMsgQueueSizes:
+		return s.outMsgQueueSizes(q)
+	case ton.GetBlockOutMsgQueueSize:
+		return s.blockOutMsgQueueSize(q)
+	case ton.GetDispatchQueueInfo:
+		return s.dispatchQueueInfo(q)
+	case ton.GetDispatchQueueMessages:
+		return s.dispatchQueueMessages(q)
+	case ton.GetValidatorStats:
+		return s.validatorStats(q)
+	case ton.GetConfigAll:
+		return s.config(q.BlockID, q.Mode)
+	case ton.GetConfigParams:
+		return s.config(q.BlockID, q.Mode)
+	case ton.GetLibraries:
+		c.mx.RLock()
+		defer c.mx.RUnlock()
+
+		res := ton.LibraryResult{Result: []*ton.LibraryEntry{}}
+		for _, hash := range q.LibraryList {
+			if lib := c.libraries[string(hash)]; lib != nil {
+				res.Result = append(res.Result, &ton.LibraryEntry{Hash: hash, Data: lib})
+			}
+		}
+		return res, nil
+	case ton.GetLibrariesWithProof:
+		return s.librariesWithProof(q)
+	case ton.SendMessage:
+		return s.sendMessage(q)
+	}
+
+	return nil, ton.LSError{Code: -400, Text: fmt.Sprintf("query %s is not supported by test liteserver", reflect.TypeOf(query).String())}
+}
+
+func (s *Server) lookupBlock(q ton.LookupBlock) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.lookup(q.ID.Workchain, q.Mode, uint32(q.ID.Seqno), q.LT, q.UTime)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+	return blockHeader(b)
+}
+
+func (s *Server) lookupBlockWithProof(q ton.LookupBlockWithProof) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	client := c.findBlock(q.MCBlockID)
+	if client == nil || client.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	b := c.lookup(q.ID.Workchain, q.Mode, uint32(q.ID.Seqno), q.LT, q.UTime)
+	if b == nil || b.id.SeqNo > client.id.SeqNo {
+		return nil, errBlockNotFound
+	}
+
+	header, err := b.block.CreateProof(blockSkeleton())
+	if err != nil {
+		return nil, err
+	}
+
+	mc := c.masterBySeqno(b.id.SeqNo).master
+	res := ton.LookupBlockResult{
+		ID:         b.id,
+		Mode:       q.Mode,
+		MCBlockID:  mc.id,
+		ShardLinks: []ton.ShardBlockLink{},
+		Header:     header.ToBOCWithFlags(false),
+	}
+
+	if q.Mode&6 != 0 && b.id.SeqNo > 1 {
+		prev, err := c.blockBySeqno(b.id.Workchain, b.id.SeqNo-1).block.CreateProof(blockSkeleton())
+		if err != nil {
+			return nil, err
+		}
+		res.PrevHeader = prev.ToBOCWithFlags(false)
+	}
+
+	if !mc.id.Equals(client.id) {
+		blockProof, stateProof, err := stateProofs(client, mcStateSkeleton())
+		if err != nil {
+			return nil, err
+		}
+		res.ClientMCStateProof = cell.ToBOCWithFlags([]*cell.Cell{blockProof, stateProof}, false)
+	}
+
+	if b != mc {
+		proof, err := mc.block.CreateProof(mcExtraSkeleton())
+		if err != nil {
+			return nil, err
+		}
+		res.MCBlockProof = proof.ToBOCWithFlags(false)
+	}
+	return res, nil
+}
+
+func (s *Server) shardInfo(q ton.GetShardInfo) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil || b.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	shard := c.masterBySeqno(b.id.SeqNo).shard
+	if q.Workchain != shard.id.Workchain || (q.Exact && q.Shard != shard.id.Shard) {
+		return nil, ton.LSError{Code: -400, Text: "shard not found"}
+	}
+
+	blockProof, stateProof, err := stateProofs(b, mcStateSkeleton())
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := shardDesc(shard)
+	if err != nil {
+		return nil, err
+	}
+
+	return ton.ShardInfo{
+		ID:               b.id,
+		ShardBlock:       shard.id,
+		ShardProof:       []*cell.Cell{blockProof, stateProof},
+		ShardDescription: desc,
+	}, nil
+}
+
+func (s *Server) shardBlockProof(q ton.GetShardBlockProof) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	if b.id.Workchain == address.MasterchainID {
+		return ton.ShardBlockProof{MasterchainID: b.id, Links: []ton.ShardBlockLink{}}, nil
+	}
+
+	last := c.blocks[len(c.blocks)-1]
+	mcProof, err := last.master.block.CreateProof(mcExtraSkeleton())
+	if err != nil {
+		return nil, err
+	}
+
+	links := []ton.ShardBlockLink{{ID: last.shard.id, Proof: mcProof.ToBOCWithFlags(false)}}
+	for seqno := last.shard.id.SeqNo; seqno > b.id.SeqNo; seqno-- {
+		proof, err := c.blockBySeqno(b.id.Workchain, seqno).block.CreateProof(blockSkeleton())
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, ton.ShardBlockLink{
+			ID:    c.blockBySeqno(b.id.Workchain, seqno-1).id,
+			Proof: proof.ToBOCWithFlags(false),
+		})
+	}
+
+	return ton.ShardBlockProof{MasterchainID: last.master.id, Links: links}, nil
+}
+
+func (s *Server) blockProof(q ton.GetBlockProof) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	known := c.findBlock(q.KnownBlock)
+	if known == nil || known.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	target := c.blocks[len(c.blocks)-1].master
+	if q.TargetBlock != nil {
+		if target = c.findBlock(q.TargetBlock); target == nil || target.id.Workchain != address.MasterchainID {
+			return nil, errBlockNotFound
+		}
+	}
+
+	res := ton.PartialBlockProof{
+		Complete: true,
+		From:     known.id,
+		To:       target.id,
+		Steps:    []any{},
+	}
+
+	if target.id.SeqNo < known.id.SeqNo {
+		step, err := backwardLink(known, target)
+		if err != nil {
+			return nil, err
+		}
+		res.Steps = append(res.Steps, step)
+		return res, nil
+	}
+
+	cur := known
+	if cur != target && !cur.keyBlock {
+		// forward link can be built only from a key block, which has validators in config
+		key := c.blockBySeqno(address.MasterchainID, cur.prevKeyBlock)
+		step, err := backwardLink(cur, key)
+		if err != nil {
+			return nil, err
+		}
+		res.Steps = append(res.Steps, step)
+		cur = key
+	}
+
+	for cur != target {
+		next := target
+		for seqno := cur.id.SeqNo + 1; seqno < target.id.SeqNo; seqno++ {
+			if b := c.blockBySeqno(address.MasterchainID, seqno); b.keyBlock {
+				next = b
+				break
+			}
+		}
+
+		step, err := forwardLink(cur, next)
+		if err != nil {
+			return nil, err
+		}
+		res.Steps = append(res.Steps, step)
+		cur = next
+	}
+	return res, nil
+}
+
+func (s *Server) allShardsInfo(q ton.GetAllShardsInfo) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil || b.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	sk := blockSkeleton()
+	// extra -> custom
+	sk.ProofRef(3).ProofRef(3)
+
+	proof, err := b.block.CreateProof(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	return ton.AllShardsInfo{
+		ID:    b.id,
+		Proof: []*cell.Cell{proof},
+		Data:  cell.BeginCell().MustStoreDict(b.shardHashes).EndCell(),
+	}, nil
+}
+
+// accountState - returns account state with proofs, when pruned is true, all refs of the account root cell with children are pruned
+func (s *Server) accountState(q ton.GetAccountState, pruned bool) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	rec, shardBlock, err := c.accountBlock(q.ID, q.Account.Workchain)
+	if err != nil {
+		return nil, err
+	}
+
+	state, shardProof, proof, err := accountProofs(rec, shardBlock, q.Account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pruned && state != nil {
+		prf, err := state.CreateProof(cell.CreateProofSkeleton())
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune account state: %w", err)
+		}
+
+		if state, err = prf.PeekRef(0); err != nil {
+			return nil, err
+		}
+	}
+
+	return ton.AccountState{
+		ID:         rec.master.id,
+		Shard:      shardBlock.id,
+		ShardProof: shardProof,
+		Proof:      proof,
+		State:      state,
+	}, nil
+}
+
+func (s *Server) runMethod(q ton.RunSmcMethod) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	rec, shardBlock, err := c.accountBlock(q.ID, q.Account.Workchain)
+	if err != nil {
+		c.mx.RUnlock()
+		return nil, err
+	}
+
+	state, shardProof, proof, err := accountProofs(rec, shardBlock, q.Account.ID)
+	if err != nil {
+		c.mx.RUnlock()
+		return nil, err
+	}
+	method := c.getMethods[getMethodKey(address.NewAddress(0, byte(q.Account.Workchain), q.Account.ID), q.MethodID)]
+	c.mx.RUnlock()
+
+	if state == nil {
+		return nil, ton.LSError{Code: -400, Text: "account not found"}
+	}
+
+	var st tlb.AccountState
+	if err = st.LoadFromCell(state.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse account state: %w", err)
+	}
+	if st.Status != tlb.AccountStatusActive {
+		return nil, ton.LSError{Code: -400, Text: "account is not active"}
+	}
+
+	acc := &Account{
+		Address: st.Address,
+		Status:  st.Status,
+		Balance: st.Balance,
+		Code:    st.StateInit.Code,
+		Data:    st.StateInit.Data,
+	}
+
+	var stack tlb.Stack
+	if err = stack.LoadFromCell(q.Params.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse params stack: %w", err)
+	}
+
+	var params []any
+	for stack.Depth() > 0 {
+		v, err := stack.Pop()
+		if err != nil {
+			return nil, fmt.Errorf("failed to pop param: %w", err)
+		}
+		params = append(params, v)
+	}
+
+	// method not found
+	exitCode := int32(11)
+	var result []any
+	if method != nil {
+		result, exitCode = method(acc, params)
+	}
+
+	// first result value should be on top
+	res := tlb.NewStack()
+	for i := len(result) - 1; i >= 0; i-- {
+		res.Push(result[i])
+	}
+
+	resCell, err := res.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize result stack: %w", err)
+	}
+
+	sk := cell.CreateProofSkeleton()
+	sk.SetRecursive()
+	stateProof, err := state.CreateProof(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	return ton.RunMethodResult{
+		Mode:       q.Mode,
+		ID:         rec.master.id,
+		ShardBlock: shardBlock.id,
+		ShardProof: shardProof,
+		Proof:      proof,
+		StateProof: stateProof,
+		ExitCode:   exitCode,
+		Result:     resCell,
+	}, nil
+}
+
+func (s *Server) transactions(q ton.GetTransactions) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	addr := address.NewAddress(0, byte(q.AccID.Workchain), q.AccID.ID)
+	list := c.txs[addr.String()]
+
+	// last transactions are at the end
+	from := -1
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].block != nil && list[i].tx.LT == uint64(q.LT) {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return nil, ton.LSError{Code: -400, Text: "cannot locate transaction in block with specified logical time"}
+	}
+	if string(list[from].tx.Hash) != string(q.TxHash) {
+		return nil, ton.LSError{Code: 0, Text: "transaction hash mismatch"}
+	}
+
+	limit := int(q.Limit)
+	if limit > 16 || limit <= 0 {
+		limit = 16
+	}
+
+	var ids []*ton.BlockIDExt
+	var cells []*cell.Cell
+	for i := from; i >= 0 && len(cells) < limit; i-- {
+		ids = append(ids, list[i].block)
+		cells = append(cells, list[i].cell)
+	}
+
+	return ton.TransactionList{
+		IDs:          ids,
+		Transactions: cell.ToBOCWithFlags(cells, false),
+	}, nil
+}
+
+func (s *Server) oneTransaction(q ton.GetOneTransaction) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	for _, tx := range b.txs {
+		if string(tx.tx.AccountAddr) != string(q.AccID.ID) || tx.tx.LT != uint64(q.LT) {
+			continue
+		}
+
+		proof, err := b.block.CreateProof(accountBlocksSkeleton())
+		if err != nil {
+			return nil, err
+		}
+
+		return ton.TransactionInfo{
+			ID:          b.id,
+			Proof:       proof.ToBOCWithFlags(false),
+			Transaction: tx.cell.ToBOCWithFlags(false),
+		}, nil
+	}
+
+	return ton.TransactionInfo{ID: b.id}, nil
+}
+
+func (s *Server) blockTransactions(q ton.ListBlockTransactions) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	list, incomplete := selectTxs(b, q.Count, q.After, q.ReverseOrder != nil)
+
+	ids := make([]ton.TransactionID, 0, len(list))
+	for _, tx := range list {
+		id := ton.TransactionID{Flags: q.Mode & (0b111 | 1<<8)}
+		if q.Mode&1 != 0 {
+			id.Account = tx.tx.AccountAddr
+		}
+		if q.Mode&2 != 0 {
+			id.LT = tx.tx.LT
+		}
+		if q.Mode&4 != 0 {
+			id.Hash = tx.tx.Hash
+		}
+		if q.Mode&(1<<8) != 0 {
+			// every transaction of the fake chain is initiated by its own inbound message
+			id.Metadata = &ton.TransactionMetadata{
+				Initiator:   ton.AccountID{Workchain: b.id.Workchain, ID: tx.tx.AccountAddr},
+				InitiatorLT: tx.tx.LT,
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	res := ton.BlockTransactions{
+		ID:             b.id,
+		ReqCount:       int32(q.Count),
+		Incomplete:     incomplete,
+		TransactionIds: ids,
+	}
+
+	if q.WantProof != nil {
+		proof, err := b.block.CreateProof(accountBlocksSkeleton())
+		if err != nil {
+			return nil, err
+		}
+		res.Proof = proof
+	}
+	return res, nil
+}
+
+func (s *Server) blockTransactionsExt(q ton.ListBlockTransactionsExt) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	list, incomplete := selectTxs(b, q.Count, q.After, q.ReverseOrder != nil)
+
+	cells := make([]*cell.Cell, 0, len(list))
+	for _, tx := range list {
+		cells = append(cells, tx.cell)
+	}
+
+	res := ton.BlockTransactionsExt{
+		ID:           b.id,
+		ReqCount:     int32(q.Count),
+		Incomplete:   incomplete,
+		Transactions: cells,
+	}
+
+	if q.WantProof != nil {
+		proof, err := b.block.CreateProof(accountBlocksSkeleton())
+		if err != nil {
+			return nil, err
+		}
+		res.Proof = proof
+	}
+	return res, nil
+}
+
+// selectTxs - transactions of block after passed one, limited by count
+func selectTxs(b *blockData, count uint32, after *ton.TransactionID3, reverse bool) ([]*txRecord, bool) {
+	list := sortedTxs(b.txs)
+	if reverse {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	if after != nil {
+		for i, tx := range list {
+			if string(tx.tx.AccountAddr) == string(after.Account) && tx.tx.LT == after.LT {
+				list = list[i+1:]
+				break
+			}
+		}
+	}
+
+	if uint32(len(list)) > count {
+		return list[:count], true
+	}
+	return list, false
+}
+
+func (s *Server) config(id *ton.BlockIDExt, mode int32) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(id)
+	if b == nil || b.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	blockProof, stateProof, err := stateProofs(b, mcStateSkeleton())
+	if err != nil {
+		return nil, err
+	}
+
+	return ton.ConfigAll{
+		Mode:        int(mode),
+		ID:          b.id,
+		StateProof:  blockProof,
+		ConfigProof: stateProof,
+	}, nil
+}
+
+func (s *Server) librariesWithProof(q ton.GetLibrariesWithProof) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil || b.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	// state stats with libraries dict
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(2).SetRecursive()
+
+	blockProof, stateProof, err := stateProofs(b, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ton.LibraryResultWithProof{
+		ID:         b.id,
+		Mode:       q.Mode,
+		Result:     []*ton.LibraryEntry{},
+		StateProof: blockProof,
+		DataProof:  stateProof,
+	}
+	for _, hash := range q.LibraryList {
+		if lib := c.libraries[string(hash)]; lib != nil {
+			res.Result = append(res.Result, &ton.LibraryEntry{Hash: hash, Data: lib})
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) outMsgQueueSizes(q ton.GetOutMsgQueueSizes) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	last := c.blocks[len(c.blocks)-1]
+
+	res := ton.OutMsgQueueSizes{
+		Shards:               []ton.OutMsgQueueSize{},
+		ExtMsgQueueSizeLimit: 8000,
+	}
+	for _, b := range []*blockData{last.master, last.shard} {
+		if q.Mode&1 != 0 && q.Workchain != b.id.Workchain {
+			continue
+		}
+		// queues are always empty in the fake chain
+		res.Shards = append(res.Shards, ton.OutMsgQueueSize{ID: b.id})
+	}
+	return res, nil
+}
+
+func (s *Server) blockOutMsgQueueSize(q ton.GetBlockOutMsgQueueSize) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	res := ton.BlockOutMsgQueueSize{ID: b.id}
+	if q.WantProof != nil {
+		proof, err := queueProof(b)
+		if err != nil {
+			return nil, err
+		}
+		res.Mode, res.Proof = 1, proof
+	}
+	return res, nil
+}
+
+func (s *Server) dispatchQueueInfo(q ton.GetDispatchQueueInfo) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	res := ton.DispatchQueueInfo{
+		ID:                    b.id,
+		AccountDispatchQueues: []ton.AccountDispatchQueueInfo{},
+		Complete:              true,
+	}
+	if q.WantProof != nil {
+		proof, err := queueProof(b)
+		if err != nil {
+			return nil, err
+		}
+		res.Mode, res.Proof = 1, proof
+	}
+	return res, nil
+}
+
+func (s *Server) dispatchQueueMessages(q ton.GetDispatchQueueMessages) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil {
+		return nil, errBlockNotFound
+	}
+
+	res := ton.DispatchQueueMessages{
+		ID:       b.id,
+		Messages: []ton.DispatchQueueMessage{},
+		Complete: true,
+	}
+	if q.WantProof != nil {
+		proof, err := queueProof(b)
+		if err != nil {
+			return nil, err
+		}
+		res.Mode, res.Proof = 1, proof
+	}
+	return res, nil
+}
+
+func (s *Server) validatorStats(q ton.GetValidatorStats) (tl.Serializable, error) {
+	c := s.chain
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	b := c.findBlock(q.ID)
+	if b == nil || b.id.Workchain != address.MasterchainID {
+		return nil, errBlockNotFound
+	}
+
+	blockProof, stateProof, err := stateProofs(b, mcStateSkeleton())
+	if err != nil {
+		return nil, err
+	}
+
+	// proof contains full state extra, so it can be parsed by client code
+	stats, err := ton.CheckValidatorStatsProof(b.id, []*cell.Cell{blockProof, stateProof})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ton.ValidatorStats{
+		ID:         b.id,
+		Complete:   true,
+		StateProof: blockProof,
+		DataProof:  stateProof,
+	}
+	for _, st := range stats {
+		if q.Mode&1 != 0 && string(st.PublicKey) <= string(q.StartAfter) {
+			continue
+		}
+		if q.Mode&4 != 0 && st.MCBlocks.LastUpdated <= q.ModifiedAfter && st.ShardBlocks.LastUpdated <= q.ModifiedAfter {
+			continue
+		}
+		if res.Count == q.Limit {
+			res.Complete = false
+			break
+		}
+		res.Count++
+	}
+	return res, nil
+}
+
+func (s *Server) sendMessage(q ton.SendMessage) (tl.Serializable, error) {
+	root, err := cell.FromBOC(q.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message boc: %w", err)
+	}
+
+	var msg tlb.ExternalMessage
+	if err = tlb.LoadFromCell(&msg, root.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse external message: %w", err)
+	}
+
+	c := s.chain
+	c.mx.Lock()
+	c.sent = append(c.sent, &msg)
+	handler := c.onMessage
+	c.mx.Unlock()
+
+	if handler != nil {
+		if err = handler(c, &msg); err != nil {
+			return nil, ton.LSError{Code: 0, Text: "cannot apply external message: " + err.Error()}
+		}
+	}
+	return ton.SendMessageStatus{Status: 1}, nil
+}
+
+// lookup - finds the first block of workchain matching lookup mode: seqno, lt or utime
+func (c *Chain) lookup(workchain int32, mode uint32, seqno uint32, lt uint64, utime uint32) *blockData {
+	for _, rec := range c.blocks {
+		b := rec.shard
+		if workchain == address.MasterchainID {
+			b = rec.master
+		} else if workchain != b.id.Workchain {
+			return nil
+		}
+
+		var ok bool
+		switch {
+		case mode&1 != 0:
+			ok = b.id.SeqNo == seqno
+		case mode&2 != 0:
+			ok = lt <= b.endLT
+		case mode&4 != 0:
+			ok = utime <= b.utime
+		}
+
+		if ok {
+			return b
+		}
+	}
+	return nil
+}
+
+func (c *Chain) blockBySeqno(workchain int32, seqno uint32) *blockData {
+	rec := c.masterBySeqno(seqno)
+	if rec == nil {
+		return nil
+	}
+	if workchain == address.MasterchainID {
+		return rec.master
+	}
+	return rec.shard
+}
+
+// accountBlock - finds masterchain record and block where account of workchain is stored
+func (c *Chain) accountBlock(id *ton.BlockIDExt, workchain int32) (*masterRecord, *blockData, error) {
+	if id == nil || id.Workchain != address.MasterchainID {
+		return nil, nil, errBlockNotFound
+	}
+
+	rec := c.masterBySeqno(id.SeqNo)
+	if rec == nil || !rec.master.id.Equals(id) {
+		return nil, nil, errBlockNotFound
+	}
+
+	if workchain == address.MasterchainID {
+		return rec, rec.master, nil
+	}
+	if workchain != rec.shard.id.Workchain {
+		return nil, nil, ton.LSError{Code: -400, Text: "workchain not found"}
+	}
+	return rec, rec.shard, nil
+}
+
+// accountProofs - returns account state cell and proofs of it in the shard block, and shard block in master
+func accountProofs(rec *masterRecord, b *blockData, accountID []byte) (state *cell.Cell, shardProof, proof []*cell.Cell, err error) {
+	if b != rec.master {
+		blockProof, stateProof, err := stateProofs(rec.master, mcStateSkeleton())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		shardProof = []*cell.Cell{blockProof, stateProof}
+	}
+
+	sk := cell.CreateProofSkeleton()
+	// accounts -> dict root
+	dictSk := sk.ProofRef(1).ProofRef(0)
+
+	key := cell.BeginCell().MustStoreSlice(accountID, 256).EndCell()
+	val, _, err := b.accounts.LoadValueWithProof(key, dictSk)
+	if err != nil && err != cell.ErrNoSuchKeyInDict {
+		return nil, nil, nil, fmt.Errorf("failed to load account: %w", err)
+	}
+
+	if val != nil {
+		if err = tlb.LoadFromCell(new(tlb.DepthBalanceInfo), val); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load balance info: %w", err)
+		}
+
+		if state, err = val.LoadRefCell(); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load account ref: %w", err)
+		}
+	}
+
+	blockProof, stateProof, err := stateProofs(b, sk)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return state, shardProof, []*cell.Cell{blockProof, stateProof}, nil
+}
+
+// stateProofs - proof of block state update, and proof of its new state with given skeleton
+func stateProofs(b *blockData, stateSk *cell.ProofSkeleton) (*cell.Cell, *cell.Cell, error) {
+	sk := blockSkeleton()
+	sk.ProofRef(2)
+
+	blockProof, err := b.block.CreateProof(sk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create block proof: %w", err)
+	}
+
+	stateProof, err := b.state.CreateProof(stateSk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create state proof: %w", err)
+	}
+	return blockProof, stateProof, nil
+}
+
+func backwardLink(from, to *blockData) (ton.BlockLinkBackward, error) {
+	dest, err := to.block.CreateProof(blockSkeleton())
+	if err != nil {
+		return ton.BlockLinkBackward{}, err
+	}
+
+	blockProof, stateProof, err := stateProofs(from, mcStateSkeleton())
+	if err != nil {
+		return ton.BlockLinkBackward{}, err
+	}
+
+	return ton.BlockLinkBackward{
+		ToKeyBlock: to.keyBlock,
+		From:       from.id,
+		To:         to.id,
+		DestProof:  dest.ToBOCWithFlags(false),
+		Proof:      blockProof.ToBOCWithFlags(false),
+		StateProof: stateProof.ToBOCWithFlags(false),
+	}, nil
+}
+
+func forwardLink(from, to *blockData) (ton.BlockLinkForward, error) {
+	dest, err := to.block.CreateProof(blockSkeleton())
+	if err != nil {
+		return ton.BlockLinkForward{}, err
+	}
+
+	config, err := from.block.CreateProof(mcExtraSkeleton())
+	if err != nil {
+		return ton.BlockLinkForward{}, err
+	}
+
+	sigs, err := signatures(to)
+	if err != nil {
+		return ton.BlockLinkForward{}, err
+	}
+
+	return ton.BlockLinkForward{
+		ToKeyBlock:   to.keyBlock,
+		From:         from.id,
+		To:           to.id,
+		DestProof:    dest.ToBOCWithFlags(false),
+		ConfigProof:  config.ToBOCWithFlags(false),
+		SignatureSet: sigs,
+	}, nil
+}
+
+// signatures - signs block by validators which were active at its creation
+func signatures(b *blockData) (*ton.SignatureSet, error) {
+	hash, err := validatorSetHash(b.ccSeqno, b.signers)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := tl.Serialize(ton.BlockID{RootHash: b.id.RootHash, FileHash: b.id.FileHash}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &ton.SignatureSet{
+		ValidatorSetHash: int32(hash),
+		CatchainSeqno:    int32(b.ccSeqno),
+	}
+	for _, key := range b.signers {
+		id, err := tl.Hash(adnl.PublicKeyED25519{Key: key.Public().(ed25519.PublicKey)})
+		if err != nil {
+			return nil, err
+		}
+		set.Signatures = append(set.Signatures, ton.Signature{
+			NodeIDShort: id,
+			Signature:   ed25519.Sign(key, data),
+		})
+	}
+	return set, nil
+}
+
+func blockHeader(b *blockData) (tl.Serializable, error) {
+	proof, err := b.block.CreateProof(blockSkeleton())
+	if err != nil {
+		return nil, err
+	}
+	return ton.BlockHeader{ID: b.id, HeaderProof: proof.ToBOCWithFlags(false)}, nil
+}
+
+// blockSkeleton - proof skeleton with block info included
+func blockSkeleton() *cell.ProofSkeleton {
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(0).SetRecursive()
+	return sk
+}
+
+// accountBlocksSkeleton - block proof with all account blocks and transactions included
+func accountBlocksSkeleton() *cell.ProofSkeleton {
+	sk := blockSkeleton()
+	sk.ProofRef(3).ProofRef(2).SetRecursive()
+	return sk
+}
+
+// mcExtraSkeleton - masterchain block proof with shard hashes and config (for key blocks) included
+func mcExtraSkeleton() *cell.ProofSkeleton {
+	sk := blockSkeleton()
+	sk.ProofRef(3).ProofRef(3).SetRecursive()
+	return sk
+}
+
+// queueProof - block and state proofs with out msg queue info, serialized as one boc
+func queueProof(b *blockData) ([]byte, error) {
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(0).SetRecursive()
+
+	blockProof, stateProof, err := stateProofs(b, sk)
+	if err != nil {
+		return nil, err
+	}
+	return cell.ToBOCWithFlags([]*cell.Cell{blockProof, stateProof}, false), nil
+}
+
+// mcStateSkeleton - state proof with masterchain state extra included
+func mcStateSkeleton() *cell.ProofSkeleton {
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(3).SetRecursive()
+	return sk
+}
@@ -0,0 +1,240 @@
+package liteservertest
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func startServer(t *testing.T, chain *Chain, policy ...ton.ProofCheckPolicy) *ton.APIClient {
+	srv := NewServer(chain)
+	if err := srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	t.Cleanup(pool.Stop)
+
+	if len(policy) == 0 {
+		policy = append(policy, ton.ProofCheckPolicyFast)
+	}
+	return ton.NewAPIClient(pool, policy[0])
+}
+
+func TestServer_Accounts(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	master := address.MustParseRawAddr("-1:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	code := cell.BeginCell().MustStoreUInt(0xAA, 8).EndCell()
+	data := cell.BeginCell().MustStoreUInt(7, 32).EndCell()
+
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1.5"),
+		Code:    code,
+		Data:    data,
+	})
+	chain.SetAccount(Account{
+		Address: master,
+		Balance: tlb.MustFromTON("3"),
+	})
+	chain.SetGetMethod(addr, "seqno_plus", func(acc *Account, params []any) ([]any, int32) {
+		seqno := acc.Data.BeginParse().MustLoadUInt(32)
+		return []any{new(big.Int).Add(big.NewInt(int64(seqno)), params[0].(*big.Int)), big.NewInt(1)}, 0
+	})
+	chain.SetConfigParam(8, cell.BeginCell().MustStoreUInt(0xC4, 8).MustStoreUInt(4, 32).EndCell())
+
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	api := startServer(t, chain)
+	ctx := context.Background()
+
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("master info err:", err)
+	}
+	if block.SeqNo != 2 {
+		t.Fatal("incorrect seqno", block.SeqNo)
+	}
+
+	acc, err := api.GetAccount(ctx, block, addr)
+	if err != nil {
+		t.Fatal("get account err:", err)
+	}
+	if !acc.IsActive || acc.State.Balance.String() != "1.5" || !bytes.Equal(acc.Code.Hash(), code.Hash()) {
+		t.Fatal("incorrect account state")
+	}
+
+	acc, err = api.GetAccount(ctx, block, master)
+	if err != nil {
+		t.Fatal("get master account err:", err)
+	}
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusUninit {
+		t.Fatal("incorrect master account state")
+	}
+
+	acc, err = api.GetAccount(ctx, block, address.MustParseRawAddr("0:"+"1111111111111111111111111111111111111111111111111111111111111111"))
+	if err != nil {
+		t.Fatal("get missing account err:", err)
+	}
+	if acc.IsActive {
+		t.Fatal("account should not exist")
+	}
+
+	res, err := api.RunGetMethod(ctx, block, addr, "seqno_plus", 5)
+	if err != nil {
+		t.Fatal("run get method err:", err)
+	}
+	if res.MustInt(0).Uint64() != 12 || res.MustInt(1).Uint64() != 1 {
+		t.Fatal("incorrect get method result", res.AsTuple())
+	}
+
+	_, err = api.RunGetMethod(ctx, block, addr, "unknown")
+	if err == nil {
+		t.Fatal("unknown method should fail")
+	}
+
+	cfg, err := api.GetBlockchainConfig(ctx, block, 8)
+	if err != nil {
+		t.Fatal("get config err:", err)
+	}
+	if cfg.Get(8) == nil || cfg.Get(8).BeginParse().MustLoadUInt(8) != 0xC4 {
+		t.Fatal("incorrect config param")
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, block)
+	if err != nil {
+		t.Fatal("get shards err:", err)
+	}
+	if len(shards) != 1 || shards[0].Workchain != 0 || shards[0].SeqNo != 2 {
+		t.Fatal("incorrect shards")
+	}
+
+	data2, err := api.GetBlockData(ctx, shards[0])
+	if err != nil {
+		t.Fatal("get block data err:", err)
+	}
+	if data2.BlockInfo.SeqNo != 2 || !data2.BlockInfo.NotMaster {
+		t.Fatal("incorrect block data")
+	}
+
+	found, err := api.LookupBlock(ctx, address.MasterchainID, shardFull, 1)
+	if err != nil {
+		t.Fatal("lookup err:", err)
+	}
+	if found.SeqNo != 1 {
+		t.Fatal("incorrect lookup result")
+	}
+
+	if _, err = api.LookupBlock(ctx, address.MasterchainID, shardFull, 100); err != ton.ErrBlockNotFound {
+		t.Fatal("block should not be found", err)
+	}
+}
+
+func TestServer_Transactions(t *testing.T) {
+	chain := NewChain()
+
+	addr := address.MustParseRawAddr("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+
+	api := startServer(t, chain)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		body := cell.BeginCell().MustStoreUInt(uint64(i), 32).EndCell()
+		err := api.SendExternalMessage(ctx, &tlb.ExternalMessage{
+			DstAddr: addr,
+			Body:    body,
+		})
+		if err != nil {
+			t.Fatal("send err:", err)
+		}
+	}
+
+	if len(chain.SentMessages()) != 3 {
+		t.Fatal("incorrect sent messages num")
+	}
+
+	block, err := api.WaitForBlock(chain.LastBlock().SeqNo).CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("master info err:", err)
+	}
+
+	acc, err := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+	if err != nil {
+		t.Fatal("get account err:", err)
+	}
+
+	list, err := api.ListTransactions(ctx, addr, 10, acc.LastTxLT, acc.LastTxHash)
+	if err != nil {
+		t.Fatal("list tx err:", err)
+	}
+	if len(list) != 3 {
+		t.Fatal("incorrect transactions num", len(list))
+	}
+	for i, tx := range list {
+		if tx.IO.In.AsExternalIn().Body.BeginParse().MustLoadUInt(32) != uint64(i) {
+			t.Fatal("incorrect transactions order")
+		}
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, block)
+	if err != nil {
+		t.Fatal("get shards err:", err)
+	}
+
+	txs, _, err := api.GetBlockTransactionsV2(ctx, shards[0], 100)
+	if err != nil {
+		t.Fatal("get block txs err:", err)
+	}
+	if len(txs) != 1 || !bytes.Equal(txs[0].Hash, acc.LastTxHash) {
+		t.Fatal("incorrect block transactions")
+	}
+
+	tx, err := api.GetTransaction(ctx, shards[0], addr, acc.LastTxLT)
+	if err != nil {
+		t.Fatal("get tx err:", err)
+	}
+	if !bytes.Equal(tx.Hash, acc.LastTxHash) {
+		t.Fatal("incorrect transaction")
+	}
+
+	// wait for the next block
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = chain.Commit()
+	}()
+
+	next, err := api.WaitForBlock(block.SeqNo + 1).GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("wait block err:", err)
+	}
+	if next.SeqNo != block.SeqNo+1 {
+		t.Fatal("incorrect next block")
+	}
+}
@@ -0,0 +1,267 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(LookupBlockWithProof{}, "liteServer.lookupBlockWithProof mode:# id:tonNode.blockId mc_block_id:tonNode.blockIdExt lt:mode.1?long utime:mode.2?int = liteServer.LookupBlockResult")
+	tl.Register(LookupBlockResult{}, "liteServer.lookupBlockResult id:tonNode.blockIdExt mode:# mc_block_id:tonNode.blockIdExt client_mc_state_proof:bytes mc_block_proof:bytes shard_links:(vector liteServer.shardBlockLink) header:bytes prev_header:bytes = liteServer.LookupBlockResult")
+}
+
+type LookupBlockWithProof struct {
+	Mode      uint32          `tl:"flags"`
+	ID        *BlockInfoShort `tl:"struct"`
+	MCBlockID *BlockIDExt     `tl:"struct"`
+	LT        uint64          `tl:"?1 long"`
+	UTime     uint32          `tl:"?2 int"`
+}
+
+// LookupBlockResult - found block with proof of its relation to the client's masterchain block.
+//
+// MCBlockID is a masterchain block which references found block (for masterchain lookup it is the found block itself),
+// ClientMCStateProof proves that MCBlockID is listed in the state of the client's masterchain block
+// (empty when they are equal), MCBlockProof is a proof of MCBlockID with shard hashes,
+// ShardLinks are headers from the shard block referenced by MCBlockID down to the found block.
+// Header is a proof of found block header, PrevHeader is a proof of its previous block header
+// and is used to check that found block is the first one matching lt or utime.
+type LookupBlockResult struct {
+	ID                 *BlockIDExt      `tl:"struct"`
+	Mode               uint32           `tl:"flags"`
+	MCBlockID          *BlockIDExt      `tl:"struct"`
+	ClientMCStateProof []byte           `tl:"bytes"`
+	MCBlockProof       []byte           `tl:"bytes"`
+	ShardLinks         []ShardBlockLink `tl:"vector struct"`
+	Header             []byte           `tl:"bytes"`
+	PrevHeader         []byte           `tl:"bytes"`
+}
+
+// LookupBlockWithProof - same as LookupBlock, but found block is proven to be related to the known masterchain block
+func (c *APIClient) LookupBlockWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error) {
+	return c.lookupBlockWithProof(ctx, master, LookupBlockWithProof{
+		Mode: 1,
+		ID: &BlockInfoShort{
+			Workchain: workchain,
+			Shard:     shard,
+			Seqno:     int32(seqno),
+		},
+	})
+}
+
+// LookupBlockByLTWithProof - finds the first block of the shard with end lt >= lt,
+// found block is proven to be related to the known masterchain block
+func (c *APIClient) LookupBlockByLTWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, lt uint64) (*BlockIDExt, error) {
+	return c.lookupBlockWithProof(ctx, master, LookupBlockWithProof{
+		Mode: 2,
+		ID: &BlockInfoShort{
+			Workchain: workchain,
+			Shard:     shard,
+		},
+		LT: lt,
+	})
+}
+
+// LookupBlockByUTimeWithProof - finds the first block of the shard generated at or after utime,
+// found block is proven to be related to the known masterchain block
+func (c *APIClient) LookupBlockByUTimeWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, utime uint32) (*BlockIDExt, error) {
+	return c.lookupBlockWithProof(ctx, master, LookupBlockWithProof{
+		Mode: 4,
+		ID: &BlockInfoShort{
+			Workchain: workchain,
+			Shard:     shard,
+		},
+		UTime: utime,
+	})
+}
+
+func (c *APIClient) lookupBlockWithProof(ctx context.Context, master *BlockIDExt, req LookupBlockWithProof) (*BlockIDExt, error) {
+	req.MCBlockID = master
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case LookupBlockResult:
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			if err = CheckLookupBlockProof(master, &req, &t); err != nil {
+				return nil, fmt.Errorf("incorrect lookup block proof: %w", err)
+			}
+		}
+		return t.ID, nil
+	case LSError:
+		// 651 = block not found code
+		if t.Code == 651 {
+			return nil, ErrBlockNotFound
+		}
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// CheckLookupBlockProof - checks that found block matches request and related to the known masterchain block
+func CheckLookupBlockProof(master *BlockIDExt, req *LookupBlockWithProof, res *LookupBlockResult) error {
+	if res.ID == nil || res.MCBlockID == nil {
+		return fmt.Errorf("block ids are not passed")
+	}
+
+	if res.ID.Workchain != req.ID.Workchain || !shardIntersects(res.ID.Shard, req.ID.Shard) {
+		return fmt.Errorf("found block is from another shard")
+	}
+
+	if req.Mode&1 != 0 && res.ID.SeqNo != uint32(req.ID.Seqno) {
+		return fmt.Errorf("found block seqno not matches requested")
+	}
+
+	header, err := checkBlockHeaderProof(res.Header, res.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check header proof: %w", err)
+	}
+
+	if req.Mode&6 != 0 {
+		switch {
+		case req.Mode&2 != 0 && header.EndLt < req.LT:
+			return fmt.Errorf("found block end lt is less than requested")
+		case req.Mode&4 != 0 && header.GenUtime < req.UTime:
+			return fmt.Errorf("found block utime is less than requested")
+		}
+
+		// make sure that previous block is not matching, to not skip blocks
+		if header.SeqNo > 1 {
+			parents, err := header.GetParentBlocks()
+			if err != nil {
+				return fmt.Errorf("failed to get parent blocks: %w", err)
+			}
+
+			prevProof, err := cell.FromBOC(res.PrevHeader)
+			if err != nil {
+				return fmt.Errorf("failed to parse prev header proof: %w", err)
+			}
+
+			var prev *tlb.Block
+			for _, parent := range parents {
+				if prev, err = CheckBlockProof(prevProof, parent.RootHash); err == nil {
+					break
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to check prev header proof: %w", err)
+			}
+
+			switch {
+			case req.Mode&2 != 0 && prev.BlockInfo.EndLt >= req.LT:
+				return fmt.Errorf("previous block also matches requested lt")
+			case req.Mode&4 != 0 && prev.BlockInfo.GenUtime >= req.UTime:
+				return fmt.Errorf("previous block also matches requested utime")
+			}
+		}
+	}
+
+	mc := res.MCBlockID
+	if !mc.Equals(master) {
+		roots, err := cell.FromBOCMultiRoot(res.ClientMCStateProof)
+		if err != nil {
+			return fmt.Errorf("failed to parse client masterchain state proof: %w", err)
+		}
+
+		if err = CheckMasterBlockInState(master, roots, mc); err != nil {
+			return fmt.Errorf("masterchain block is not related to known block: %w", err)
+		}
+	}
+
+	if res.ID.Workchain == address.MasterchainID {
+		if !res.ID.Equals(mc) {
+			return fmt.Errorf("masterchain block should be proved by itself")
+		}
+		return nil
+	}
+
+	mcProof, err := cell.FromBOC(res.MCBlockProof)
+	if err != nil {
+		return fmt.Errorf("failed to parse masterchain block proof: %w", err)
+	}
+
+	mcBlock, err := CheckBlockProof(mcProof, mc.RootHash)
+	if err != nil {
+		return fmt.Errorf("failed to check masterchain block proof: %w", err)
+	}
+
+	if mcBlock.Extra == nil || mcBlock.Extra.Custom == nil {
+		return fmt.Errorf("no shard hashes in masterchain block proof")
+	}
+
+	shards, err := LoadShardsFromHashes(mcBlock.Extra.Custom.ShardHashes, true)
+	if err != nil {
+		return fmt.Errorf("failed to load shard hashes: %w", err)
+	}
+
+	var expected *BlockIDExt
+	for _, shard := range shards {
+		if shard.Workchain == res.ID.Workchain && shardIntersects(shard.Shard, res.ID.Shard) {
+			expected = shard
+			break
+		}
+	}
+	if expected == nil {
+		return fmt.Errorf("shard is not found in masterchain block")
+	}
+
+	for i, link := range res.ShardLinks {
+		if link.ID == nil || link.ID.Workchain != expected.Workchain ||
+			link.ID.SeqNo != expected.SeqNo || !bytes.Equal(link.ID.RootHash, expected.RootHash) {
+			return fmt.Errorf("link %d is not expected block", i)
+		}
+
+		hdr, err := checkBlockHeaderProof(link.Proof, link.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check link %d proof: %w", i, err)
+		}
+
+		parents, err := hdr.GetParentBlocks()
+		if err != nil {
+			return fmt.Errorf("failed to get parents of link %d: %w", i, err)
+		}
+
+		expected = nil
+		for _, parent := range parents {
+			if shardIntersects(parent.Shard, res.ID.Shard) {
+				expected = parent
+				break
+			}
+		}
+		if expected == nil {
+			return fmt.Errorf("no parent of link %d in requested shard", i)
+		}
+	}
+
+	if expected.SeqNo != res.ID.SeqNo || !bytes.Equal(expected.RootHash, res.ID.RootHash) {
+		return fmt.Errorf("found block is not linked to masterchain block")
+	}
+	return nil
+}
+
+func checkBlockHeaderProof(data []byte, id *BlockIDExt) (*tlb.BlockHeader, error) {
+	proof, err := cell.FromBOC(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proof: %w", err)
+	}
+
+	block, err := CheckBlockProof(proof, id.RootHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.BlockInfo.SeqNo != id.SeqNo || block.BlockInfo.Shard.WorkchainID != id.Workchain {
+		return nil, fmt.Errorf("header not matches block id")
+	}
+	return &block.BlockInfo, nil
+}
@@ -0,0 +1,348 @@
+package multisig
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Opcodes of multisig and order messages
+const (
+	OpNewOrder        = 0xf718510f
+	OpExecute         = 0x75097f5d
+	OpExecuteInternal = 0xa32c59bf
+	OpOrderInit       = 0x9c73fba2
+	OpApprove         = 0xa762230f
+	OpApproveAccepted = 0x82609bf6
+	OpApproveRejected = 0xafaf283e
+)
+
+const (
+	opActionSendMessage  = 0xf1381e5b
+	opActionUpdateParams = 0x1d0cfbd3
+)
+
+// MaxActions - max number of actions in one order
+const MaxActions = 255
+
+// MaxSigners - max number of signers or proposers, index of each one is 8 bits
+const MaxSigners = 255
+
+// NextOrderSeqno - when passed as order seqno, multisig assigns its next order seqno to the new order.
+// Works only when arbitrary order seqno is not allowed.
+var NextOrderSeqno = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// SendMessageAction - order action which sends message from the multisig
+type SendMessageAction struct {
+	Mode    uint8
+	Message *tlb.InternalMessage
+}
+
+// UpdateParamsAction - order action which replaces threshold, signers and proposers of the multisig
+type UpdateParamsAction struct {
+	Threshold uint8
+	Signers   []*address.Address
+	Proposers []*address.Address
+}
+
+// NewOrderPayload - request of signer or proposer to create order, it is sent to multisig,
+// and multisig deploys order contract. Value should cover order processing, see Client.EstimateOrderCost.
+type NewOrderPayload struct {
+	QueryID uint64
+	// OrderSeqno - unsigned 256-bit number of the order, see NextOrderSeqno
+	OrderSeqno *big.Int
+	// IsSigner - true when Index is in signers list, false when it is in proposers
+	IsSigner bool
+	Index    uint8
+	// ExpirationDate - unix time, 48 bits
+	ExpirationDate uint64
+	Order          *cell.Cell
+}
+
+func (p NewOrderPayload) ToCell() (*cell.Cell, error) {
+	if p.Order == nil {
+		return nil, errors.New("order is not set")
+	}
+	if p.OrderSeqno == nil || p.OrderSeqno.Sign() < 0 || p.OrderSeqno.BitLen() > 256 {
+		return nil, errors.New("order seqno should be unsigned 256-bit number")
+	}
+	if p.ExpirationDate >= 1<<48 {
+		return nil, errors.New("expiration date is too big")
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(OpNewOrder, 32).
+		MustStoreUInt(p.QueryID, 64).
+		MustStoreBigUInt(p.OrderSeqno, 256).
+		MustStoreBoolBit(p.IsSigner).
+		MustStoreUInt(uint64(p.Index), 8).
+		MustStoreUInt(p.ExpirationDate, 48).
+		MustStoreRef(p.Order).
+		EndCell(), nil
+}
+
+func (p *NewOrderPayload) LoadFromCell(loader *cell.Slice) error {
+	op, err := loader.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("failed to load op: %w", err)
+	}
+	if op != OpNewOrder {
+		return fmt.Errorf("incorrect op %x", op)
+	}
+
+	if p.QueryID, err = loader.LoadUInt(64); err != nil {
+		return fmt.Errorf("failed to load query id: %w", err)
+	}
+	if p.OrderSeqno, err = loader.LoadBigUInt(256); err != nil {
+		return fmt.Errorf("failed to load order seqno: %w", err)
+	}
+	if p.IsSigner, err = loader.LoadBoolBit(); err != nil {
+		return fmt.Errorf("failed to load signer flag: %w", err)
+	}
+
+	index, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+	p.Index = uint8(index)
+
+	if p.ExpirationDate, err = loader.LoadUInt(48); err != nil {
+		return fmt.Errorf("failed to load expiration date: %w", err)
+	}
+	if p.Order, err = loader.LoadRefCell(); err != nil {
+		return fmt.Errorf("failed to load order: %w", err)
+	}
+	return nil
+}
+
+// ApprovePayload - approval of the order by signer, it is sent to order contract
+type ApprovePayload struct {
+	_           tlb.Magic `tlb:"#a762230f"`
+	QueryID     uint64    `tlb:"## 64"`
+	SignerIndex uint8     `tlb:"## 8"`
+}
+
+// BuildOrder - packs actions to the order cell, actions should be SendMessageAction or UpdateParamsAction
+func BuildOrder(actions []any) (*cell.Cell, error) {
+	if len(actions) == 0 {
+		return nil, errors.New("order should contain at least one action")
+	}
+	if len(actions) > MaxActions {
+		return nil, fmt.Errorf("max %d actions can be in one order", MaxActions)
+	}
+
+	dict := cell.NewDict(8)
+	for i, action := range actions {
+		c, err := buildAction(action)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build action %d: %w", i, err)
+		}
+
+		if err = dict.Set(cell.BeginCell().MustStoreUInt(uint64(i), 8).EndCell(),
+			cell.BeginCell().MustStoreRef(c).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to store action %d: %w", i, err)
+		}
+	}
+	return dict.AsCell(), nil
+}
+
+func buildAction(action any) (*cell.Cell, error) {
+	switch a := action.(type) {
+	case *SendMessageAction:
+		return buildAction(*a)
+	case *UpdateParamsAction:
+		return buildAction(*a)
+	case SendMessageAction:
+		if a.Message == nil {
+			return nil, errors.New("message is not set")
+		}
+
+		msg, err := tlb.ToCell(a.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert message to cell: %w", err)
+		}
+
+		return cell.BeginCell().
+			MustStoreUInt(opActionSendMessage, 32).
+			MustStoreUInt(uint64(a.Mode), 8).
+			MustStoreRef(msg).
+			EndCell(), nil
+	case UpdateParamsAction:
+		if err := checkParams(a.Threshold, a.Signers, a.Proposers); err != nil {
+			return nil, err
+		}
+
+		signers, err := addrDict(a.Signers)
+		if err != nil {
+			return nil, err
+		}
+
+		proposers, err := addrDict(a.Proposers)
+		if err != nil {
+			return nil, err
+		}
+
+		return cell.BeginCell().
+			MustStoreUInt(opActionUpdateParams, 32).
+			MustStoreUInt(uint64(a.Threshold), 8).
+			MustStoreRef(signers.AsCell()).
+			MustStoreDict(proposers).
+			EndCell(), nil
+	}
+	return nil, fmt.Errorf("unsupported action type %T", action)
+}
+
+// ParseOrder - unpacks actions of the order, it can be used to review order before approval.
+// Actions are returned in execution order as SendMessageAction and UpdateParamsAction.
+func ParseOrder(order *cell.Cell) ([]any, error) {
+	kvs, err := order.AsDict(8).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order actions: %w", err)
+	}
+
+	actions := make([]any, 0, len(kvs))
+	for _, kv := range kvs {
+		ref, err := kv.Value.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load action ref: %w", err)
+		}
+
+		action, err := parseAction(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse action %d: %w", kv.Key.MustLoadUInt(8), err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func parseAction(s *cell.Slice) (any, error) {
+	op, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load op: %w", err)
+	}
+
+	switch op {
+	case opActionSendMessage:
+		mode, err := s.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mode: %w", err)
+		}
+
+		ref, err := s.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load message ref: %w", err)
+		}
+
+		var msg tlb.InternalMessage
+		if err = tlb.LoadFromCell(&msg, ref); err != nil {
+			return nil, fmt.Errorf("failed to parse message: %w", err)
+		}
+
+		return SendMessageAction{
+			Mode:    uint8(mode),
+			Message: &msg,
+		}, nil
+	case opActionUpdateParams:
+		threshold, err := s.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load threshold: %w", err)
+		}
+
+		signersRef, err := s.LoadRefCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signers ref: %w", err)
+		}
+
+		signers, err := parseAddrDict(signersRef.AsDict(8))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signers: %w", err)
+		}
+
+		proposersDict, err := s.LoadDict(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load proposers: %w", err)
+		}
+
+		proposers, err := parseAddrDict(proposersDict)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proposers: %w", err)
+		}
+
+		return UpdateParamsAction{
+			Threshold: uint8(threshold),
+			Signers:   signers,
+			Proposers: proposers,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown action op %x", op)
+}
+
+func checkParams(threshold uint8, signers, proposers []*address.Address) error {
+	if len(signers) == 0 {
+		return errors.New("at least one signer is required")
+	}
+	if len(signers) > MaxSigners || len(proposers) > MaxSigners {
+		return fmt.Errorf("max %d signers and proposers are allowed", MaxSigners)
+	}
+	if threshold == 0 || int(threshold) > len(signers) {
+		return fmt.Errorf("threshold should be between 1 and number of signers (%d)", len(signers))
+	}
+	return nil
+}
+
+// addrDict - packs addresses to dictionary with their indexes as keys
+func addrDict(list []*address.Address) (*cell.Dictionary, error) {
+	dict := cell.NewDict(8)
+	for i, addr := range list {
+		if addr == nil {
+			return nil, fmt.Errorf("address %d is not set", i)
+		}
+
+		if err := dict.Set(cell.BeginCell().MustStoreUInt(uint64(i), 8).EndCell(),
+			cell.BeginCell().MustStoreAddr(addr).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to store address %d: %w", i, err)
+		}
+	}
+	return dict, nil
+}
+
+// parseAddrDict - unpacks addresses from dictionary, index of address in the list is equal to its key
+func parseAddrDict(dict *cell.Dictionary) ([]*address.Address, error) {
+	if dict == nil {
+		return nil, nil
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*address.Address, len(kvs))
+	for _, kv := range kvs {
+		index := kv.Key.MustLoadUInt(8)
+		if index >= uint64(len(list)) {
+			return nil, fmt.Errorf("indexes are not sequential, found %d", index)
+		}
+
+		list[index], err = kv.Value.LoadAddr()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load address %d: %w", index, err)
+		}
+	}
+	return list, nil
+}
+
+func randomQueryID() (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
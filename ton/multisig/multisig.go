@@ -0,0 +1,380 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Multisig v2 contract, which creates separate order contract for each request:
+// https://github.com/ton-blockchain/multisig-contract-v2
+//
+// Signer or proposer sends new order to multisig, multisig deploys order contract,
+// signers approve order, and when threshold is reached order sends it to multisig for execution.
+// Compiled code of multisig and order is not embedded, it should be passed to the functions which need it.
+
+// ErrNoMultisigData - multisig account is not active, so it has no data
+var ErrNoMultisigData = errors.New("multisig contract is not active")
+
+// ErrNotSigner - address is not in signers or proposers list of the multisig or order
+var ErrNotSigner = errors.New("address is not a signer")
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+	GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error)
+}
+
+// Config - parameters of the new multisig
+type Config struct {
+	// Threshold - number of signers approvals required to execute order
+	Threshold uint8
+	// Signers - can create and approve orders
+	Signers []*address.Address
+	// Proposers - can only create orders
+	Proposers []*address.Address
+	// AllowArbitrarySeqno - when true, order seqno is chosen by order creator,
+	// otherwise orders are numbered sequentially by multisig
+	AllowArbitrarySeqno bool
+}
+
+// Data - decoded data of the multisig contract
+type Data struct {
+	NextOrderSeqno      *big.Int
+	Threshold           uint8
+	Signers             []*address.Address
+	Proposers           []*address.Address
+	AllowArbitrarySeqno bool
+}
+
+type multisigData struct {
+	NextOrderSeqno      []byte           `tlb:"bits 256"`
+	Threshold           uint8            `tlb:"## 8"`
+	Signers             *cell.Cell       `tlb:"^"`
+	SignersNum          uint8            `tlb:"## 8"`
+	Proposers           *cell.Dictionary `tlb:"dict 8"`
+	AllowArbitrarySeqno bool             `tlb:"bool"`
+}
+
+// SignerIndex - returns index of the address in signers list
+func (d *Data) SignerIndex(addr *address.Address) (uint8, bool) {
+	return findIndex(d.Signers, addr)
+}
+
+// ProposerIndex - returns index of the address in proposers list
+func (d *Data) ProposerIndex(addr *address.Address) (uint8, bool) {
+	return findIndex(d.Proposers, addr)
+}
+
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+func NewMultisigClient(api TonApi, multisigAddr *address.Address) *Client {
+	return &Client{
+		addr: multisigAddr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// GetStateInit - builds state init of the new multisig
+func GetStateInit(code *cell.Cell, cfg Config) (*tlb.StateInit, error) {
+	if code == nil {
+		return nil, errors.New("multisig code is not set")
+	}
+
+	if err := checkParams(cfg.Threshold, cfg.Signers, cfg.Proposers); err != nil {
+		return nil, err
+	}
+
+	signers, err := addrDict(cfg.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signers: %w", err)
+	}
+
+	proposers, err := addrDict(cfg.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proposers: %w", err)
+	}
+
+	data, err := tlb.ToCell(multisigData{
+		NextOrderSeqno:      make([]byte, 32),
+		Threshold:           cfg.Threshold,
+		Signers:             signers.AsCell(),
+		SignersNum:          uint8(len(cfg.Signers)),
+		Proposers:           proposers,
+		AllowArbitrarySeqno: cfg.AllowArbitrarySeqno,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build multisig data: %w", err)
+	}
+
+	return &tlb.StateInit{
+		Code: code,
+		Data: data,
+	}, nil
+}
+
+// BuildDeployMessage - builds wallet message which deploys multisig to the given workchain, amount is initial balance
+func BuildDeployMessage(code *cell.Cell, cfg Config, workchain int8, amount tlb.Coins) (*wallet.Message, *address.Address, error) {
+	state, err := GetStateInit(code, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := stateInitAddress(workchain, state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &wallet.Message{
+		Mode: 1 + 2,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      false,
+			DstAddr:     addr,
+			Amount:      amount,
+			// top up message
+			Body:      cell.BeginCell().MustStoreUInt(0, 32).MustStoreUInt(0, 64).EndCell(),
+			StateInit: state,
+		},
+	}, addr, nil
+}
+
+// GetData - loads multisig account and decodes its data
+func (c *Client) GetData(ctx context.Context) (*Data, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetDataAtBlock(ctx, b)
+}
+
+func (c *Client) GetDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*Data, error) {
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multisig account: %w", err)
+	}
+
+	if !acc.IsActive || acc.Data == nil {
+		return nil, ErrNoMultisigData
+	}
+	return ParseData(acc.Data)
+}
+
+// ParseData - decodes multisig contract data
+func ParseData(data *cell.Cell) (*Data, error) {
+	var d multisigData
+	if err := tlb.LoadFromCell(&d, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse multisig data: %w", err)
+	}
+
+	signers, err := parseAddrDict(d.Signers.AsDict(8))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signers: %w", err)
+	}
+
+	proposers, err := parseAddrDict(d.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proposers: %w", err)
+	}
+
+	return &Data{
+		NextOrderSeqno:      new(big.Int).SetBytes(d.NextOrderSeqno),
+		Threshold:           d.Threshold,
+		Signers:             signers,
+		Proposers:           proposers,
+		AllowArbitrarySeqno: d.AllowArbitrarySeqno,
+	}, nil
+}
+
+// GetOrderAddress - returns address of the order with the given seqno, using get_order_address get method
+func (c *Client) GetOrderAddress(ctx context.Context, orderSeqno *big.Int) (*address.Address, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetOrderAddressAtBlock(ctx, orderSeqno, b)
+}
+
+func (c *Client) GetOrderAddressAtBlock(ctx context.Context, orderSeqno *big.Int, b *ton.BlockIDExt) (*address.Address, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_order_address", orderSeqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_order_address method: %w", err)
+	}
+
+	x, err := res.Slice(0)
+	if err != nil {
+		return nil, fmt.Errorf("result get err: %w", err)
+	}
+
+	addr, err := x.LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load address from result slice: %w", err)
+	}
+	return addr, nil
+}
+
+// EstimateOrderCost - returns minimal value of the new order message, using get_order_estimate get method
+func (c *Client) EstimateOrderCost(ctx context.Context, order *cell.Cell, expireAt time.Time) (tlb.Coins, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.EstimateOrderCostAtBlock(ctx, order, expireAt, b)
+}
+
+func (c *Client) EstimateOrderCostAtBlock(ctx context.Context, order *cell.Cell, expireAt time.Time, b *ton.BlockIDExt) (tlb.Coins, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_order_estimate", order, big.NewInt(expireAt.Unix()))
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to run get_order_estimate method: %w", err)
+	}
+
+	var result struct {
+		Amount tlb.Coins `tvm:"coins"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to decode get_order_estimate result: %w", err)
+	}
+	return result.Amount, nil
+}
+
+// BuildNewOrderMessage - builds wallet message of the signer or proposer, which creates order with the given actions.
+// Use NextOrderSeqno as orderSeqno to let multisig choose it, amount should be not less than EstimateOrderCost.
+func BuildNewOrderMessage(multisig *address.Address, data *Data, sender *address.Address, orderSeqno *big.Int, actions []any, expireAt time.Time, amount tlb.Coins) (*wallet.Message, error) {
+	isSigner := true
+	index, ok := data.SignerIndex(sender)
+	if !ok {
+		isSigner = false
+		if index, ok = data.ProposerIndex(sender); !ok {
+			return nil, ErrNotSigner
+		}
+	}
+
+	order, err := BuildOrder(actions)
+	if err != nil {
+		return nil, err
+	}
+
+	queryID, err := randomQueryID()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := tlb.ToCell(NewOrderPayload{
+		QueryID:        queryID,
+		OrderSeqno:     orderSeqno,
+		IsSigner:       isSigner,
+		Index:          index,
+		ExpirationDate: uint64(expireAt.Unix()),
+		Order:          order,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert NewOrderPayload to cell: %w", err)
+	}
+
+	return wallet.SimpleMessage(multisig, amount, body), nil
+}
+
+// NewOrder - creates order from the signer or proposer wallet and waits for the wallet transaction.
+// Order seqno is the next seqno of the multisig, so when another order was created first, multisig rejects this one.
+// Value is estimated using EstimateOrderCost.
+// Returns address of the created order.
+func (c *Client) NewOrder(ctx context.Context, w *wallet.Wallet, actions []any, expireAt time.Time) (*address.Address, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	data, err := c.GetDataAtBlock(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.AllowArbitrarySeqno {
+		return nil, errors.New("multisig uses arbitrary order seqno, use BuildNewOrderMessage with chosen seqno")
+	}
+
+	order, err := BuildOrder(actions)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := c.EstimateOrderCostAtBlock(ctx, order, expireAt, b)
+	if err != nil {
+		return nil, err
+	}
+
+	orderAddr, err := c.GetOrderAddressAtBlock(ctx, data.NextOrderSeqno, b)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := BuildNewOrderMessage(c.addr, data, w.WalletAddress(), data.NextOrderSeqno, actions, expireAt, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = w.SendWaitTransaction(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to send new order: %w", err)
+	}
+	return orderAddr, nil
+}
+
+// GetOrderStateInit - builds state init of the order deployed by multisig, orderCode is code of the order contract
+func GetOrderStateInit(orderCode *cell.Cell, multisig *address.Address, orderSeqno *big.Int) (*tlb.StateInit, error) {
+	if orderCode == nil {
+		return nil, errors.New("order code is not set")
+	}
+
+	data := cell.BeginCell().
+		MustStoreAddr(multisig).
+		MustStoreBigUInt(orderSeqno, 256).
+		EndCell()
+
+	return &tlb.StateInit{
+		Code: orderCode,
+		Data: data,
+	}, nil
+}
+
+// OrderAddress - calculates address of the order with the given seqno without network requests,
+// order is deployed in the same workchain as multisig
+func OrderAddress(orderCode *cell.Cell, multisig *address.Address, orderSeqno *big.Int) (*address.Address, error) {
+	state, err := GetOrderStateInit(orderCode, multisig, orderSeqno)
+	if err != nil {
+		return nil, err
+	}
+	return stateInitAddress(int8(multisig.Workchain()), state)
+}
+
+func stateInitAddress(workchain int8, state *tlb.StateInit) (*address.Address, error) {
+	stateCell, err := tlb.ToCell(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert state init to cell: %w", err)
+	}
+	return address.NewAddress(0, byte(workchain), stateCell.Hash()), nil
+}
+
+func findIndex(list []*address.Address, addr *address.Address) (uint8, bool) {
+	for i, a := range list {
+		if a.Workchain() == addr.Workchain() && string(a.Data()) == string(addr.Data()) {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
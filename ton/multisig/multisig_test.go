@@ -0,0 +1,293 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/liteservertest"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	testMultisigCode = cell.BeginCell().MustStoreUInt(0x3151, 16).EndCell()
+	testOrderCode    = cell.BeginCell().MustStoreUInt(0x0DE5, 16).EndCell()
+
+	signer1  = address.MustParseRawAddr("0:" + "1111111111111111111111111111111111111111111111111111111111111111")
+	signer2  = address.MustParseRawAddr("0:" + "2222222222222222222222222222222222222222222222222222222222222222")
+	signer3  = address.MustParseRawAddr("-1:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	proposer = address.MustParseRawAddr("0:" + "4444444444444444444444444444444444444444444444444444444444444444")
+)
+
+func testConfig() Config {
+	return Config{
+		Threshold: 2,
+		Signers:   []*address.Address{signer1, signer2, signer3},
+		Proposers: []*address.Address{proposer},
+	}
+}
+
+func testActions() []any {
+	return []any{
+		SendMessageAction{
+			Mode: 3,
+			Message: &tlb.InternalMessage{
+				Bounce:  true,
+				DstAddr: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+				Amount:  tlb.MustFromTON("1000"),
+				Body:    cell.BeginCell().EndCell(),
+			},
+		},
+		&UpdateParamsAction{
+			Threshold: 1,
+			Signers:   []*address.Address{signer1, signer2},
+		},
+	}
+}
+
+func sameAddrs(a, b []*address.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].String() != b[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+func TestClient_GetData(t *testing.T) {
+	chain := liteservertest.NewChain()
+
+	deploy, multisigAddr, err := BuildDeployMessage(testMultisigCode, testConfig(), 0, tlb.MustFromTON("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := deploy.InternalMessage.StateInit
+	chain.SetAccount(liteservertest.Account{
+		Address: multisigAddr,
+		Balance: tlb.MustFromTON("5000"),
+		Code:    state.Code,
+		Data:    state.Data,
+	})
+	chain.SetGetMethod(multisigAddr, "get_order_address", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		addr, err := OrderAddress(testOrderCode, multisigAddr, params[0].(*big.Int))
+		if err != nil {
+			return nil, 9
+		}
+		return []any{cell.BeginCell().MustStoreAddr(addr).EndCell().BeginParse()}, 0
+	})
+	chain.SetGetMethod(multisigAddr, "get_order_estimate", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		if _, ok := params[0].(*cell.Cell); !ok {
+			return nil, 7
+		}
+		return []any{tlb.MustFromTON("0.2").Nano()}, 0
+	})
+
+	orderAddr, err := OrderAddress(testOrderCode, multisigAddr, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := BuildOrder(testActions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers, err := addrDict(testConfig().Signers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orderState, err := GetOrderStateInit(testOrderCode, multisigAddr, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderInit, err := tlb.ToCell(orderData{
+		Threshold:      2,
+		Signers:        signers.AsCell(),
+		ApprovalsMask:  big.NewInt(1 << 2).FillBytes(make([]byte, 32)),
+		ApprovalsNum:   1,
+		ExpirationDate: 1700000000,
+		Order:          order,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.SetAccount(liteservertest.Account{
+		Address: orderAddr,
+		Balance: tlb.MustFromTON("0.1"),
+		Code:    testOrderCode,
+		Data:    cell.BeginCell().MustStoreBuilder(orderState.Data.ToBuilder()).MustStoreBuilder(orderInit.ToBuilder()).EndCell(),
+	})
+
+	if _, err = chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	srv := liteservertest.NewServer(chain)
+	if err = srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	defer pool.Stop()
+	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+
+	client := NewMultisigClient(api, multisigAddr)
+
+	data, err := client.GetData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.NextOrderSeqno.Sign() != 0 || data.Threshold != 2 || data.AllowArbitrarySeqno ||
+		!sameAddrs(data.Signers, testConfig().Signers) || !sameAddrs(data.Proposers, testConfig().Proposers) {
+		t.Fatal("incorrect multisig data", data)
+	}
+
+	gotOrderAddr, err := client.GetOrderAddress(ctx, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotOrderAddr.String() != orderAddr.String() {
+		t.Fatal("incorrect order address", gotOrderAddr.String(), orderAddr.String())
+	}
+
+	cost, err := client.EstimateOrderCost(ctx, order, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost.String() != "0.2" {
+		t.Fatal("incorrect estimate", cost.String())
+	}
+
+	orderData, err := NewOrderClient(api, orderAddr).GetData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !orderData.Initialized || orderData.Multisig.String() != multisigAddr.String() || orderData.OrderSeqno.Sign() != 0 ||
+		orderData.Threshold != 2 || orderData.SentForExecution || orderData.ApprovalsNum != 1 || orderData.ExpirationDate != 1700000000 ||
+		!sameAddrs(orderData.Signers, testConfig().Signers) {
+		t.Fatal("incorrect order data", orderData)
+	}
+
+	if !orderData.IsApprovedBy(2) || orderData.IsApprovedBy(0) {
+		t.Fatal("incorrect approvals")
+	}
+
+	actions, err := orderData.Actions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 {
+		t.Fatal("incorrect actions num", len(actions))
+	}
+	if send, ok := actions[0].(SendMessageAction); !ok || send.Mode != 3 || send.Message.Amount.String() != "1000" {
+		t.Fatal("incorrect send action", actions[0])
+	}
+	if upd, ok := actions[1].(UpdateParamsAction); !ok || upd.Threshold != 1 || !sameAddrs(upd.Signers, []*address.Address{signer1, signer2}) || len(upd.Proposers) != 0 {
+		t.Fatal("incorrect update action", actions[1])
+	}
+
+	msg, err := BuildApproveMessage(orderAddr, orderData, signer1, tlb.MustFromTON("0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var approve ApprovePayload
+	if err = tlb.LoadFromCell(&approve, msg.InternalMessage.Body.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if approve.SignerIndex != 0 || msg.InternalMessage.DstAddr.String() != orderAddr.String() {
+		t.Fatal("incorrect approve message")
+	}
+
+	if _, err = BuildApproveMessage(orderAddr, orderData, signer3, tlb.MustFromTON("0.1")); err == nil {
+		t.Fatal("approve should be rejected for already approved signer")
+	}
+
+	if _, err = BuildApproveMessage(orderAddr, orderData, proposer, tlb.MustFromTON("0.1")); !errors.Is(err, ErrNotSigner) {
+		t.Fatal("proposer cannot approve", err)
+	}
+
+	_, err = NewOrderClient(api, address.MustParseRawAddr("0:"+"5555555555555555555555555555555555555555555555555555555555555555")).GetData(ctx)
+	if !errors.Is(err, ErrNoOrderData) {
+		t.Fatal("expected not active order error, got", err)
+	}
+}
+
+func TestBuildNewOrderMessage(t *testing.T) {
+	state, err := GetStateInit(testMultisigCode, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ParseData(state.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multisigAddr := address.MustParseRawAddr("0:" + "6666666666666666666666666666666666666666666666666666666666666666")
+	expireAt := time.Unix(1700000000, 0)
+
+	for _, tt := range []struct {
+		sender   *address.Address
+		isSigner bool
+		index    uint8
+	}{
+		{signer2, true, 1},
+		{proposer, false, 0},
+	} {
+		msg, err := BuildNewOrderMessage(multisigAddr, data, tt.sender, NextOrderSeqno, testActions(), expireAt, tlb.MustFromTON("0.5"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var p NewOrderPayload
+		if err = tlb.LoadFromCell(&p, msg.InternalMessage.Body.BeginParse()); err != nil {
+			t.Fatal(err)
+		}
+		if p.IsSigner != tt.isSigner || p.Index != tt.index || p.ExpirationDate != 1700000000 || p.OrderSeqno.Cmp(NextOrderSeqno) != 0 {
+			t.Fatal("incorrect new order payload", p)
+		}
+
+		actions, err := ParseOrder(p.Order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actions) != 2 {
+			t.Fatal("incorrect order")
+		}
+	}
+
+	if _, err = BuildNewOrderMessage(multisigAddr, data, multisigAddr, NextOrderSeqno, testActions(), expireAt, tlb.MustFromTON("0.5")); !errors.Is(err, ErrNotSigner) {
+		t.Fatal("unknown sender should be rejected", err)
+	}
+
+	if _, err = BuildOrder(nil); err == nil {
+		t.Fatal("empty order should be rejected")
+	}
+
+	if _, err = BuildOrder([]any{UpdateParamsAction{Threshold: 3, Signers: []*address.Address{signer1}}}); err == nil {
+		t.Fatal("threshold above signers number should be rejected")
+	}
+
+	cfg := testConfig()
+	cfg.Threshold = 0
+	if _, err = GetStateInit(testMultisigCode, cfg); err == nil {
+		t.Fatal("zero threshold should be rejected")
+	}
+}
@@ -0,0 +1,194 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrNoOrderData - order account is not active, it is not deployed or was destroyed after execution
+var ErrNoOrderData = errors.New("order contract is not active")
+
+// OrderData - decoded data of the order contract
+type OrderData struct {
+	Multisig   *address.Address
+	OrderSeqno *big.Int
+	// Initialized - false when order was deployed, but init message from multisig was not processed,
+	// in this case only Multisig and OrderSeqno are set
+	Initialized      bool
+	Threshold        uint8
+	SentForExecution bool
+	Signers          []*address.Address
+	// ApprovalsMask - bit i is set when signer with index i has approved order
+	ApprovalsMask  *big.Int
+	ApprovalsNum   uint8
+	ExpirationDate uint64
+	Order          *cell.Cell
+}
+
+type orderInitData struct {
+	Multisig   *address.Address `tlb:"addr"`
+	OrderSeqno []byte           `tlb:"bits 256"`
+}
+
+type orderData struct {
+	Threshold        uint8      `tlb:"## 8"`
+	SentForExecution bool       `tlb:"bool"`
+	Signers          *cell.Cell `tlb:"^"`
+	ApprovalsMask    []byte     `tlb:"bits 256"`
+	ApprovalsNum     uint8      `tlb:"## 8"`
+	ExpirationDate   uint64     `tlb:"## 48"`
+	Order            *cell.Cell `tlb:"^"`
+}
+
+// IsApprovedBy - checks is order approved by signer with the given index
+func (d *OrderData) IsApprovedBy(signerIndex uint8) bool {
+	return d.ApprovalsMask != nil && d.ApprovalsMask.Bit(int(signerIndex)) == 1
+}
+
+// SignerIndex - returns index of the address in order signers list
+func (d *OrderData) SignerIndex(addr *address.Address) (uint8, bool) {
+	return findIndex(d.Signers, addr)
+}
+
+// Actions - unpacks actions of the order, see ParseOrder
+func (d *OrderData) Actions() ([]any, error) {
+	if d.Order == nil {
+		return nil, errors.New("order is not initialized")
+	}
+	return ParseOrder(d.Order)
+}
+
+type OrderClient struct {
+	addr *address.Address
+	api  TonApi
+}
+
+func NewOrderClient(api TonApi, orderAddr *address.Address) *OrderClient {
+	return &OrderClient{
+		addr: orderAddr,
+		api:  api,
+	}
+}
+
+func (c *OrderClient) Address() *address.Address {
+	return c.addr
+}
+
+// GetData - loads order account and decodes its data
+func (c *OrderClient) GetData(ctx context.Context) (*OrderData, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetDataAtBlock(ctx, b)
+}
+
+func (c *OrderClient) GetDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*OrderData, error) {
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order account: %w", err)
+	}
+
+	if !acc.IsActive || acc.Data == nil {
+		return nil, ErrNoOrderData
+	}
+	return ParseOrderData(acc.Data)
+}
+
+// ParseOrderData - decodes order contract data
+func ParseOrderData(data *cell.Cell) (*OrderData, error) {
+	s := data.BeginParse()
+
+	var init orderInitData
+	if err := tlb.LoadFromCell(&init, s); err != nil {
+		return nil, fmt.Errorf("failed to parse order data: %w", err)
+	}
+
+	res := &OrderData{
+		Multisig:   init.Multisig,
+		OrderSeqno: new(big.Int).SetBytes(init.OrderSeqno),
+	}
+
+	if s.BitsLeft() == 0 {
+		return res, nil
+	}
+
+	var d orderData
+	if err := tlb.LoadFromCell(&d, s); err != nil {
+		return nil, fmt.Errorf("failed to parse order data: %w", err)
+	}
+
+	signers, err := parseAddrDict(d.Signers.AsDict(8))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signers: %w", err)
+	}
+
+	res.Initialized = true
+	res.Threshold = d.Threshold
+	res.SentForExecution = d.SentForExecution
+	res.Signers = signers
+	res.ApprovalsMask = new(big.Int).SetBytes(d.ApprovalsMask)
+	res.ApprovalsNum = d.ApprovalsNum
+	res.ExpirationDate = d.ExpirationDate
+	res.Order = d.Order
+	return res, nil
+}
+
+// BuildApproveMessage - builds wallet message of the signer, which approves order
+func BuildApproveMessage(order *address.Address, data *OrderData, signer *address.Address, amount tlb.Coins) (*wallet.Message, error) {
+	if !data.Initialized {
+		return nil, errors.New("order is not initialized")
+	}
+
+	index, ok := data.SignerIndex(signer)
+	if !ok {
+		return nil, ErrNotSigner
+	}
+
+	if data.IsApprovedBy(index) {
+		return nil, errors.New("order is already approved by this signer")
+	}
+
+	queryID, err := randomQueryID()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := tlb.ToCell(ApprovePayload{
+		QueryID:     queryID,
+		SignerIndex: index,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert ApprovePayload to cell: %w", err)
+	}
+
+	return wallet.SimpleMessage(order, amount, body), nil
+}
+
+// Approve - approves order from the signer wallet and waits for the wallet transaction,
+// amount is used for fees and the rest is returned with approve response
+func (c *OrderClient) Approve(ctx context.Context, w *wallet.Wallet, amount tlb.Coins) (*tlb.Transaction, error) {
+	data, err := c.GetData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := BuildApproveMessage(c.addr, data, w.WalletAddress(), amount)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, _, err := w.SendWaitTransaction(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send approve: %w", err)
+	}
+	return tx, nil
+}
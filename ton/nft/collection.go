@@ -172,35 +172,24 @@ func (c *CollectionClient) GetCollectionDataAtBlock(ctx context.Context, b *ton.
 		return nil, fmt.Errorf("failed to run get_collection_data method: %w", err)
 	}
 
-	nextIndex, err := res.Int(0)
-	if err != nil {
-		return nil, fmt.Errorf("next index get err: %w", err)
+	var data struct {
+		NextIndex *big.Int         `tvm:"int"`
+		Content   *cell.Cell       `tvm:"cell"`
+		Owner     *address.Address `tvm:"addr"`
 	}
-
-	content, err := res.Cell(1)
-	if err != nil {
-		return nil, fmt.Errorf("content get err: %w", err)
-	}
-
-	ownerRes, err := res.Slice(2)
-	if err != nil {
-		return nil, fmt.Errorf("owner get err: %w", err)
-	}
-
-	addr, err := ownerRes.LoadAddr()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load owner address from result slice: %w", err)
+	if err = res.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode get_collection_data result: %w", err)
 	}
 
-	cnt, err := ContentFromCell(content)
+	cnt, err := ContentFromCell(data.Content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse content: %w", err)
 	}
 
 	return &CollectionData{
-		NextItemIndex: nextIndex,
+		NextItemIndex: data.NextIndex,
 		Content:       cnt,
-		OwnerAddress:  addr,
+		OwnerAddress:  data.Owner,
 	}, nil
 }
 
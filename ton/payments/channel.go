@@ -52,6 +52,8 @@ func NewPaymentChannelClient(api TonApi) *Client {
 
 var ErrVerificationNotPassed = fmt.Errorf("verification not passed")
 
+// GetAsyncChannel - loads channel from account state. Channel get methods are not used, because their execution
+// cannot be proven, so data and code from the proven account state are parsed, and status is calculated locally.
 func (c *Client) GetAsyncChannel(ctx context.Context, block *ton.BlockIDExt, addr *address.Address, verify bool) (*AsyncChannel, error) {
 	acc, err := c.api.GetAccount(ctx, block, addr)
 	if err != nil {
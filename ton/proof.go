@@ -224,40 +224,153 @@ func CheckBackwardBlockProof(from, to *BlockIDExt, toKey bool, stateProof, destP
 		return fmt.Errorf("target block type not matches requested")
 	}
 
-	stateExtra, err := CheckShardMcStateExtraProof(from, []*cell.Cell{proof, stateProof})
+	blk, err := loadPrevBlockRef(from, []*cell.Cell{proof, stateProof}, to.SeqNo)
 	if err != nil {
-		return fmt.Errorf("failed to check proof for mc state extra: %w", err)
+		return err
+	}
+
+	if blk.IsKey != toKey {
+		return fmt.Errorf("target block type in proof not matches requested")
+	}
+
+	if !bytes.Equal(blk.BlkRef.RootHash, to.RootHash) {
+		return fmt.Errorf("incorret target block hash in proof")
+	}
+	return nil
+}
+
+// loadPrevBlockRef - loads reference to the previous masterchain block with seqno from the state proof of master block
+func loadPrevBlockRef(master *BlockIDExt, stateProof []*cell.Cell, seqno uint32) (*tlb.KeyExtBlkRef, error) {
+	stateExtra, err := CheckShardMcStateExtraProof(master, stateProof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check proof for mc state extra: %w", err)
 	}
 
 	var info tlb.McStateExtraBlockInfo
 	err = tlb.LoadFromCellAsProof(&info, stateExtra.Info.BeginParse())
 	if err != nil {
-		return fmt.Errorf("failed to load tx CurrencyCollection proof cell: %w", err)
+		return nil, fmt.Errorf("failed to load mc state extra info proof cell: %w", err)
 	}
 
-	toInfo := info.PrevBlocks.GetByIntKey(big.NewInt(int64(to.SeqNo)))
+	toInfo := info.PrevBlocks.GetByIntKey(big.NewInt(int64(seqno)))
 	if toInfo == nil {
-		return fmt.Errorf("target block not found in state proof")
+		return nil, fmt.Errorf("target block not found in state proof")
 	}
 
 	slc := toInfo.BeginParse()
 	err = tlb.LoadFromCellAsProof(new(tlb.KeyMaxLt), slc)
 	if err != nil {
-		return fmt.Errorf("failed to load block KeyMaxLt proof cell: %w", err)
+		return nil, fmt.Errorf("failed to load block KeyMaxLt proof cell: %w", err)
 	}
 
 	var blk tlb.KeyExtBlkRef
 	err = tlb.LoadFromCellAsProof(&blk, slc)
 	if err != nil {
-		return fmt.Errorf("failed to load block KeyExtBlkRef proof cell: %w", err)
+		return nil, fmt.Errorf("failed to load block KeyExtBlkRef proof cell: %w", err)
 	}
+	return &blk, nil
+}
 
-	if blk.IsKey != toKey {
-		return fmt.Errorf("target block type in proof not matches requested")
+// CheckMasterBlockInState - checks that previous masterchain block is listed in the state of the known master block
+func CheckMasterBlockInState(master *BlockIDExt, stateProof []*cell.Cell, prev *BlockIDExt) error {
+	if prev.Workchain != address.MasterchainID || prev.SeqNo >= master.SeqNo {
+		return fmt.Errorf("block should be from masterchain and older than known master block")
 	}
 
-	if !bytes.Equal(blk.BlkRef.RootHash, to.RootHash) {
-		return fmt.Errorf("incorret target block hash in proof")
+	blk, err := loadPrevBlockRef(master, stateProof, prev.SeqNo)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(blk.BlkRef.RootHash, prev.RootHash) || !bytes.Equal(blk.BlkRef.FileHash, prev.FileHash) {
+		return fmt.Errorf("incorrect block hash in proof")
+	}
+	return nil
+}
+
+// CheckShardBlockProof - checks links from masterchain block to the shard block, returned by liteServer.getShardBlockProof.
+// First link is a shard block referenced by the masterchain block, with proof of masterchain block.
+// Each next link is a parent of previous link, with header proof of previous link's block.
+// Last link should be the requested block.
+func CheckShardBlockProof(block *BlockIDExt, proof *ShardBlockProof) error {
+	if proof.MasterchainID == nil || proof.MasterchainID.Workchain != address.MasterchainID {
+		return fmt.Errorf("masterchain block is not passed")
+	}
+
+	if block.Workchain == address.MasterchainID {
+		if len(proof.Links) > 0 || !proof.MasterchainID.Equals(block) {
+			return fmt.Errorf("masterchain block should be proved by itself")
+		}
+		return nil
+	}
+
+	if len(proof.Links) == 0 {
+		return fmt.Errorf("no links in proof")
+	}
+
+	first, err := cell.FromBOC(proof.Links[0].Proof)
+	if err != nil {
+		return fmt.Errorf("failed to parse masterchain block proof: %w", err)
+	}
+
+	master, err := CheckBlockProof(first, proof.MasterchainID.RootHash)
+	if err != nil {
+		return fmt.Errorf("failed to check masterchain block proof: %w", err)
+	}
+
+	if master.Extra == nil || master.Extra.Custom == nil {
+		return fmt.Errorf("no shard hashes in masterchain block proof")
+	}
+
+	shards, err := LoadShardsFromHashes(master.Extra.Custom.ShardHashes, true)
+	if err != nil {
+		return fmt.Errorf("failed to load shard hashes: %w", err)
+	}
+
+	found := false
+	for _, shard := range shards {
+		if shard.Equals(proof.Links[0].ID) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("first link is not found in masterchain block")
+	}
+
+	for i := 1; i < len(proof.Links); i++ {
+		prev, cur := proof.Links[i-1].ID, proof.Links[i].ID
+
+		hdrProof, err := cell.FromBOC(proof.Links[i].Proof)
+		if err != nil {
+			return fmt.Errorf("failed to parse link %d proof: %w", i, err)
+		}
+
+		hdr, err := CheckBlockProof(hdrProof, prev.RootHash)
+		if err != nil {
+			return fmt.Errorf("failed to check link %d proof: %w", i, err)
+		}
+
+		parents, err := hdr.BlockInfo.GetParentBlocks()
+		if err != nil {
+			return fmt.Errorf("failed to get parents of link %d: %w", i, err)
+		}
+
+		found = false
+		for _, parent := range parents {
+			if parent.Workchain == cur.Workchain && parent.Shard == cur.Shard &&
+				parent.SeqNo == cur.SeqNo && bytes.Equal(parent.RootHash, cur.RootHash) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("link %d is not a parent of previous link", i)
+		}
+	}
+
+	if !proof.Links[len(proof.Links)-1].ID.Equals(block) {
+		return fmt.Errorf("last link is not the requested block")
 	}
 	return nil
 }
@@ -530,26 +643,55 @@ func calcValidatorSetHash(ccSeqno uint32, validators []*tlb.ValidatorAddr) (uint
 }
 
 func (c *APIClient) VerifyProofChain(ctx context.Context, from, to *BlockIDExt) error {
+	_, err := c.verifyProofChain(ctx, from, to)
+	return err
+}
+
+// verifyProofChain - verifies proof chain and returns key blocks which were proven on the way
+func (c *APIClient) verifyProofChain(ctx context.Context, from, to *BlockIDExt) ([]*KeyBlock, error) {
 	isForward := to.SeqNo > from.SeqNo
 
+	var keyBlocks []*KeyBlock
+	addKeyBlock := func(id *BlockIDExt, destProof *cell.Cell) error {
+		block, err := CheckBlockProof(destProof, id.RootHash)
+		if err != nil {
+			return fmt.Errorf("failed to check key block %d proof: %w", id.SeqNo, err)
+		}
+
+		for _, kb := range keyBlocks {
+			if kb.ID.SeqNo == id.SeqNo {
+				return nil
+			}
+		}
+		keyBlocks = append(keyBlocks, &KeyBlock{ID: id.Copy(), GenUTime: block.BlockInfo.GenUtime})
+		return nil
+	}
+
 	for from.SeqNo != to.SeqNo {
 		part, err := c.GetBlockProof(ctx, from, to)
 		if err != nil {
 			if lsErr, ok := err.(LSError); ok && (lsErr.Code == 651 || lsErr.Code == -400) { // block not applied error
 				// try next node
 				if ctx, err = c.client.StickyContextNextNode(ctx); err != nil {
-					return fmt.Errorf("failed to pick next node: %w", err)
+					return nil, fmt.Errorf("failed to pick next node: %w", err)
 				}
 				continue
 			}
-			return fmt.Errorf("failed to get master block proof from %d to %d: %w", from.SeqNo, to.SeqNo, err)
+			return nil, fmt.Errorf("failed to get master block proof from %d to %d: %w", from.SeqNo, to.SeqNo, err)
 		}
 
 		if !part.From.Equals(from) {
-			return fmt.Errorf("unexpected from block: %d, want %d", part.From.SeqNo, from.SeqNo)
+			return nil, fmt.Errorf("unexpected from block: %d, want %d", part.From.SeqNo, from.SeqNo)
 		}
 
+		// every step should start from the block proven by the previous one
+		cur := from
+
 		checkBackProof := func(bwd *BlockLinkBackward) error {
+			if !bwd.From.Equals(cur) {
+				return fmt.Errorf("backward link from %d is not connected to block %d", bwd.From.SeqNo, cur.SeqNo)
+			}
+
 			destProof, err := cell.FromBOC(bwd.DestProof)
 			if err != nil {
 				return fmt.Errorf("dest proof boc parse err: %w", err)
@@ -569,6 +711,13 @@ func (c *APIClient) VerifyProofChain(ctx context.Context, from, to *BlockIDExt)
 			if err != nil {
 				return fmt.Errorf("invalid backward block from %d to %d proof: %w", bwd.From.SeqNo, bwd.To.SeqNo, err)
 			}
+
+			if bwd.ToKeyBlock {
+				if err = addKeyBlock(bwd.To, destProof); err != nil {
+					return err
+				}
+			}
+			cur = bwd.To
 			return nil
 		}
 
@@ -579,49 +728,62 @@ func (c *APIClient) VerifyProofChain(ctx context.Context, from, to *BlockIDExt)
 					// proof back to key block
 					bwd, ok := step.(BlockLinkBackward)
 					if !ok {
-						return fmt.Errorf("wrong proof step type %v", reflect.TypeOf(step).String())
+						return nil, fmt.Errorf("wrong proof step type %v", reflect.TypeOf(step).String())
 					}
 
 					if err = checkBackProof(&bwd); err != nil {
-						return err
+						return nil, err
 					}
-
-					from = bwd.To
 					continue
 				}
 
+				if !fwd.From.Equals(cur) {
+					return nil, fmt.Errorf("forward link from %d is not connected to block %d", fwd.From.SeqNo, cur.SeqNo)
+				}
+
 				destProof, err := cell.FromBOC(fwd.DestProof)
 				if err != nil {
-					return fmt.Errorf("dest proof boc parse err: %w", err)
+					return nil, fmt.Errorf("dest proof boc parse err: %w", err)
 				}
 
 				configProof, err := cell.FromBOC(fwd.ConfigProof)
 				if err != nil {
-					return fmt.Errorf("config proof boc parse err: %w", err)
+					return nil, fmt.Errorf("config proof boc parse err: %w", err)
 				}
 
 				err = CheckForwardBlockProof(fwd.From, fwd.To, fwd.ToKeyBlock, configProof, destProof, fwd.SignatureSet)
 				if err != nil {
-					return fmt.Errorf("invalid forward block from %d to %d proof: %w", fwd.From.SeqNo, fwd.To.SeqNo, err)
+					return nil, fmt.Errorf("invalid forward block from %d to %d proof: %w", fwd.From.SeqNo, fwd.To.SeqNo, err)
 				}
+
+				if fwd.ToKeyBlock {
+					if err = addKeyBlock(fwd.To, destProof); err != nil {
+						return nil, err
+					}
+				}
+				cur = fwd.To
 			}
 		} else {
 			for _, step := range part.Steps {
 				bwd, ok := step.(BlockLinkBackward)
 				if !ok {
-					return fmt.Errorf("wrong proof direction in response bw %v", reflect.TypeOf(step).String())
+					return nil, fmt.Errorf("wrong proof direction in response bw %v", reflect.TypeOf(step).String())
 				}
 
 				if err = checkBackProof(&bwd); err != nil {
-					return err
+					return nil, err
 				}
 			}
 		}
-		from = part.To
+
+		if !cur.Equals(part.To) {
+			return nil, fmt.Errorf("proof steps are not leading to block %d", part.To.SeqNo)
+		}
+		from = cur
 	}
 
 	if !from.Equals(to) {
-		return fmt.Errorf("target block not equals expected")
+		return nil, fmt.Errorf("target block not equals expected")
 	}
-	return nil
+	return keyBlocks, nil
 }
@@ -0,0 +1,355 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(OutMsgQueueSize{}, "liteServer.outMsgQueueSize id:tonNode.blockIdExt size:int = liteServer.OutMsgQueueSize")
+	tl.Register(OutMsgQueueSizes{}, "liteServer.outMsgQueueSizes shards:(vector liteServer.outMsgQueueSize) ext_msg_queue_size_limit:int = liteServer.OutMsgQueueSizes")
+	tl.Register(GetOutMsgQueueSizes{}, "liteServer.getOutMsgQueueSizes mode:# wc:mode.0?int shard:mode.0?long = liteServer.OutMsgQueueSizes")
+
+	tl.Register(BlockOutMsgQueueSize{}, "liteServer.blockOutMsgQueueSize mode:# id:tonNode.blockIdExt size:long proof:mode.0?bytes = liteServer.BlockOutMsgQueueSize")
+	tl.Register(GetBlockOutMsgQueueSize{}, "liteServer.getBlockOutMsgQueueSize mode:# id:tonNode.blockIdExt want_proof:mode.0?true = liteServer.BlockOutMsgQueueSize")
+
+	tl.Register(AccountDispatchQueueInfo{}, "liteServer.accountDispatchQueueInfo addr:int256 size:long min_lt:long max_lt:long = liteServer.AccountDispatchQueueInfo")
+	tl.Register(DispatchQueueInfo{}, "liteServer.dispatchQueueInfo mode:# id:tonNode.blockIdExt account_dispatch_queues:(vector liteServer.accountDispatchQueueInfo) complete:Bool proof:mode.0?bytes = liteServer.DispatchQueueInfo")
+	tl.Register(GetDispatchQueueInfo{}, "liteServer.getDispatchQueueInfo mode:# id:tonNode.blockIdExt after_addr:mode.1?int256 max_accounts:int want_proof:mode.0?true = liteServer.DispatchQueueInfo")
+
+	tl.Register(DispatchQueueMessage{}, "liteServer.dispatchQueueMessage addr:int256 lt:long hash:int256 metadata:liteServer.transactionMetadata = liteServer.DispatchQueueMessage")
+	tl.Register(DispatchQueueMessages{}, "liteServer.dispatchQueueMessages mode:# id:tonNode.blockIdExt messages:(vector liteServer.dispatchQueueMessage) complete:Bool proof:mode.0?bytes messages_boc:mode.2?bytes = liteServer.DispatchQueueMessages")
+	tl.Register(GetDispatchQueueMessages{}, "liteServer.getDispatchQueueMessages mode:# id:tonNode.blockIdExt addr:int256 after_lt:long max_messages:int want_proof:mode.0?true one_account:mode.1?true messages_boc:mode.2?true = liteServer.DispatchQueueMessages")
+}
+
+type OutMsgQueueSize struct {
+	ID   *BlockIDExt `tl:"struct"`
+	Size int32       `tl:"int"`
+}
+
+type OutMsgQueueSizes struct {
+	Shards               []OutMsgQueueSize `tl:"vector struct"`
+	ExtMsgQueueSizeLimit int32             `tl:"int"`
+}
+
+type GetOutMsgQueueSizes struct {
+	Mode      uint32 `tl:"flags"`
+	Workchain int32  `tl:"?0 int"`
+	Shard     int64  `tl:"?0 long"`
+}
+
+type BlockOutMsgQueueSize struct {
+	Mode  uint32      `tl:"flags"`
+	ID    *BlockIDExt `tl:"struct"`
+	Size  uint64      `tl:"long"`
+	Proof []byte      `tl:"?0 bytes"`
+}
+
+type GetBlockOutMsgQueueSize struct {
+	Mode      uint32      `tl:"flags"`
+	ID        *BlockIDExt `tl:"struct"`
+	WantProof *True       `tl:"?0 struct"`
+}
+
+type AccountDispatchQueueInfo struct {
+	Addr  []byte `tl:"int256"`
+	Size  uint64 `tl:"long"`
+	MinLT uint64 `tl:"long"`
+	MaxLT uint64 `tl:"long"`
+}
+
+type DispatchQueueInfo struct {
+	Mode                  uint32                     `tl:"flags"`
+	ID                    *BlockIDExt                `tl:"struct"`
+	AccountDispatchQueues []AccountDispatchQueueInfo `tl:"vector struct"`
+	Complete              bool                       `tl:"bool"`
+	Proof                 []byte                     `tl:"?0 bytes"`
+}
+
+type GetDispatchQueueInfo struct {
+	Mode        uint32      `tl:"flags"`
+	ID          *BlockIDExt `tl:"struct"`
+	AfterAddr   []byte      `tl:"?1 int256"`
+	MaxAccounts int32       `tl:"int"`
+	WantProof   *True       `tl:"?0 struct"`
+}
+
+type DispatchQueueMessage struct {
+	Addr     []byte              `tl:"int256"`
+	LT       uint64              `tl:"long"`
+	Hash     []byte              `tl:"int256"`
+	Metadata TransactionMetadata `tl:"struct"`
+}
+
+type DispatchQueueMessages struct {
+	Mode        uint32                 `tl:"flags"`
+	ID          *BlockIDExt            `tl:"struct"`
+	Messages    []DispatchQueueMessage `tl:"vector struct"`
+	Complete    bool                   `tl:"bool"`
+	Proof       []byte                 `tl:"?0 bytes"`
+	MessagesBOC []byte                 `tl:"?2 bytes"`
+}
+
+type GetDispatchQueueMessages struct {
+	Mode        uint32      `tl:"flags"`
+	ID          *BlockIDExt `tl:"struct"`
+	Addr        []byte      `tl:"int256"`
+	AfterLT     uint64      `tl:"long"`
+	MaxMessages int32       `tl:"int"`
+	WantProof   *True       `tl:"?0 struct"`
+	OneAccount  *True       `tl:"?1 struct"`
+	MessagesBOC *True       `tl:"?2 struct"`
+}
+
+// GetOutMsgQueueSizes - gets sizes of outbound message queues of the latest shard blocks, not covered by proof
+func (c *APIClient) GetOutMsgQueueSizes(ctx context.Context) (*OutMsgQueueSizes, error) {
+	return c.getOutMsgQueueSizes(ctx, GetOutMsgQueueSizes{})
+}
+
+// GetShardOutMsgQueueSizes - same as GetOutMsgQueueSizes, but only for shards intersecting with the passed one
+func (c *APIClient) GetShardOutMsgQueueSizes(ctx context.Context, workchain int32, shard int64) (*OutMsgQueueSizes, error) {
+	return c.getOutMsgQueueSizes(ctx, GetOutMsgQueueSizes{
+		Mode:      1,
+		Workchain: workchain,
+		Shard:     shard,
+	})
+}
+
+func (c *APIClient) getOutMsgQueueSizes(ctx context.Context, req GetOutMsgQueueSizes) (*OutMsgQueueSizes, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case OutMsgQueueSizes:
+		return &t, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// GetBlockOutMsgQueueSize - gets size of outbound message queue in the state of the block
+func (c *APIClient) GetBlockOutMsgQueueSize(ctx context.Context, block *BlockIDExt) (uint64, error) {
+	req := GetBlockOutMsgQueueSize{ID: block}
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		req.Mode = 1
+		req.WantProof = &True{}
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return 0, err
+	}
+
+	switch t := resp.(type) {
+	case BlockOutMsgQueueSize:
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			queue, err := checkOutMsgQueueProof(t.Proof, block)
+			if err != nil {
+				return 0, err
+			}
+
+			if queue.Extra == nil || queue.Extra.OutQueueSize == nil {
+				return 0, fmt.Errorf("queue size is not stored in state")
+			}
+
+			if *queue.Extra.OutQueueSize != t.Size {
+				return 0, fmt.Errorf("queue size not matches proof")
+			}
+		}
+		return t.Size, nil
+	case LSError:
+		return 0, t
+	}
+	return 0, errUnexpectedResponse(resp)
+}
+
+// GetDispatchQueueInfo - gets accounts with deferred messages in the state of the block, sorted by address.
+// afterAddr can be nil to start from the beginning. Returns list and flag which is true when there are no more accounts.
+func (c *APIClient) GetDispatchQueueInfo(ctx context.Context, block *BlockIDExt, afterAddr []byte, maxAccounts int32) ([]AccountDispatchQueueInfo, bool, error) {
+	req := GetDispatchQueueInfo{
+		ID:          block,
+		MaxAccounts: maxAccounts,
+	}
+	if afterAddr != nil {
+		req.Mode |= 1 << 1
+		req.AfterAddr = afterAddr
+	}
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		req.Mode |= 1
+		req.WantProof = &True{}
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case DispatchQueueInfo:
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			queue, err := checkOutMsgQueueProof(t.Proof, block)
+			if err != nil {
+				return nil, false, err
+			}
+
+			for _, acc := range t.AccountDispatchQueues {
+				if afterAddr != nil && bytes.Compare(acc.Addr, afterAddr) <= 0 {
+					return nil, false, fmt.Errorf("account %x is not after requested address", acc.Addr)
+				}
+
+				dq, err := loadAccountDispatchQueue(queue, acc.Addr)
+				if err != nil {
+					return nil, false, err
+				}
+
+				if dq.Count != acc.Size {
+					return nil, false, fmt.Errorf("incorrect dispatch queue size of %x", acc.Addr)
+				}
+			}
+		}
+		return t.AccountDispatchQueues, t.Complete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
+// GetDispatchQueueMessages - gets deferred messages of account in the state of the block, starting after afterLT.
+// When oneAccount is false, messages of next accounts are also returned until maxMessages.
+// Returns list and flag which is true when there are no more messages.
+func (c *APIClient) GetDispatchQueueMessages(ctx context.Context, block *BlockIDExt, addr *address.Address, afterLT uint64, maxMessages int32, oneAccount bool) ([]DispatchQueueMessage, bool, error) {
+	req := GetDispatchQueueMessages{
+		ID:          block,
+		Addr:        addr.Data(),
+		AfterLT:     afterLT,
+		MaxMessages: maxMessages,
+	}
+	if oneAccount {
+		req.Mode |= 1 << 1
+		req.OneAccount = &True{}
+	}
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		req.Mode |= 1
+		req.WantProof = &True{}
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case DispatchQueueMessages:
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			queue, err := checkOutMsgQueueProof(t.Proof, block)
+			if err != nil {
+				return nil, false, err
+			}
+
+			for _, msg := range t.Messages {
+				if oneAccount && !bytes.Equal(msg.Addr, addr.Data()) {
+					return nil, false, fmt.Errorf("message of another account %x", msg.Addr)
+				}
+
+				dq, err := loadAccountDispatchQueue(queue, msg.Addr)
+				if err != nil {
+					return nil, false, err
+				}
+
+				if err = checkDispatchMessage(dq, &msg); err != nil {
+					return nil, false, fmt.Errorf("incorrect message %x: %w", msg.Hash, err)
+				}
+			}
+		}
+		return t.Messages, t.Complete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
+// checkOutMsgQueueProof - checks block and state proof, returns outbound queue info of the block state
+func checkOutMsgQueueProof(proof []byte, block *BlockIDExt) (*tlb.OutMsgQueueInfo, error) {
+	if len(proof) == 0 {
+		return nil, fmt.Errorf("no proof passed by ls")
+	}
+
+	roots, err := cell.FromBOCMultiRoot(proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proof: %w", err)
+	}
+
+	state, err := CheckBlockShardStateProof(roots, block.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check state proof: %w", err)
+	}
+
+	var queue tlb.OutMsgQueueInfo
+	if err = tlb.LoadFromCellAsProof(&queue, state.OutMsgQueueInfo.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to load out msg queue info: %w", err)
+	}
+	return &queue, nil
+}
+
+func loadAccountDispatchQueue(queue *tlb.OutMsgQueueInfo, addr []byte) (*tlb.AccountDispatchQueue, error) {
+	if queue.Extra == nil {
+		return nil, fmt.Errorf("no dispatch queue in state")
+	}
+
+	val, err := queue.Extra.DispatchQueue.LoadValue(cell.BeginCell().MustStoreSlice(addr, 256).EndCell())
+	if err != nil {
+		return nil, fmt.Errorf("account %x is not found in dispatch queue: %w", addr, err)
+	}
+
+	// augmented extra
+	if _, err = val.LoadUInt(64); err != nil {
+		return nil, fmt.Errorf("failed to load dispatch queue extra: %w", err)
+	}
+
+	var dq tlb.AccountDispatchQueue
+	if err = tlb.LoadFromCellAsProof(&dq, val); err != nil {
+		return nil, fmt.Errorf("failed to load account dispatch queue: %w", err)
+	}
+	return &dq, nil
+}
+
+func checkDispatchMessage(dq *tlb.AccountDispatchQueue, msg *DispatchQueueMessage) error {
+	val, err := dq.Messages.LoadValueByIntKey(new(big.Int).SetUint64(msg.LT))
+	if err != nil {
+		return fmt.Errorf("message is not found in dispatch queue: %w", err)
+	}
+
+	// enqueued_lt:uint64 out_msg:^MsgEnvelope
+	if _, err = val.LoadUInt(64); err != nil {
+		return fmt.Errorf("failed to load enqueued lt: %w", err)
+	}
+
+	envelope, err := val.LoadRef()
+	if err != nil {
+		return fmt.Errorf("failed to load message envelope: %w", err)
+	}
+
+	m, err := envelope.LoadRefCell()
+	if err != nil {
+		return fmt.Errorf("failed to load message: %w", err)
+	}
+
+	if !bytes.Equal(m.Hash(0), msg.Hash) {
+		return fmt.Errorf("incorrect message hash")
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package shard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ErrNotFound - there is no shard which contains account, usually workchain is unknown
+var ErrNotFound = errors.New("shard for account not found")
+
+type TonApi interface {
+	LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error)
+	GetBlockShardsInfo(ctx context.Context, master *ton.BlockIDExt) ([]*ton.BlockIDExt, error)
+}
+
+// FindAccountShard - returns block of the shard which contains account, masterchain accounts are not in shards list
+func FindAccountShard(shards []*ton.BlockIDExt, addr *address.Address) (*ton.BlockIDExt, error) {
+	for _, s := range shards {
+		if FromBlock(s).Contains(addr) {
+			return s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindInShardHashes - same as FindAccountShard, but searches in ShardHashes of the masterchain block or state,
+// pruned branches of the proof are skipped.
+func FindInShardHashes(shardHashes *cell.Dictionary, addr *address.Address) (*ton.BlockIDExt, error) {
+	shards, err := ton.LoadShardsFromHashes(shardHashes, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shards: %w", err)
+	}
+	return FindAccountShard(shards, addr)
+}
+
+// FindShard - gets shards of the masterchain block and returns block of the shard which contains account
+func FindShard(ctx context.Context, api TonApi, master *ton.BlockIDExt, addr *address.Address) (*ton.BlockIDExt, error) {
+	shards, err := api.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shards of %d master block: %w", master.SeqNo, err)
+	}
+	return FindAccountShard(shards, addr)
+}
+
+// ChangeType - type of the shards topology change
+type ChangeType int
+
+const (
+	ChangeSplit ChangeType = iota + 1
+	ChangeMerge
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeSplit:
+		return "split"
+	case ChangeMerge:
+		return "merge"
+	}
+	return "unknown"
+}
+
+// Change - shards topology change between 2 masterchain blocks.
+// For split From has single shard and To has its descendants, for merge it is the opposite.
+type Change struct {
+	Type ChangeType
+	From []ID
+	To   []ID
+}
+
+// Router - routes accounts to the current shards, shards are updated from masterchain blocks
+type Router struct {
+	mx     sync.RWMutex
+	shards map[ID]*ton.BlockIDExt
+}
+
+func NewRouter() *Router {
+	return &Router{
+		shards: map[ID]*ton.BlockIDExt{},
+	}
+}
+
+// Update - replaces shards with the new list and returns topology changes.
+// First update returns no changes, because there is nothing to compare with.
+func (r *Router) Update(shards []*ton.BlockIDExt) []*Change {
+	next := make(map[ID]*ton.BlockIDExt, len(shards))
+	for _, s := range shards {
+		next[FromBlock(s)] = s
+	}
+
+	r.mx.Lock()
+	prev := r.shards
+	r.shards = next
+	r.mx.Unlock()
+
+	if len(prev) == 0 {
+		return nil
+	}
+	return diffShards(prev, next)
+}
+
+// Route - returns the latest known block of the shard which contains account
+func (r *Router) Route(addr *address.Address) (*ton.BlockIDExt, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	for id, s := range r.shards {
+		if id.Contains(addr) {
+			return s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Shards - returns the latest known shards blocks ordered by workchain and shard
+func (r *Router) Shards() []*ton.BlockIDExt {
+	r.mx.RLock()
+	list := make([]*ton.BlockIDExt, 0, len(r.shards))
+	for _, s := range r.shards {
+		list = append(list, s)
+	}
+	r.mx.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Workchain != list[j].Workchain {
+			return list[i].Workchain < list[j].Workchain
+		}
+		return uint64(list[i].Shard) < uint64(list[j].Shard)
+	})
+	return list
+}
+
+// diffShards - finds splits and merges, shards which were not changed and new workchains are skipped
+func diffShards(prev, next map[ID]*ton.BlockIDExt) []*Change {
+	splits := map[ID]*Change{}
+	merges := map[ID]*Change{}
+
+	for id := range next {
+		if _, ok := prev[id]; ok {
+			continue
+		}
+
+		for old := range prev {
+			switch {
+			case old.IsAncestorOf(id):
+				ch := splits[old]
+				if ch == nil {
+					ch = &Change{Type: ChangeSplit, From: []ID{old}}
+					splits[old] = ch
+				}
+				ch.To = append(ch.To, id)
+			case id.IsAncestorOf(old):
+				ch := merges[id]
+				if ch == nil {
+					ch = &Change{Type: ChangeMerge, To: []ID{id}}
+					merges[id] = ch
+				}
+				ch.From = append(ch.From, old)
+			}
+		}
+	}
+
+	var changes []*Change
+	for _, m := range []map[ID]*Change{splits, merges} {
+		for _, ch := range m {
+			sortIDs(ch.From)
+			sortIDs(ch.To)
+			changes = append(changes, ch)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].From[0], changes[j].From[0]
+		if a.Workchain != b.Workchain {
+			return a.Workchain < b.Workchain
+		}
+		return a.Shard < b.Shard
+	})
+	return changes
+}
+
+func sortIDs(ids []ID) {
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Workchain != ids[j].Workchain {
+			return ids[i].Workchain < ids[j].Workchain
+		}
+		return ids[i].Shard < ids[j].Shard
+	})
+}
+
+// TrackChanges - walks masterchain blocks from fromSeqno to toSeqno inclusive and calls fn
+// for every block where shards topology was changed compared to the previous one.
+func TrackChanges(ctx context.Context, api TonApi, fromSeqno, toSeqno uint32, fn func(master *ton.BlockIDExt, changes []*Change) error) error {
+	if fromSeqno > toSeqno {
+		return fmt.Errorf("from seqno %d is greater than to seqno %d", fromSeqno, toSeqno)
+	}
+
+	r := NewRouter()
+	for seqno := fromSeqno; ; seqno++ {
+		master, err := api.LookupBlock(ctx, -1, ID{Workchain: -1, Shard: Full}.BlockShard(), seqno)
+		if err != nil {
+			return fmt.Errorf("failed to lookup master block %d: %w", seqno, err)
+		}
+
+		shards, err := api.GetBlockShardsInfo(ctx, master)
+		if err != nil {
+			return fmt.Errorf("failed to get shards of %d master block: %w", seqno, err)
+		}
+
+		if changes := r.Update(shards); len(changes) > 0 {
+			if err = fn(master, changes); err != nil {
+				return err
+			}
+		}
+
+		if seqno == toSeqno {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package shard
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// Full - shard id which covers the whole workchain
+const Full uint64 = 1 << 63
+
+// MaxPrefixLen - max depth of the shard split
+const MaxPrefixLen = 60
+
+// ID - shard of the workchain. Shard is encoded the same way as in the node:
+// prefix bits, then a single 1 bit tag, then zeroes, so 0x8000000000000000 is the whole workchain,
+// 0x4000000000000000 and 0xc000000000000000 are its left and right children.
+type ID struct {
+	Workchain int32
+	Shard     uint64
+}
+
+// FromBlock - shard of the block
+func FromBlock(block *ton.BlockIDExt) ID {
+	return ID{Workchain: block.Workchain, Shard: uint64(block.Shard)}
+}
+
+// FromIdent - shard from the block header ident
+func FromIdent(si tlb.ShardIdent) ID {
+	wc, shard := tlb.ConvertShardIdentToShard(si)
+	return ID{Workchain: wc, Shard: shard}
+}
+
+// ForAccount - shard with prefixLen bits which contains account
+func ForAccount(addr *address.Address, prefixLen uint8) ID {
+	if prefixLen > MaxPrefixLen {
+		prefixLen = MaxPrefixLen
+	}
+
+	return ID{
+		Workchain: addr.Workchain(),
+		Shard:     tlb.ShardFromPrefix(accountPrefix(addr), prefixLen),
+	}
+}
+
+// accountPrefix - first 64 bits of the account id
+func accountPrefix(addr *address.Address) uint64 {
+	data := addr.Data()
+	if len(data) < 8 {
+		var buf [8]byte
+		copy(buf[:], data)
+		return binary.BigEndian.Uint64(buf[:])
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// IsValid - checks that shard has the tag bit and is not deeper than MaxPrefixLen
+func (s ID) IsValid() bool {
+	return s.Shard != 0 && bits.TrailingZeros64(s.Shard) >= 63-MaxPrefixLen
+}
+
+// PrefixLen - number of the prefix bits, 0 for the whole workchain
+func (s ID) PrefixLen() uint8 {
+	return uint8(63 - bits.TrailingZeros64(s.Shard))
+}
+
+// BlockShard - shard in the form used in BlockIDExt and liteserver queries
+func (s ID) BlockShard() int64 {
+	return int64(s.Shard)
+}
+
+// IsFull - true when shard is the whole workchain
+func (s ID) IsFull() bool {
+	return s.Shard == Full
+}
+
+// Parent - shard which was split into this one, the whole workchain is returned as is
+func (s ID) Parent() ID {
+	if s.IsFull() {
+		return s
+	}
+
+	return ID{Workchain: s.Workchain, Shard: tlb.ShardParent(s.Shard)}
+}
+
+// Child - left or right child of the shard after split
+func (s ID) Child(left bool) ID {
+	return ID{Workchain: s.Workchain, Shard: tlb.ShardChild(s.Shard, left)}
+}
+
+// Contains - checks that account belongs to the shard
+func (s ID) Contains(addr *address.Address) bool {
+	if addr.Workchain() != s.Workchain {
+		return false
+	}
+	return tlb.ShardContains(s.Shard, accountPrefix(addr))
+}
+
+// IsAncestorOf - checks that shard is the same as other or contains it, so other was split from it
+func (s ID) IsAncestorOf(other ID) bool {
+	if s.Workchain != other.Workchain {
+		return false
+	}
+
+	return tlb.ShardIsAncestor(s.Shard, other.Shard)
+}
+
+// IsDescendantOf - checks that shard is the same as other or is contained in it
+func (s ID) IsDescendantOf(other ID) bool {
+	return other.IsAncestorOf(s)
+}
+
+// Intersects - checks that one shard is an ancestor of another
+func (s ID) Intersects(other ID) bool {
+	return s.IsAncestorOf(other) || other.IsAncestorOf(s)
+}
+
+func (s ID) String() string {
+	return fmt.Sprintf("%d:%016x", s.Workchain, s.Shard)
+}
@@ -0,0 +1,242 @@
+package shard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/liteservertest"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestID_Math(t *testing.T) {
+	addr := address.MustParseRawAddr("0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+
+	full := ID{Workchain: 0, Shard: Full}
+	if !full.IsFull() || full.PrefixLen() != 0 || !full.Contains(addr) || full.Parent() != full {
+		t.Fatal("incorrect full shard")
+	}
+
+	left, right := full.Child(true), full.Child(false)
+	if left.Shard != 0x4000000000000000 || right.Shard != 0xc000000000000000 {
+		t.Fatal("incorrect children", left, right)
+	}
+	if left.Parent() != full || right.Parent() != full || left.PrefixLen() != 1 {
+		t.Fatal("incorrect parent")
+	}
+	if left.Contains(addr) || !right.Contains(addr) {
+		t.Fatal("account should be in the right shard")
+	}
+
+	for _, tt := range []struct {
+		bits  uint8
+		shard uint64
+	}{
+		{0, 0x8000000000000000},
+		{1, 0xc000000000000000},
+		{2, 0xa000000000000000},
+		{4, 0x8800000000000000},
+		{8, 0x8380000000000000},
+		{60, 0x83dfd552e63729b8},
+	} {
+		id := ForAccount(addr, tt.bits)
+		if id.Shard != tt.shard || id.PrefixLen() != tt.bits || !id.IsValid() {
+			t.Fatalf("incorrect shard for %d bits: %s", tt.bits, id)
+		}
+		if !id.Contains(addr) || !full.IsAncestorOf(id) || !id.IsDescendantOf(full) {
+			t.Fatal("shard should contain account", id)
+		}
+		if tt.bits > 0 && (!id.Parent().IsAncestorOf(id) || id.IsAncestorOf(id.Parent())) {
+			t.Fatal("incorrect ancestor check", id)
+		}
+	}
+
+	deep := ForAccount(addr, 3)
+	if !right.Intersects(deep) || left.Intersects(deep) || !deep.Intersects(right) {
+		t.Fatal("incorrect intersection")
+	}
+
+	if (ID{Workchain: -1, Shard: Full}).Contains(addr) || (ID{Workchain: -1, Shard: Full}).IsAncestorOf(deep) {
+		t.Fatal("other workchain should not contain account")
+	}
+
+	if (ID{Shard: 0}).IsValid() || (ID{Shard: 1}).IsValid() {
+		t.Fatal("shard should be invalid")
+	}
+
+	ident := FromIdent(tlb.ShardIdent{PrefixBits: 2, WorkchainID: 0, ShardPrefix: 0x8000000000000000})
+	if ident != ForAccount(addr, 2) {
+		t.Fatal("incorrect shard from ident", ident)
+	}
+}
+
+func block(wc int32, shard uint64, seqno uint32) *ton.BlockIDExt {
+	return &ton.BlockIDExt{Workchain: wc, Shard: int64(shard), SeqNo: seqno}
+}
+
+func TestRouter_Update(t *testing.T) {
+	addr := address.MustParseRawAddr("0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+
+	full := ID{Workchain: 0, Shard: Full}
+	left, right := full.Child(true), full.Child(false)
+
+	r := NewRouter()
+	if _, err := r.Route(addr); !errors.Is(err, ErrNotFound) {
+		t.Fatal("should be not found")
+	}
+
+	if changes := r.Update([]*ton.BlockIDExt{block(0, Full, 10)}); changes != nil {
+		t.Fatal("first update should have no changes")
+	}
+
+	if changes := r.Update([]*ton.BlockIDExt{block(0, Full, 11)}); len(changes) != 0 {
+		t.Fatal("no changes expected")
+	}
+
+	changes := r.Update([]*ton.BlockIDExt{block(0, left.Shard, 12), block(0, right.Shard, 12)})
+	exp := []*Change{{Type: ChangeSplit, From: []ID{full}, To: []ID{left, right}}}
+	if !reflect.DeepEqual(changes, exp) {
+		t.Fatal("incorrect split", changes[0])
+	}
+
+	b, err := r.Route(addr)
+	if err != nil || uint64(b.Shard) != right.Shard || b.SeqNo != 12 {
+		t.Fatal("incorrect route", b, err)
+	}
+
+	rl, rr := right.Child(true), right.Child(false)
+	changes = r.Update([]*ton.BlockIDExt{block(0, left.Shard, 13), block(0, rl.Shard, 13), block(0, rr.Shard, 13)})
+	exp = []*Change{{Type: ChangeSplit, From: []ID{right}, To: []ID{rl, rr}}}
+	if !reflect.DeepEqual(changes, exp) {
+		t.Fatal("incorrect second split", changes)
+	}
+
+	// left merged with nothing, right children merged back, and a new workchain appeared
+	changes = r.Update([]*ton.BlockIDExt{block(0, left.Shard, 14), block(0, right.Shard, 14), block(1, Full, 1)})
+	exp = []*Change{{Type: ChangeMerge, From: []ID{rl, rr}, To: []ID{right}}}
+	if !reflect.DeepEqual(changes, exp) {
+		t.Fatal("incorrect merge", changes)
+	}
+
+	if list := r.Shards(); len(list) != 3 || uint64(list[0].Shard) != left.Shard || list[2].Workchain != 1 {
+		t.Fatal("incorrect shards list")
+	}
+}
+
+type mockAPI struct {
+	shards map[uint32][]*ton.BlockIDExt
+}
+
+func (m *mockAPI) LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error) {
+	if _, ok := m.shards[seqno]; !ok {
+		return nil, ton.ErrBlockNotFound
+	}
+	return &ton.BlockIDExt{Workchain: workchain, Shard: shard, SeqNo: seqno}, nil
+}
+
+func (m *mockAPI) GetBlockShardsInfo(ctx context.Context, master *ton.BlockIDExt) ([]*ton.BlockIDExt, error) {
+	return m.shards[master.SeqNo], nil
+}
+
+func TestTrackChanges(t *testing.T) {
+	full := ID{Workchain: 0, Shard: Full}
+	left, right := full.Child(true), full.Child(false)
+
+	api := &mockAPI{shards: map[uint32][]*ton.BlockIDExt{
+		1: {block(0, Full, 1)},
+		2: {block(0, Full, 2)},
+		3: {block(0, left.Shard, 3), block(0, right.Shard, 3)},
+		4: {block(0, left.Shard, 4), block(0, right.Shard, 4)},
+		5: {block(0, Full, 5)},
+	}}
+
+	var got []string
+	err := TrackChanges(context.Background(), api, 1, 5, func(master *ton.BlockIDExt, changes []*Change) error {
+		for _, ch := range changes {
+			got = append(got, fmt.Sprintf("%d %s %v %v", master.SeqNo, ch.Type, ch.From, ch.To))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		"3 split [0:8000000000000000] [0:4000000000000000 0:c000000000000000]",
+		"5 merge [0:4000000000000000 0:c000000000000000] [0:8000000000000000]",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatal("incorrect changes", got)
+	}
+
+	if err = TrackChanges(context.Background(), api, 1, 6, func(*ton.BlockIDExt, []*Change) error { return nil }); !errors.Is(err, ton.ErrBlockNotFound) {
+		t.Fatal("should fail on unknown block", err)
+	}
+}
+
+func TestFindShard(t *testing.T) {
+	chain := liteservertest.NewChain()
+
+	addr := address.MustParseRawAddr("0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	chain.SetAccount(liteservertest.Account{
+		Address: addr,
+		Balance: tlb.MustFromTON("1"),
+		Code:    cell.BeginCell().EndCell(),
+		Data:    cell.BeginCell().EndCell(),
+	})
+	if _, err := chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	srv := liteservertest.NewServer(chain)
+	if err := srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	defer pool.Stop()
+	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+
+	master, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shardBlock, err := FindShard(ctx, api, master, addr)
+	if err != nil {
+		t.Fatal("find err:", err)
+	}
+	if shardBlock.Workchain != 0 || uint64(shardBlock.Shard) != Full || shardBlock.SeqNo != master.SeqNo {
+		t.Fatal("incorrect shard block", shardBlock)
+	}
+
+	if _, err = FindShard(ctx, api, master, address.MustParseRawAddr("7:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")); !errors.Is(err, ErrNotFound) {
+		t.Fatal("should be not found", err)
+	}
+
+	data, err := api.GetBlockData(ctx, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromHashes, err := FindInShardHashes(data.Extra.Custom.ShardHashes, addr)
+	if err != nil {
+		t.Fatal("find in hashes err:", err)
+	}
+	if !fromHashes.Equals(shardBlock) {
+		t.Fatal("incorrect shard block from hashes")
+	}
+}
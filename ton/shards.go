@@ -0,0 +1,96 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// GetShardInfo - gets the shard block at given masterchain state, which shard contains (or equals, when exact) passed shard
+func (c *APIClient) GetShardInfo(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, exact bool) (*BlockIDExt, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetShardInfo{
+		ID:        master,
+		Workchain: workchain,
+		Shard:     shard,
+		Exact:     exact,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ShardInfo:
+		if t.ShardBlock == nil || t.ShardBlock.Workchain != workchain {
+			return nil, fmt.Errorf("incorrect shard block in response")
+		}
+
+		if exact && t.ShardBlock.Shard != shard {
+			return nil, fmt.Errorf("shard not matches requested")
+		} else if !shardIntersects(t.ShardBlock.Shard, shard) {
+			return nil, fmt.Errorf("shard not contains requested")
+		}
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			stateExtra, err := CheckShardMcStateExtraProof(master, t.ShardProof)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check proof for mc state extra: %w", err)
+			}
+
+			shards, err := LoadShardsFromHashes(stateExtra.ShardHashes, true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load shard hashes: %w", err)
+			}
+
+			found := false
+			for _, s := range shards {
+				if s.Equals(t.ShardBlock) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("shard block not found in proof")
+			}
+		}
+		return t.ShardBlock, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// GetShardBlockProof - gets chain of links from masterchain block to the shard block.
+// Returned proof is checked, its MasterchainID can be used to verify block relation to the trusted masterchain block.
+func (c *APIClient) GetShardBlockProof(ctx context.Context, block *BlockIDExt) (*ShardBlockProof, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetShardBlockProof{ID: block}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ShardBlockProof:
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			if err = CheckShardBlockProof(block, &t); err != nil {
+				return nil, fmt.Errorf("incorrect shard block proof: %w", err)
+			}
+		}
+		return &t, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// shardIntersects - true when one of shards is an ancestor of the other (or they are equal)
+func shardIntersects(a, b int64) bool {
+	return shardIsAncestor(uint64(a), uint64(b)) || shardIsAncestor(uint64(b), uint64(a))
+}
+
+func shardIsAncestor(parent, child uint64) bool {
+	x := parent & (^parent + 1)
+	y := child & (^child + 1)
+	return x >= y && (parent^child)&((^x+1)<<1) == 0
+}
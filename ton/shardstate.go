@@ -0,0 +1,252 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// DefaultMaxShardStateSize - default limit of the state BoC size which GetShardState decodes
+const DefaultMaxShardStateSize = 64 << 20
+
+// ErrShardStateTooBig - state is bigger than the limit set by SetMaxShardStateSize
+var ErrShardStateTooBig = errors.New("shard state is too big")
+
+// ShardState - full state of the shard (or masterchain) at some block.
+// Accounts and other structures are parsed lazily, when requested,
+// so iteration over accounts does not allocate the whole list.
+type ShardState struct {
+	Block *BlockIDExt
+	State *tlb.ShardStateUnsplit
+}
+
+// ShardStateAccount - account record from the shard state accounts dictionary
+type ShardStateAccount struct {
+	Address    *address.Address
+	Balance    tlb.CurrencyCollection
+	LastTxLT   uint64
+	LastTxHash []byte
+
+	state *cell.Cell
+}
+
+// GetShardState - downloads and parses full state of the block.
+// Downloaded data hash is checked against block id, and when proof check policy is not unsafe,
+// state root is also checked against the state update of the block data.
+// Block id itself is not checked, use VerifyBlockID if it is taken from untrusted source.
+//
+// Memory used by the decoded state is bounded by SetMaxShardStateSize,
+// ErrShardStateTooBig is returned for the bigger states before they are decoded.
+func (c *APIClient) GetShardState(ctx context.Context, block *BlockIDExt) (*ShardState, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetState{ID: block}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case BlockState:
+		if !t.ID.Equals(block) {
+			return nil, fmt.Errorf("response with incorrect block")
+		}
+
+		if limit := c.root().shardStateSizeLimit(); int64(len(t.Data)) > limit {
+			return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrShardStateTooBig, len(t.Data), limit)
+		}
+
+		fileHash := sha256.Sum256(t.Data)
+		if !bytes.Equal(fileHash[:], t.FileHash) {
+			return nil, fmt.Errorf("incorrect state file hash")
+		}
+
+		root, err := cell.FromBOC(t.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse state boc: %w", err)
+		}
+
+		if !bytes.Equal(root.Hash(), t.RootHash) {
+			return nil, fmt.Errorf("incorrect state root hash")
+		}
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			data, err := c.GetBlockData(ctx, block)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get block data: %w", err)
+			}
+
+			if data.StateUpdate == nil || data.StateUpdate.RefsNum() < 2 {
+				return nil, fmt.Errorf("no state update in block")
+			}
+
+			newState, err := data.StateUpdate.PeekRef(1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load new state hash from block: %w", err)
+			}
+
+			if !bytes.Equal(newState.Hash(0), t.RootHash) {
+				return nil, fmt.Errorf("state root hash not match block state update")
+			}
+		}
+
+		var state tlb.ShardStateUnsplit
+		if err = tlb.LoadFromCell(&state, root.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse shard state: %w", err)
+		}
+
+		return &ShardState{
+			Block: block,
+			State: &state,
+		}, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// SetMaxShardStateSize - sets limit of the state BoC size for GetShardState,
+// decoded cells take several times more memory than the BoC. Default is DefaultMaxShardStateSize.
+func (c *APIClient) SetMaxShardStateSize(size int64) {
+	atomic.StoreInt64(&c.root().maxShardStateSize, size)
+}
+
+func (c *APIClient) shardStateSizeLimit() int64 {
+	if limit := atomic.LoadInt64(&c.maxShardStateSize); limit > 0 {
+		return limit
+	}
+	return DefaultMaxShardStateSize
+}
+
+// GetAccount - returns account from the state, not active account is returned when it is not exists.
+// Error is returned when account is not from the shard of the state.
+func (s *ShardState) GetAccount(addr *address.Address) (*tlb.Account, error) {
+	if addr.Workchain() != s.Block.Workchain {
+		return nil, fmt.Errorf("account is from another workchain")
+	}
+
+	if !tlb.ShardContains(uint64(s.Block.Shard), accountPrefix(addr)) {
+		return nil, fmt.Errorf("account is from another shard")
+	}
+
+	if s.State.Accounts.ShardAccounts == nil {
+		return &tlb.Account{IsActive: false}, nil
+	}
+
+	val := s.State.Accounts.ShardAccounts.Get(cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell())
+	if val == nil {
+		return &tlb.Account{IsActive: false}, nil
+	}
+
+	acc, err := s.parseAccount(addr, val.BeginParse())
+	if err != nil {
+		return nil, err
+	}
+	return acc.Load()
+}
+
+// ForEachAccount - calls fn for every account in the state, ordered by address.
+// Account states are not parsed, use Load of the passed account when it is needed.
+// Iteration stops on the first error returned by fn, and this error is returned.
+func (s *ShardState) ForEachAccount(fn func(acc *ShardStateAccount) error) error {
+	if s.State.Accounts.ShardAccounts == nil {
+		return nil
+	}
+
+	return s.State.Accounts.ShardAccounts.ForEach(func(key, value *cell.Slice) error {
+		data, err := key.LoadSlice(256)
+		if err != nil {
+			return fmt.Errorf("failed to load account address: %w", err)
+		}
+
+		acc, err := s.parseAccount(address.NewAddress(0, byte(s.Block.Workchain), data), value)
+		if err != nil {
+			return err
+		}
+		return fn(acc)
+	})
+}
+
+// Stats - returns state statistics, it contains total balance of the shard accounts
+func (s *ShardState) Stats() (*tlb.ShardStateStats, error) {
+	if s.State.Stats == nil {
+		return nil, fmt.Errorf("no stats in state")
+	}
+
+	var stats tlb.ShardStateStats
+	if err := tlb.LoadFromCell(&stats, s.State.Stats.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse state stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// GlobalBalance - returns total supply of the network, available only in masterchain state
+func (s *ShardState) GlobalBalance() (*tlb.CurrencyCollection, error) {
+	if s.State.McStateExtra == nil {
+		return nil, fmt.Errorf("not a masterchain state")
+	}
+
+	var extra tlb.McStateExtra
+	if err := tlb.LoadFromCell(&extra, s.State.McStateExtra.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse masterchain state extra: %w", err)
+	}
+	return &extra.GlobalBalance, nil
+}
+
+// accountPrefix - first 64 bits of the account id
+func accountPrefix(addr *address.Address) uint64 {
+	var buf [8]byte
+	copy(buf[:], addr.Data())
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func (s *ShardState) parseAccount(addr *address.Address, loader *cell.Slice) (*ShardStateAccount, error) {
+	var balanceInfo tlb.DepthBalanceInfo
+	if err := tlb.LoadFromCell(&balanceInfo, loader); err != nil {
+		return nil, fmt.Errorf("failed to load DepthBalanceInfo of %s: %w", addr.String(), err)
+	}
+
+	var accInfo tlb.ShardAccount
+	if err := tlb.LoadFromCell(&accInfo, loader); err != nil {
+		return nil, fmt.Errorf("failed to load ShardAccount of %s: %w", addr.String(), err)
+	}
+
+	return &ShardStateAccount{
+		Address:    addr,
+		Balance:    balanceInfo.Currencies,
+		LastTxLT:   accInfo.LastTransLT,
+		LastTxHash: accInfo.LastTransHash,
+		state:      accInfo.Account,
+	}, nil
+}
+
+// Load - parses account state
+func (a *ShardStateAccount) Load() (*tlb.Account, error) {
+	var st tlb.AccountState
+	if err := st.LoadFromCell(a.state.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to load account state: %w", err)
+	}
+
+	if !st.IsValid {
+		return &tlb.Account{IsActive: false}, nil
+	}
+
+	acc := &tlb.Account{
+		IsActive:   true,
+		State:      &st,
+		LastTxLT:   a.LastTxLT,
+		LastTxHash: a.LastTxHash,
+	}
+	if st.Status == tlb.AccountStatusActive {
+		acc.Code = st.StateInit.Code
+		acc.Data = st.StateInit.Data
+	}
+	return acc, nil
+}
@@ -0,0 +1,396 @@
+package ton
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Stack values are mapped to struct fields in order of declaration, using `tvm` tag, for example:
+//
+//	type JettonData struct {
+//		TotalSupply *big.Int         `tvm:"int"`
+//		Mintable    bool             `tvm:"bool"`
+//		Admin       *address.Address `tvm:"addr"`
+//		Content     *cell.Cell       `tvm:"cell"`
+//		WalletCode  *cell.Cell       `tvm:"maybe cell"`
+//	}
+//
+// Supported kinds:
+//
+//	int     - integer, field can be *big.Int or any int/uint type, overflow is an error
+//	bool    - integer, not zero is true, encoded as -1 and 0
+//	coins   - integer, field should be tlb.Coins
+//	addr    - slice with address, field should be *address.Address
+//	cell    - *cell.Cell
+//	slice   - *cell.Slice
+//	builder - *cell.Builder
+//	tuple   - tuple, field should be a struct (or pointer to it) with tags, or []any to keep raw values
+//	tlb     - cell or slice parsed with tlb.LoadFromCell into field type
+//	any     - raw stack value
+//
+// Kind can be prefixed with `maybe`, then null value is allowed and leaves field zero.
+// Fields without tag are ignored, blank `_` fields consume stack value without storing it.
+// Tagged fields should be exported, otherwise error is returned.
+
+// Decode - parses result stack values into struct fields described with `tvm` tags, dst should be a pointer to struct.
+// Result can have more values than fields, extra values are ignored.
+func (r ExecutionResult) Decode(dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dst should be a pointer to struct")
+	}
+	return decodeStackStruct(r.result, rv.Elem())
+}
+
+// EncodeParams - converts struct with `tvm` tags to get method parameters,
+// result can be passed to RunGetMethod as params.
+func EncodeParams(src any) ([]any, error) {
+	rv := reflect.ValueOf(src)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("src should not be nil")
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("src should be a struct")
+	}
+	return encodeStackStruct(rv)
+}
+
+type stackField struct {
+	kind  string
+	maybe bool
+}
+
+func parseStackTag(tag string) (stackField, error) {
+	parts := strings.Fields(tag)
+	var f stackField
+	if len(parts) > 0 && parts[0] == "maybe" {
+		f.maybe = true
+		parts = parts[1:]
+	}
+
+	if len(parts) != 1 {
+		return f, fmt.Errorf("incorrect tag %q", tag)
+	}
+	f.kind = parts[0]
+	return f, nil
+}
+
+func decodeStackStruct(values []any, rv reflect.Value) error {
+	rt := rv.Type()
+
+	idx := 0
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		tag, ok := structField.Tag.Lookup("tvm")
+		if !ok {
+			continue
+		}
+
+		if !structField.IsExported() && structField.Name != "_" {
+			return fmt.Errorf("field %s is unexported", structField.Name)
+		}
+
+		f, err := parseStackTag(tag)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", structField.Name, err)
+		}
+
+		if idx >= len(values) {
+			return fmt.Errorf("no value for field %s at index %d: %w", structField.Name, idx, ErrResultIndexOutOfRange)
+		}
+		val := values[idx]
+		idx++
+
+		if val == nil && f.maybe {
+			continue
+		}
+
+		target := reflect.New(structField.Type).Elem()
+		if err = decodeStackValue(f.kind, val, target); err != nil {
+			return fmt.Errorf("failed to decode field %s at index %d: %w", structField.Name, idx-1, err)
+		}
+
+		if structField.Name != "_" {
+			rv.Field(i).Set(target)
+		}
+	}
+	return nil
+}
+
+func decodeStackValue(kind string, val any, field reflect.Value) error {
+	switch kind {
+	case "int", "bool", "coins":
+		v, ok := val.(*big.Int)
+		if !ok {
+			return fmt.Errorf("expected int, got %T: %w", val, ErrIncorrectResultType)
+		}
+		return setStackInt(kind, v, field)
+	case "addr":
+		s, ok := val.(*cell.Slice)
+		if !ok {
+			return fmt.Errorf("expected slice, got %T: %w", val, ErrIncorrectResultType)
+		}
+
+		addr, err := s.Copy().LoadAddr()
+		if err != nil {
+			return fmt.Errorf("failed to load address: %w", err)
+		}
+		return setStackField(field, addr)
+	case "cell":
+		c, ok := val.(*cell.Cell)
+		if !ok {
+			return fmt.Errorf("expected cell, got %T: %w", val, ErrIncorrectResultType)
+		}
+		return setStackField(field, c)
+	case "slice":
+		s, ok := val.(*cell.Slice)
+		if !ok {
+			return fmt.Errorf("expected slice, got %T: %w", val, ErrIncorrectResultType)
+		}
+		return setStackField(field, s)
+	case "builder":
+		b, ok := val.(*cell.Builder)
+		if !ok {
+			return fmt.Errorf("expected builder, got %T: %w", val, ErrIncorrectResultType)
+		}
+		return setStackField(field, b)
+	case "tuple":
+		t, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("expected tuple, got %T: %w", val, ErrIncorrectResultType)
+		}
+
+		if field.Type() == reflect.TypeOf([]any{}) {
+			field.Set(reflect.ValueOf(t))
+			return nil
+		}
+
+		if field.Kind() == reflect.Pointer {
+			field.Set(reflect.New(field.Type().Elem()))
+			field = field.Elem()
+		}
+
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("tuple field should be a struct or []any, not %s", field.Type())
+		}
+		return decodeStackStruct(t, field)
+	case "tlb":
+		var s *cell.Slice
+		switch v := val.(type) {
+		case *cell.Cell:
+			s = v.BeginParse()
+		case *cell.Slice:
+			s = v.Copy()
+		default:
+			return fmt.Errorf("expected cell or slice, got %T: %w", val, ErrIncorrectResultType)
+		}
+
+		ptr := field.Addr()
+		if field.Kind() == reflect.Pointer {
+			field.Set(reflect.New(field.Type().Elem()))
+			ptr = field
+		}
+
+		if err := tlb.LoadFromCell(ptr.Interface(), s); err != nil {
+			return fmt.Errorf("failed to parse tlb: %w", err)
+		}
+		return nil
+	case "any":
+		if val == nil {
+			return nil
+		}
+		return setStackField(field, val)
+	}
+	return fmt.Errorf("unknown kind %q", kind)
+}
+
+func setStackInt(kind string, v *big.Int, field reflect.Value) error {
+	switch kind {
+	case "bool":
+		if field.Kind() != reflect.Bool {
+			return fmt.Errorf("bool field should be bool, not %s", field.Type())
+		}
+		field.SetBool(v.Sign() != 0)
+		return nil
+	case "coins":
+		if field.Type() != reflect.TypeOf(tlb.Coins{}) {
+			return fmt.Errorf("coins field should be tlb.Coins, not %s", field.Type())
+		}
+		if v.Sign() < 0 {
+			return fmt.Errorf("negative coins value %s", v.String())
+		}
+		field.Set(reflect.ValueOf(tlb.FromNanoTON(v)))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !v.IsInt64() || field.OverflowInt(v.Int64()) {
+			return fmt.Errorf("value %s overflows %s", v.String(), field.Type())
+		}
+		field.SetInt(v.Int64())
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !v.IsUint64() || field.OverflowUint(v.Uint64()) {
+			return fmt.Errorf("value %s overflows %s", v.String(), field.Type())
+		}
+		field.SetUint(v.Uint64())
+		return nil
+	}
+	return setStackField(field, new(big.Int).Set(v))
+}
+
+func setStackField(field reflect.Value, val any) error {
+	v := reflect.ValueOf(val)
+	if !v.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("value of type %s is not assignable to %s", v.Type(), field.Type())
+	}
+	field.Set(v)
+	return nil
+}
+
+func encodeStackStruct(rv reflect.Value) ([]any, error) {
+	rt := rv.Type()
+
+	var values []any
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		tag, ok := structField.Tag.Lookup("tvm")
+		if !ok {
+			continue
+		}
+
+		if structField.Name == "_" {
+			return nil, fmt.Errorf("blank fields cannot be encoded")
+		}
+
+		if !structField.IsExported() {
+			return nil, fmt.Errorf("field %s is unexported", structField.Name)
+		}
+
+		f, err := parseStackTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", structField.Name, err)
+		}
+
+		field := rv.Field(i)
+		if f.maybe && isNilStackField(field) {
+			values = append(values, nil)
+			continue
+		}
+
+		val, err := encodeStackValue(f.kind, field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode field %s: %w", structField.Name, err)
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+func isNilStackField(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return field.IsNil()
+	}
+	return false
+}
+
+func encodeStackValue(kind string, field reflect.Value) (any, error) {
+	if isNilStackField(field) && kind != "any" {
+		return nil, fmt.Errorf("value is nil, use maybe to pass null")
+	}
+
+	switch kind {
+	case "int":
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return big.NewInt(field.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return new(big.Int).SetUint64(field.Uint()), nil
+		}
+
+		v, ok := field.Interface().(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("int field should be *big.Int or integer, not %s", field.Type())
+		}
+		return v, nil
+	case "bool":
+		if field.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("bool field should be bool, not %s", field.Type())
+		}
+		if field.Bool() {
+			return big.NewInt(-1), nil
+		}
+		return big.NewInt(0), nil
+	case "coins":
+		v, ok := field.Interface().(tlb.Coins)
+		if !ok {
+			return nil, fmt.Errorf("coins field should be tlb.Coins, not %s", field.Type())
+		}
+		return v.Nano(), nil
+	case "addr":
+		v, ok := field.Interface().(*address.Address)
+		if !ok {
+			return nil, fmt.Errorf("addr field should be *address.Address, not %s", field.Type())
+		}
+		return cell.BeginCell().MustStoreAddr(v).EndCell().BeginParse(), nil
+	case "cell":
+		v, ok := field.Interface().(*cell.Cell)
+		if !ok {
+			return nil, fmt.Errorf("cell field should be *cell.Cell, not %s", field.Type())
+		}
+		return v, nil
+	case "slice":
+		v, ok := field.Interface().(*cell.Slice)
+		if !ok {
+			return nil, fmt.Errorf("slice field should be *cell.Slice, not %s", field.Type())
+		}
+		return v, nil
+	case "builder":
+		v, ok := field.Interface().(*cell.Builder)
+		if !ok {
+			return nil, fmt.Errorf("builder field should be *cell.Builder, not %s", field.Type())
+		}
+		return v, nil
+	case "tuple":
+		if v, ok := field.Interface().([]any); ok {
+			return v, nil
+		}
+
+		if field.Kind() == reflect.Pointer {
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("tuple field should be a struct or []any, not %s", field.Type())
+		}
+
+		t, err := encodeStackStruct(field)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			t = []any{}
+		}
+		return t, nil
+	case "tlb":
+		c, err := tlb.ToCell(field.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize tlb: %w", err)
+		}
+		return c, nil
+	case "any":
+		return field.Interface(), nil
+	}
+	return nil, fmt.Errorf("unknown kind %q", kind)
+}
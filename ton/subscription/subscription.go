@@ -0,0 +1,217 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Standard subscription plugin of V4 wallet:
+// https://github.com/ton-blockchain/wallet-contract/blob/main/func/simple-subscription-plugin.fc
+//
+// Plugin is deployed and installed by the wallet, then anyone can send empty external message to it
+// when payment period is passed, and plugin requests payment from the wallet and forwards it to beneficiary.
+// Code of the plugin is not embedded, compiled code should be passed to GetStateInit and BuildDeployRequest.
+
+// ErrNoSubscriptionData - subscription account is not active, it is not deployed or destroyed
+var ErrNoSubscriptionData = errors.New("subscription contract is not active")
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+	GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error)
+	SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error
+}
+
+// Data - persistent data of the subscription plugin
+type Data struct {
+	Wallet      *address.Address `tlb:"addr"`
+	Beneficiary *address.Address `tlb:"addr"`
+	// Amount - payment for each period
+	Amount tlb.Coins `tlb:"."`
+	// Period - time between payments in seconds
+	Period    uint32 `tlb:"## 32"`
+	StartTime uint32 `tlb:"## 32"`
+	// Timeout - minimal time between payment requests in seconds
+	Timeout         uint32 `tlb:"## 32"`
+	LastPaymentTime uint32 `tlb:"## 32"`
+	LastRequestTime uint32 `tlb:"## 32"`
+	FailedAttempts  uint8  `tlb:"## 8"`
+	SubscriptionID  uint32 `tlb:"## 32"`
+}
+
+// IsPaymentDue - checks that payment can be requested at the given unix time
+func (d *Data) IsPaymentDue(now uint32) bool {
+	return uint64(now) >= uint64(d.LastPaymentTime)+uint64(d.Period) &&
+		uint64(now) >= uint64(d.LastRequestTime)+uint64(d.Timeout)
+}
+
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+func NewSubscriptionClient(api TonApi, subscriptionAddr *address.Address) *Client {
+	return &Client{
+		addr: subscriptionAddr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// GetSubscriptionData - returns subscription data using get_subscription_data get method
+func (c *Client) GetSubscriptionData(ctx context.Context) (*Data, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetSubscriptionDataAtBlock(ctx, b)
+}
+
+type stackAddr struct {
+	Workchain int8     `tvm:"int"`
+	Hash      *big.Int `tvm:"int"`
+}
+
+func (a stackAddr) address() *address.Address {
+	return address.NewAddress(0, byte(a.Workchain), a.Hash.FillBytes(make([]byte, 32)))
+}
+
+func (c *Client) GetSubscriptionDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*Data, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_subscription_data")
+	if err != nil {
+		if cErr, ok := err.(ton.ContractExecError); ok && cErr.Code == ton.ErrCodeContractNotInitialized {
+			return nil, ErrNoSubscriptionData
+		}
+		return nil, fmt.Errorf("failed to run get_subscription_data method: %w", err)
+	}
+
+	var data struct {
+		Wallet          stackAddr `tvm:"tuple"`
+		Beneficiary     stackAddr `tvm:"tuple"`
+		Amount          tlb.Coins `tvm:"coins"`
+		Period          uint32    `tvm:"int"`
+		StartTime       uint32    `tvm:"int"`
+		Timeout         uint32    `tvm:"int"`
+		LastPaymentTime uint32    `tvm:"int"`
+		LastRequestTime uint32    `tvm:"int"`
+		FailedAttempts  uint8     `tvm:"int"`
+		SubscriptionID  uint32    `tvm:"int"`
+	}
+	if err = res.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode get_subscription_data result: %w", err)
+	}
+
+	if data.Wallet.Hash == nil || data.Beneficiary.Hash == nil {
+		return nil, errors.New("incorrect address in get_subscription_data result")
+	}
+
+	return &Data{
+		Wallet:          data.Wallet.address(),
+		Beneficiary:     data.Beneficiary.address(),
+		Amount:          data.Amount,
+		Period:          data.Period,
+		StartTime:       data.StartTime,
+		Timeout:         data.Timeout,
+		LastPaymentTime: data.LastPaymentTime,
+		LastRequestTime: data.LastRequestTime,
+		FailedAttempts:  data.FailedAttempts,
+		SubscriptionID:  data.SubscriptionID,
+	}, nil
+}
+
+// BuildPaymentRequest - builds external message which triggers payment request from plugin to the wallet,
+// it is accepted only when payment is due, see Data.IsPaymentDue
+func (c *Client) BuildPaymentRequest() *tlb.ExternalMessage {
+	return &tlb.ExternalMessage{
+		DstAddr: c.addr,
+		Body:    cell.BeginCell().EndCell(),
+	}
+}
+
+// RequestPayment - sends payment request, see BuildPaymentRequest
+func (c *Client) RequestPayment(ctx context.Context) error {
+	if err := c.api.SendExternalMessage(ctx, c.BuildPaymentRequest()); err != nil {
+		return fmt.Errorf("failed to send payment request: %w", err)
+	}
+	return nil
+}
+
+// BuildRemoveRequest - builds wallet request which removes plugin from the wallet, plugin is destroyed after it.
+// Should be sent using SpecV4R2.SendPluginRequest of the subscriber wallet.
+func (c *Client) BuildRemoveRequest(amount tlb.Coins, queryID uint64) wallet.V4R2RemovePlugin {
+	return wallet.V4R2RemovePlugin{
+		Plugin:  c.addr,
+		Amount:  amount,
+		QueryID: queryID,
+	}
+}
+
+// GetStateInit - builds state init of the new subscription, last payment and request times are reset
+func GetStateInit(code *cell.Cell, data Data) (*tlb.StateInit, error) {
+	if code == nil {
+		return nil, errors.New("subscription plugin code is not set")
+	}
+
+	data.LastPaymentTime = 0
+	data.LastRequestTime = 0
+	data.FailedAttempts = 0
+
+	dataCell, err := tlb.ToCell(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build subscription data: %w", err)
+	}
+
+	return &tlb.StateInit{
+		Code: code,
+		Data: dataCell,
+	}, nil
+}
+
+// BuildDeployRequest - builds wallet request which deploys subscription plugin and installs it,
+// amount is sent to plugin with deploy message and forwarded to beneficiary as the first payment.
+// Should be sent using SpecV4R2.SendPluginRequest of the subscriber wallet.
+func BuildDeployRequest(code *cell.Cell, data Data, amount tlb.Coins) (*wallet.V4R2DeployAndInstallPlugin, *address.Address, error) {
+	if data.Wallet == nil || data.Beneficiary == nil {
+		return nil, nil, errors.New("wallet and beneficiary should be set")
+	}
+
+	state, err := GetStateInit(code, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workchain := int8(data.Wallet.Workchain())
+	addr, err := wallet.PluginAddress(workchain, state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &wallet.V4R2DeployAndInstallPlugin{
+		Workchain: workchain,
+		Amount:    amount,
+		StateInit: state,
+		// deploy message looks like the response to the payment request
+		Body: cell.BeginCell().MustStoreUInt(wallet.OpPluginRequestFunds|0x80000000, 32).EndCell(),
+	}, addr, nil
+}
+
+// BuildDestroyPayload - builds body of the internal message which destroys plugin,
+// it is accepted only from beneficiary or wallet, remaining balance is sent to wallet.
+func BuildDestroyPayload(queryID uint64) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(wallet.OpPluginDestruct, 32).
+		MustStoreUInt(queryID, 64).
+		EndCell()
+}
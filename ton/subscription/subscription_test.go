@@ -0,0 +1,163 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/liteservertest"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var testCode = cell.BeginCell().MustStoreUInt(0xC0DE, 16).EndCell()
+
+func testData() Data {
+	return Data{
+		Wallet:          address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Beneficiary:     address.MustParseRawAddr("0:" + "2222222222222222222222222222222222222222222222222222222222222222"),
+		Amount:          tlb.MustFromTON("1.5"),
+		Period:          30 * 24 * 60 * 60,
+		StartTime:       1700000000,
+		Timeout:         60 * 60,
+		LastPaymentTime: 1700000000,
+		LastRequestTime: 1700000000,
+		FailedAttempts:  1,
+		SubscriptionID:  7,
+	}
+}
+
+func stackAddress(addr *address.Address) []any {
+	return []any{big.NewInt(int64(addr.Workchain())), new(big.Int).SetBytes(addr.Data())}
+}
+
+func TestClient_GetSubscriptionData(t *testing.T) {
+	chain := liteservertest.NewChain()
+
+	data := testData()
+	dataCell, err := tlb.ToCell(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subAddr := address.MustParseRawAddr("0:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	chain.SetAccount(liteservertest.Account{
+		Address: subAddr,
+		Balance: tlb.MustFromTON("0.1"),
+		Code:    testCode,
+		Data:    dataCell,
+	})
+	chain.SetGetMethod(subAddr, "get_subscription_data", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		var d Data
+		if err := tlb.LoadFromCell(&d, acc.Data.BeginParse()); err != nil {
+			return nil, 9
+		}
+		return []any{
+			stackAddress(d.Wallet), stackAddress(d.Beneficiary), d.Amount.Nano(),
+			big.NewInt(int64(d.Period)), big.NewInt(int64(d.StartTime)), big.NewInt(int64(d.Timeout)),
+			big.NewInt(int64(d.LastPaymentTime)), big.NewInt(int64(d.LastRequestTime)),
+			big.NewInt(int64(d.FailedAttempts)), big.NewInt(int64(d.SubscriptionID)),
+		}, 0
+	})
+
+	destroyedAddr := address.MustParseRawAddr("0:" + "4444444444444444444444444444444444444444444444444444444444444444")
+	chain.SetAccount(liteservertest.Account{
+		Address: destroyedAddr,
+		Balance: tlb.MustFromTON("0.1"),
+		Code:    testCode,
+		Data:    cell.BeginCell().EndCell(),
+	})
+	chain.SetGetMethod(destroyedAddr, "get_subscription_data", func(acc *liteservertest.Account, params []any) ([]any, int32) {
+		return nil, ton.ErrCodeContractNotInitialized
+	})
+
+	if _, err = chain.Commit(); err != nil {
+		t.Fatal("commit err:", err)
+	}
+
+	srv := liteservertest.NewServer(chain)
+	if err = srv.Start(); err != nil {
+		t.Fatal("start err:", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.NewConnectionPool(ctx)
+	if err != nil {
+		t.Fatal("connect err:", err)
+	}
+	defer pool.Stop()
+	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+
+	res, err := NewSubscriptionClient(api, subAddr).GetSubscriptionData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Wallet.String() != data.Wallet.String() || res.Beneficiary.String() != data.Beneficiary.String() {
+		t.Fatal("incorrect addresses", res.Wallet, res.Beneficiary)
+	}
+	if res.Amount.Nano().Cmp(data.Amount.Nano()) != 0 || res.Period != data.Period || res.StartTime != data.StartTime ||
+		res.Timeout != data.Timeout || res.LastPaymentTime != data.LastPaymentTime || res.LastRequestTime != data.LastRequestTime ||
+		res.FailedAttempts != data.FailedAttempts || res.SubscriptionID != data.SubscriptionID {
+		t.Fatal("incorrect data", res)
+	}
+
+	if res.IsPaymentDue(data.LastPaymentTime + 10) {
+		t.Fatal("payment should not be due")
+	}
+	if !res.IsPaymentDue(data.LastPaymentTime + data.Period) {
+		t.Fatal("payment should be due")
+	}
+
+	_, err = NewSubscriptionClient(api, destroyedAddr).GetSubscriptionData(ctx)
+	if !errors.Is(err, ErrNoSubscriptionData) {
+		t.Fatal("expected not active subscription error, got", err)
+	}
+}
+
+func TestBuildDeployRequest(t *testing.T) {
+	data := testData()
+
+	req, addr, err := BuildDeployRequest(testCode, data, tlb.MustFromTON("1.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored Data
+	if err = tlb.LoadFromCell(&stored, req.StateInit.Data.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if stored.LastPaymentTime != 0 || stored.LastRequestTime != 0 || stored.FailedAttempts != 0 ||
+		stored.SubscriptionID != data.SubscriptionID || stored.Beneficiary.String() != data.Beneficiary.String() {
+		t.Fatal("incorrect initial data", stored)
+	}
+
+	stateCell, err := tlb.ToCell(req.StateInit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Workchain() != 0 || string(addr.Data()) != string(stateCell.Hash()) || req.Workchain != 0 {
+		t.Fatal("incorrect plugin address", addr)
+	}
+
+	if op := req.Body.BeginParse().MustLoadUInt(32); op != wallet.OpPluginRequestFunds|0x80000000 {
+		t.Fatal("incorrect deploy op", op)
+	}
+
+	if _, _, err = BuildDeployRequest(nil, data, tlb.MustFromTON("1.5")); err == nil {
+		t.Fatal("code should be required")
+	}
+
+	p := BuildDestroyPayload(5).BeginParse()
+	if p.MustLoadUInt(32) != wallet.OpPluginDestruct || p.MustLoadUInt(64) != 5 {
+		t.Fatal("incorrect destroy payload")
+	}
+}
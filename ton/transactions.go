@@ -46,6 +46,13 @@ type GetTransactions struct {
 // ListTransactions - returns list of transactions before (including) passed lt and hash, the oldest one is first in result slice
 // Transactions will be verified to match final tx hash, which should be taken from proved account state, then it is safe.
 func (c *APIClient) ListTransactions(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error) {
+	list, _, err := c.listTransactions(ctx, addr, limit, lt, txHash)
+	return list, err
+}
+
+// listTransactions - same as ListTransactions, but also returns blocks of transactions in the same order,
+// block is nil when liteserver has not returned it
+func (c *APIClient) listTransactions(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, []*BlockIDExt, error) {
 	var resp tl.Serializable
 	err := c.client.QueryLiteserver(ctx, GetTransactions{
 		Limit: int32(limit),
@@ -57,21 +64,22 @@ func (c *APIClient) ListTransactions(ctx context.Context, addr *address.Address,
 		TxHash: txHash,
 	}, &resp)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch t := resp.(type) {
 	case TransactionList:
 		if len(t.Transactions) == 0 {
-			return nil, ErrNoTransactionsWereFound
+			return nil, nil, ErrNoTransactionsWereFound
 		}
 
 		txList, err := cell.FromBOCMultiRoot(t.Transactions)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse cell from transaction bytes: %w", err)
+			return nil, nil, fmt.Errorf("failed to parse cell from transaction bytes: %w", err)
 		}
 
 		res := make([]*tlb.Transaction, len(txList))
+		blocks := make([]*BlockIDExt, len(txList))
 
 		for i := 0; i < len(txList); i++ {
 			loader := txList[i].BeginParse()
@@ -79,25 +87,28 @@ func (c *APIClient) ListTransactions(ctx context.Context, addr *address.Address,
 			var tx tlb.Transaction
 			err = tlb.LoadFromCell(&tx, loader)
 			if err != nil {
-				return nil, fmt.Errorf("failed to load transaction from cell: %w", err)
+				return nil, nil, fmt.Errorf("failed to load transaction from cell: %w", err)
 			}
 			tx.Hash = txList[i].Hash()
 
 			if !bytes.Equal(txHash, tx.Hash) {
-				return nil, fmt.Errorf("incorrect transaction hash, not matches prev tx hash")
+				return nil, nil, fmt.Errorf("incorrect transaction hash, not matches prev tx hash")
 			}
 			txHash = tx.PrevTxHash
 			res[(len(txList)-1)-i] = &tx
+			if i < len(t.IDs) {
+				blocks[(len(txList)-1)-i] = t.IDs[i]
+			}
 		}
-		return res, nil
+		return res, blocks, nil
 	case LSError:
 		if t.Code == 0 {
-			return nil, ErrNoTransactionsWereFound
+			return nil, nil, ErrNoTransactionsWereFound
 		}
-		return nil, t
+		return nil, nil, t
 	}
 
-	return nil, errors.New("unknown response type")
+	return nil, nil, errors.New("unknown response type")
 }
 
 func (c *APIClient) GetTransaction(ctx context.Context, block *BlockIDExt, addr *address.Address, lt uint64) (*tlb.Transaction, error) {
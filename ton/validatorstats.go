@@ -0,0 +1,165 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(GetValidatorStats{}, "liteServer.getValidatorStats#091a58bc mode:# id:tonNode.blockIdExt limit:int start_after:mode.0?int256 modified_after:mode.2?int = liteServer.ValidatorStats")
+	tl.Register(ValidatorStats{}, "liteServer.validatorStats mode:# id:tonNode.blockIdExt count:int complete:Bool state_proof:bytes data_proof:bytes = liteServer.ValidatorStats")
+}
+
+type GetValidatorStats struct {
+	Mode          uint32      `tl:"flags"`
+	ID            *BlockIDExt `tl:"struct"`
+	Limit         int32       `tl:"int"`
+	StartAfter    []byte      `tl:"?0 int256"`
+	ModifiedAfter uint32      `tl:"?2 int"`
+}
+
+type ValidatorStats struct {
+	Mode       uint32      `tl:"flags"`
+	ID         *BlockIDExt `tl:"struct"`
+	Count      int32       `tl:"int"`
+	Complete   bool        `tl:"bool"`
+	StateProof *cell.Cell  `tl:"cell"`
+	DataProof  *cell.Cell  `tl:"cell"`
+}
+
+// CreatorStats - counters of blocks created by validator
+type CreatorStats struct {
+	PublicKey []byte
+	tlb.CreatorStats
+}
+
+// GetValidatorStats - gets block creation stats of validators from the masterchain state, sorted by public key.
+// startAfter can be nil to start from the beginning, modifiedAfter filters entries updated after this time (0 = all).
+// Stats are always read from the proof. Returns list and flag which is true when there are no more entries.
+func (c *APIClient) GetValidatorStats(ctx context.Context, master *BlockIDExt, limit int32, startAfter []byte, modifiedAfter uint32) ([]*CreatorStats, bool, error) {
+	req := GetValidatorStats{
+		ID:    master,
+		Limit: limit,
+	}
+	if startAfter != nil {
+		req.Mode |= 1
+		req.StartAfter = startAfter
+	}
+	if modifiedAfter > 0 {
+		req.Mode |= 1 << 2
+		req.ModifiedAfter = modifiedAfter
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case ValidatorStats:
+		if t.Count < 0 || t.Count > limit {
+			return nil, false, fmt.Errorf("incorrect stats count")
+		}
+
+		stats, err := CheckValidatorStatsProof(master, []*cell.Cell{t.StateProof, t.DataProof})
+		if err != nil {
+			return nil, false, fmt.Errorf("incorrect proof: %w", err)
+		}
+
+		res := make([]*CreatorStats, 0, t.Count)
+		for _, st := range stats {
+			if len(res) == int(t.Count) {
+				break
+			}
+			if startAfter != nil && bytes.Compare(st.PublicKey, startAfter) <= 0 {
+				continue
+			}
+			if modifiedAfter > 0 && st.MCBlocks.LastUpdated <= modifiedAfter && st.ShardBlocks.LastUpdated <= modifiedAfter {
+				continue
+			}
+			res = append(res, st)
+		}
+
+		if len(res) != int(t.Count) {
+			return nil, false, fmt.Errorf("not all stats are included in proof")
+		}
+		return res, t.Complete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
+// CheckValidatorStatsProof - checks masterchain state proof and returns creator stats which are included in it
+func CheckValidatorStatsProof(master *BlockIDExt, stateProof []*cell.Cell) ([]*CreatorStats, error) {
+	stateExtra, err := CheckShardMcStateExtraProof(master, stateProof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check proof for mc state extra: %w", err)
+	}
+
+	var info tlb.McStateExtraBlockInfo
+	if err = tlb.LoadFromCellAsProof(&info, stateExtra.Info.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to load mc state extra info: %w", err)
+	}
+
+	if info.Flags&1 == 0 {
+		return []*CreatorStats{}, nil
+	}
+
+	slc := info.BlockCreateStats.BeginParse()
+	tag, err := slc.Copy().LoadUInt(8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load block create stats tag: %w", err)
+	}
+
+	var counters *cell.Dictionary
+	augmented := false
+	switch tag {
+	case 0x17:
+		var st tlb.BlockCreateStats
+		if err = tlb.LoadFromCellAsProof(&st, slc); err != nil {
+			return nil, fmt.Errorf("failed to load block create stats: %w", err)
+		}
+		counters = st.Counters
+	case 0x34:
+		var st tlb.BlockCreateStatsExt
+		if err = tlb.LoadFromCellAsProof(&st, slc); err != nil {
+			return nil, fmt.Errorf("failed to load block create stats: %w", err)
+		}
+		counters, augmented = st.Counters, true
+	default:
+		return nil, fmt.Errorf("unknown block create stats tag %x", tag)
+	}
+
+	kvs, err := counters.LoadAllProven()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load counters dict: %w", err)
+	}
+
+	res := make([]*CreatorStats, 0, len(kvs))
+	for _, kv := range kvs {
+		if augmented {
+			if _, err = kv.Value.LoadUInt(32); err != nil {
+				return nil, fmt.Errorf("failed to load counters extra: %w", err)
+			}
+		}
+
+		st := &CreatorStats{PublicKey: kv.Key.MustLoadSlice(256)}
+		if err = tlb.LoadFromCell(&st.CreatorStats, kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to load creator stats: %w", err)
+		}
+		res = append(res, st)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i].PublicKey, res[j].PublicKey) < 0
+	})
+	return res, nil
+}
@@ -0,0 +1,112 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var ErrUnsafeProofCheckPolicy = errors.New("verification is not possible with unsafe proof check policy")
+
+// VerifyBlockID - checks that block is related to the trusted master block.
+// Shard block is linked to some masterchain block using shard block proof,
+// then masterchain blocks are connected with proof chain.
+// After this check block data can be safely requested by its id.
+func (c *APIClient) VerifyBlockID(ctx context.Context, master, block *BlockIDExt) error {
+	if c.proofCheckPolicy == ProofCheckPolicyUnsafe {
+		return ErrUnsafeProofCheckPolicy
+	}
+
+	if master.Workchain != address.MasterchainID {
+		return fmt.Errorf("trusted block should be from masterchain")
+	}
+
+	mc := block
+	if block.Workchain != address.MasterchainID {
+		proof, err := c.GetShardBlockProof(ctx, block)
+		if err != nil {
+			return fmt.Errorf("failed to get shard block proof: %w", err)
+		}
+		mc = proof.MasterchainID
+	}
+
+	if mc.SeqNo == master.SeqNo {
+		if !mc.Equals(master) {
+			return fmt.Errorf("block is related to another masterchain block with the same seqno")
+		}
+		return nil
+	}
+
+	if _, err := c.verifyProofChain(ctx, master, mc); err != nil {
+		return fmt.Errorf("failed to verify masterchain block %d: %w", mc.SeqNo, err)
+	}
+	return nil
+}
+
+// ListTransactionsVerified - returns list of account transactions, the oldest one is first in result slice.
+// When from is nil, list starts from the last transaction of account state proven in the trusted master block,
+// otherwise from the transaction before passed one, it should be taken from the previous verified list.
+//
+// Besides hash chain, inclusion of every transaction into its block is checked by proof,
+// and blocks are checked to be related to the master block, so liteserver is not trusted at all.
+// It costs additional queries for each transaction and block.
+func (c *APIClient) ListTransactionsVerified(ctx context.Context, master *BlockIDExt, addr *address.Address, limit uint32, from *tlb.Transaction) ([]*tlb.Transaction, error) {
+	if c.proofCheckPolicy == ProofCheckPolicyUnsafe {
+		return nil, ErrUnsafeProofCheckPolicy
+	}
+
+	var lt uint64
+	var hash []byte
+	if from == nil {
+		acc, err := c.GetAccount(ctx, master, addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account state: %w", err)
+		}
+		lt, hash = acc.LastTxLT, acc.LastTxHash
+	} else {
+		lt, hash = from.PrevTxLT, from.PrevTxHash
+	}
+
+	if lt == 0 {
+		return nil, ErrNoTransactionsWereFound
+	}
+
+	list, blocks, err := c.listTransactions(ctx, addr, limit, lt, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := map[string]bool{}
+	for i, tx := range list {
+		block := blocks[i]
+		if block == nil {
+			return nil, fmt.Errorf("block of transaction %d is not passed", tx.LT)
+		}
+
+		if block.Workchain != addr.Workchain() {
+			return nil, fmt.Errorf("block of transaction %d is from another workchain", tx.LT)
+		}
+
+		if !verified[string(block.RootHash)] {
+			if err = c.VerifyBlockID(ctx, master, block); err != nil {
+				return nil, fmt.Errorf("failed to verify block of transaction %d: %w", tx.LT, err)
+			}
+			verified[string(block.RootHash)] = true
+		}
+
+		// checks transaction proof in block
+		proven, err := c.GetTransaction(ctx, block, addr, tx.LT)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proof of transaction %d: %w", tx.LT, err)
+		}
+
+		if !bytes.Equal(proven.Hash, tx.Hash) {
+			return nil, fmt.Errorf("transaction %d in block not matches listed one", tx.LT)
+		}
+	}
+	return list, nil
+}
@@ -19,14 +19,16 @@ func AddressFromPubKey(key ed25519.PublicKey, ver Version, subwallet uint32) (*a
 		return nil, fmt.Errorf("failed to get state: %w", err)
 	}
 
+	return addressFromStateInit(state)
+}
+
+func addressFromStateInit(state *tlb.StateInit) (*address.Address, error) {
 	stateCell, err := tlb.ToCell(state)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get state cell: %w", err)
 	}
 
-	addr := address.NewAddress(0, 0, stateCell.Hash())
-
-	return addr, nil
+	return address.NewAddress(0, 0, stateCell.Hash()), nil
 }
 
 func GetWalletVersion(account *tlb.Account) Version {
@@ -55,6 +57,11 @@ func GetStateInit(pubKey ed25519.PublicKey, ver Version, subWallet uint32) (*tlb
 
 	var data *cell.Cell
 	switch ver {
+	case V1R1, V1R2, V1R3, V2R1, V2R2:
+		data = cell.BeginCell().
+			MustStoreUInt(0, 32). // seqno
+			MustStoreSlice(pubKey, 256).
+			EndCell()
 	case V3R1, V3R2:
 		data = cell.BeginCell().
 			MustStoreUInt(0, 32).                 // seqno
@@ -68,6 +75,17 @@ func GetStateInit(pubKey ed25519.PublicKey, ver Version, subWallet uint32) (*tlb
 			MustStoreSlice(pubKey, 256).
 			MustStoreDict(nil). // empty dict of plugins
 			EndCell()
+	case V5R1:
+		// for V5R1 subWallet is a full wallet id, see V5R1WalletID
+		var err error
+		data, err = v5r1StateData(pubKey, subWallet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build v5r1 data: %w", err)
+		}
+	case HighloadV3:
+		return GetHighloadV3StateInit(pubKey, subWallet, DefaultHighloadV3Timeout)
+	case Lockup:
+		return nil, fmt.Errorf("lockup wallet data depends on its config, use GetLockupStateInit: %w", ErrUnsupportedWalletVersion)
 	case HighloadV2R2, HighloadV2Verified:
 		data = cell.BeginCell().
 			MustStoreUInt(uint64(subWallet), 32).
@@ -0,0 +1,210 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// BIP39 mnemonics are used by most of the multi-chain and hardware wallets, keys for TON
+// are derived from BIP39 seed using SLIP-0010 for ed25519 along the path m/44'/607'/account'.
+// TON-native mnemonics use the same word list, but have no checksum and are derived differently,
+// see DetectMnemonic to find out which scheme phrase belongs to.
+
+// DefaultBIP39Path - derivation path of the first account, used by the most of wallets
+const DefaultBIP39Path = "m/44'/607'/0'"
+
+const (
+	_BIP39Iterations = 2048
+	_BIP39Salt       = "mnemonic"
+	_SLIP10Curve     = "ed25519 seed"
+
+	slip10Hardened = 0x80000000
+)
+
+var ErrInvalidBIP39Checksum = errors.New("invalid BIP39 mnemonic checksum")
+
+type MnemonicScheme int
+
+const (
+	MnemonicUnknown MnemonicScheme = iota
+	MnemonicTON
+	MnemonicBIP39
+	// MnemonicAmbiguous - phrase is valid for both schemes, it happens rarely, because checks are probabilistic,
+	// addresses derived by both schemes should be checked to find the right one
+	MnemonicAmbiguous
+)
+
+func (s MnemonicScheme) String() string {
+	switch s {
+	case MnemonicTON:
+		return "TON"
+	case MnemonicBIP39:
+		return "BIP39"
+	case MnemonicAmbiguous:
+		return "ambiguous"
+	}
+	return "unknown"
+}
+
+// bip39Index - index of the word in BIP39 english list, list is sorted alphabetically
+var bip39Index = func() map[string]uint16 {
+	list := make([]string, 0, len(words))
+	for w := range words {
+		list = append(list, w)
+	}
+	sort.Strings(list)
+
+	index := make(map[string]uint16, len(list))
+	for i, w := range list {
+		index[w] = uint16(i)
+	}
+	return index
+}()
+
+// BIP39AccountPath - returns derivation path of the TON account with the given index
+func BIP39AccountPath(account uint32) string {
+	return fmt.Sprintf("m/44'/607'/%d'", account)
+}
+
+// DetectMnemonic - finds out the scheme of the phrase, password is used only for TON-native check,
+// because BIP39 passphrase does not affect validity of the phrase
+func DetectMnemonic(seed []string, password string) MnemonicScheme {
+	isTON := IsTONMnemonic(seed, password)
+	isBIP39 := ValidateBIP39Mnemonic(seed) == nil
+
+	switch {
+	case isTON && isBIP39:
+		return MnemonicAmbiguous
+	case isTON:
+		return MnemonicTON
+	case isBIP39:
+		return MnemonicBIP39
+	}
+	return MnemonicUnknown
+}
+
+// ValidateBIP39Mnemonic - checks words number, words and checksum of BIP39 mnemonic
+func ValidateBIP39Mnemonic(seed []string) error {
+	if len(seed) < 12 || len(seed) > 24 || len(seed)%3 != 0 {
+		return fmt.Errorf("BIP39 mnemonic should have 12, 15, 18, 21 or 24 words")
+	}
+
+	// every word is 11 bits, last len/3 bits are checksum
+	data := make([]byte, (len(seed)*11+7)/8)
+	for i, w := range seed {
+		idx, ok := bip39Index[w]
+		if !ok {
+			return fmt.Errorf("unknown word '%s' in seed", w)
+		}
+
+		for b := 0; b < 11; b++ {
+			if idx&(1<<(10-b)) != 0 {
+				pos := i*11 + b
+				data[pos/8] |= 1 << (7 - pos%8)
+			}
+		}
+	}
+
+	checksumBits := len(seed) / 3
+	entropy := data[:(len(seed)*11-checksumBits)/8]
+	hash := sha256.Sum256(entropy)
+
+	for b := 0; b < checksumBits; b++ {
+		pos := len(entropy)*8 + b
+		if (data[pos/8]>>(7-pos%8))&1 != (hash[b/8]>>(7-b%8))&1 {
+			return ErrInvalidBIP39Checksum
+		}
+	}
+	return nil
+}
+
+// BIP39Seed - converts mnemonic to 64 bytes BIP39 seed. Passphrase is used as is,
+// when it contains non-ASCII characters it should be normalized to NFKD form by the caller.
+func BIP39Seed(seed []string, passphrase string) ([]byte, error) {
+	if err := ValidateBIP39Mnemonic(seed); err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key([]byte(strings.Join(seed, " ")), []byte(_BIP39Salt+passphrase), _BIP39Iterations, 64, sha512.New), nil
+}
+
+// BIP39PrivateKey - derives ed25519 key from BIP39 mnemonic using SLIP-0010 along the path, like DefaultBIP39Path.
+// Only hardened derivation is defined for ed25519, so every path index should be hardened.
+func BIP39PrivateKey(seed []string, passphrase, path string) (ed25519.PrivateKey, error) {
+	bip39Seed, err := BIP39Seed(seed, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := slip10DeriveEd25519(bip39Seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(key), nil
+}
+
+// FromBIP39Seed - initializes wallet with key derived from BIP39 mnemonic, see BIP39PrivateKey
+func FromBIP39Seed(api TonAPI, seed []string, passphrase, path string, version Version) (*Wallet, error) {
+	key, err := BIP39PrivateKey(seed, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	return FromPrivateKey(api, key, version)
+}
+
+// slip10DeriveEd25519 - derives 32 bytes private key seed from master seed
+func slip10DeriveEd25519(seed []byte, path string) ([]byte, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, []byte(_SLIP10Curve))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, index := range indexes {
+		data := make([]byte, 1+32+4)
+		copy(data[1:], key)
+		binary.BigEndian.PutUint32(data[33:], index)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return key, nil
+}
+
+// parseDerivationPath - parses path like m/44'/607'/0', h suffix is also accepted for hardened index
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path should start with m: %s", path)
+	}
+
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		num := strings.TrimRight(part, "'hH")
+		if len(part)-len(num) != 1 {
+			return nil, fmt.Errorf("only hardened indexes are supported for ed25519, incorrect part '%s'", part)
+		}
+
+		index, err := strconv.ParseUint(num, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect path index '%s': %w", part, err)
+		}
+		indexes = append(indexes, uint32(index)|slip10Hardened)
+	}
+	return indexes, nil
+}
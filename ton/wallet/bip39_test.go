@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBIP39Seed(t *testing.T) {
+	for _, tt := range []struct {
+		mnemonic string
+		seed     string
+	}{
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+				"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+			"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+		},
+	} {
+		seed, err := BIP39Seed(strings.Split(tt.mnemonic, " "), "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(seed) != tt.seed {
+			t.Fatal("incorrect seed", tt.mnemonic, hex.EncodeToString(seed))
+		}
+	}
+
+	if err := ValidateBIP39Mnemonic(strings.Split(strings.Repeat("abandon ", 12)[:12*8-1], " ")); !errors.Is(err, ErrInvalidBIP39Checksum) {
+		t.Fatal("checksum should be incorrect", err)
+	}
+
+	if err := ValidateBIP39Mnemonic(strings.Split(strings.Repeat("abandon ", 13)[:13*8-1], " ")); err == nil {
+		t.Fatal("13 words should be rejected")
+	}
+}
+
+func TestSLIP10DeriveEd25519(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	for path, key := range map[string]string{
+		"m":                         "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		"m/0'":                      "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		"m/0H/1H":                   "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+		"m/0'/1'/2'":                "92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9",
+		"m/0'/1'/2'/2'":             "30d1dc7e5fc04c31219ab25a27ae00b50f6fd66622f6e9c913253d6511d1e662",
+		"m/0'/1'/2'/2'/1000000000'": "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793",
+	} {
+		k, err := slip10DeriveEd25519(seed, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(k) != key {
+			t.Fatal("incorrect key for", path, hex.EncodeToString(k))
+		}
+	}
+
+	for _, path := range []string{"", "44'/607'/0'", "m/44'/607'/0", "m/44'/607'/x'", "m/2147483648'", "m/44''"} {
+		if _, err := slip10DeriveEd25519(seed, path); err == nil {
+			t.Fatal("path should be rejected", path)
+		}
+	}
+}
+
+func TestFromBIP39Seed(t *testing.T) {
+	mnemonic := strings.Split("legal winner thank year wave sausage worth useful legal winner thank yellow", " ")
+
+	if DetectMnemonic(mnemonic, "") != MnemonicBIP39 {
+		t.Fatal("should be detected as BIP39")
+	}
+
+	_, err := FromSeed(nil, mnemonic, V4R2)
+	if !errors.Is(err, ErrInvalidSeed) || !strings.Contains(err.Error(), "FromBIP39Seed") {
+		t.Fatal("TON derivation should suggest BIP39", err)
+	}
+
+	w, err := FromBIP39Seed(nil, mnemonic, "", DefaultBIP39Path, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := BIP39PrivateKey(mnemonic, "", BIP39AccountPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.PrivateKey(), key) {
+		t.Fatal("default path should be the first account")
+	}
+
+	key1, err := BIP39PrivateKey(mnemonic, "", BIP39AccountPath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPass, err := BIP39PrivateKey(mnemonic, "pass", DefaultBIP39Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key1, key) || bytes.Equal(keyPass, key) {
+		t.Fatal("account and passphrase should change the key")
+	}
+
+	// fixed vector for the first account m/44'/607'/0' of the standard BIP39 test mnemonic,
+	// key matches independent SLIP-10 and RFC 8032 implementation
+	ref, err := FromBIP39Seed(nil, strings.Split("abandon abandon abandon abandon abandon abandon "+
+		"abandon abandon abandon abandon abandon about", " "), "", DefaultBIP39Path, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(ref.PrivateKey().Seed()) != "b477ef5ed17fb8a2b8faddd7a9835a227243a82c70b190c7af4896155aa7df9f" {
+		t.Fatal("incorrect private key", hex.EncodeToString(ref.PrivateKey().Seed()))
+	}
+	if pub := ref.PrivateKey().Public().(ed25519.PublicKey); hex.EncodeToString(pub) != "7952e94118f34607c75e23258dd9220d66ccac5a3ee074125c25068e8107bfbf" {
+		t.Fatal("incorrect public key", hex.EncodeToString(pub))
+	}
+	if addr := ref.WalletAddress().String(); addr != "UQAzWZa6nM5mJev91wGc7VCSfBoIsYRqKJpV78N8Add9-RKY" {
+		t.Fatal("incorrect address", addr)
+	}
+
+	tonSeed := NewSeed()
+	if s := DetectMnemonic(tonSeed, ""); s != MnemonicTON && s != MnemonicAmbiguous {
+		t.Fatal("should be detected as TON", s)
+	}
+
+	if DetectMnemonic([]string{"birth", "core"}, "") != MnemonicUnknown {
+		t.Fatal("should be unknown")
+	}
+}
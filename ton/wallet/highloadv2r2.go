@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/xssnick/tonutils-go/tlb"
 	"math/big"
-	"time"
 
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -17,13 +16,41 @@ const _HighloadV2R2CodeHex = "B5EE9C720101090100E9000114FF00F4A413F4BCF2C80B0102
 // technically 99% same with _HighloadV2R2CodeHex, but verified, used verified source of https://verifier.ton.org/EQB9oQAr5-OZVQAQePzWOrBwwhnIOh1OuVrYUDqfzXebXz5h
 const _HighloadV2VerifiedCodeHex = "b5ee9c724101090100e5000114ff00f4a413f4bcf2c80b010201200203020148040501eaf28308d71820d31fd33ff823aa1f5320b9f263ed44d0d31fd33fd3fff404d153608040f40e6fa131f2605173baf2a207f901541087f910f2a302f404d1f8007f8e16218010f4786fa5209802d307d43001fb009132e201b3e65b8325a1c840348040f4438ae63101c8cb1f13cb3fcbfff400c9ed54080004d03002012006070017bd9ce76a26869af98eb85ffc0041be5f976a268698f98e99fe9ff98fa0268a91040207a0737d098c92dbfc95dd1f140034208040f4966fa56c122094305303b9de2093333601926c21e2b39f9e545a"
 
+// max messages which can be sent by highload V2 wallet in one request
+const highloadV2MaxMessages = 254
+
 type SpecHighloadV2R2 struct {
 	SpecRegular
 	SpecQuery
 }
 
-func (s *SpecHighloadV2R2) BuildMessage(_ context.Context, messages []*Message) (*cell.Cell, error) {
-	if len(messages) > 254 {
+func (s *SpecHighloadV2R2) BuildMessage(ctx context.Context, messages []*Message) (*cell.Cell, error) {
+	var ttl, queryID uint32
+	if s.customQueryIDFetcher != nil {
+		ttl, queryID = s.customQueryIDFetcher()
+	} else {
+		queryID = randUint32()
+		ttl = s.validUntil()
+	}
+
+	boundedID := (uint64(ttl) << 32) + uint64(queryID)
+	payload, err := buildHighloadV2R2Payload(s.wallet.subwallet, boundedID, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
+	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
+
+	return msg, nil
+}
+
+// buildHighloadV2R2Payload - builds signed part of highload V2R2 wallet external message
+func buildHighloadV2R2Payload(subwallet uint32, boundedID uint64, messages []*Message) (*cell.Builder, error) {
+	if len(messages) > highloadV2MaxMessages {
 		return nil, errors.New("for this type of wallet max 254 messages can be sent in the same time")
 	}
 
@@ -45,21 +72,7 @@ func (s *SpecHighloadV2R2) BuildMessage(_ context.Context, messages []*Message)
 		}
 	}
 
-	var ttl, queryID uint32
-	if s.customQueryIDFetcher != nil {
-		ttl, queryID = s.customQueryIDFetcher()
-	} else {
-		queryID = randUint32()
-		ttl = uint32(timeNow().Add(time.Duration(s.messagesTTL) * time.Second).UTC().Unix())
-	}
-
-	boundedID := (uint64(ttl) << 32) + uint64(queryID)
-	payload := cell.BeginCell().MustStoreUInt(uint64(s.wallet.subwallet), 32).
+	return cell.BeginCell().MustStoreUInt(uint64(subwallet), 32).
 		MustStoreUInt(boundedID, 64).
-		MustStoreDict(dict)
-
-	sign := payload.EndCell().Sign(s.wallet.key)
-	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
-
-	return msg, nil
+		MustStoreDict(dict), nil
 }
@@ -0,0 +1,581 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// https://github.com/ton-blockchain/highload-wallet-contract-v3/blob/main/contracts/highload-wallet-v3.func
+const _HighloadV3CodeHex = "b5ee9c7241021001000228000114ff00f4a413f4bcf2c80b01020120020d02014803040078d020d74bc00101c060b0915be101d0d3030171b0915be0fa4030f828c705b39130e0d31f018210ae42e5a4ba9d8040d721d74cf82a01ed55fb04e030020120050a02027306070011adce76a2686b85ffc00201200809001aabb6ed44d0810122d721d70b3f0018aa3bed44d08307d721d70b1f0201200b0c001bb9a6eed44d0810162d721d70b15800e5b8bf2eda2edfb21ab09028409b0ed44d0810120d721f404f404d33fd315d1058e1bf82325a15210b99f326df82305aa0015a112b992306dde923033e2923033e25230800df40f6fa19ed021d721d70a00955f037fdb31e09130e259800df40f6fa19cd001d721d70a00937fdb31e0915be270801f6f2d48308d718d121f900ed44d0d3ffd31ff404f404d33fd315d1f82321a15220b98e12336df82324aa00a112b9926d32de58f82301de541675f910f2a106d0d31fd4d307d30cd309d33fd315d15168baf2a2515abaf2a6f8232aa15250bcf2a304f823bbf2a35304800df40f6fa199d024d721d70a00f2649130e20e01fe5309800df40f6fa18e13d05004d718d20001f264c858cf16cf8301cf168e1030c824cf40cf8384095005a1a514cf40e2f800c94039800df41704c8cbff13cb1ff40012f40012cb3f12cb15c9ed54f80f21d0d30001f265d3020171b0925f03e0fa4001d70b01c000f2a5fa4031fa0031f401fa0031fa00318060d721d300010f0020f265d2000193d431d19130e272b1fb00b585bf03"
+
+// DefaultHighloadV3Timeout - timeout of the highload V3 wallet created with FromPrivateKey, it is a part of the wallet address
+const DefaultHighloadV3Timeout = 60 * 60 * 2
+
+const (
+	OpHighloadV3InternalTransfer = 0xae42e5a4
+
+	highloadV3MaxShift     = 1<<13 - 1
+	highloadV3MaxBitNumber = 1022
+
+	// c5 register can contain max 255 actions, one of them is taken by the set_code action
+	// which contract adds to keep its code unchanged by the batch
+	highloadV3MaxActions = 254
+
+	// created at should be not in the future for the liteserver,
+	// so we take time a bit older than the last master block
+	highloadV3CreatedAtShift = 30
+
+	// limit of processed? checks when searching for the free query id without saved state
+	highloadV3MaxProcessedChecks = 64
+)
+
+var (
+	ErrQueryAlreadyProcessed = errors.New("query was already processed by wallet")
+	ErrQueryExpired          = errors.New("query is expired and cannot be processed")
+)
+
+// HighloadQueryID - query id of highload V3 wallet, 13 bits of shift and 10 bits of bit number.
+// Contract remembers processed ids for the timeout, so every request should have its own id.
+type HighloadQueryID uint32
+
+// NewHighloadQueryID - creates query id from shift (max 8191) and bit number (max 1022)
+func NewHighloadQueryID(shift, bitNumber uint16) (HighloadQueryID, error) {
+	if shift > highloadV3MaxShift {
+		return 0, fmt.Errorf("shift should be <= %d", highloadV3MaxShift)
+	}
+	if bitNumber > highloadV3MaxBitNumber {
+		return 0, fmt.Errorf("bit number should be <= %d", highloadV3MaxBitNumber)
+	}
+	return HighloadQueryID(uint32(shift)<<10 | uint32(bitNumber)), nil
+}
+
+func (q HighloadQueryID) Shift() uint16 {
+	return uint16(q >> 10)
+}
+
+func (q HighloadQueryID) BitNumber() uint16 {
+	return uint16(q & 0x3FF)
+}
+
+// Next - returns next query id in sequence. After the last id sequence starts from zero,
+// it is safe because contract forgets processed ids after the two timeouts,
+// and there are more than 8 millions of ids.
+func (q HighloadQueryID) Next() HighloadQueryID {
+	shift, bit := q.Shift(), q.BitNumber()+1
+	if bit > highloadV3MaxBitNumber {
+		bit = 0
+		shift++
+	}
+	if shift > highloadV3MaxShift {
+		shift = 0
+	}
+	return HighloadQueryID(uint32(shift)<<10 | uint32(bit))
+}
+
+// HighloadQueryIDStorage - storage of the last allocated query id.
+// It should be persistent, otherwise after restart ids will be allocated again
+// and requests with them will be rejected by contract till the timeout.
+type HighloadQueryIDStorage interface {
+	// LoadLastQueryID - returns last allocated query id, found is false when nothing was allocated yet
+	LoadLastQueryID(ctx context.Context) (id HighloadQueryID, found bool, err error)
+	SaveLastQueryID(ctx context.Context, id HighloadQueryID) error
+}
+
+type memoryQueryIDStorage struct {
+	last  HighloadQueryID
+	found bool
+}
+
+func (m *memoryQueryIDStorage) LoadLastQueryID(_ context.Context) (HighloadQueryID, bool, error) {
+	return m.last, m.found, nil
+}
+
+func (m *memoryQueryIDStorage) SaveLastQueryID(_ context.Context, id HighloadQueryID) error {
+	m.last, m.found = id, true
+	return nil
+}
+
+// FileQueryIDStorage - HighloadQueryIDStorage which keeps the last query id in json file
+type FileQueryIDStorage struct {
+	path  string
+	last  HighloadQueryID
+	found bool
+	mx    sync.Mutex
+}
+
+type queryIDStorageJSON struct {
+	LastQueryID HighloadQueryID `json:"last_query_id"`
+}
+
+// NewFileQueryIDStorage - opens storage at path, file will be created on the first save
+func NewFileQueryIDStorage(path string) (*FileQueryIDStorage, error) {
+	s := &FileQueryIDStorage{
+		path: path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read query id file: %w", err)
+	}
+
+	var st queryIDStorageJSON
+	if err = json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse query id file: %w", err)
+	}
+	s.last, s.found = st.LastQueryID, true
+	return s, nil
+}
+
+func (s *FileQueryIDStorage) LoadLastQueryID(_ context.Context) (HighloadQueryID, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.last, s.found, nil
+}
+
+func (s *FileQueryIDStorage) SaveLastQueryID(_ context.Context, id HighloadQueryID) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	data, err := json.Marshal(queryIDStorageJSON{LastQueryID: id})
+	if err != nil {
+		return fmt.Errorf("failed to serialize query id: %w", err)
+	}
+
+	// write to temp file first, to not lose the last id on crash
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write query id file: %w", err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace query id file: %w", err)
+	}
+
+	s.last, s.found = id, true
+	return nil
+}
+
+// HighloadV3Query - identity of the highload V3 request, request with the same query
+// can be sent multiple times, but will be processed only once
+type HighloadV3Query struct {
+	ID        HighloadQueryID
+	CreatedAt uint64
+}
+
+// HighloadV3Data - persistent data of highload V3 wallet
+type HighloadV3Data struct {
+	PublicKey     []byte           `tlb:"bits 256"`
+	SubwalletID   uint32           `tlb:"## 32"`
+	OldQueries    *cell.Dictionary `tlb:"dict 13"`
+	Queries       *cell.Dictionary `tlb:"dict 13"`
+	LastCleanTime uint64           `tlb:"## 64"`
+	Timeout       uint32           `tlb:"## 22"`
+}
+
+type SpecHighloadV3 struct {
+	SpecRegular
+
+	// Time in seconds during which contract accepts request after its creation,
+	// and remembers processed query ids. It is a part of the wallet address.
+	timeout uint32
+
+	mx       sync.Mutex
+	queryIDs HighloadQueryIDStorage
+}
+
+// FromPrivateKeyHighloadV3 - initializes highload V3 wallet with the custom subwallet and timeout
+func FromPrivateKeyHighloadV3(api TonAPI, key ed25519.PrivateKey, subwallet, timeout uint32) (*Wallet, error) {
+	return FromSignerHighloadV3(api, NewKeySigner(key), subwallet, timeout)
+}
+
+// FromSignerHighloadV3 - same as FromPrivateKeyHighloadV3, but messages are signed by signer
+func FromSignerHighloadV3(api TonAPI, signer Signer, subwallet, timeout uint32) (*Wallet, error) {
+	state, err := GetHighloadV3StateInit(signer.PublicKey(), subwallet, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := addressFromStateInit(state)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Wallet{
+		api:       api,
+		signer:    signer,
+		addr:      addr,
+		ver:       HighloadV3,
+		subwallet: subwallet,
+	}
+
+	w.spec, err = getSpec(w)
+	if err != nil {
+		return nil, err
+	}
+	w.spec.(*SpecHighloadV3).timeout = timeout
+
+	return w, nil
+}
+
+// GetHighloadV3StateInit - same as GetStateInit but with custom timeout, max timeout is 2^22-1 seconds
+func GetHighloadV3StateInit(pubKey ed25519.PublicKey, subWallet, timeout uint32) (*tlb.StateInit, error) {
+	if timeout >= 1<<22 {
+		return nil, fmt.Errorf("timeout should be less than %d", 1<<22)
+	}
+
+	data, err := tlb.ToCell(HighloadV3Data{
+		PublicKey:   pubKey,
+		SubwalletID: subWallet,
+		Timeout:     timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build highload v3 data: %w", err)
+	}
+
+	return &tlb.StateInit{
+		Data: data,
+		Code: walletCode[HighloadV3],
+	}, nil
+}
+
+// SetQueryIDStorage - sets storage of the query ids sequence, by default it is stored in memory.
+// When storage has no saved id, sequence continues after the ids remembered by the contract,
+// but ids of requests which are still on the way can repeat, so use persistent storage
+// like FileQueryIDStorage for long-living services.
+func (s *SpecHighloadV3) SetQueryIDStorage(storage HighloadQueryIDStorage) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.queryIDs = storage
+}
+
+func (s *SpecHighloadV3) Timeout() uint32 {
+	return s.timeout
+}
+
+// AllocateQuery - takes next query id from the sequence and saves it to storage.
+// When storage has no saved id, ids already processed by the deployed wallet are skipped.
+func (s *SpecHighloadV3) AllocateQuery(ctx context.Context) (*HighloadV3Query, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	last, found, err := s.queryIDs.LoadLastQueryID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last query id: %w", err)
+	}
+
+	id := HighloadQueryID(0)
+	if found {
+		id = last.Next()
+	} else if s.wallet.api != nil {
+		if id, err = s.firstFreeQueryID(ctx); err != nil {
+			return nil, fmt.Errorf("failed to find free query id: %w", err)
+		}
+	}
+
+	if err = s.queryIDs.SaveLastQueryID(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to save query id: %w", err)
+	}
+
+	return &HighloadV3Query{
+		ID:        id,
+		CreatedAt: uint64(timeNow().Unix() - highloadV3CreatedAtShift),
+	}, nil
+}
+
+// firstFreeQueryID - returns id after the last one remembered by the contract,
+// which is not reported by processed? method
+func (s *SpecHighloadV3) firstFreeQueryID(ctx context.Context) (HighloadQueryID, error) {
+	block, err := s.wallet.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := s.wallet.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, s.wallet.addr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive || acc.Data == nil {
+		// nothing was processed by not deployed wallet
+		return 0, nil
+	}
+
+	var data HighloadV3Data
+	if err = tlb.LoadFromCell(&data, acc.Data.BeginParse()); err != nil {
+		return 0, fmt.Errorf("failed to parse wallet data: %w", err)
+	}
+
+	id := HighloadQueryID(0)
+	for _, dict := range []*cell.Dictionary{data.OldQueries, data.Queries} {
+		last, found, err := highloadV3LastStoredQuery(dict)
+		if err != nil {
+			return 0, err
+		}
+		if found && last >= id {
+			id = last.Next()
+		}
+	}
+
+	for i := 0; i < highloadV3MaxProcessedChecks; i++ {
+		processed, err := highloadV3Processed(ctx, s.wallet.api, block, s.wallet.addr, id, true)
+		if err != nil {
+			return 0, err
+		}
+		if !processed {
+			return id, nil
+		}
+		id = id.Next()
+	}
+	return 0, fmt.Errorf("all %d checked query ids are already processed", highloadV3MaxProcessedChecks)
+}
+
+// highloadV3LastStoredQuery - returns the biggest query id from the contract dictionary,
+// where key is a shift and value is a bitmap of processed bit numbers
+func highloadV3LastStoredQuery(dict *cell.Dictionary) (HighloadQueryID, bool, error) {
+	if dict == nil {
+		return 0, false, nil
+	}
+
+	items, err := dict.LoadAll()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load queries dictionary: %w", err)
+	}
+
+	var last HighloadQueryID
+	var found bool
+	for _, kv := range items {
+		shift, err := kv.Key.LoadUInt(13)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to load queries shift: %w", err)
+		}
+
+		sz := kv.Value.BitsLeft()
+		bits, err := kv.Value.LoadSlice(sz)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to load queries bitmap: %w", err)
+		}
+
+		for bit := int(sz) - 1; bit >= 0; bit-- {
+			if bits[bit/8]&(0x80>>(bit%8)) == 0 {
+				continue
+			}
+
+			id := HighloadQueryID(uint32(shift)<<10 | uint32(bit))
+			if !found || id > last {
+				last, found = id, true
+			}
+			break
+		}
+	}
+	return last, found, nil
+}
+
+// BuildMessage - allocates new query and builds message body with it
+func (s *SpecHighloadV3) BuildMessage(ctx context.Context, messages []*Message) (*cell.Cell, error) {
+	query, err := s.AllocateQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.BuildMessageForQuery(ctx, query, messages)
+}
+
+// BuildMessageForQuery - builds message body with the given query. Single message is sent directly,
+// multiple messages are sent by the wallet to itself in batches, which are nested when they are too big.
+func (s *SpecHighloadV3) BuildMessageForQuery(ctx context.Context, query *HighloadV3Query, messages []*Message) (*cell.Cell, error) {
+	payload, err := buildHighloadV3Payload(s.wallet.addr, s.wallet.subwallet, s.timeout, query, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := s.wallet.sign(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return cell.BeginCell().
+		MustStoreSlice(sign, 512).
+		MustStoreRef(payload).
+		EndCell(), nil
+}
+
+// buildHighloadV3Payload - builds signed part of highload V3 wallet external message
+func buildHighloadV3Payload(addr *address.Address, subwallet, timeout uint32, query *HighloadV3Query, messages []*Message) (*cell.Cell, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("no messages to send")
+	}
+
+	msg := messages[0]
+	if len(messages) > 1 {
+		var err error
+		msg, err = packHighloadV3Messages(addr, uint64(query.ID), messages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	intMsg, err := tlb.ToCell(msg.InternalMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert internal message to cell: %w", err)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(uint64(subwallet), 32).
+		MustStoreRef(intMsg).
+		MustStoreUInt(uint64(msg.Mode), 8).
+		MustStoreUInt(uint64(query.ID), 23).
+		MustStoreUInt(query.CreatedAt, 64).
+		MustStoreUInt(uint64(timeout), 22).
+		EndCell(), nil
+}
+
+// packHighloadV3Messages - wraps messages to the internal transfer of the wallet to itself,
+// messages which are not fit into the one transfer are packed to the nested one
+func packHighloadV3Messages(addr *address.Address, queryID uint64, messages []*Message) (*Message, error) {
+	if len(messages) > highloadV3MaxActions {
+		nested, err := packHighloadV3Messages(addr, queryID, messages[highloadV3MaxActions-1:])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages[:highloadV3MaxActions-1:highloadV3MaxActions-1], nested)
+	}
+
+	amount := big.NewInt(0)
+	list := cell.BeginCell().EndCell()
+	for i, message := range messages {
+		intMsg, err := tlb.ToCell(message.InternalMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert internal message %d to cell: %w", i, err)
+		}
+		amount.Add(amount, message.InternalMessage.Amount.Nano())
+
+		list = cell.BeginCell().
+			MustStoreRef(list).
+			MustStoreUInt(opActionSendMsg, 32).
+			MustStoreUInt(uint64(message.Mode), 8).
+			MustStoreRef(intMsg).
+			EndCell()
+	}
+
+	return &Message{
+		Mode: 1 + 2,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			DstAddr:     addr,
+			Amount:      tlb.FromNanoTON(amount),
+			Body: cell.BeginCell().
+				MustStoreUInt(OpHighloadV3InternalTransfer, 32).
+				MustStoreUInt(queryID, 64).
+				MustStoreRef(list).
+				EndCell(),
+		},
+	}, nil
+}
+
+// IsProcessed - checks is query id was processed by wallet using processed? get method.
+// When needClean is true, ids which are already forgotten by the contract are not reported.
+func (s *SpecHighloadV3) IsProcessed(ctx context.Context, block *ton.BlockIDExt, queryID HighloadQueryID, needClean bool) (bool, error) {
+	return highloadV3Processed(ctx, s.wallet.api, block, s.wallet.addr, queryID, needClean)
+}
+
+func highloadV3Processed(ctx context.Context, api TonAPI, block *ton.BlockIDExt, addr *address.Address, queryID HighloadQueryID, needClean bool) (bool, error) {
+	params, err := ton.EncodeParams(struct {
+		QueryID   uint32 `tvm:"int"`
+		NeedClean bool   `tvm:"bool"`
+	}{
+		QueryID:   uint32(queryID),
+		NeedClean: needClean,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	res, err := api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, addr, "processed?", params...)
+	if err != nil {
+		return false, fmt.Errorf("failed to run processed? method: %w", err)
+	}
+
+	var result struct {
+		Processed bool `tvm:"bool"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode processed? result: %w", err)
+	}
+	return result.Processed, nil
+}
+
+// BuildExternalMessageForQuery - builds external message with the given query, with state init if wallet is not deployed
+func (s *SpecHighloadV3) BuildExternalMessageForQuery(ctx context.Context, query *HighloadV3Query, messages []*Message) (*tlb.ExternalMessage, error) {
+	_, _, stateInit, err := s.wallet.prepareExternal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.BuildMessageForQuery(ctx, query, messages)
+	if err != nil {
+		return nil, fmt.Errorf("build message err: %w", err)
+	}
+
+	return &tlb.ExternalMessage{
+		DstAddr:   s.wallet.addr,
+		StateInit: stateInit,
+		Body:      body,
+	}, nil
+}
+
+// SendQuery - sends messages with the given query. It is safe to call it again with the same query and messages,
+// when result of the previous send is unknown, contract will process it only once.
+// Returns ErrQueryAlreadyProcessed if query was processed before, and ErrQueryExpired
+// when it is too old to be processed, new query should be allocated in this case.
+func (s *SpecHighloadV3) SendQuery(ctx context.Context, query *HighloadV3Query, messages []*Message) (*tlb.ExternalMessage, error) {
+	block, initialized, stateInit, err := s.wallet.prepareExternal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if initialized {
+		processed, err := s.IsProcessed(ctx, block, query.ID, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if processed {
+			return nil, ErrQueryAlreadyProcessed
+		}
+	}
+
+	if int64(query.CreatedAt) <= timeNow().Unix()-int64(s.timeout) {
+		return nil, ErrQueryExpired
+	}
+
+	body, err := s.BuildMessageForQuery(ctx, query, messages)
+	if err != nil {
+		return nil, fmt.Errorf("build message err: %w", err)
+	}
+
+	ext := &tlb.ExternalMessage{
+		DstAddr:   s.wallet.addr,
+		StateInit: stateInit,
+		Body:      body,
+	}
+
+	if err = s.wallet.api.SendExternalMessage(ctx, ext); err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	return ext, nil
+}
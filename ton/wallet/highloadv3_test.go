@@ -0,0 +1,511 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestHighloadQueryID_Next(t *testing.T) {
+	q, err := NewHighloadQueryID(5, 1022)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q = q.Next()
+	if q.Shift() != 6 || q.BitNumber() != 0 {
+		t.Fatal("incorrect next", q.Shift(), q.BitNumber())
+	}
+
+	q = q.Next()
+	if q.Shift() != 6 || q.BitNumber() != 1 {
+		t.Fatal("incorrect next", q.Shift(), q.BitNumber())
+	}
+
+	last, err := NewHighloadQueryID(8191, 1022)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Next() != 0 {
+		t.Fatal("sequence should start from zero after the last id")
+	}
+
+	if _, err = NewHighloadQueryID(8192, 0); err == nil {
+		t.Fatal("shift should be validated")
+	}
+	if _, err = NewHighloadQueryID(0, 1023); err == nil {
+		t.Fatal("bit number should be validated")
+	}
+}
+
+type testQueryIDStorage struct {
+	last  HighloadQueryID
+	found bool
+	saves int
+}
+
+func (s *testQueryIDStorage) LoadLastQueryID(_ context.Context) (HighloadQueryID, bool, error) {
+	return s.last, s.found, nil
+}
+
+func (s *testQueryIDStorage) SaveLastQueryID(_ context.Context, id HighloadQueryID) error {
+	s.last, s.found = id, true
+	s.saves++
+	return nil
+}
+
+func TestHighloadV3_Wallet(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	w, err := FromPrivateKey(nil, pkey, HighloadV3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.GetSpec().(*SpecHighloadV3).Timeout() != DefaultHighloadV3Timeout {
+		t.Fatal("incorrect default timeout")
+	}
+
+	state, err := GetStateInit(pkey.Public().(ed25519.PublicKey), HighloadV3, DefaultSubwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data HighloadV3Data
+	if err = tlb.LoadFromCell(&data, state.Data.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data.PublicKey, pkey.Public().(ed25519.PublicKey)) || data.SubwalletID != DefaultSubwallet ||
+		data.Timeout != DefaultHighloadV3Timeout || data.LastCleanTime != 0 {
+		t.Fatal("incorrect data")
+	}
+
+	acc := &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid: true,
+			AccountStorage: tlb.AccountStorage{
+				Status: tlb.AccountStatusActive,
+			},
+		},
+		Code: state.Code,
+	}
+	if ver := GetWalletVersion(acc); ver != HighloadV3 {
+		t.Fatal("incorrect version", ver.String())
+	}
+
+	custom, err := FromPrivateKeyHighloadV3(nil, pkey, DefaultSubwallet, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.Address().String() == w.Address().String() {
+		t.Fatal("timeout should change address")
+	}
+
+	sub, err := custom.GetSubwallet(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.GetSpec().(*SpecHighloadV3).Timeout() != 600 {
+		t.Fatal("subwallet should keep timeout")
+	}
+
+	subState, err := GetHighloadV3StateInit(pkey.Public().(ed25519.PublicKey), 1, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subStateCell, _ := tlb.ToCell(subState)
+	if !bytes.Equal(sub.Address().Data(), subStateCell.Hash()) {
+		t.Fatal("incorrect subwallet address")
+	}
+}
+
+func TestSpecHighloadV3_AllocateQuery(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(nil, pkey, HighloadV3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecHighloadV3)
+
+	q, err := spec.AllocateQuery(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.ID != 0 || q.CreatedAt != 1000000-highloadV3CreatedAtShift {
+		t.Fatal("incorrect first query", q.ID, q.CreatedAt)
+	}
+
+	last, _ := NewHighloadQueryID(3, 1022)
+	storage := &testQueryIDStorage{last: last, found: true}
+	spec.SetQueryIDStorage(storage)
+
+	for i, exp := range []HighloadQueryID{4 << 10, 4<<10 | 1} {
+		q, err = spec.AllocateQuery(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.ID != exp || storage.last != exp {
+			t.Fatal("incorrect query", i, q.ID)
+		}
+	}
+
+	if storage.saves != 2 {
+		t.Fatal("query ids are not saved")
+	}
+}
+
+func TestSpecHighloadV3_AllocateQueryWithoutState(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	bitmap := func(bits ...uint) *cell.Cell {
+		b := make([]byte, 16)
+		for _, bit := range bits {
+			b[bit/8] |= 0x80 >> (bit % 8)
+		}
+		return cell.BeginCell().MustStoreSlice(b, 128).EndCell()
+	}
+
+	queries := cell.NewDict(13)
+	_ = queries.SetIntKey(big.NewInt(2), bitmap(0, 1, 2, 3, 4))
+	oldQueries := cell.NewDict(13)
+	_ = oldQueries.SetIntKey(big.NewInt(1), bitmap(100))
+
+	data, err := tlb.ToCell(HighloadV3Data{
+		PublicKey:   pkey.Public().(ed25519.PublicKey),
+		SubwalletID: DefaultSubwallet,
+		OldQueries:  oldQueries,
+		Queries:     queries,
+		Timeout:     DefaultHighloadV3Timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var checked []uint64
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status: tlb.AccountStatusActive,
+				},
+			},
+			Data: data,
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		if method != "processed?" || len(params) != 2 || params[1].(*big.Int).Sign() == 0 {
+			t.Fatal("incorrect method call", method)
+		}
+
+		id := params[0].(*big.Int).Uint64()
+		checked = append(checked, id)
+		// query sent before restart is already processed, but not yet in the fetched data
+		if id == 2<<10|5 {
+			return ton.NewExecutionResult([]any{big.NewInt(-1)}), nil
+		}
+		return ton.NewExecutionResult([]any{big.NewInt(0)}), nil
+	}
+
+	w, err := FromPrivateKey(m, pkey, HighloadV3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecHighloadV3)
+
+	q, err := spec.AllocateQuery(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.ID != 2<<10|6 || len(checked) != 2 {
+		t.Fatal("incorrect query", q.ID, checked)
+	}
+
+	// saved id is continued without checks
+	q, err = spec.AllocateQuery(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.ID != 2<<10|7 || len(checked) != 2 {
+		t.Fatal("incorrect next query", q.ID, checked)
+	}
+
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		return ton.NewExecutionResult([]any{big.NewInt(-1)}), nil
+	}
+	spec.SetQueryIDStorage(&testQueryIDStorage{})
+	if _, err = spec.AllocateQuery(context.Background()); err == nil {
+		t.Fatal("should fail when all checked ids are processed")
+	}
+}
+
+func TestFileQueryIDStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query_id.json")
+
+	s, err := NewFileQueryIDStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := s.LoadLastQueryID(context.Background()); found {
+		t.Fatal("should be empty")
+	}
+
+	if err = s.SaveLastQueryID(context.Background(), 5<<10|7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("temp file should be renamed")
+	}
+
+	// reopen, like after restart
+	s, err = NewFileQueryIDStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, found, err := s.LoadLastQueryID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || id != 5<<10|7 {
+		t.Fatal("incorrect loaded id", id, found)
+	}
+
+	if err = os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewFileQueryIDStorage(path); err == nil {
+		t.Fatal("corrupted file should be rejected")
+	}
+}
+
+func TestSpecHighloadV3_BuildMessage(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(nil, pkey, HighloadV3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecHighloadV3)
+
+	intMsg := &tlb.InternalMessage{
+		Bounce:  true,
+		DstAddr: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Amount:  tlb.MustFromTON("0.01"),
+	}
+
+	query := &HighloadV3Query{ID: 7<<10 | 5, CreatedAt: 1000}
+
+	body, err := spec.BuildMessageForQuery(context.Background(), query, []*Message{{Mode: 3, InternalMessage: intMsg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := body.BeginParse()
+	sign := p.MustLoadSlice(512)
+	inner := p.MustLoadRef().MustToCell()
+	if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), inner.Hash(), sign) {
+		t.Fatal("sign incorrect")
+	}
+
+	ip := inner.BeginParse()
+	if ip.MustLoadUInt(32) != DefaultSubwallet {
+		t.Fatal("subwallet incorrect")
+	}
+
+	intMsgCell, _ := tlb.ToCell(intMsg)
+	if !bytes.Equal(ip.MustLoadRef().MustToCell().Hash(), intMsgCell.Hash()) {
+		t.Fatal("message incorrect")
+	}
+	if ip.MustLoadUInt(8) != 3 {
+		t.Fatal("mode incorrect")
+	}
+	if ip.MustLoadUInt(13) != 7 || ip.MustLoadUInt(10) != 5 {
+		t.Fatal("query id incorrect")
+	}
+	if ip.MustLoadUInt(64) != 1000 || ip.MustLoadUInt(22) != DefaultHighloadV3Timeout {
+		t.Fatal("created at or timeout incorrect")
+	}
+
+	var messages []*Message
+	for i := 0; i < 300; i++ {
+		messages = append(messages, &Message{Mode: 3, InternalMessage: intMsg})
+	}
+
+	body, err = spec.BuildMessageForQuery(context.Background(), query, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ip = body.BeginParse().MustLoadRef()
+	ip.MustLoadUInt(32)
+
+	var batch tlb.InternalMessage
+	if err = tlb.LoadFromCell(&batch, ip.MustLoadRef()); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := 0
+	for level := 0; ; level++ {
+		if batch.DstAddr.String() != w.Address().String() {
+			t.Fatal("batch should be sent to wallet itself")
+		}
+		if batch.Amount.Nano().Cmp(new(big.Int).Mul(intMsg.Amount.Nano(), big.NewInt(int64(300-sent)))) != 0 {
+			t.Fatal("batch amount incorrect", level, batch.Amount.String())
+		}
+
+		bp := batch.Body.BeginParse()
+		if bp.MustLoadUInt(32) != OpHighloadV3InternalTransfer || bp.MustLoadUInt(64) != uint64(query.ID) {
+			t.Fatal("internal transfer header incorrect")
+		}
+
+		var actions []*cell.Slice
+		for list := bp.MustLoadRef(); list.RefsNum() > 0; {
+			prev := list.MustLoadRef()
+			if list.MustLoadUInt(32) != opActionSendMsg {
+				t.Fatal("action incorrect")
+			}
+			actions = append([]*cell.Slice{list}, actions...)
+			list = prev
+		}
+
+		if len(actions) > highloadV3MaxActions {
+			t.Fatal("too many actions", len(actions))
+		}
+
+		var nested *tlb.InternalMessage
+		for i, a := range actions {
+			a.MustLoadUInt(8)
+			var m tlb.InternalMessage
+			if err = tlb.LoadFromCell(&m, a.MustLoadRef()); err != nil {
+				t.Fatal(err)
+			}
+
+			if m.DstAddr.String() == w.Address().String() {
+				if i != len(actions)-1 {
+					t.Fatal("nested batch should be the last action")
+				}
+				nested = &m
+				continue
+			}
+			sent++
+		}
+
+		if nested == nil {
+			break
+		}
+		batch = *nested
+	}
+
+	if sent != 300 {
+		t.Fatal("not all messages are packed", sent)
+	}
+}
+
+func TestSpecHighloadV3_SendQuery(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	processed := false
+	var sent *tlb.ExternalMessage
+
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status: tlb.AccountStatusActive,
+				},
+			},
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		if method != "processed?" || len(params) != 2 {
+			t.Fatal("incorrect method call", method)
+		}
+		if params[0].(*big.Int).Uint64() != 77 || params[1].(*big.Int).Sign() != 0 {
+			t.Fatal("incorrect params")
+		}
+
+		if processed {
+			return ton.NewExecutionResult([]any{big.NewInt(-1)}), nil
+		}
+		return ton.NewExecutionResult([]any{big.NewInt(0)}), nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		sent = msg
+		return nil
+	}
+
+	w, err := FromPrivateKey(m, pkey, HighloadV3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecHighloadV3)
+
+	msg := &Message{Mode: 3, InternalMessage: &tlb.InternalMessage{
+		DstAddr: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Amount:  tlb.MustFromTON("0.01"),
+	}}
+	query := &HighloadV3Query{ID: 77, CreatedAt: 1000000 - 60}
+
+	ext, err := spec.SendQuery(context.Background(), query, []*Message{msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil || !bytes.Equal(sent.Body.Hash(), ext.Body.Hash()) || ext.StateInit != nil {
+		t.Fatal("incorrect sent message")
+	}
+
+	// resend with the same query should produce the same message
+	ext2, err := spec.SendQuery(context.Background(), query, []*Message{msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ext2.Body.Hash(), ext.Body.Hash()) {
+		t.Fatal("resent message is different")
+	}
+
+	processed = true
+	if _, err = spec.SendQuery(context.Background(), query, []*Message{msg}); !errors.Is(err, ErrQueryAlreadyProcessed) {
+		t.Fatal("should be already processed", err)
+	}
+
+	processed = false
+	expired := &HighloadV3Query{ID: 77, CreatedAt: 1000000 - DefaultHighloadV3Timeout}
+	if _, err = spec.SendQuery(context.Background(), expired, []*Message{msg}); !errors.Is(err, ErrQueryExpired) {
+		t.Fatal("should be expired", err)
+	}
+}
@@ -1,4 +1,728 @@
 package wallet
 
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
 // https://github.com/toncenter/tonweb/blob/master/src/contract/wallet/WalletSources.md#lockup-wallet
 const _LockupCodeHex = "B5EE9C7241021E01000261000114FF00F4A413F4BCF2C80B010201200203020148040501F2F28308D71820D31FD31FD31F802403F823BB13F2F2F003802251A9BA1AF2F4802351B7BA1BF2F4801F0BF9015410C5F9101AF2F4F8005057F823F0065098F823F0062071289320D74A8E8BD30731D4511BDB3C12B001E8309229A0DF72FB02069320D74A96D307D402FB00E8D103A4476814154330F004ED541D0202CD0607020120131402012008090201200F100201200A0B002D5ED44D0D31FD31FD3FFD3FFF404FA00F404FA00F404D1803F7007434C0C05C6C2497C0F83E900C0871C02497C0F80074C7C87040A497C1383C00D46D3C00608420BABE7114AC2F6C2497C338200A208420BABE7106EE86BCBD20084AE0840EE6B2802FBCBD01E0C235C62008087E4055040DBE4404BCBD34C7E00A60840DCEAA7D04EE84BCBD34C034C7CC0078C3C412040DD78CA00C0D0E00130875D27D2A1BE95B0C60000C1039480AF00500161037410AF0050810575056001010244300F004ED540201201112004548E1E228020F4966FA520933023BB9131E2209835FA00D113A14013926C21E2B3E6308003502323287C5F287C572FFC4F2FFFD00007E80BD00007E80BD00326000431448A814C4E0083D039BE865BE803444E800A44C38B21400FE809004E0083D10C06002012015160015BDE9F780188242F847800C02012017180201481B1C002DB5187E006D88868A82609E00C6207E00C63F04EDE20B30020158191A0017ADCE76A268699F98EB85FFC00017AC78F6A268698F98EB858FC00011B325FB513435C2C7E00017B1D1BE08E0804230FB50F620002801D0D3030178B0925B7FE0FA4031FA403001F001A80EDAA4"
+
+// lockupAddrKeyLen - allowed destinations are stored in prefix dictionary with serialized std address as a key
+const lockupAddrKeyLen = 267
+
+// ErrLockupNotEnoughFunds - messages want to send more than lockup wallet can spend now
+var ErrLockupNotEnoughFunds = errors.New("amount exceeds spendable balance of lockup wallet")
+
+// LockupConfig - parameters of the lockup wallet which are set on deploy, they are part of the wallet address
+type LockupConfig struct {
+	// ConfigPublicKey - key of the lockup issuer, requests signed by it lock and restrict funds on the wallet
+	ConfigPublicKey ed25519.PublicKey
+	// AllowedDestinations - addresses where restricted funds can be sent
+	AllowedDestinations []*address.Address
+}
+
+// LockupVesting - amount which becomes available at the given time
+type LockupVesting struct {
+	UnlockAt time.Time
+	Amount   tlb.Coins
+}
+
+// LockupData - decoded data of the lockup wallet.
+// Locked funds cannot be sent before their unlock time, restricted funds
+// can be sent before their unlock time only to the allowed destinations.
+type LockupData struct {
+	Seqno           uint32
+	Subwallet       uint32
+	PublicKey       ed25519.PublicKey
+	ConfigPublicKey ed25519.PublicKey
+	// AllowedDestinations - full addresses from the allowed destinations dictionary,
+	// dictionary can also contain shorter prefixes, use IsDestinationAllowed to check address
+	AllowedDestinations []*address.Address
+
+	// TotalLocked and TotalRestricted are updated by contract only on the next transaction,
+	// so they can include already unlocked amounts, use BalancesAt to get actual values
+	TotalLocked     tlb.Coins
+	Locked          []LockupVesting
+	TotalRestricted tlb.Coins
+	Restricted      []LockupVesting
+
+	allowedPrefixes [][]bool
+}
+
+// LockupBalances - balance of the lockup wallet with the amounts which are not unlocked yet
+type LockupBalances struct {
+	Balance    tlb.Coins
+	Restricted tlb.Coins
+	Locked     tlb.Coins
+}
+
+// Spendable - amount which can be sent to any destination
+func (b *LockupBalances) Spendable() tlb.Coins {
+	return subCoins(b.Balance.Nano(), b.Locked.Nano(), b.Restricted.Nano())
+}
+
+// SpendableToAllowed - amount which can be sent when all messages are sent to the allowed destinations
+func (b *LockupBalances) SpendableToAllowed() tlb.Coins {
+	return subCoins(b.Balance.Nano(), b.Locked.Nano())
+}
+
+type SpecLockup struct {
+	SpecRegular
+	SpecSeqno
+
+	config LockupConfig
+}
+
+// FromPrivateKeyLockup - initializes lockup wallet, its address depends on the config,
+// so it should be the same as was used on deploy
+func FromPrivateKeyLockup(api TonAPI, key ed25519.PrivateKey, subwallet uint32, cfg LockupConfig) (*Wallet, error) {
+	return FromSignerLockup(api, NewKeySigner(key), subwallet, cfg)
+}
+
+// FromSignerLockup - same as FromPrivateKeyLockup, but messages are signed by signer
+func FromSignerLockup(api TonAPI, signer Signer, subwallet uint32, cfg LockupConfig) (*Wallet, error) {
+	state, err := GetLockupStateInit(signer.PublicKey(), subwallet, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := addressFromStateInit(state)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Wallet{
+		api:       api,
+		signer:    signer,
+		addr:      addr,
+		ver:       Lockup,
+		subwallet: subwallet,
+	}
+
+	w.spec, err = getSpec(w)
+	if err != nil {
+		return nil, err
+	}
+	w.spec.(*SpecLockup).config = cfg
+
+	return w, nil
+}
+
+// GetLockupStateInit - same as GetStateInit but for lockup wallet, which has no locked funds initially
+func GetLockupStateInit(pubKey ed25519.PublicKey, subWallet uint32, cfg LockupConfig) (*tlb.StateInit, error) {
+	if len(cfg.ConfigPublicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("config public key is not set")
+	}
+
+	keys := make([][]bool, 0, len(cfg.AllowedDestinations))
+	for i, addr := range cfg.AllowedDestinations {
+		key, err := lockupAddrKey(addr)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect allowed destination %d: %w", i, err)
+		}
+		keys = append(keys, key)
+	}
+
+	allowed, err := buildPfxDict(keys, lockupAddrKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build allowed destinations: %w", err)
+	}
+
+	data := cell.BeginCell().
+		MustStoreUInt(0, 32). // seqno
+		MustStoreUInt(uint64(subWallet), 32).
+		MustStoreSlice(pubKey, 256).
+		MustStoreSlice(cfg.ConfigPublicKey, 256).
+		MustStoreMaybeRef(allowed).
+		MustStoreBigCoins(big.NewInt(0)). // total locked
+		MustStoreDict(nil).
+		MustStoreBigCoins(big.NewInt(0)). // total restricted
+		MustStoreDict(nil).
+		EndCell()
+
+	return &tlb.StateInit{
+		Data: data,
+		Code: walletCode[Lockup],
+	}, nil
+}
+
+// Config - returns config which was used to calculate wallet address
+func (s *SpecLockup) Config() LockupConfig {
+	return s.config
+}
+
+// BuildMessage - builds signed body of lockup wallet external message, format is the same as V3.
+// When wallet is initialized, messages are checked against its locked and restricted funds,
+// ErrLockupNotEnoughFunds is returned when contract will not be able to send them.
+func (s *SpecLockup) BuildMessage(ctx context.Context, isInitialized bool, block *ton.BlockIDExt, messages []*Message) (*cell.Cell, error) {
+	var seq uint32
+	if isInitialized {
+		acc, err := s.wallet.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, s.wallet.addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account state: %w", err)
+		}
+
+		data, err := checkLockupMessages(acc, messages)
+		if err != nil {
+			return nil, err
+		}
+		seq = data.Seqno
+	}
+
+	if s.customSeqnoFetcher != nil {
+		seq = s.customSeqnoFetcher()
+	}
+
+	payload, err := buildV3Payload(s.wallet.subwallet, s.validUntil(), seq, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
+	return cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell(), nil
+}
+
+// checkLockupMessages - checks that lockup wallet account can send messages now, returns its decoded data
+func checkLockupMessages(acc *tlb.Account, messages []*Message) (*LockupData, error) {
+	if !acc.IsActive || acc.Data == nil {
+		return nil, errors.New("wallet is not active")
+	}
+
+	data, err := ParseLockupData(acc.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = data.CheckMessages(acc.State.Balance, timeNow(), messages); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetData - loads and decodes data of the lockup wallet
+func (s *SpecLockup) GetData(ctx context.Context, block *ton.BlockIDExt) (*LockupData, error) {
+	acc, err := s.wallet.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, s.wallet.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !acc.IsActive || acc.Data == nil {
+		return nil, errors.New("wallet is not active")
+	}
+	return ParseLockupData(acc.Data)
+}
+
+// GetBalances - runs get_balances method of the contract, which returns balance with locked and restricted amounts at block time
+func (s *SpecLockup) GetBalances(ctx context.Context, block *ton.BlockIDExt) (*LockupBalances, error) {
+	return s.getBalances(ctx, block, "get_balances")
+}
+
+// GetBalancesAt - runs get_balances_at method of the contract, it calculates locked and restricted amounts at the given time
+func (s *SpecLockup) GetBalancesAt(ctx context.Context, block *ton.BlockIDExt, at time.Time) (*LockupBalances, error) {
+	return s.getBalances(ctx, block, "get_balances_at", at.Unix())
+}
+
+func (s *SpecLockup) getBalances(ctx context.Context, block *ton.BlockIDExt, method string, params ...any) (*LockupBalances, error) {
+	res, err := s.wallet.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, s.wallet.addr, method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s method: %w", method, err)
+	}
+
+	vals := make([]tlb.Coins, 3)
+	for i := range vals {
+		v, err := res.Int(uint(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s result %d: %w", method, i, err)
+		}
+		vals[i] = tlb.FromNanoTON(v)
+	}
+
+	return &LockupBalances{
+		Balance:    vals[0],
+		Restricted: vals[1],
+		Locked:     vals[2],
+	}, nil
+}
+
+// ParseLockupData - decodes data of the lockup wallet contract
+func ParseLockupData(data *cell.Cell) (*LockupData, error) {
+	s := data.BeginParse()
+
+	seqno, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load seqno: %w", err)
+	}
+
+	subwallet, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subwallet: %w", err)
+	}
+
+	pubKey, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public key: %w", err)
+	}
+
+	configKey, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config public key: %w", err)
+	}
+
+	allowed, err := s.LoadMaybeRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load allowed destinations: %w", err)
+	}
+
+	res := &LockupData{
+		Seqno:           uint32(seqno),
+		Subwallet:       uint32(subwallet),
+		PublicKey:       pubKey,
+		ConfigPublicKey: configKey,
+	}
+
+	if allowed != nil {
+		root, err := allowed.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load allowed destinations: %w", err)
+		}
+
+		if err = parsePfxEdge(root, lockupAddrKeyLen, nil, &res.allowedPrefixes); err != nil {
+			return nil, fmt.Errorf("failed to parse allowed destinations: %w", err)
+		}
+
+		for _, key := range res.allowedPrefixes {
+			if len(key) != lockupAddrKeyLen {
+				continue
+			}
+
+			addr, err := bitsToCell(key).BeginParse().LoadAddr()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse allowed destination: %w", err)
+			}
+			res.AllowedDestinations = append(res.AllowedDestinations, addr)
+		}
+	}
+
+	if res.TotalLocked, res.Locked, err = loadLockupVesting(s); err != nil {
+		return nil, fmt.Errorf("failed to load locked funds: %w", err)
+	}
+	if res.TotalRestricted, res.Restricted, err = loadLockupVesting(s); err != nil {
+		return nil, fmt.Errorf("failed to load restricted funds: %w", err)
+	}
+	return res, nil
+}
+
+func loadLockupVesting(s *cell.Slice) (tlb.Coins, []LockupVesting, error) {
+	total, err := s.LoadBigCoins()
+	if err != nil {
+		return tlb.Coins{}, nil, fmt.Errorf("failed to load total: %w", err)
+	}
+
+	dict, err := s.LoadDict(32)
+	if err != nil {
+		return tlb.Coins{}, nil, fmt.Errorf("failed to load dict: %w", err)
+	}
+
+	var list []LockupVesting
+	if dict != nil {
+		kvs, err := dict.LoadAll()
+		if err != nil {
+			return tlb.Coins{}, nil, fmt.Errorf("failed to load dict: %w", err)
+		}
+
+		for _, kv := range kvs {
+			at := kv.Key.MustLoadUInt(32)
+			amount, err := kv.Value.LoadBigCoins()
+			if err != nil {
+				return tlb.Coins{}, nil, fmt.Errorf("failed to load amount unlocked at %d: %w", at, err)
+			}
+
+			list = append(list, LockupVesting{
+				UnlockAt: time.Unix(int64(at), 0),
+				Amount:   tlb.FromNanoTON(amount),
+			})
+		}
+
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].UnlockAt.Before(list[j].UnlockAt)
+		})
+	}
+	return tlb.FromNanoTON(total), list, nil
+}
+
+// IsDestinationAllowed - checks is restricted funds can be sent to the address
+func (d *LockupData) IsDestinationAllowed(addr *address.Address) bool {
+	key, err := lockupAddrKey(addr)
+	if err != nil {
+		return false
+	}
+
+	for _, pfx := range d.allowedPrefixes {
+		if len(pfx) <= len(key) && equalBits(pfx, key[:len(pfx)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// BalancesAt - calculates locked and restricted amounts at the given time, in the same way as contract does,
+// funds are unlocked when their unlock time is not after the current time
+func (d *LockupData) BalancesAt(balance tlb.Coins, at time.Time) *LockupBalances {
+	stillLocked := func(list []LockupVesting) tlb.Coins {
+		sum := big.NewInt(0)
+		for _, v := range list {
+			if v.UnlockAt.After(at) {
+				sum.Add(sum, v.Amount.Nano())
+			}
+		}
+		return tlb.FromNanoTON(sum)
+	}
+
+	return &LockupBalances{
+		Balance:    balance,
+		Restricted: stillLocked(d.Restricted),
+		Locked:     stillLocked(d.Locked),
+	}
+}
+
+// CheckMessages - checks that wallet with the given balance can send messages at the given time.
+// Contract reserves locked funds, and restricted funds too when any message is sent not to the allowed destination.
+// Amount of the messages with mode 128 is not known, so they are only checked for the destination.
+func (d *LockupData) CheckMessages(balance tlb.Coins, at time.Time, messages []*Message) error {
+	balances := d.BalancesAt(balance, at)
+
+	allAllowed := true
+	total := big.NewInt(0)
+	for i, message := range messages {
+		if message == nil || message.InternalMessage == nil {
+			return fmt.Errorf("message %d is empty", i)
+		}
+
+		if !d.IsDestinationAllowed(message.InternalMessage.DstAddr) {
+			allAllowed = false
+		}
+
+		if message.Mode&128 == 0 {
+			total.Add(total, message.InternalMessage.Amount.Nano())
+		}
+	}
+
+	spendable := balances.Spendable()
+	if allAllowed {
+		spendable = balances.SpendableToAllowed()
+	}
+
+	if total.Cmp(spendable.Nano()) > 0 {
+		return fmt.Errorf("%w: want to send %s TON, but only %s TON can be spent", ErrLockupNotEnoughFunds, tlb.FromNanoTON(total).String(), spendable.String())
+	}
+	return nil
+}
+
+// subCoins - subtracts values from the amount, result cannot be less than zero
+func subCoins(amount *big.Int, values ...*big.Int) tlb.Coins {
+	res := new(big.Int).Set(amount)
+	for _, v := range values {
+		res.Sub(res, v)
+	}
+
+	if res.Sign() < 0 {
+		res.SetInt64(0)
+	}
+	return tlb.FromNanoTON(res)
+}
+
+// lockupAddrKey - returns bits of serialized std address, which is used as a key of allowed destinations
+func lockupAddrKey(addr *address.Address) ([]bool, error) {
+	if addr == nil || addr.Type() != address.StdAddress {
+		return nil, errors.New("only std address can be allowed destination")
+	}
+
+	c := cell.BeginCell()
+	if err := c.StoreAddr(addr); err != nil {
+		return nil, err
+	}
+
+	s := c.EndCell().BeginParse()
+	key := make([]bool, s.BitsLeft())
+	for i := range key {
+		key[i] = s.MustLoadBoolBit()
+	}
+	return key, nil
+}
+
+func bitsToCell(key []bool) *cell.Cell {
+	b := cell.BeginCell()
+	for _, bit := range key {
+		b.MustStoreBoolBit(bit)
+	}
+	return b.EndCell()
+}
+
+func equalBits(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildPfxDict - builds prefix dictionary with empty values, nil is returned for empty keys list.
+// Keys which have other key as a prefix are skipped, because they are already matched by shorter one.
+func buildPfxDict(keys [][]bool, keyLen uint) (*cell.Cell, error) {
+	var list [][]bool
+	for i, key := range keys {
+		if uint(len(key)) > keyLen {
+			return nil, fmt.Errorf("key %d is longer than %d bits", i, keyLen)
+		}
+
+		covered := false
+		for j, other := range keys {
+			if j == i || len(other) > len(key) || !equalBits(other, key[:len(other)]) {
+				continue
+			}
+			// for the same keys only the first one is kept
+			if len(other) < len(key) || j < i {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			list = append(list, key)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return buildPfxEdge(list, keyLen)
+}
+
+// buildPfxEdge - builds node of prefix dictionary, keys should be unique and should not be prefixes of each other
+func buildPfxEdge(keys [][]bool, keyLen uint) (*cell.Cell, error) {
+	pfx := keys[0]
+	for _, key := range keys[1:] {
+		n := 0
+		for n < len(pfx) && n < len(key) && pfx[n] == key[n] {
+			n++
+		}
+		pfx = pfx[:n]
+	}
+
+	b := cell.BeginCell()
+	if err := storePfxLabel(b, pfx, keyLen); err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 1 && len(keys[0]) == len(pfx) {
+		// phmn_leaf$0 with empty value
+		return b.MustStoreUInt(0, 1).EndCell(), nil
+	}
+
+	var left, right [][]bool
+	for _, key := range keys {
+		if len(key) == len(pfx) {
+			return nil, errors.New("key is a prefix of other key")
+		}
+
+		if key[len(pfx)] {
+			right = append(right, key[len(pfx)+1:])
+		} else {
+			left = append(left, key[len(pfx)+1:])
+		}
+	}
+
+	rest := keyLen - uint(len(pfx)) - 1
+	l, err := buildPfxEdge(left, rest)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := buildPfxEdge(right, rest)
+	if err != nil {
+		return nil, err
+	}
+
+	// phmn_fork$1
+	return b.MustStoreUInt(1, 1).MustStoreRef(l).MustStoreRef(r).EndCell(), nil
+}
+
+// storePfxLabel - stores HmLabel in the shortest form, like node does
+func storePfxLabel(b *cell.Builder, label []bool, keyLen uint) error {
+	ln := uint(len(label))
+	lenBits := uint(bits.Len(keyLen))
+
+	allSame := ln > 0
+	for _, bit := range label {
+		if bit != label[0] {
+			allSame = false
+			break
+		}
+	}
+
+	shortSz, longSz, sameSz := 2+2*ln, 2+lenBits+ln, 3+lenBits
+	switch {
+	case allSame && sameSz < shortSz && sameSz < longSz:
+		// hml_same$11
+		if err := b.StoreUInt(0b11, 2); err != nil {
+			return err
+		}
+		if err := b.StoreBoolBit(label[0]); err != nil {
+			return err
+		}
+		return b.StoreUInt(uint64(ln), lenBits)
+	case shortSz <= longSz:
+		// hml_short$0 with unary length
+		if err := b.StoreUInt(0, 1); err != nil {
+			return err
+		}
+		for i := uint(0); i < ln; i++ {
+			if err := b.StoreBoolBit(true); err != nil {
+				return err
+			}
+		}
+		if err := b.StoreBoolBit(false); err != nil {
+			return err
+		}
+	default:
+		// hml_long$10
+		if err := b.StoreUInt(0b10, 2); err != nil {
+			return err
+		}
+		if err := b.StoreUInt(uint64(ln), lenBits); err != nil {
+			return err
+		}
+	}
+
+	for _, bit := range label {
+		if err := b.StoreBoolBit(bit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePfxEdge - collects keys of prefix dictionary node
+func parsePfxEdge(c *cell.Cell, keyLen uint, prefix []bool, keys *[][]bool) error {
+	s := c.BeginParse()
+
+	label, err := loadPfxLabel(s, keyLen)
+	if err != nil {
+		return fmt.Errorf("failed to load label: %w", err)
+	}
+
+	key := append(append([]bool{}, prefix...), label...)
+	rest := keyLen - uint(len(label))
+
+	isFork, err := s.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load node type: %w", err)
+	}
+
+	if !isFork {
+		*keys = append(*keys, key)
+		return nil
+	}
+
+	if rest == 0 {
+		return errors.New("fork with the full key")
+	}
+
+	for _, bit := range []bool{false, true} {
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			return fmt.Errorf("failed to load fork branch: %w", err)
+		}
+
+		if err = parsePfxEdge(ref, rest-1, append(append([]bool{}, key...), bit), keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadPfxLabel(s *cell.Slice, keyLen uint) ([]bool, error) {
+	lenBits := uint(bits.Len(keyLen))
+
+	isShort, err := s.LoadBoolBit()
+	if err != nil {
+		return nil, err
+	}
+
+	var ln uint64
+	if !isShort {
+		// hml_short$0
+		for {
+			bit, err := s.LoadBoolBit()
+			if err != nil {
+				return nil, err
+			}
+			if !bit {
+				break
+			}
+			ln++
+		}
+	} else {
+		isSame, err := s.LoadBoolBit()
+		if err != nil {
+			return nil, err
+		}
+
+		if isSame {
+			// hml_same$11
+			bit, err := s.LoadBoolBit()
+			if err != nil {
+				return nil, err
+			}
+
+			if ln, err = s.LoadUInt(lenBits); err != nil {
+				return nil, err
+			}
+			if ln > uint64(keyLen) {
+				return nil, errors.New("label is longer than key")
+			}
+
+			label := make([]bool, ln)
+			for i := range label {
+				label[i] = bit
+			}
+			return label, nil
+		}
+
+		// hml_long$10
+		if ln, err = s.LoadUInt(lenBits); err != nil {
+			return nil, err
+		}
+	}
+
+	if ln > uint64(keyLen) {
+		return nil, errors.New("label is longer than key")
+	}
+
+	label := make([]bool, ln)
+	for i := range label {
+		if label[i], err = s.LoadBoolBit(); err != nil {
+			return nil, err
+		}
+	}
+	return label, nil
+}
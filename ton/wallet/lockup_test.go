@@ -0,0 +1,287 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	lockupElector = address.MustParseRawAddr("-1:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	lockupPool    = address.MustParseRawAddr("0:" + "1111111111111111111111111111111111111111111111111111111111111111")
+	lockupOther   = address.MustParseRawAddr("0:" + "2222222222222222222222222222222222222222222222222222222222222222")
+)
+
+func lockupVestingDict(vals map[uint32]string) *cell.Dictionary {
+	dict := cell.NewDict(32)
+	for at, amount := range vals {
+		if err := dict.Set(cell.BeginCell().MustStoreUInt(uint64(at), 32).EndCell(),
+			cell.BeginCell().MustStoreBigCoins(tlb.MustFromTON(amount).Nano()).EndCell()); err != nil {
+			panic(err)
+		}
+	}
+	return dict
+}
+
+func testLockupData(t *testing.T, pubKey, configKey ed25519.PublicKey, seqno uint32, allowed [][]bool) *cell.Cell {
+	root, err := buildPfxDict(allowed, lockupAddrKeyLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(DefaultSubwallet, 32).
+		MustStoreSlice(pubKey, 256).
+		MustStoreSlice(configKey, 256).
+		MustStoreMaybeRef(root).
+		MustStoreBigCoins(tlb.MustFromTON("300").Nano()).
+		MustStoreDict(lockupVestingDict(map[uint32]string{1000000 - 10: "100", 1000000: "50", 1000000 + 100: "150"})).
+		MustStoreBigCoins(tlb.MustFromTON("200").Nano()).
+		MustStoreDict(lockupVestingDict(map[uint32]string{1000000 + 50: "120", 1000000 + 200: "80"})).
+		EndCell()
+}
+
+func TestParseLockupData(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	configKey := ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab")).Public().(ed25519.PublicKey)
+
+	var keys [][]bool
+	for _, addr := range []*address.Address{lockupElector, lockupPool, lockupElector} {
+		key, err := lockupAddrKey(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	// prefix of the workchain -1 std address, so all masterchain addresses are allowed,
+	// it covers elector address which should not be in the dictionary twice
+	keys = append(keys, keys[0][:11])
+
+	data, err := ParseLockupData(testLockupData(t, pkey.Public().(ed25519.PublicKey), configKey, 7, keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Seqno != 7 || data.Subwallet != DefaultSubwallet ||
+		!bytes.Equal(data.PublicKey, pkey.Public().(ed25519.PublicKey)) || !bytes.Equal(data.ConfigPublicKey, configKey) {
+		t.Fatal("incorrect data", data)
+	}
+
+	if len(data.AllowedDestinations) != 1 || data.AllowedDestinations[0].String() != lockupPool.String() || len(data.allowedPrefixes) != 2 {
+		t.Fatal("incorrect allowed destinations", data.AllowedDestinations, len(data.allowedPrefixes))
+	}
+
+	for addr, allowed := range map[*address.Address]bool{
+		lockupElector: true,
+		lockupPool:    true,
+		lockupOther:   false,
+		address.MustParseRawAddr("-1:" + "5555555555555555555555555555555555555555555555555555555555555555"): true,
+	} {
+		if data.IsDestinationAllowed(addr) != allowed {
+			t.Fatal("incorrect destination check", addr.String())
+		}
+	}
+
+	if data.TotalLocked.String() != "300" || len(data.Locked) != 3 || data.Locked[0].UnlockAt.Unix() != 1000000-10 ||
+		data.Locked[2].Amount.String() != "150" || data.TotalRestricted.String() != "200" || len(data.Restricted) != 2 {
+		t.Fatal("incorrect vesting", data.Locked, data.Restricted)
+	}
+
+	balances := data.BalancesAt(tlb.MustFromTON("1000"), time.Unix(1000000, 0))
+	if balances.Locked.String() != "150" || balances.Restricted.String() != "200" ||
+		balances.Spendable().String() != "650" || balances.SpendableToAllowed().String() != "850" {
+		t.Fatal("incorrect balances", balances)
+	}
+
+	balances = data.BalancesAt(tlb.MustFromTON("100"), time.Unix(1000000+60, 0))
+	if balances.Locked.String() != "150" || balances.Restricted.String() != "80" ||
+		balances.Spendable().String() != "0" || balances.SpendableToAllowed().String() != "0" {
+		t.Fatal("incorrect balances", balances)
+	}
+}
+
+func TestPfxDict(t *testing.T) {
+	var keys [][]bool
+	for i := 0; i < 20; i++ {
+		addr := address.NewAddress(0, byte(i%2), bytes.Repeat([]byte{byte(i * 13)}, 32))
+		key, err := lockupAddrKey(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+
+	root, err := buildPfxDict(keys, lockupAddrKeyLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed [][]bool
+	if err = parsePfxEdge(root, lockupAddrKeyLen, nil, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != len(keys) {
+		t.Fatal("incorrect keys num", len(parsed))
+	}
+
+next:
+	for _, key := range keys {
+		for _, p := range parsed {
+			if equalBits(key, p) {
+				continue next
+			}
+		}
+		t.Fatal("key not found")
+	}
+
+	if _, err = buildPfxDict([][]bool{make([]bool, lockupAddrKeyLen+1)}, lockupAddrKeyLen); err == nil {
+		t.Fatal("too long key should be rejected")
+	}
+}
+
+func TestSpecLockup_BuildMessage(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	configKey := ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab")).Public().(ed25519.PublicKey)
+	cfg := LockupConfig{
+		ConfigPublicKey:     configKey,
+		AllowedDestinations: []*address.Address{lockupElector, lockupPool},
+	}
+
+	state, err := GetLockupStateInit(pkey.Public().(ed25519.PublicKey), DefaultSubwallet, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empty, err := ParseLockupData(state.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.AllowedDestinations) != 2 || !empty.IsDestinationAllowed(lockupElector) || empty.IsDestinationAllowed(lockupOther) ||
+		len(empty.Locked) != 0 || empty.TotalRestricted.Nano().Sign() != 0 {
+		t.Fatal("incorrect initial data", empty)
+	}
+
+	allowed := make([][]bool, 0, len(empty.allowedPrefixes))
+	allowed = append(allowed, empty.allowedPrefixes...)
+
+	initialized := true
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		if !initialized {
+			return &tlb.Account{}, nil
+		}
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status:  tlb.AccountStatusActive,
+					Balance: tlb.MustFromTON("1000"),
+				},
+			},
+			Data: testLockupData(t, pkey.Public().(ed25519.PublicKey), configKey, 9, allowed),
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		switch method {
+		case "get_balances":
+			return ton.NewExecutionResult([]any{tlb.MustFromTON("1000").Nano(), tlb.MustFromTON("200").Nano(), tlb.MustFromTON("150").Nano()}), nil
+		case "get_balances_at":
+			if params[0].(int64) != 1000000+300 {
+				t.Fatal("incorrect time")
+			}
+			return ton.NewExecutionResult([]any{tlb.MustFromTON("1000").Nano(), big.NewInt(0), big.NewInt(0)}), nil
+		}
+		t.Fatal("unexpected method", method)
+		return nil, nil
+	}
+
+	w, err := FromPrivateKeyLockup(m, pkey, DefaultSubwallet, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stateCell, _ := tlb.ToCell(state)
+	if !bytes.Equal(w.WalletAddress().Data(), stateCell.Hash()) {
+		t.Fatal("incorrect address")
+	}
+
+	if _, err = FromPrivateKey(m, pkey, Lockup); !errors.Is(err, ErrUnsupportedWalletVersion) {
+		t.Fatal("lockup should require config", err)
+	}
+
+	transfer := func(to *address.Address, amount string, mode uint8) *Message {
+		return &Message{Mode: mode, InternalMessage: &tlb.InternalMessage{DstAddr: to, Amount: tlb.MustFromTON(amount), Body: cell.BeginCell().EndCell()}}
+	}
+
+	ext, err := w.BuildExternalMessageForMany(context.Background(), []*Message{transfer(lockupElector, "600", 3), transfer(lockupPool, "250", 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := ext.Body.BeginParse()
+	sign := p.MustLoadSlice(512)
+	if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), p.MustToCell().Hash(), sign) {
+		t.Fatal("incorrect signature")
+	}
+	if ext.StateInit != nil || p.MustLoadUInt(32) != DefaultSubwallet || p.MustLoadUInt(32) != 1000000+60*3 || p.MustLoadUInt(32) != 9 || p.RefsNum() != 2 {
+		t.Fatal("incorrect body")
+	}
+
+	for _, messages := range [][]*Message{
+		{transfer(lockupElector, "851", 3)},
+		{transfer(lockupElector, "100", 3), transfer(lockupOther, "551", 3)},
+	} {
+		if _, err = w.BuildExternalMessageForMany(context.Background(), messages); !errors.Is(err, ErrLockupNotEnoughFunds) {
+			t.Fatal("amount above spendable should be rejected", err)
+		}
+	}
+
+	if _, err = w.BuildExternalMessage(context.Background(), transfer(lockupOther, "0", 128)); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := w.GetSpec().(*SpecLockup)
+	balances, err := spec.GetBalances(context.Background(), &ton.BlockIDExt{SeqNo: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Spendable().String() != "650" || balances.SpendableToAllowed().String() != "850" {
+		t.Fatal("incorrect balances", balances)
+	}
+
+	balances, err = spec.GetBalancesAt(context.Background(), &ton.BlockIDExt{SeqNo: 2}, time.Unix(1000000+300, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Spendable().String() != "1000" {
+		t.Fatal("incorrect balances", balances)
+	}
+
+	initialized = false
+	ext, err = w.BuildExternalMessage(context.Background(), transfer(lockupOther, "1", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ext.StateInit == nil || !bytes.Equal(ext.StateInit.Data.Hash(), state.Data.Hash()) {
+		t.Fatal("state init should be attached")
+	}
+}
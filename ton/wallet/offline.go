@@ -0,0 +1,710 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Air-gapped signing flow:
+//
+//  1. Online machine creates watch-only wallet using FromPublicKey, builds bundle
+//     with BuildUnsignedBundle and exports it using json.Marshal.
+//  2. Offline machine parses it with ParseUnsignedBundle, shows Summary to the user,
+//     and signs it with Sign, signed bundle is exported using json.Marshal.
+//  3. Online machine parses it with ParseSignedBundle and broadcasts it with Send.
+
+var (
+	ErrBundleSummaryMismatch = errors.New("bundle summary does not match its content")
+	ErrBundleAddressMismatch = errors.New("bundle address does not match its public key, version and subwallet")
+	ErrBundleExpired         = errors.New("bundle is expired")
+	ErrBundleSignature       = errors.New("bundle signature is incorrect")
+	ErrSeqnoChanged          = errors.New("wallet seqno has changed since bundle was created")
+)
+
+// UnsignedBundle - wallet request prepared on the online machine, which should be signed offline
+type UnsignedBundle struct {
+	Address   *address.Address
+	Version   Version
+	PublicKey ed25519.PublicKey
+	// Subwallet - subwallet id, for V5R1 it is a full wallet id
+	Subwallet uint32
+
+	// Seqno - seqno of the request, for seqno based wallets
+	Seqno uint32
+	// QueryID - bounded query id for highload V2 and query id for highload V3
+	QueryID uint64
+	// CreatedAt and Timeout are used only by highload V3
+	CreatedAt uint64
+	Timeout   uint32
+
+	// ValidUntil - unix time after which request will be rejected by wallet,
+	// V1 wallet has no expiration time, so it is zero for it
+	ValidUntil uint32
+	// Deploy - is state init should be attached, when wallet is not deployed yet
+	Deploy bool
+
+	// Lockup - config of the lockup wallet, it is a part of the wallet address
+	Lockup *LockupConfig
+
+	Messages []*Message
+	// Summary - human-readable description of the request, it is checked by Verify
+	Summary string
+}
+
+// SignedBundle - bundle with the signature made offline, ready to be sent
+type SignedBundle struct {
+	*UnsignedBundle
+	Signature []byte
+}
+
+type bundleMessageJSON struct {
+	Mode    uint8      `json:"mode"`
+	Message *cell.Cell `json:"message"`
+}
+
+type bundleLockupJSON struct {
+	ConfigPublicKey     []byte             `json:"config_public_key"`
+	AllowedDestinations []*address.Address `json:"allowed_destinations"`
+}
+
+type bundleJSON struct {
+	Address    *address.Address    `json:"address"`
+	Version    Version             `json:"version"`
+	PublicKey  []byte              `json:"public_key"`
+	Subwallet  uint32              `json:"subwallet"`
+	Seqno      uint32              `json:"seqno"`
+	QueryID    uint64              `json:"query_id,omitempty"`
+	CreatedAt  uint64              `json:"created_at,omitempty"`
+	Timeout    uint32              `json:"timeout,omitempty"`
+	ValidUntil uint32              `json:"valid_until"`
+	Deploy     bool                `json:"deploy"`
+	Lockup     *bundleLockupJSON   `json:"lockup,omitempty"`
+	Messages   []bundleMessageJSON `json:"messages"`
+	Summary    string              `json:"summary"`
+}
+
+type signedBundleJSON struct {
+	bundleJSON
+	Signature []byte `json:"signature"`
+}
+
+// BuildUnsignedBundle - fetches current wallet state and prepares request with messages to be signed offline.
+// Wallet can be watch-only, see FromPublicKey, for lockup wallet use FromSignerLockup with NewPublicKeySigner.
+func (w *Wallet) BuildUnsignedBundle(ctx context.Context, messages []*Message) (*UnsignedBundle, error) {
+	for i, message := range messages {
+		if message == nil || message.InternalMessage == nil {
+			return nil, fmt.Errorf("message %d is empty", i)
+		}
+	}
+
+	block, initialized, _, err := w.prepareExternal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.prepareBundle(ctx, messages, initialized, func() (uint32, error) {
+		// called only for seqno based wallets
+		return seqnoSpec(w.spec).seqno(ctx, w, initialized, block)
+	})
+}
+
+// prepareBundle - fills bundle with the version specific fields, seqno is used only by seqno based wallets
+func (w *Wallet) prepareBundle(ctx context.Context, messages []*Message, initialized bool, seqno func() (uint32, error)) (*UnsignedBundle, error) {
+	b := &UnsignedBundle{
+		Address:   w.addr,
+		Version:   w.ver,
+		PublicKey: w.signer.PublicKey(),
+		Subwallet: w.subwallet,
+		Deploy:    !initialized,
+		Messages:  messages,
+	}
+
+	var err error
+	switch spec := w.spec.(type) {
+	case *SpecV1:
+		b.Seqno, err = seqno()
+	case *SpecV2:
+		b.Seqno, err = seqno()
+		b.ValidUntil = spec.validUntil()
+	case *SpecV3:
+		b.Seqno, err = seqno()
+		b.ValidUntil = spec.validUntil()
+	case *SpecV4R2:
+		b.Seqno, err = seqno()
+		b.ValidUntil = spec.validUntil()
+	case *SpecV5R1:
+		b.Seqno, err = seqno()
+		b.ValidUntil = spec.validUntil()
+	case *SpecLockup:
+		if initialized {
+			// funds are checked the same way as by BuildMessage, before request is signed
+			if err = w.checkLockupMessages(ctx, messages); err != nil {
+				return nil, err
+			}
+		}
+		b.Seqno, err = seqno()
+		b.ValidUntil = spec.validUntil()
+
+		cfg := spec.config
+		b.Lockup = &cfg
+	case *SpecHighloadV2R2:
+		var ttl, queryID uint32
+		if spec.customQueryIDFetcher != nil {
+			ttl, queryID = spec.customQueryIDFetcher()
+		} else {
+			queryID = randUint32()
+			ttl = spec.validUntil()
+		}
+		b.QueryID = (uint64(ttl) << 32) + uint64(queryID)
+		b.ValidUntil = ttl
+	case *SpecHighloadV3:
+		var query *HighloadV3Query
+		query, err = spec.AllocateQuery(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b.QueryID = uint64(query.ID)
+		b.CreatedAt = query.CreatedAt
+		b.Timeout = spec.timeout
+		b.ValidUntil = uint32(query.CreatedAt + uint64(spec.timeout))
+	default:
+		return nil, fmt.Errorf("offline signing is not yet supported: %w", ErrUnsupportedWalletVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// check that request can be built before passing it to the offline machine
+	if _, err = b.payload(); err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	b.Summary = b.describe()
+
+	return b, nil
+}
+
+// seqnoSpec - returns seqno settings of the seqno based wallet spec, nil for other specs
+func seqnoSpec(spec any) *SpecSeqno {
+	switch s := spec.(type) {
+	case *SpecV1:
+		return &s.SpecSeqno
+	case *SpecV2:
+		return &s.SpecSeqno
+	case *SpecV3:
+		return &s.SpecSeqno
+	case *SpecV4R2:
+		return &s.SpecSeqno
+	case *SpecV5R1:
+		return &s.SpecSeqno
+	case *SpecLockup:
+		return &s.SpecSeqno
+	}
+	return nil
+}
+
+// checkLockupMessages - checks that lockup wallet can send messages at the current state
+func (w *Wallet) checkLockupMessages(ctx context.Context, messages []*Message) error {
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	_, err = checkLockupMessages(acc, messages)
+	return err
+}
+
+// ParseUnsignedBundle - parses bundle from json and verifies it
+func ParseUnsignedBundle(data []byte) (*UnsignedBundle, error) {
+	var b UnsignedBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Verify - checks that address is derived from the public key, version and subwallet,
+// that request can be built and that summary describes the bundle content.
+func (b *UnsignedBundle) Verify() error {
+	if b.Address == nil {
+		return errors.New("bundle has no address")
+	}
+
+	if len(b.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("incorrect public key size %d", len(b.PublicKey))
+	}
+
+	state, err := b.stateInit()
+	if err != nil {
+		return err
+	}
+
+	addr, err := addressFromStateInit(state)
+	if err != nil {
+		return err
+	}
+
+	if addr.Workchain() != b.Address.Workchain() || !bytes.Equal(addr.Data(), b.Address.Data()) {
+		return ErrBundleAddressMismatch
+	}
+
+	if _, err = b.payload(); err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	if b.Summary != b.describe() {
+		return ErrBundleSummaryMismatch
+	}
+	return nil
+}
+
+// Sign - verifies bundle and signs it, signer should have the bundle public key
+func (b *UnsignedBundle) Sign(ctx context.Context, signer Signer) (*SignedBundle, error) {
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(signer.PublicKey(), b.PublicKey) {
+		return nil, errors.New("signer public key does not match bundle")
+	}
+
+	if b.expired() {
+		return nil, ErrBundleExpired
+	}
+
+	payload, err := b.payload()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	sign, err := signer.Sign(ctx, payload.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign bundle: %w", err)
+	}
+
+	if !ed25519.Verify(b.PublicKey, payload.Hash(), sign) {
+		return nil, ErrBundleSignature
+	}
+
+	return &SignedBundle{
+		UnsignedBundle: b,
+		Signature:      sign,
+	}, nil
+}
+
+// payload - builds signed part of the wallet external message
+func (b *UnsignedBundle) payload() (*cell.Cell, error) {
+	switch b.Version {
+	case V1R1, V1R2, V1R3:
+		p, err := buildV1Payload(b.Seqno, b.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return p.EndCell(), nil
+	case V2R1, V2R2:
+		p, err := buildV2Payload(b.ValidUntil, b.Seqno, b.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return p.EndCell(), nil
+	case V3R1, V3R2, Lockup:
+		p, err := buildV3Payload(b.Subwallet, b.ValidUntil, b.Seqno, b.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return p.EndCell(), nil
+	case V4R1, V4R2:
+		p, err := buildV4R2Payload(b.Subwallet, b.ValidUntil, b.Seqno, b.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return p.EndCell(), nil
+	case V5R1:
+		for i, message := range b.Messages {
+			if message.Mode&2 == 0 {
+				return nil, fmt.Errorf("message %d should have ignore errors (+2) mode to be sent by external request", i)
+			}
+		}
+
+		inner, err := BuildV5R1Actions(b.Messages, nil)
+		if err != nil {
+			return nil, err
+		}
+		return buildV5R1Payload(OpV5R1ExternalSigned, b.Subwallet, b.ValidUntil, b.Seqno, inner).EndCell(), nil
+	case HighloadV2R2, HighloadV2Verified:
+		p, err := buildHighloadV2R2Payload(b.Subwallet, b.QueryID, b.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return p.EndCell(), nil
+	case HighloadV3:
+		return buildHighloadV3Payload(b.Address, b.Subwallet, b.Timeout, b.query(), b.Messages)
+	}
+	return nil, fmt.Errorf("offline signing is not yet supported: %w", ErrUnsupportedWalletVersion)
+}
+
+func (b *UnsignedBundle) query() *HighloadV3Query {
+	return &HighloadV3Query{
+		ID:        HighloadQueryID(b.QueryID),
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+func (b *UnsignedBundle) stateInit() (*tlb.StateInit, error) {
+	switch b.Version {
+	case HighloadV3:
+		return GetHighloadV3StateInit(b.PublicKey, b.Subwallet, b.Timeout)
+	case Lockup:
+		if b.Lockup == nil {
+			return nil, errors.New("bundle has no lockup config")
+		}
+		return GetLockupStateInit(b.PublicKey, b.Subwallet, *b.Lockup)
+	}
+	return GetStateInit(b.PublicKey, b.Version, b.Subwallet)
+}
+
+// expired - checks that it is too late to send the bundle, V1 requests have no expiration time
+func (b *UnsignedBundle) expired() bool {
+	switch b.Version {
+	case V1R1, V1R2, V1R3:
+		return false
+	}
+	return int64(b.ValidUntil) <= timeNow().Unix()
+}
+
+// describe - builds human-readable summary of the bundle
+func (b *UnsignedBundle) describe() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("wallet %s (%s, subwallet %d)\n", b.Address.String(), b.Version.String(), b.Subwallet))
+	switch b.Version {
+	case HighloadV2R2, HighloadV2Verified:
+		sb.WriteString(fmt.Sprintf("query id %d\n", b.QueryID))
+	case HighloadV3:
+		sb.WriteString(fmt.Sprintf("query id %d, created at %s, timeout %ds\n", b.QueryID,
+			time.Unix(int64(b.CreatedAt), 0).UTC().Format(time.RFC3339), b.Timeout))
+	default:
+		sb.WriteString(fmt.Sprintf("seqno %d\n", b.Seqno))
+	}
+	switch b.Version {
+	case V1R1, V1R2, V1R3:
+		sb.WriteString("no expiration time\n")
+	default:
+		sb.WriteString(fmt.Sprintf("valid until %s\n", time.Unix(int64(b.ValidUntil), 0).UTC().Format(time.RFC3339)))
+	}
+	if b.Deploy {
+		sb.WriteString("wallet will be deployed\n")
+	}
+
+	for i, message := range b.Messages {
+		sb.WriteString(fmt.Sprintf("message %d: %s\n", i+1, describeMessage(message)))
+	}
+	return sb.String()
+}
+
+func describeMessage(message *Message) string {
+	msg := message.InternalMessage
+	if msg == nil {
+		return "empty"
+	}
+
+	desc := fmt.Sprintf("send %s TON to %s, mode %d, bounce %t", msg.Amount.String(), msg.DstAddr.String(), message.Mode, msg.Bounce)
+	if msg.StateInit != nil {
+		if state, err := tlb.ToCell(msg.StateInit); err == nil {
+			desc += fmt.Sprintf(", state init %x", state.Hash())
+		}
+	}
+
+	if msg.Body == nil || (msg.Body.BitsSize() == 0 && msg.Body.RefsNum() == 0) {
+		return desc
+	}
+
+	body := msg.Body.BeginParse()
+	if op, err := body.LoadUInt(32); err == nil {
+		switch op {
+		case 0:
+			if text, err := body.LoadStringSnake(); err == nil {
+				return desc + fmt.Sprintf(", comment %q", text)
+			}
+		case EncryptedCommentOpcode:
+			return desc + ", encrypted comment"
+		default:
+			desc += fmt.Sprintf(", op 0x%08x", op)
+		}
+	}
+	return desc + fmt.Sprintf(", body hash %x", msg.Body.Hash())
+}
+
+func (b *UnsignedBundle) toJSON() (bundleJSON, error) {
+	res := bundleJSON{
+		Address:    b.Address,
+		Version:    b.Version,
+		PublicKey:  b.PublicKey,
+		Subwallet:  b.Subwallet,
+		Seqno:      b.Seqno,
+		QueryID:    b.QueryID,
+		CreatedAt:  b.CreatedAt,
+		Timeout:    b.Timeout,
+		ValidUntil: b.ValidUntil,
+		Deploy:     b.Deploy,
+		Messages:   make([]bundleMessageJSON, 0, len(b.Messages)),
+		Summary:    b.Summary,
+	}
+
+	if b.Lockup != nil {
+		res.Lockup = &bundleLockupJSON{
+			ConfigPublicKey:     b.Lockup.ConfigPublicKey,
+			AllowedDestinations: b.Lockup.AllowedDestinations,
+		}
+	}
+
+	for i, message := range b.Messages {
+		msg, err := tlb.ToCell(message.InternalMessage)
+		if err != nil {
+			return bundleJSON{}, fmt.Errorf("failed to convert message %d to cell: %w", i, err)
+		}
+		res.Messages = append(res.Messages, bundleMessageJSON{Mode: message.Mode, Message: msg})
+	}
+	return res, nil
+}
+
+func (b *UnsignedBundle) fromJSON(data bundleJSON) error {
+	messages := make([]*Message, 0, len(data.Messages))
+	for i, message := range data.Messages {
+		if message.Message == nil {
+			return fmt.Errorf("message %d is empty", i)
+		}
+
+		var msg tlb.InternalMessage
+		if err := tlb.LoadFromCell(&msg, message.Message.BeginParse()); err != nil {
+			return fmt.Errorf("failed to parse message %d: %w", i, err)
+		}
+		messages = append(messages, &Message{Mode: message.Mode, InternalMessage: &msg})
+	}
+
+	*b = UnsignedBundle{
+		Address:    data.Address,
+		Version:    data.Version,
+		PublicKey:  data.PublicKey,
+		Subwallet:  data.Subwallet,
+		Seqno:      data.Seqno,
+		QueryID:    data.QueryID,
+		CreatedAt:  data.CreatedAt,
+		Timeout:    data.Timeout,
+		ValidUntil: data.ValidUntil,
+		Deploy:     data.Deploy,
+		Messages:   messages,
+		Summary:    data.Summary,
+	}
+
+	if data.Lockup != nil {
+		b.Lockup = &LockupConfig{
+			ConfigPublicKey:     data.Lockup.ConfigPublicKey,
+			AllowedDestinations: data.Lockup.AllowedDestinations,
+		}
+	}
+	return nil
+}
+
+// MarshalJSON - serializes bundle to json, messages are stored as BoC
+func (b *UnsignedBundle) MarshalJSON() ([]byte, error) {
+	data, err := b.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+func (b *UnsignedBundle) UnmarshalJSON(data []byte) error {
+	var res bundleJSON
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	return b.fromJSON(res)
+}
+
+// ParseSignedBundle - parses signed bundle from json and verifies it with the signature
+func ParseSignedBundle(data []byte) (*SignedBundle, error) {
+	var b SignedBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Verify - verifies bundle content and its signature
+func (b *SignedBundle) Verify() error {
+	if b.UnsignedBundle == nil {
+		return errors.New("bundle is empty")
+	}
+
+	if err := b.UnsignedBundle.Verify(); err != nil {
+		return err
+	}
+
+	payload, err := b.payload()
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	if !ed25519.Verify(b.PublicKey, payload.Hash(), b.Signature) {
+		return ErrBundleSignature
+	}
+	return nil
+}
+
+// MarshalJSON - serializes signed bundle to json, messages are stored as BoC
+func (b *SignedBundle) MarshalJSON() ([]byte, error) {
+	if b.UnsignedBundle == nil {
+		return nil, errors.New("bundle is empty")
+	}
+
+	data, err := b.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(signedBundleJSON{bundleJSON: data, Signature: b.Signature})
+}
+
+func (b *SignedBundle) UnmarshalJSON(data []byte) error {
+	var res signedBundleJSON
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	b.UnsignedBundle = &UnsignedBundle{}
+	if err := b.UnsignedBundle.fromJSON(res.bundleJSON); err != nil {
+		return err
+	}
+	b.Signature = res.Signature
+	return nil
+}
+
+// ExternalMessage - assembles signed external message, with state init if bundle deploys the wallet
+func (b *SignedBundle) ExternalMessage() (*tlb.ExternalMessage, error) {
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+
+	payload, err := b.payload()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	var body *cell.Cell
+	switch b.Version {
+	case V5R1:
+		// signature is in the end of request
+		body = cell.BeginCell().MustStoreBuilder(payload.ToBuilder()).MustStoreSlice(b.Signature, 512).EndCell()
+	case HighloadV3:
+		body = cell.BeginCell().MustStoreSlice(b.Signature, 512).MustStoreRef(payload).EndCell()
+	default:
+		body = cell.BeginCell().MustStoreSlice(b.Signature, 512).MustStoreBuilder(payload.ToBuilder()).EndCell()
+	}
+
+	var stateInit *tlb.StateInit
+	if b.Deploy {
+		stateInit, err = b.stateInit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get state init: %w", err)
+		}
+	}
+
+	return &tlb.ExternalMessage{
+		DstAddr:   b.Address,
+		StateInit: stateInit,
+		Body:      body,
+	}, nil
+}
+
+// Send - checks that bundle can still be processed by the wallet and broadcasts it.
+// Returns ErrSeqnoChanged if wallet seqno is not the same as in bundle, ErrBundleExpired when it is too late to send it,
+// for highload V3 ErrQueryAlreadyProcessed when query was already processed,
+// and for lockup wallet ErrLockupNotEnoughFunds when funds cannot be spent anymore.
+func (b *SignedBundle) Send(ctx context.Context, api TonAPI) (*tlb.ExternalMessage, error) {
+	ext, err := b.ExternalMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	if b.expired() {
+		return nil, ErrBundleExpired
+	}
+
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, b.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+	initialized := acc.IsActive && acc.State.Status == tlb.AccountStatusActive
+
+	if b.Version == Lockup && initialized {
+		// funds could be locked or spent since bundle was created
+		if _, err = checkLockupMessages(acc, b.Messages); err != nil {
+			return nil, err
+		}
+	}
+
+	switch b.Version {
+	case HighloadV2R2, HighloadV2Verified:
+		// query id is checked by contract
+	case HighloadV3:
+		if initialized {
+			processed, err := highloadV3Processed(ctx, api, block, b.Address, HighloadQueryID(b.QueryID), false)
+			if err != nil {
+				return nil, err
+			}
+
+			if processed {
+				return nil, ErrQueryAlreadyProcessed
+			}
+		}
+	default:
+		var seq uint32
+		if initialized {
+			seq, err = getSeqno(ctx, api, block, b.Address, b.Version)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if seq != b.Seqno {
+			return nil, fmt.Errorf("%w: wallet seqno is %d, bundle seqno is %d", ErrSeqnoChanged, seq, b.Seqno)
+		}
+	}
+
+	if !initialized && ext.StateInit == nil {
+		return nil, errors.New("wallet is not deployed and bundle has no state init")
+	}
+
+	if err = api.SendExternalMessage(ctx, ext); err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	return ext, nil
+}
@@ -0,0 +1,311 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+func TestUnsignedBundle_Flow(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+	randUint32 = func() uint32 {
+		return 777
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{IsActive: false}, nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		m.extMsgSent = msg
+		return nil
+	}
+
+	to := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	lockupCfg := LockupConfig{
+		ConfigPublicKey:     ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab")).Public().(ed25519.PublicKey),
+		AllowedDestinations: []*address.Address{lockupElector},
+	}
+
+	for _, ver := range []Version{V1R3, V3, V4R2, V5R1, HighloadV2R2, HighloadV3, Lockup} {
+		fromSigner := func(signer Signer) (*Wallet, error) {
+			if ver == Lockup {
+				return FromSignerLockup(m, signer, DefaultSubwallet, lockupCfg)
+			}
+			return FromSigner(m, signer, ver)
+		}
+
+		online, err := fromSigner(NewPublicKeySigner(pkey.Public().(ed25519.PublicKey)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		transfer, err := online.BuildTransfer(to, tlb.MustFromTON("0.5"), true, "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bundle, err := online.BuildUnsignedBundle(context.Background(), []*Message{transfer})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bundle.Deploy || !strings.Contains(bundle.Summary, `send 0.5 TON to `+to.String()) ||
+			!strings.Contains(bundle.Summary, `comment "hello"`) {
+			t.Fatal("incorrect summary", ver.String(), bundle.Summary)
+		}
+
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		offline, err := ParseUnsignedBundle(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := offline.Sign(context.Background(), NewKeySigner(pkey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err = json.Marshal(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err = ParseSignedBundle(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ext, err := signed.Send(context.Background(), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m.extMsgSent != ext || ext.StateInit == nil {
+			t.Fatal("message not sent", ver.String())
+		}
+
+		// same message should be built by the wallet with private key
+		w, err := fromSigner(NewKeySigner(pkey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := w.BuildExternalMessageForMany(context.Background(), []*Message{transfer})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(expected.Body.Hash(), ext.Body.Hash()) {
+			t.Fatal("body not match", ver.String())
+		}
+
+		if _, err = online.BuildExternalMessageForMany(context.Background(), []*Message{transfer}); !errors.Is(err, ErrWatchOnly) {
+			t.Fatal("watch-only wallet should not sign", ver.String(), err)
+		}
+	}
+}
+
+func TestUnsignedBundle_Checks(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	seqno := int64(3)
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status: tlb.AccountStatusActive,
+				},
+			},
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		if method != "seqno" {
+			t.Fatal("unexpected method", method)
+		}
+		return ton.NewExecutionResult([]any{big.NewInt(seqno)}), nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		return nil
+	}
+
+	w, err := FromPublicKey(m, pkey.Public().(ed25519.PublicKey), V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err := w.BuildTransfer(address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.5"), true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := w.BuildUnsignedBundle(context.Background(), []*Message{transfer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundle.Seqno != 3 || bundle.Deploy {
+		t.Fatal("incorrect bundle state")
+	}
+
+	tampered := *bundle
+	tampered.Messages = []*Message{SimpleMessage(transfer.InternalMessage.DstAddr, tlb.MustFromTON("5"), nil)}
+	if err = tampered.Verify(); !errors.Is(err, ErrBundleSummaryMismatch) {
+		t.Fatal("tampered messages should be detected", err)
+	}
+
+	tampered = *bundle
+	tampered.Subwallet++
+	if err = tampered.Verify(); !errors.Is(err, ErrBundleAddressMismatch) {
+		t.Fatal("tampered subwallet should be detected", err)
+	}
+
+	if _, err = bundle.Sign(context.Background(), NewKeySigner(ed25519.NewKeyFromSeed(make([]byte, 32)))); err == nil {
+		t.Fatal("sign with other key should fail")
+	}
+
+	signed, err := bundle.Sign(context.Background(), NewKeySigner(pkey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badSign := &SignedBundle{UnsignedBundle: bundle, Signature: make([]byte, 64)}
+	if _, err = badSign.ExternalMessage(); !errors.Is(err, ErrBundleSignature) {
+		t.Fatal("incorrect signature should be detected", err)
+	}
+
+	seqno = 4
+	if _, err = signed.Send(context.Background(), m); !errors.Is(err, ErrSeqnoChanged) {
+		t.Fatal("changed seqno should be detected", err)
+	}
+
+	seqno = 3
+	timeNow = func() time.Time {
+		return time.Unix(1000000+60*60, 0)
+	}
+	if _, err = signed.Send(context.Background(), m); !errors.Is(err, ErrBundleExpired) {
+		t.Fatal("expired bundle should be detected", err)
+	}
+
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+	if _, err = signed.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnsignedBundle_Lockup(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	configKey := ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab")).Public().(ed25519.PublicKey)
+
+	allowed, err := lockupAddrKey(lockupElector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balance := tlb.MustFromTON("1000")
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status:  tlb.AccountStatusActive,
+					Balance: balance,
+				},
+			},
+			Data: testLockupData(t, pkey.Public().(ed25519.PublicKey), configKey, 9, [][]bool{allowed}),
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		if method != "seqno" {
+			t.Fatal("unexpected method", method)
+		}
+		return ton.NewExecutionResult([]any{big.NewInt(9)}), nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		return nil
+	}
+
+	cfg := LockupConfig{ConfigPublicKey: configKey, AllowedDestinations: []*address.Address{lockupElector}}
+	w, err := FromSignerLockup(m, NewPublicKeySigner(pkey.Public().(ed25519.PublicKey)), DefaultSubwallet, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 150 locked and 200 restricted at this time
+	if _, err = w.BuildUnsignedBundle(context.Background(), []*Message{SimpleMessage(lockupOther, tlb.MustFromTON("700"), nil)}); !errors.Is(err, ErrLockupNotEnoughFunds) {
+		t.Fatal("locked funds should be checked", err)
+	}
+
+	bundle, err := w.BuildUnsignedBundle(context.Background(), []*Message{SimpleMessage(lockupOther, tlb.MustFromTON("600"), nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundle.Seqno != 9 || bundle.Deploy || bundle.Lockup == nil {
+		t.Fatal("incorrect bundle state")
+	}
+
+	tampered := *bundle
+	tampered.Lockup = &LockupConfig{ConfigPublicKey: configKey}
+	if err = tampered.Verify(); !errors.Is(err, ErrBundleAddressMismatch) {
+		t.Fatal("tampered lockup config should be detected", err)
+	}
+
+	signed, err := bundle.Sign(context.Background(), NewKeySigner(pkey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balance = tlb.MustFromTON("900")
+	if _, err = signed.Send(context.Background(), m); !errors.Is(err, ErrLockupNotEnoughFunds) {
+		t.Fatal("funds should be checked again before send", err)
+	}
+
+	balance = tlb.MustFromTON("1000")
+	if _, err = signed.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+}
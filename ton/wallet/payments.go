@@ -0,0 +1,802 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Payments processing flow:
+//
+//  1. Application creates invoices and adds them with AddInvoice. Invoice is paid either
+//     to the receiving address with the comment, or to its own deposit address, like subwallet of the receiving wallet.
+//  2. Watcher scans transactions of the watched addresses, transaction is processed only when
+//     the given number of master blocks was created after the block where it was found.
+//  3. Confirmed payments are added to their invoices, and when invoice is fully paid, OnPaid is called.
+//     Processed transactions and invoices are saved to PaymentStore, to continue after restart.
+
+const OpJettonTransferNotification = 0x7362d09c
+
+var (
+	ErrInvoiceExists        = errors.New("invoice already exists")
+	ErrPaymentWatcherClosed = errors.New("payment watcher is closed")
+)
+
+// Invoice - expected payment, amounts are compared in the smallest units of TON or jetton,
+// so for jettons Coins are created with TON decimals, and Nano should be used to get the amount
+type Invoice struct {
+	ID     string
+	Amount tlb.Coins
+	// Jetton - master of the jetton which should be paid, nil for TON payments
+	Jetton *address.Address
+	// Comment - text which payer should put to the plain or encrypted comment, not used with Deposit
+	Comment string
+	// Deposit - address which receives payments only for this invoice
+	Deposit *address.Address
+}
+
+// Payment - confirmed incoming transfer
+type Payment struct {
+	// InvoiceID - id of the matched invoice, empty when payment is not matched
+	InvoiceID string
+	// Address - watched address which has received payment
+	Address *address.Address
+	// From - payer, for jettons it is the sender of the jettons
+	From *address.Address
+	// Jetton - master of the jetton, nil for TON payment
+	Jetton    *address.Address
+	Amount    tlb.Coins
+	Comment   string
+	Encrypted bool
+	// Bounced - message was bounced back to the payer, so payment is not counted
+	Bounced bool
+	TxLT    uint64
+	TxHash  []byte
+}
+
+// InvoiceState - invoice with the payments received for it
+type InvoiceState struct {
+	Invoice
+	// Received - sum of the not bounced payments
+	Received tlb.Coins
+	Payments []*Payment
+	// Paid - received amount is not less than invoice amount
+	Paid bool
+	// PaidKey - idempotency key of the OnPaid notification, it is set together with Paid
+	// from the invoice id and the payments which have completed it, and is not changed after
+	PaidKey string
+	// Notified - OnPaid callback was successfully called for the invoice
+	Notified bool
+}
+
+// Overpaid - returns amount received above the invoice amount
+func (s *InvoiceState) Overpaid() tlb.Coins {
+	return subCoins(s.Received.Nano(), s.Amount.Nano())
+}
+
+// Remaining - returns amount which should be paid to complete invoice
+func (s *InvoiceState) Remaining() tlb.Coins {
+	return subCoins(s.Amount.Nano(), s.Received.Nano())
+}
+
+// paidKey - builds idempotency key from the invoice id and hashes of its payment transactions
+func (s *InvoiceState) paidKey() string {
+	h := sha256.New()
+	h.Write([]byte(s.ID))
+	for _, p := range s.Payments {
+		h.Write(p.TxHash)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *InvoiceState) hasPayment(tx *tlb.Transaction) bool {
+	for _, p := range s.Payments {
+		if p.TxLT == tx.LT && bytes.Equal(p.TxHash, tx.Hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *InvoiceState) copy() *InvoiceState {
+	cp := *s
+	cp.Payments = append([]*Payment{}, s.Payments...)
+	return &cp
+}
+
+// PaymentStore - persistent storage of the watcher progress. Invoice state is always saved
+// before the checkpoint, so after restart transaction can be processed again, but its payment is not duplicated.
+type PaymentStore interface {
+	// LoadCheckpoint - returns lt of the last processed transaction of the address, found is false when nothing was processed
+	LoadCheckpoint(ctx context.Context, addr *address.Address) (lt uint64, found bool, err error)
+	SaveCheckpoint(ctx context.Context, addr *address.Address, lt uint64) error
+	LoadInvoice(ctx context.Context, id string) (state *InvoiceState, found bool, err error)
+	SaveInvoice(ctx context.Context, state *InvoiceState) error
+}
+
+type memoryPaymentStore struct {
+	mx          sync.Mutex
+	checkpoints map[string]uint64
+	invoices    map[string]*InvoiceState
+}
+
+func (m *memoryPaymentStore) LoadCheckpoint(_ context.Context, addr *address.Address) (uint64, bool, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	lt, ok := m.checkpoints[rawAddr(addr)]
+	return lt, ok, nil
+}
+
+func (m *memoryPaymentStore) SaveCheckpoint(_ context.Context, addr *address.Address, lt uint64) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.checkpoints[rawAddr(addr)] = lt
+	return nil
+}
+
+func (m *memoryPaymentStore) LoadInvoice(_ context.Context, id string) (*InvoiceState, bool, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	s, ok := m.invoices[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return s.copy(), true, nil
+}
+
+func (m *memoryPaymentStore) SaveInvoice(_ context.Context, state *InvoiceState) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.invoices[state.ID] = state.copy()
+	return nil
+}
+
+// PaymentWatcherConfig - settings of the payment watcher, only OnPaid is required
+type PaymentWatcherConfig struct {
+	// Key - private key of the receiving wallet, it is used to decrypt encrypted comments, can be nil
+	Key ed25519.PrivateKey
+	// Confirmations - number of master blocks which should be created after the block
+	// where transaction was found, before it is processed, 0 means process immediately
+	Confirmations uint32
+	// PollInterval - how often addresses are checked for new transactions, default is 3 seconds
+	PollInterval time.Duration
+	// Store - storage of the checkpoints and invoices, by default it is stored in memory
+	Store PaymentStore
+
+	// OnPaid - called for every fully paid invoice, when it returns error it is called again on the next poll.
+	// Delivery is at least once: invoice is marked as notified in Store only after the successful call,
+	// so callback is repeated when process was stopped before it was saved, or when saving has failed.
+	// Every call for the invoice has the same state.PaidKey, it is persisted before the first call,
+	// so application should use it to make fulfillment idempotent, for example as unique key in its database.
+	OnPaid func(ctx context.Context, state *InvoiceState) error
+	// OnPayment - optional, called for every confirmed incoming transfer, including not matched and bounced.
+	// Payment which is already added to its invoice is not reported again, but not matched payment
+	// of the last transaction before restart can be reported twice, TxLT and TxHash can be used to skip it.
+	OnPayment func(payment *Payment)
+}
+
+type watchedAddress struct {
+	addr *address.Address
+	// invoice - id of the invoice for the deposit address, empty for the receiving address
+	invoice string
+
+	loaded  bool
+	seenLT  uint64
+	pending []*pendingTx
+}
+
+type pendingTx struct {
+	tx *tlb.Transaction
+	// seenAt - seqno of the master block where transaction was found
+	seenAt uint32
+}
+
+type jettonTransferNotification struct {
+	_              tlb.Magic        `tlb:"#7362d09c"`
+	QueryID        uint64           `tlb:"## 64"`
+	Amount         tlb.Coins        `tlb:"."`
+	Sender         *address.Address `tlb:"addr"`
+	ForwardPayload *cell.Cell       `tlb:"either . ^"`
+}
+
+// PaymentWatcher - matches incoming transfers of the receiving address and deposit addresses to invoices.
+// Transfers are matched by plain or encrypted comment, or by deposit address of the invoice.
+// TON payments and jetton transfer notifications from our jetton wallets are supported,
+// partial payments are summed, bounced payments are not counted.
+type PaymentWatcher struct {
+	api  TonAPI
+	addr *address.Address
+	cfg  PaymentWatcherConfig
+
+	mx        sync.Mutex
+	invoices  map[string]*InvoiceState
+	addresses map[string]*watchedAddress
+	// jettonWallets - cache of our jetton wallets, key is master and owner
+	jettonWallets map[string]*address.Address
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+// NewPaymentWatcher - creates watcher of payments to the receiving address and starts its loop, Close should be called to stop it.
+// Receiving address is scanned from its last transaction at the first start, deposit addresses are scanned from the beginning.
+func NewPaymentWatcher(api TonAPI, receiver *address.Address, cfg PaymentWatcherConfig) (*PaymentWatcher, error) {
+	if receiver == nil {
+		return nil, errors.New("receiving address is not set")
+	}
+	if cfg.OnPaid == nil {
+		return nil, errors.New("OnPaid callback is not set")
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 3 * time.Second
+	}
+	if cfg.Store == nil {
+		cfg.Store = &memoryPaymentStore{
+			checkpoints: map[string]uint64{},
+			invoices:    map[string]*InvoiceState{},
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &PaymentWatcher{
+		api:      api,
+		addr:     receiver,
+		cfg:      cfg,
+		invoices: map[string]*InvoiceState{},
+		addresses: map[string]*watchedAddress{
+			rawAddr(receiver): {addr: receiver},
+		},
+		jettonWallets: map[string]*address.Address{},
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan bool),
+	}
+	go w.loop()
+
+	return w, nil
+}
+
+// AddInvoice - starts waiting for invoice payments, state of the invoice is loaded from store,
+// so invoices which were added before restart should be added again
+func (w *PaymentWatcher) AddInvoice(ctx context.Context, inv Invoice) error {
+	if inv.ID == "" {
+		return errors.New("invoice id is not set")
+	}
+	if inv.Amount.Nano().Sign() <= 0 {
+		return errors.New("invoice amount should be positive")
+	}
+	if inv.Deposit == nil && strings.TrimSpace(inv.Comment) == "" {
+		return errors.New("invoice should have comment or deposit address")
+	}
+	if inv.Deposit != nil && rawAddr(inv.Deposit) == rawAddr(w.addr) {
+		return errors.New("deposit address cannot be the receiving address")
+	}
+	inv.Comment = strings.TrimSpace(inv.Comment)
+
+	state, found, err := w.cfg.Store.LoadInvoice(ctx, inv.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load invoice: %w", err)
+	}
+	if !found {
+		state = &InvoiceState{Received: tlb.ZeroCoins}
+	}
+	// invoice params are taken from application, and progress from store
+	state.Invoice = inv
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if w.ctx.Err() != nil {
+		return ErrPaymentWatcherClosed
+	}
+
+	if _, ok := w.invoices[inv.ID]; ok {
+		return ErrInvoiceExists
+	}
+
+	for _, s := range w.invoices {
+		if inv.Deposit != nil && s.Deposit != nil && rawAddr(s.Deposit) == rawAddr(inv.Deposit) {
+			return fmt.Errorf("%w: deposit address is used by invoice %s", ErrInvoiceExists, s.ID)
+		}
+		if inv.Deposit == nil && s.Deposit == nil && s.Comment == inv.Comment {
+			return fmt.Errorf("%w: comment is used by invoice %s", ErrInvoiceExists, s.ID)
+		}
+	}
+
+	w.invoices[inv.ID] = state
+	if inv.Deposit != nil {
+		w.addresses[rawAddr(inv.Deposit)] = &watchedAddress{
+			addr:    inv.Deposit,
+			invoice: inv.ID,
+		}
+	}
+	return nil
+}
+
+// RemoveInvoice - stops waiting for invoice payments, for example when it is expired
+func (w *PaymentWatcher) RemoveInvoice(id string) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if s, ok := w.invoices[id]; ok {
+		if s.Deposit != nil {
+			delete(w.addresses, rawAddr(s.Deposit))
+		}
+		delete(w.invoices, id)
+	}
+}
+
+// Invoice - returns copy of the current invoice state
+func (w *PaymentWatcher) Invoice(id string) (*InvoiceState, bool) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	s, ok := w.invoices[id]
+	if !ok {
+		return nil, false
+	}
+	return s.copy(), true
+}
+
+// Close - stops watcher loop
+func (w *PaymentWatcher) Close() {
+	w.mx.Lock()
+	w.cancel()
+	w.mx.Unlock()
+
+	<-w.done
+}
+
+func (w *PaymentWatcher) loop() {
+	defer close(w.done)
+
+	wait := 0 * time.Second
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = w.cfg.PollInterval
+
+		// errors are temporary, everything which was not processed will be retried on the next poll
+		_ = w.poll(w.ctx)
+	}
+}
+
+func (w *PaymentWatcher) poll(ctx context.Context) error {
+	master, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+
+	w.mx.Lock()
+	list := make([]*watchedAddress, 0, len(w.addresses))
+	for _, wa := range w.addresses {
+		list = append(list, wa)
+	}
+	w.mx.Unlock()
+
+	var firstErr error
+	for _, wa := range list {
+		if err = w.scan(ctx, master, wa); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if err = w.notify(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// scan - fetches new transactions of the address, and processes confirmed ones
+func (w *PaymentWatcher) scan(ctx context.Context, master *ton.BlockIDExt, wa *watchedAddress) error {
+	acc, err := w.api.WaitForBlock(master.SeqNo).GetAccount(ctx, master, wa.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !wa.loaded {
+		lt, found, err := w.cfg.Store.LoadCheckpoint(ctx, wa.addr)
+		if err != nil {
+			return fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+
+		if !found && wa.invoice == "" {
+			// receiving address was not watched before, so we start from its current state
+			lt = acc.LastTxLT
+			if err = w.cfg.Store.SaveCheckpoint(ctx, wa.addr, lt); err != nil {
+				return fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+		}
+		wa.seenLT, wa.loaded = lt, true
+	}
+
+	if acc.LastTxLT > wa.seenLT {
+		txs, err := w.listTransactions(ctx, master, wa, acc)
+		if err != nil {
+			return err
+		}
+
+		for _, tx := range txs {
+			wa.pending = append(wa.pending, &pendingTx{tx: tx, seenAt: master.SeqNo})
+		}
+		wa.seenLT = acc.LastTxLT
+	}
+
+	for len(wa.pending) > 0 {
+		p := wa.pending[0]
+		if master.SeqNo < p.seenAt+w.cfg.Confirmations {
+			break
+		}
+
+		if err = w.process(ctx, wa, p.tx); err != nil {
+			return err
+		}
+
+		if err = w.cfg.Store.SaveCheckpoint(ctx, wa.addr, p.tx.LT); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+		wa.pending = wa.pending[1:]
+	}
+	return nil
+}
+
+// listTransactions - returns transactions after the last seen one, in order from old to new
+func (w *PaymentWatcher) listTransactions(ctx context.Context, master *ton.BlockIDExt, wa *watchedAddress, acc *tlb.Account) ([]*tlb.Transaction, error) {
+	var res []*tlb.Transaction
+	for lt, hash := acc.LastTxLT, acc.LastTxHash; lt > wa.seenLT; {
+		list, err := w.api.WaitForBlock(master.SeqNo).ListTransactions(ctx, wa.addr, 10, lt, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		if len(list) == 0 {
+			break
+		}
+
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].LT <= wa.seenLT {
+				break
+			}
+			res = append(res, list[i])
+		}
+
+		// get previous of the oldest tx, in case if we need to scan deeper
+		lt, hash = list[0].PrevTxLT, list[0].PrevTxHash
+	}
+
+	// reverse to process from old to new
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
+
+// process - adds payment of the transaction to the matched invoice
+func (w *PaymentWatcher) process(ctx context.Context, wa *watchedAddress, tx *tlb.Transaction) error {
+	payment, err := w.parsePayment(ctx, wa, tx)
+	if err != nil {
+		return err
+	}
+	if payment == nil {
+		return nil
+	}
+
+	w.mx.Lock()
+	var updated *InvoiceState
+	if inv := w.matchInvoice(wa, payment); inv != nil {
+		payment.InvoiceID = inv.ID
+		if inv.hasPayment(tx) {
+			// transaction is processed again after restart, payment was already reported
+			w.mx.Unlock()
+			return nil
+		}
+		updated = inv.copy()
+	}
+	w.mx.Unlock()
+
+	if updated != nil {
+		updated.Payments = append(updated.Payments, payment)
+		if !payment.Bounced {
+			updated.Received = tlb.FromNanoTON(new(big.Int).Add(updated.Received.Nano(), payment.Amount.Nano()))
+			if !updated.Paid && updated.Received.Nano().Cmp(updated.Amount.Nano()) >= 0 {
+				updated.Paid = true
+				updated.PaidKey = updated.paidKey()
+			}
+		}
+
+		if err = w.cfg.Store.SaveInvoice(ctx, updated); err != nil {
+			return fmt.Errorf("failed to save invoice: %w", err)
+		}
+
+		w.mx.Lock()
+		if _, ok := w.invoices[updated.ID]; ok {
+			w.invoices[updated.ID] = updated
+		}
+		w.mx.Unlock()
+	}
+
+	if w.cfg.OnPayment != nil {
+		w.cfg.OnPayment(payment)
+	}
+	return nil
+}
+
+// matchInvoice - finds invoice of the payment, should be called under lock
+func (w *PaymentWatcher) matchInvoice(wa *watchedAddress, p *Payment) *InvoiceState {
+	sameAsset := func(s *InvoiceState) bool {
+		if s.Jetton == nil || p.Jetton == nil {
+			return s.Jetton == nil && p.Jetton == nil
+		}
+		return rawAddr(s.Jetton) == rawAddr(p.Jetton)
+	}
+
+	if wa.invoice != "" {
+		if s, ok := w.invoices[wa.invoice]; ok && sameAsset(s) {
+			return s
+		}
+		return nil
+	}
+
+	if p.Comment == "" {
+		return nil
+	}
+
+	for _, s := range w.invoices {
+		if s.Deposit == nil && s.Comment == p.Comment && sameAsset(s) {
+			return s
+		}
+	}
+	return nil
+}
+
+// parsePayment - returns incoming transfer of the transaction, nil if it is not a payment
+func (w *PaymentWatcher) parsePayment(ctx context.Context, wa *watchedAddress, tx *tlb.Transaction) (*Payment, error) {
+	if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+		return nil, nil
+	}
+
+	msg := tx.IO.In.AsInternal()
+	if msg.Bounced {
+		// our own message which was returned back
+		return nil, nil
+	}
+
+	p := &Payment{
+		Address: wa.addr,
+		From:    msg.SrcAddr,
+		Amount:  msg.Amount,
+		Bounced: isBouncedTransaction(tx),
+		TxLT:    tx.LT,
+		TxHash:  tx.Hash,
+	}
+
+	if msg.Body == nil {
+		return p, nil
+	}
+
+	op, err := msg.Body.BeginParse().LoadUInt(32)
+	if err != nil || op != OpJettonTransferNotification {
+		p.Comment, p.Encrypted = w.parseComment(msg.Body, msg.SrcAddr)
+		return p, nil
+	}
+
+	var notification jettonTransferNotification
+	if err = tlb.LoadFromCell(&notification, msg.Body.BeginParse()); err != nil {
+		// not a valid notification, so it is not a jetton payment
+		return p, nil
+	}
+
+	master, err := w.findJetton(ctx, wa, msg.SrcAddr)
+	if err != nil {
+		return nil, err
+	}
+	if master == nil {
+		// notification is not from our jetton wallet, it can be fake
+		return p, nil
+	}
+
+	p.Jetton = master
+	p.From = notification.Sender
+	p.Amount = notification.Amount
+	// jettons are already on our jetton wallet, even when notification was bounced
+	p.Bounced = false
+	if notification.ForwardPayload != nil {
+		p.Comment, p.Encrypted = w.parseComment(notification.ForwardPayload, notification.Sender)
+	}
+	return p, nil
+}
+
+// parseComment - loads plain or encrypted comment, encrypted one is returned only when it was decrypted
+func (w *PaymentWatcher) parseComment(body *cell.Cell, sender *address.Address) (string, bool) {
+	s := body.BeginParse()
+	op, err := s.LoadUInt(32)
+	if err != nil {
+		return "", false
+	}
+
+	switch op {
+	case 0:
+		text, err := s.LoadStringSnake()
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(text), false
+	case EncryptedCommentOpcode:
+		if w.cfg.Key == nil || sender == nil {
+			return "", false
+		}
+
+		// sender key is not known, but it can be restored from the xor of both keys
+		xorKey, err := s.LoadSlice(256)
+		if err != nil {
+			return "", false
+		}
+
+		ourKey := w.cfg.Key.Public().(ed25519.PublicKey)
+		theirKey := make(ed25519.PublicKey, ed25519.PublicKeySize)
+		for i := range theirKey {
+			theirKey[i] = xorKey[i] ^ ourKey[i]
+		}
+
+		text, err := DecryptCommentCell(body, sender, w.cfg.Key, theirKey)
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(text)), true
+	}
+	return "", false
+}
+
+// findJetton - returns master of the jetton, if notification sender is our jetton wallet of one of invoices jettons
+func (w *PaymentWatcher) findJetton(ctx context.Context, wa *watchedAddress, sender *address.Address) (*address.Address, error) {
+	w.mx.Lock()
+	var masters []*address.Address
+	for _, s := range w.invoices {
+		if s.Jetton == nil {
+			continue
+		}
+		if (wa.invoice == "" && s.Deposit == nil) || s.ID == wa.invoice {
+			masters = append(masters, s.Jetton)
+		}
+	}
+	w.mx.Unlock()
+
+	for _, master := range masters {
+		jw, err := w.jettonWallet(ctx, master, wa.addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if sender != nil && rawAddr(jw) == rawAddr(sender) {
+			return master, nil
+		}
+	}
+	return nil, nil
+}
+
+// jettonWallet - resolves jetton wallet of the owner, result is cached
+func (w *PaymentWatcher) jettonWallet(ctx context.Context, master, owner *address.Address) (*address.Address, error) {
+	key := rawAddr(master) + "/" + rawAddr(owner)
+
+	w.mx.Lock()
+	jw, ok := w.jettonWallets[key]
+	w.mx.Unlock()
+	if ok {
+		return jw, nil
+	}
+
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	res, err := w.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, master, "get_wallet_address",
+		cell.BeginCell().MustStoreAddr(owner).EndCell().BeginParse())
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_wallet_address method: %w", err)
+	}
+
+	s, err := res.Slice(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jetton wallet: %w", err)
+	}
+
+	jw, err = s.LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jetton wallet address: %w", err)
+	}
+
+	w.mx.Lock()
+	w.jettonWallets[key] = jw
+	w.mx.Unlock()
+
+	return jw, nil
+}
+
+// notify - calls OnPaid for the paid invoices which were not notified yet
+func (w *PaymentWatcher) notify(ctx context.Context) error {
+	w.mx.Lock()
+	var paid []*InvoiceState
+	for _, s := range w.invoices {
+		if s.Paid && !s.Notified {
+			paid = append(paid, s.copy())
+		}
+	}
+	w.mx.Unlock()
+
+	var firstErr error
+	for _, s := range paid {
+		if s.PaidKey == "" {
+			// state was saved without the key, it should be persisted before the first call to stay the same
+			s.PaidKey = s.paidKey()
+			if err := w.cfg.Store.SaveInvoice(ctx, s); err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to save invoice: %w", err)
+				}
+				continue
+			}
+
+			w.mx.Lock()
+			if cur, ok := w.invoices[s.ID]; ok {
+				cur.PaidKey = s.PaidKey
+			}
+			w.mx.Unlock()
+		}
+
+		if err := w.cfg.OnPaid(ctx, s.copy()); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("invoice %s callback failed: %w", s.ID, err)
+			}
+			continue
+		}
+
+		s.Notified = true
+		w.mx.Lock()
+		if cur, ok := w.invoices[s.ID]; ok {
+			cur.Notified = true
+		}
+		w.mx.Unlock()
+
+		if err := w.cfg.Store.SaveInvoice(ctx, s); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to save invoice: %w", err)
+		}
+	}
+	return firstErr
+}
+
+// isBouncedTransaction - checks is incoming message value was returned to the sender
+func isBouncedTransaction(tx *tlb.Transaction) bool {
+	desc, ok := tx.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok || desc.BouncePhase == nil {
+		return false
+	}
+
+	_, ok = desc.BouncePhase.Phase.(tlb.BouncePhaseOk)
+	return ok
+}
+
+func rawAddr(addr *address.Address) string {
+	return fmt.Sprintf("%d:%x", addr.Workchain(), addr.Data())
+}
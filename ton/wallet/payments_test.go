@@ -0,0 +1,252 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// addPaymentTx - adds transaction with the incoming internal message, bounced transaction is aborted with bounce phase
+func addPaymentTx(c *testChain, addr *address.Address, msg *tlb.InternalMessage, bounced bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	tx := c.appendTx(addr, &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: msg})
+	if bounced {
+		tx.Description.Description = tlb.TransactionDescriptionOrdinary{
+			Aborted:     true,
+			BouncePhase: &tlb.BouncePhase{Phase: tlb.BouncePhaseOk{}},
+		}
+	}
+}
+
+// paymentsAPI - chain mock which resolves jetton wallet of the owner
+func paymentsAPI(c *testChain, jettonMaster, owner, jettonWallet *address.Address) *MockAPI {
+	m := c.api()
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		if method != "get_wallet_address" || rawAddr(addr) != rawAddr(jettonMaster) {
+			return nil, errors.New("unexpected method")
+		}
+
+		ownerAddr := params[0].(*cell.Slice).MustLoadAddr()
+		if rawAddr(ownerAddr) != rawAddr(owner) {
+			return nil, errors.New("unexpected owner")
+		}
+		return ton.NewExecutionResult([]any{cell.BeginCell().MustStoreAddr(jettonWallet).EndCell().BeginParse()}), nil
+	}
+	return m
+}
+
+func TestPaymentWatcher(t *testing.T) {
+	receiverKey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	payerKey := ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab"))
+
+	receiver := address.MustParseRawAddr("0:" + "1111111111111111111111111111111111111111111111111111111111111111")
+	deposit := address.MustParseRawAddr("0:" + "2222222222222222222222222222222222222222222222222222222222222222")
+	payer := address.MustParseRawAddr("0:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	jettonMaster := address.MustParseRawAddr("0:" + "4444444444444444444444444444444444444444444444444444444444444444")
+	jettonWallet := address.MustParseRawAddr("0:" + "5555555555555555555555555555555555555555555555555555555555555555")
+	fakeJettonWallet := address.MustParseRawAddr("0:" + "6666666666666666666666666666666666666666666666666666666666666666")
+
+	chain := newTestChain(10)
+	api := paymentsAPI(chain, jettonMaster, receiver, jettonWallet)
+
+	comment := func(text string) *cell.Cell {
+		c, err := CreateCommentCell(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	transfer := func(to *address.Address, amount string, body *cell.Cell) *tlb.InternalMessage {
+		return &tlb.InternalMessage{Bounce: true, SrcAddr: payer, DstAddr: to, Amount: tlb.MustFromTON(amount), Body: body}
+	}
+	notification := func(from *address.Address, amount uint64, text string) *tlb.InternalMessage {
+		body, err := tlb.ToCell(jettonTransferNotification{
+			Amount:         tlb.FromNanoTONU(amount),
+			Sender:         payer,
+			ForwardPayload: comment(text),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &tlb.InternalMessage{SrcAddr: from, DstAddr: receiver, Amount: tlb.MustFromTON("0.01"), Body: body}
+	}
+
+	// transaction before the first start should not be processed
+	addPaymentTx(chain, receiver, transfer(receiver, "5", comment("order-1")), false)
+
+	store := &memoryPaymentStore{checkpoints: map[string]uint64{}, invoices: map[string]*InvoiceState{}}
+
+	var mx sync.Mutex
+	paid := map[string]int{}
+	keys := map[string][]string{}
+	failed := false
+	var payments []*Payment
+
+	cfg := PaymentWatcherConfig{
+		Key:           receiverKey,
+		Confirmations: 2,
+		PollInterval:  5 * time.Millisecond,
+		Store:         store,
+		OnPaid: func(ctx context.Context, state *InvoiceState) error {
+			mx.Lock()
+			defer mx.Unlock()
+
+			keys[state.ID] = append(keys[state.ID], state.PaidKey)
+			if state.ID == "deposit" && !failed {
+				failed = true
+				return errors.New("temporary error")
+			}
+			paid[state.ID]++
+			return nil
+		},
+		OnPayment: func(payment *Payment) {
+			mx.Lock()
+			defer mx.Unlock()
+
+			payments = append(payments, payment)
+		},
+	}
+
+	invoices := []Invoice{
+		{ID: "comment", Amount: tlb.MustFromTON("2"), Comment: "order-1"},
+		{ID: "encrypted", Amount: tlb.MustFromTON("1"), Comment: "order-2"},
+		{ID: "deposit", Amount: tlb.MustFromTON("3"), Deposit: deposit},
+		{ID: "jetton", Amount: tlb.FromNanoTONU(100), Jetton: jettonMaster, Comment: "order-4"},
+		{ID: "unpaid", Amount: tlb.MustFromTON("1"), Comment: "order-5"},
+	}
+
+	w, err := NewPaymentWatcher(api, receiver, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inv := range invoices {
+		if err = w.AddInvoice(context.Background(), inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = w.AddInvoice(context.Background(), Invoice{ID: "dup", Amount: tlb.MustFromTON("1"), Comment: "order-1"}); !errors.Is(err, ErrInvoiceExists) {
+		t.Fatal("duplicate comment should be rejected", err)
+	}
+
+	// let watcher set checkpoint of the receiver
+	time.Sleep(50 * time.Millisecond)
+
+	encrypted, err := CreateEncryptedCommentCell("order-2", payer, payerKey, receiverKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addPaymentTx(chain, receiver, transfer(receiver, "1.5", comment("order-1")), false)
+	addPaymentTx(chain, receiver, transfer(receiver, "0.5", comment(" order-1 ")), false)
+	addPaymentTx(chain, receiver, transfer(receiver, "1", encrypted), false)
+	addPaymentTx(chain, receiver, notification(jettonWallet, 100, "order-4"), false)
+	addPaymentTx(chain, receiver, notification(fakeJettonWallet, 100, "order-4"), false)
+	addPaymentTx(chain, receiver, transfer(receiver, "7", comment("unknown")), false)
+	addPaymentTx(chain, deposit, transfer(deposit, "1", nil), false)
+	addPaymentTx(chain, deposit, transfer(deposit, "5", nil), true)
+	addPaymentTx(chain, deposit, transfer(deposit, "2.5", nil), false)
+
+	// transactions are found, but not confirmed yet
+	time.Sleep(50 * time.Millisecond)
+	chain.nextBlocks(1)
+	time.Sleep(50 * time.Millisecond)
+
+	mx.Lock()
+	if len(payments) != 0 || len(paid) != 0 {
+		t.Fatal("payments should not be processed before confirmations", len(payments), paid)
+	}
+	mx.Unlock()
+
+	chain.nextBlocks(1)
+	time.Sleep(200 * time.Millisecond)
+	w.Close()
+
+	mx.Lock()
+	if len(paid) != 4 || paid["comment"] != 1 || paid["encrypted"] != 1 || paid["deposit"] != 1 || paid["jetton"] != 1 {
+		t.Fatal("incorrect callbacks", paid)
+	}
+	if len(payments) != 9 {
+		t.Fatal("incorrect payments num", len(payments))
+	}
+	mx.Unlock()
+
+	state, ok := w.Invoice("deposit")
+	if !ok || !state.Paid || !state.Notified || len(state.Payments) != 3 || !state.Payments[1].Bounced ||
+		state.Received.String() != "3.5" || state.Overpaid().String() != "0.5" {
+		t.Fatal("incorrect deposit invoice", state)
+	}
+
+	// failed callback should be repeated with the same key
+	if len(keys["deposit"]) != 2 || keys["deposit"][0] == "" || keys["deposit"][0] != keys["deposit"][1] ||
+		keys["deposit"][0] != state.PaidKey || keys["comment"][0] == state.PaidKey {
+		t.Fatal("incorrect paid keys", keys)
+	}
+
+	state, _ = w.Invoice("encrypted")
+	if !state.Paid || len(state.Payments) != 1 || !state.Payments[0].Encrypted || state.Payments[0].From.String() != payer.String() {
+		t.Fatal("incorrect encrypted invoice", state)
+	}
+
+	state, _ = w.Invoice("jetton")
+	if !state.Paid || len(state.Payments) != 1 || state.Payments[0].Jetton == nil || state.Received.Nano().Uint64() != 100 {
+		t.Fatal("incorrect jetton invoice", state)
+	}
+
+	state, _ = w.Invoice("unpaid")
+	if state.Paid || state.Remaining().String() != "1" {
+		t.Fatal("incorrect unpaid invoice", state)
+	}
+
+	// process was stopped before the last deposit checkpoint was saved,
+	// so its transaction is processed again, but payment should not be duplicated
+	if err = store.SaveCheckpoint(context.Background(), deposit, 2000); err != nil {
+		t.Fatal(err)
+	}
+
+	// after restart state is restored from store and callbacks are not repeated
+	w, err = NewPaymentWatcher(api, receiver, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, inv := range invoices {
+		if err = w.AddInvoice(context.Background(), inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	addPaymentTx(chain, receiver, transfer(receiver, "1", comment("order-5")), false)
+	chain.nextBlocks(2)
+	time.Sleep(50 * time.Millisecond)
+	chain.nextBlocks(2)
+	time.Sleep(100 * time.Millisecond)
+
+	mx.Lock()
+	if len(paid) != 5 || paid["comment"] != 1 || paid["deposit"] != 1 || paid["unpaid"] != 1 || len(payments) != 10 {
+		t.Fatal("incorrect callbacks after restart", paid, len(payments))
+	}
+	mx.Unlock()
+
+	state, _ = w.Invoice("comment")
+	if !state.Notified || len(state.Payments) != 2 {
+		t.Fatal("state should be restored", state)
+	}
+
+	state, _ = w.Invoice("deposit")
+	if len(state.Payments) != 3 || state.Received.String() != "3.5" {
+		t.Fatal("reprocessed payment should not be added again", state)
+	}
+}
@@ -2,10 +2,15 @@ package wallet
 
 import (
 	"context"
+	"time"
+
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
+// action_send_msg opcode of the out actions list
+const opActionSendMsg = 0x0ec3c86d
+
 type RegularBuilder interface {
 	BuildMessage(ctx context.Context, isInitialized bool, block *ton.BlockIDExt, messages []*Message) (*cell.Cell, error)
 }
@@ -24,6 +29,11 @@ func (s *SpecRegular) SetMessagesTTL(ttl uint32) {
 	s.messagesTTL = ttl
 }
 
+// validUntil - expiration time of the new request, it is used by both online and offline built messages
+func (s *SpecRegular) validUntil() uint32 {
+	return uint32(timeNow().Add(time.Duration(s.messagesTTL) * time.Second).UTC().Unix())
+}
+
 type SpecSeqno struct {
 	// Instead of calling contract 'seqno' method,
 	// this function wil be used (if not nil) to get seqno for new transaction.
@@ -37,6 +47,19 @@ func (s *SpecSeqno) SetCustomSeqnoFetcher(fetcher func() uint32) {
 	s.customSeqnoFetcher = fetcher
 }
 
+// seqno - returns seqno for the new request of the wallet, it is taken from the custom fetcher when it is set,
+// otherwise it is 0 for not initialized wallet, and is loaded from contract at the block for initialized one
+func (s *SpecSeqno) seqno(ctx context.Context, w *Wallet, isInitialized bool, block *ton.BlockIDExt) (uint32, error) {
+	if s.customSeqnoFetcher != nil {
+		return s.customSeqnoFetcher(), nil
+	}
+
+	if !isInitialized {
+		return 0, nil
+	}
+	return getSeqno(ctx, w.api, block, w.addr, w.ver)
+}
+
 type SpecQuery struct {
 	// Instead of generating random query id with message ttl,
 	// this function wil be used (if not nil) to get query id for new transaction.
@@ -20,6 +20,8 @@ const (
 	_PasswordSalt = "TON fast seed version"
 )
 
+var ErrInvalidSeed = errors.New("invalid seed")
+
 func NewSeed() []string {
 	return NewSeedWithPassword("")
 }
@@ -39,20 +41,8 @@ func NewSeedWithPassword(password string) []string {
 			}
 		}
 
-		mac := hmac.New(sha512.New, []byte(strings.Join(seed, " ")))
-		mac.Write([]byte(password))
-		hash := mac.Sum(nil)
-
-		if len(password) > 0 {
-			p := pbkdf2.Key(hash, []byte(_PasswordSalt), 1, 1, sha512.New)
-			if p[0] != 1 {
-				continue
-			}
-		} else {
-			p := pbkdf2.Key(hash, []byte(_BasicSalt), _Iterations/256, 1, sha512.New)
-			if p[0] != 0 {
-				continue
-			}
+		if !isTONMnemonicHash(tonMnemonicHash(seed, password), password) {
+			continue
 		}
 
 		return seed
@@ -74,20 +64,12 @@ func FromSeedWithPassword(api TonAPI, seed []string, password string, version Ve
 		}
 	}
 
-	mac := hmac.New(sha512.New, []byte(strings.Join(seed, " ")))
-	mac.Write([]byte(password))
-	hash := mac.Sum(nil)
-
-	if len(password) > 0 {
-		p := pbkdf2.Key(hash, []byte(_PasswordSalt), 1, 1, sha512.New)
-		if p[0] != 1 {
-			return nil, errors.New("invalid seed")
-		}
-	} else {
-		p := pbkdf2.Key(hash, []byte(_BasicSalt), _Iterations/256, 1, sha512.New)
-		if p[0] != 0 {
-			return nil, errors.New("invalid seed")
+	hash := tonMnemonicHash(seed, password)
+	if !isTONMnemonicHash(hash, password) {
+		if ValidateBIP39Mnemonic(seed) == nil {
+			return nil, fmt.Errorf("%w: it is BIP39 mnemonic, use FromBIP39Seed", ErrInvalidSeed)
 		}
+		return nil, ErrInvalidSeed
 	}
 
 	k := pbkdf2.Key(hash, []byte(_Salt), _Iterations, 32, sha512.New)
@@ -95,6 +77,32 @@ func FromSeedWithPassword(api TonAPI, seed []string, password string, version Ve
 	return FromPrivateKey(api, ed25519.NewKeyFromSeed(k), version)
 }
 
+// IsTONMnemonic - checks that words are TON-native mnemonic, which was generated with the given password
+func IsTONMnemonic(seed []string, password string) bool {
+	if len(seed) < 12 {
+		return false
+	}
+	for _, s := range seed {
+		if !words[s] {
+			return false
+		}
+	}
+	return isTONMnemonicHash(tonMnemonicHash(seed, password), password)
+}
+
+func tonMnemonicHash(seed []string, password string) []byte {
+	mac := hmac.New(sha512.New, []byte(strings.Join(seed, " ")))
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+func isTONMnemonicHash(hash []byte, password string) bool {
+	if len(password) > 0 {
+		return pbkdf2.Key(hash, []byte(_PasswordSalt), 1, 1, sha512.New)[0] == 1
+	}
+	return pbkdf2.Key(hash, []byte(_BasicSalt), _Iterations/256, 1, sha512.New)[0] == 0
+}
+
 var wordsArr = func() []string {
 	wa := make([]string, 0, len(words))
 	for w := range words {
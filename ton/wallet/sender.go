@@ -0,0 +1,427 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+var (
+	ErrSenderClosed = errors.New("sender is closed")
+	errBatchExpired = errors.New("batch is expired")
+)
+
+// time after valid until, during which we still wait for the batch transaction,
+// because accepted message can be included to the block a bit later
+const senderExpireGrace = 30 * time.Second
+
+// delay before the next attempt when wallet state or block cannot be fetched
+const senderRetryDelay = 500 * time.Millisecond
+
+// SendResult - outcome of the message sent by Sender
+type SendResult struct {
+	// Transaction of the wallet which has processed the batch with message
+	Transaction *tlb.Transaction
+	// Block - master block where transaction was found
+	Block *ton.BlockIDExt
+	Err   error
+}
+
+type senderRequest struct {
+	message *Message
+	result  chan *SendResult
+}
+
+// Sender - sends messages of the single wallet from multiple goroutines.
+// Messages are queued and packed to batches, seqno is assigned locally,
+// and next batch is sent only when transaction of the previous one is found.
+// When batch is expired without being processed, it is sent again with the fresh seqno.
+type Sender struct {
+	wallet *Wallet
+
+	// maximum number of attempts to send the batch before it is failed
+	maxAttempts int
+
+	mx     sync.Mutex
+	queue  []*senderRequest
+	notify chan bool
+
+	// state of the wallet, used only by the sending loop
+	synced      bool
+	initialized bool
+	seqno       uint32
+	lastLT      uint64
+	lastHash    []byte
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+// NewSender - creates sender and starts its sending loop, Close should be called to stop it
+func NewSender(w *Wallet) *Sender {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Sender{
+		wallet:      w,
+		maxAttempts: 3,
+		notify:      make(chan bool, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan bool),
+	}
+	go s.loop()
+
+	return s
+}
+
+// SetMaxAttempts - sets how many times expired batch can be sent before its messages are failed, default is 3
+func (s *Sender) SetMaxAttempts(attempts int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.maxAttempts = attempts
+}
+
+// Enqueue - adds message to the queue, result will be written to the returned channel
+func (s *Sender) Enqueue(message *Message) <-chan *SendResult {
+	req := &senderRequest{
+		message: message,
+		result:  make(chan *SendResult, 1),
+	}
+
+	s.mx.Lock()
+	if s.ctx.Err() != nil {
+		s.mx.Unlock()
+		req.result <- &SendResult{Err: ErrSenderClosed}
+		return req.result
+	}
+	s.queue = append(s.queue, req)
+	s.mx.Unlock()
+
+	select {
+	case s.notify <- true:
+	default:
+	}
+	return req.result
+}
+
+// Send - enqueues message and waits for its transaction. When context is done before,
+// message is still in the queue and may be sent.
+func (s *Sender) Send(ctx context.Context, message *Message) (*tlb.Transaction, *ton.BlockIDExt, error) {
+	select {
+	case res := <-s.Enqueue(message):
+		return res.Transaction, res.Block, res.Err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// Close - stops sending loop, messages which are not sent yet are failed with ErrSenderClosed.
+// If batch is waiting for transaction, its messages may still be processed by the wallet.
+func (s *Sender) Close() {
+	s.mx.Lock()
+	s.cancel()
+	s.mx.Unlock()
+
+	<-s.done
+}
+
+func (s *Sender) loop() {
+	defer close(s.done)
+
+	for {
+		batch := s.nextBatch()
+		if batch == nil {
+			select {
+			case <-s.notify:
+				continue
+			case <-s.ctx.Done():
+				s.failQueue()
+				return
+			}
+		}
+
+		tx, block, err := s.sendBatch(batch)
+		if err != nil && s.ctx.Err() != nil {
+			err = fmt.Errorf("%w, batch result is unknown: %s", ErrSenderClosed, err.Error())
+		}
+
+		for _, req := range batch {
+			req.result <- &SendResult{
+				Transaction: tx,
+				Block:       block,
+				Err:         err,
+			}
+		}
+	}
+}
+
+func (s *Sender) nextBatch() []*senderRequest {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.ctx.Err() != nil {
+		return nil
+	}
+
+	num := len(s.queue)
+	if limit := senderBatchSize(s.wallet.ver); num > limit {
+		num = limit
+	}
+
+	if num == 0 {
+		return nil
+	}
+
+	batch := s.queue[:num:num]
+	s.queue = s.queue[num:]
+	return batch
+}
+
+func (s *Sender) failQueue() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for _, req := range s.queue {
+		req.result <- &SendResult{Err: ErrSenderClosed}
+	}
+	s.queue = nil
+}
+
+func senderBatchSize(ver Version) int {
+	switch ver {
+	case V1R1, V1R2, V1R3:
+		return 1
+	case V5R1:
+		return v5r1MaxActions
+	case HighloadV2R2, HighloadV2Verified:
+		return highloadV2MaxMessages
+	case HighloadV3:
+		return highloadV3MaxActions
+	}
+	return 4
+}
+
+func (s *Sender) sendBatch(batch []*senderRequest) (*tlb.Transaction, *ton.BlockIDExt, error) {
+	messages := make([]*Message, 0, len(batch))
+	for _, req := range batch {
+		messages = append(messages, req.message)
+	}
+
+	s.mx.Lock()
+	attempts := s.maxAttempts
+	s.mx.Unlock()
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if !s.synced {
+			if err = s.sync(); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		var tx *tlb.Transaction
+		var block *ton.BlockIDExt
+		tx, block, err = s.trySend(messages)
+		if err == nil {
+			s.seqno++
+			s.initialized = true
+			return tx, block, nil
+		}
+
+		// wallet state is unknown after failure, so we fetch it again,
+		// expired batch is sent again with the actual seqno
+		s.synced = false
+		if !errors.Is(err, errBatchExpired) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %d attempts made", ErrTxWasNotConfirmed, attempts)
+}
+
+// sync - loads current seqno and last transaction of the wallet
+func (s *Sender) sync() error {
+	w := s.wallet
+
+	block, err := w.api.CurrentMasterchainInfo(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(s.ctx, block, w.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	s.initialized = acc.IsActive && acc.State.Status == tlb.AccountStatusActive
+	s.lastLT, s.lastHash = acc.LastTxLT, acc.LastTxHash
+	s.seqno = 0
+
+	if s.initialized && seqnoSpec(w.spec) != nil {
+		s.seqno, err = getSeqno(s.ctx, w.api, block, w.addr, w.ver)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.synced = true
+	return nil
+}
+
+func (s *Sender) trySend(messages []*Message) (*tlb.Transaction, *ton.BlockIDExt, error) {
+	w := s.wallet
+
+	bundle, err := w.prepareBundle(s.ctx, messages, s.initialized, func() (uint32, error) {
+		return s.seqno, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signed, err := bundle.Sign(s.ctx, w.signer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ext, err := signed.ExternalMessage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// message can be delivered even when error is returned, for example on timeout,
+	// so we wait for it till the expiration, like for the successfully sent one
+	sendErr := w.api.SendExternalMessage(s.ctx, ext)
+
+	tx, block, err := s.waitTransaction(ext, bundle.ValidUntil)
+	if sendErr != nil && errors.Is(err, errBatchExpired) {
+		// wallet state after the expiration has no our transaction, so seqno was not used
+		// and batch is failed with the original error instead of sending it again
+		return nil, nil, fmt.Errorf("failed to send message: %w", sendErr)
+	}
+	return tx, block, err
+}
+
+// waitTransaction - scans new wallet transactions until the one with our external message is found,
+// or until message is expired
+func (s *Sender) waitTransaction(ext *tlb.ExternalMessage, validUntil uint32) (*tlb.Transaction, *ton.BlockIDExt, error) {
+	w := s.wallet
+	expireAt := time.Unix(int64(validUntil), 0).Add(senderExpireGrace)
+
+	block, err := w.api.CurrentMasterchainInfo(s.ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	for {
+		// V1 requests have no expiration time, so we wait for them till the sender is closed
+		expired := validUntil != 0 && !timeNow().Before(expireAt)
+
+		tx, err := s.checkTransaction(block, ext, expired)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil, nil, s.ctx.Err()
+			}
+			if expired {
+				// we cannot be sure that batch was not processed, so it is not safe to send it again
+				return nil, nil, fmt.Errorf("failed to check wallet state after expiration: %w", err)
+			}
+
+			if err = s.retryDelay(); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if tx != nil {
+			return tx, block, nil
+		}
+
+		// account state is checked after expiration time, so message cannot be processed anymore
+		if expired {
+			return nil, nil, errBatchExpired
+		}
+
+		blockNew, err := w.api.WaitForBlock(block.SeqNo + 1).GetMasterchainInfo(s.ctx)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil, nil, s.ctx.Err()
+			}
+
+			if err = s.retryDelay(); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		block = blockNew
+	}
+}
+
+func (s *Sender) retryDelay() error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case <-time.After(senderRetryDelay):
+		return nil
+	}
+}
+
+// checkTransaction - looks for our transaction in the wallet state at the block,
+// sends message again when there are no new transactions and it is not expired yet
+func (s *Sender) checkTransaction(block *ton.BlockIDExt, ext *tlb.ExternalMessage, expired bool) (*tlb.Transaction, error) {
+	w := s.wallet
+
+	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(s.ctx, block, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if acc.LastTxLT == s.lastLT {
+		if !expired {
+			// if not in block, maybe LS lost our message, send it again
+			_ = w.api.SendExternalMessage(s.ctx, ext)
+		}
+		return nil, nil
+	}
+
+	tx, err := s.findTransaction(block, acc, ext)
+	if err != nil {
+		return nil, err
+	}
+	s.lastLT, s.lastHash = acc.LastTxLT, acc.LastTxHash
+	return tx, nil
+}
+
+// findTransaction - looks for transaction with our external message between last seen and current account transaction
+func (s *Sender) findTransaction(block *ton.BlockIDExt, acc *tlb.Account, ext *tlb.ExternalMessage) (*tlb.Transaction, error) {
+	for lt, hash := acc.LastTxLT, acc.LastTxHash; lt > s.lastLT; {
+		txList, err := s.wallet.api.WaitForBlock(block.SeqNo).ListTransactions(s.ctx, s.wallet.addr, 10, lt, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		if len(txList) == 0 {
+			break
+		}
+
+		for _, tx := range txList {
+			if tx.LT <= s.lastLT || tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeExternalIn {
+				continue
+			}
+
+			if bytes.Equal(tx.IO.In.AsExternalIn().Body.Hash(), ext.Body.Hash()) {
+				return tx, nil
+			}
+		}
+
+		// get previous of the oldest tx, in case if we need to scan deeper
+		lt, hash = txList[0].PrevTxLT, txList[0].PrevTxHash
+	}
+	return nil, nil
+}
@@ -0,0 +1,434 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// senderChain - emulates wallet account, which processes external messages with the expected seqno
+type senderChain struct {
+	*testChain
+
+	wallet *address.Address
+	seqno  uint32
+	sent   int
+	// drop - number of next messages which will be lost
+	drop int
+	// onDrop - called when message is lost
+	onDrop func()
+	// sendErrs - number of next messages for which error is returned, even if they are processed
+	sendErrs int
+	// accountErrs - number of next account requests which will fail, negative means all
+	accountErrs int
+	// v1 - messages have no subwallet and valid until
+	v1      bool
+	data    *cell.Cell
+	balance tlb.Coins
+}
+
+func newSenderChain(seqno uint32) *senderChain {
+	return &senderChain{testChain: newTestChain(2), seqno: seqno}
+}
+
+// walletTxs - returns transactions of the wallet, which external messages were sent to
+func (c *senderChain) walletTxs() []*tlb.Transaction {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.wallet == nil {
+		return nil
+	}
+	return c.txs[rawAddr(c.wallet)]
+}
+
+func (c *senderChain) api() *MockAPI {
+	m := c.testChain.api()
+
+	getAccount := m.getAccount
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		c.mx.Lock()
+		if c.accountErrs != 0 {
+			c.accountErrs--
+			c.mx.Unlock()
+			return nil, errors.New("liteserver is not available")
+		}
+		c.mx.Unlock()
+
+		acc, err := getAccount(ctx, block, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// wallet is deployed before the first transaction in these tests
+		acc.IsActive = true
+		acc.State = &tlb.AccountState{
+			IsValid: true,
+			AccountStorage: tlb.AccountStorage{
+				Status:  tlb.AccountStatusActive,
+				Balance: c.balance,
+			},
+		}
+		acc.Data = c.data
+		return acc, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+
+		return ton.NewExecutionResult([]any{big.NewInt(int64(c.seqno))}), nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+
+		c.sent++
+		c.wallet = msg.DstAddr
+
+		var err error
+		if c.sendErrs != 0 {
+			c.sendErrs--
+			err = errors.New("adnl query timeout")
+		}
+
+		if c.drop > 0 {
+			c.drop--
+			if c.onDrop != nil {
+				c.onDrop()
+			}
+			return err
+		}
+
+		p := msg.Body.BeginParse()
+		p.MustLoadSlice(512)
+		if !c.v1 {
+			p.MustLoadUInt(64) // subwallet and valid until
+		}
+		if uint32(p.MustLoadUInt(32)) != c.seqno {
+			// rejected by contract
+			return err
+		}
+		c.seqno++
+
+		tx := c.appendTx(msg.DstAddr, &tlb.Message{
+			MsgType: tlb.MsgTypeExternalIn,
+			Msg:     msg,
+		})
+		tx.OutMsgCount = uint16(msg.Body.RefsNum())
+		return err
+	}
+	return m
+}
+
+func TestSender_Concurrent(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	chain := newSenderChain(5)
+
+	w, err := FromPrivateKey(chain.api(), pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := NewSender(w)
+	defer sender.Close()
+
+	const num = 10
+
+	var wg sync.WaitGroup
+	txs := make([]*tlb.Transaction, num)
+	for i := 0; i < num; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			msg := SimpleMessage(address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.1"), nil)
+			tx, _, err := sender.Send(ctx, msg)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			txs[i] = tx
+		}(i)
+	}
+	wg.Wait()
+
+	if t.Failed() {
+		return
+	}
+
+	sent := map[*tlb.Transaction]bool{}
+	for _, tx := range txs {
+		if tx == nil {
+			t.Fatal("no transaction")
+		}
+		sent[tx] = true
+	}
+
+	total := 0
+	for tx := range sent {
+		if tx.OutMsgCount > 4 {
+			t.Fatal("too many messages in batch")
+		}
+		total += int(tx.OutMsgCount)
+	}
+
+	if total != num {
+		t.Fatal("not all messages sent", total)
+	}
+
+	if chain.seqno != 5+uint32(len(sent)) || len(chain.walletTxs()) != len(sent) {
+		t.Fatal("incorrect number of batches", chain.seqno, len(chain.walletTxs()), len(sent))
+	}
+}
+
+func TestSender_ResendExpired(t *testing.T) {
+	var now int64 = 1000000
+	timeNow = func() time.Time {
+		return time.Unix(atomic.LoadInt64(&now), 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	chain := newSenderChain(3)
+	chain.drop = 1
+	chain.onDrop = func() {
+		// message is lost, and its valid until is passed
+		atomic.AddInt64(&now, 60*60)
+	}
+
+	w, err := FromPrivateKey(chain.api(), pkey, V3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := NewSender(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg := SimpleMessage(address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.1"), nil)
+	tx, _, err := sender.Send(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chain.sent != 2 || chain.seqno != 4 || tx != chain.walletTxs()[0] {
+		t.Fatal("batch was not resent", chain.sent, chain.seqno)
+	}
+
+	sender.Close()
+
+	if _, _, err = sender.Send(ctx, msg); !errors.Is(err, ErrSenderClosed) {
+		t.Fatal("closed sender should not accept messages", err)
+	}
+}
+
+func TestSender_AccountErrors(t *testing.T) {
+	var now int64 = 1000000
+	timeNow = func() time.Time {
+		return time.Unix(atomic.LoadInt64(&now), 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	chain := newSenderChain(3)
+	chain.onDrop = func() {
+		chain.accountErrs = 2
+	}
+
+	w, err := FromPrivateKey(chain.api(), pkey, V3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := NewSender(w)
+	defer sender.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg := SimpleMessage(address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.1"), nil)
+
+	// state is not available for a while, batch should be found after retries
+	chain.mx.Lock()
+	chain.drop = 1
+	chain.mx.Unlock()
+
+	tx, _, err := sender.Send(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.seqno != 4 || tx != chain.walletTxs()[0] {
+		t.Fatal("batch was not found", chain.sent, chain.seqno)
+	}
+
+	// state is not available after expiration, batch should not be sent again
+	chain.mx.Lock()
+	chain.drop = 1
+	chain.onDrop = func() {
+		chain.accountErrs = -1
+		atomic.AddInt64(&now, 60*60)
+	}
+	sent := chain.sent
+	chain.mx.Unlock()
+
+	if _, _, err = sender.Send(ctx, msg); err == nil || ctx.Err() != nil {
+		t.Fatal("send should fail when state is unknown after expiration", err)
+	}
+
+	chain.mx.Lock()
+	defer chain.mx.Unlock()
+	if chain.sent != sent+1 || chain.seqno != 4 {
+		t.Fatal("batch should not be resent", chain.sent-sent, chain.seqno)
+	}
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	var now int64 = 1000000
+	timeNow = func() time.Time {
+		return time.Unix(atomic.LoadInt64(&now), 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	chain := newSenderChain(3)
+	chain.sendErrs = 1
+
+	w, err := FromPrivateKey(chain.api(), pkey, V3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := NewSender(w)
+	defer sender.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg := SimpleMessage(address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.1"), nil)
+
+	// message is processed, but liteserver reports error, transaction should be found anyway
+	tx, _, err := sender.Send(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.sent != 1 || chain.seqno != 4 || tx != chain.walletTxs()[0] {
+		t.Fatal("batch was not found", chain.sent, chain.seqno)
+	}
+
+	// message is not delivered and expired, batch should fail with send error without resending
+	chain.mx.Lock()
+	chain.sendErrs = -1
+	chain.drop = 1
+	chain.onDrop = func() {
+		atomic.AddInt64(&now, 60*60)
+	}
+	chain.mx.Unlock()
+
+	if _, _, err = sender.Send(ctx, msg); err == nil || ctx.Err() != nil || !strings.Contains(err.Error(), "failed to send message") {
+		t.Fatal("send should fail with send error after expiration", err)
+	}
+
+	chain.mx.Lock()
+	defer chain.mx.Unlock()
+	if chain.sent != 2 || chain.seqno != 4 || len(chain.txs[rawAddr(chain.wallet)]) != 1 {
+		t.Fatal("batch should not be resent", chain.sent, chain.seqno)
+	}
+}
+
+func TestSender_V1AndLockup(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	configKey := ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab")).Public().(ed25519.PublicKey)
+
+	v1Chain := newSenderChain(1)
+	v1Chain.v1 = true
+	v1, err := FromPrivateKey(v1Chain.api(), pkey, V1R3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lockupChain := newSenderChain(7)
+	lockupChain.data = testLockupData(t, pkey.Public().(ed25519.PublicKey), configKey, 7, nil)
+	lockupChain.balance = tlb.MustFromTON("1000")
+	lockup, err := FromPrivateKeyLockup(lockupChain.api(), pkey, DefaultSubwallet, LockupConfig{ConfigPublicKey: configKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	for _, c := range []struct {
+		w     *Wallet
+		chain *senderChain
+	}{{v1, v1Chain}, {lockup, lockupChain}} {
+		sender := NewSender(c.w)
+
+		const num = 3
+
+		var wg sync.WaitGroup
+		for i := 0; i < num; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				if _, _, err := sender.Send(ctx, SimpleMessage(to, tlb.MustFromTON("0.1"), nil)); err != nil {
+					t.Error(c.w.ver.String(), err)
+				}
+			}()
+		}
+		wg.Wait()
+		sender.Close()
+
+		if t.Failed() {
+			return
+		}
+
+		total := 0
+		for _, tx := range c.chain.walletTxs() {
+			total += int(tx.OutMsgCount)
+		}
+		if total != num {
+			t.Fatal("not all messages sent", c.w.ver.String(), total)
+		}
+	}
+
+	if len(v1Chain.walletTxs()) != 3 {
+		t.Fatal("v1 wallet should send one message per request", len(v1Chain.walletTxs()))
+	}
+
+	// locked and restricted funds should be checked before sending
+	sender := NewSender(lockup)
+	defer sender.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, _, err = sender.Send(ctx, SimpleMessage(to, tlb.MustFromTON("700"), nil)); !errors.Is(err, ErrLockupNotEnoughFunds) {
+		t.Fatal("lockup funds should be checked", err)
+	}
+}
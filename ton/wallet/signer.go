@@ -0,0 +1,246 @@
+package wallet
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+)
+
+var (
+	ErrSharedKeyNotSupported = errors.New("signer does not support shared key computation")
+	ErrWatchOnly             = errors.New("wallet is watch-only, messages should be signed offline")
+)
+
+// Signer - signs wallet messages, private key can be kept outside of application memory,
+// for example in KMS, HSM or in the separate signing daemon.
+type Signer interface {
+	PublicKey() ed25519.PublicKey
+	// Sign - returns ed25519 signature of the hash
+	Sign(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// SharedKeySigner - signer which can compute x25519 shared key with another key,
+// it is needed to encrypt comments.
+type SharedKeySigner interface {
+	Signer
+	SharedKey(ctx context.Context, theirKey ed25519.PublicKey) ([]byte, error)
+}
+
+// KeySigner - signer with the private key in process memory
+type KeySigner struct {
+	key ed25519.PrivateKey
+}
+
+func NewKeySigner(key ed25519.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (s *KeySigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *KeySigner) Sign(_ context.Context, hash []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, hash), nil
+}
+
+func (s *KeySigner) SharedKey(_ context.Context, theirKey ed25519.PublicKey) ([]byte, error) {
+	return adnl.SharedKey(s.key, theirKey)
+}
+
+func (s *KeySigner) PrivateKey() ed25519.PrivateKey {
+	return s.key
+}
+
+// PublicKeySigner - watch-only signer without private key, it can be used to prepare
+// unsigned bundles on the online machine, see BuildUnsignedBundle.
+type PublicKeySigner struct {
+	pubKey ed25519.PublicKey
+}
+
+func NewPublicKeySigner(pubKey ed25519.PublicKey) *PublicKeySigner {
+	return &PublicKeySigner{pubKey: pubKey}
+}
+
+func (s *PublicKeySigner) PublicKey() ed25519.PublicKey {
+	return s.pubKey
+}
+
+func (s *PublicKeySigner) Sign(_ context.Context, _ []byte) ([]byte, error) {
+	return nil, ErrWatchOnly
+}
+
+// Signing daemon protocol: client opens connection to the unix socket for each request,
+// writes json request in one line and reads json response in one line.
+//
+//	{"method":"public_key"}                   -> {"public_key":"<base64>"}
+//	{"method":"sign","data":"<base64>"}       -> {"result":"<base64>"}
+//	{"method":"shared_key","data":"<base64>"} -> {"result":"<base64>"}
+//
+// On failure response contains only "error" field with the description.
+
+const (
+	signerMethodPublicKey = "public_key"
+	signerMethodSign      = "sign"
+	signerMethodSharedKey = "shared_key"
+)
+
+type signerRequest struct {
+	Method string `json:"method"`
+	Data   []byte `json:"data,omitempty"`
+}
+
+type signerResponse struct {
+	PublicKey []byte `json:"public_key,omitempty"`
+	Result    []byte `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// UnixSocketSigner - signer which sends requests to the local signing daemon over unix socket
+type UnixSocketSigner struct {
+	path   string
+	pubKey ed25519.PublicKey
+
+	// timeout of the request if context has no deadline
+	timeout time.Duration
+}
+
+// NewUnixSocketSigner - connects to the signing daemon and requests its public key
+func NewUnixSocketSigner(ctx context.Context, path string) (*UnixSocketSigner, error) {
+	s := &UnixSocketSigner{
+		path:    path,
+		timeout: 30 * time.Second,
+	}
+
+	resp, err := s.request(ctx, signerRequest{Method: signerMethodPublicKey})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	if len(resp.PublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("incorrect public key size %d", len(resp.PublicKey))
+	}
+	s.pubKey = resp.PublicKey
+
+	return s, nil
+}
+
+func (s *UnixSocketSigner) PublicKey() ed25519.PublicKey {
+	return s.pubKey
+}
+
+func (s *UnixSocketSigner) Sign(ctx context.Context, hash []byte) ([]byte, error) {
+	resp, err := s.request(ctx, signerRequest{Method: signerMethodSign, Data: hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	if !ed25519.Verify(s.pubKey, hash, resp.Result) {
+		return nil, fmt.Errorf("signing daemon returned incorrect signature")
+	}
+	return resp.Result, nil
+}
+
+func (s *UnixSocketSigner) SharedKey(ctx context.Context, theirKey ed25519.PublicKey) ([]byte, error) {
+	resp, err := s.request(ctx, signerRequest{Method: signerMethodSharedKey, Data: theirKey})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared key: %w", err)
+	}
+	return resp.Result, nil
+}
+
+func (s *UnixSocketSigner) request(ctx context.Context, req signerRequest) (*signerResponse, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to signing daemon: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
+
+	var resp signerResponse
+	if err = json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signing daemon error: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// ServeSigner - serves signing daemon protocol on the listener using signer, can be used to implement daemon.
+// Returns when listener is closed.
+func ServeSigner(listener net.Listener, signer Signer) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		go serveSignerConn(conn, signer)
+	}
+}
+
+func serveSignerConn(conn net.Conn, signer Signer) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req signerRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+
+	var resp signerResponse
+	var err error
+	switch req.Method {
+	case signerMethodPublicKey:
+		resp.PublicKey = signer.PublicKey()
+	case signerMethodSign:
+		resp.Result, err = signer.Sign(ctx, req.Data)
+	case signerMethodSharedKey:
+		sk, ok := signer.(SharedKeySigner)
+		if !ok {
+			err = ErrSharedKeyNotSupported
+			break
+		}
+		if len(req.Data) != ed25519.PublicKeySize {
+			err = fmt.Errorf("incorrect public key size %d", len(req.Data))
+			break
+		}
+		resp.Result, err = sk.SharedKey(ctx, req.Data)
+	default:
+		err = fmt.Errorf("unknown method %q", req.Method)
+	}
+
+	if err != nil {
+		resp = signerResponse{Error: err.Error()}
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
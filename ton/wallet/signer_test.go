@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// signOnlySigner - signer without shared key support, like most of HSMs
+type signOnlySigner struct {
+	key   ed25519.PrivateKey
+	calls int
+}
+
+func (s *signOnlySigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *signOnlySigner) Sign(_ context.Context, hash []byte) ([]byte, error) {
+	s.calls++
+	return ed25519.Sign(s.key, hash), nil
+}
+
+func TestFromSigner(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{IsActive: false}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		theirKey := ed25519.NewKeyFromSeed([]byte("22345678901234567890123456789012")).Public().(ed25519.PublicKey)
+		return ton.NewExecutionResult([]any{new(big.Int).SetBytes(theirKey)}), nil
+	}
+
+	for _, ver := range []Version{V3, V4R2, V5R1, HighloadV2R2, HighloadV3} {
+		signer := &signOnlySigner{key: pkey}
+
+		w, err := FromSigner(m, signer, ver)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wk, err := FromPrivateKey(m, pkey, ver)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.Address().String() != wk.Address().String() {
+			t.Fatal("address not match", ver.String())
+		}
+
+		if w.PrivateKey() != nil {
+			t.Fatal("private key should not be available", ver.String())
+		}
+
+		sub, err := w.GetSubwallet(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		subK, err := wk.GetSubwallet(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sub.Address().String() != subK.Address().String() || sub.Signer() != signer {
+			t.Fatal("subwallet not match", ver.String())
+		}
+
+		transfer, err := w.BuildTransfer(address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.1"), true, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = w.BuildExternalMessageForMany(context.Background(), []*Message{transfer}); err != nil {
+			t.Fatal(err)
+		}
+
+		if signer.calls != 1 {
+			t.Fatal("message is not signed by signer", ver.String())
+		}
+
+		_, err = w.BuildTransferEncrypted(context.Background(), address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"), tlb.MustFromTON("0.1"), true, "hello")
+		if !errors.Is(err, ErrSharedKeyNotSupported) {
+			t.Fatal("encrypted comment should require shared key", err)
+		}
+	}
+}
+
+func TestUnixSocketSigner(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	theirKey := ed25519.NewKeyFromSeed([]byte("22345678901234567890123456789012"))
+
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		_ = ServeSigner(listener, NewKeySigner(pkey))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	signer, err := NewUnixSocketSigner(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(signer.PublicKey(), pkey.Public().(ed25519.PublicKey)) {
+		t.Fatal("incorrect public key")
+	}
+
+	hash := make([]byte, 32)
+	hash[0] = 7
+
+	sign, err := signer.Sign(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), hash, sign) {
+		t.Fatal("incorrect signature")
+	}
+
+	sharedKey, err := signer.SharedKey(ctx, theirKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	comment, err := createEncryptedCommentCell("hello", sender, signer.PublicKey(), sharedKey, theirKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := DecryptCommentCell(comment, sender, theirKey, pkey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Fatal("incorrect comment")
+	}
+
+	w, err := FromSigner(nil, signer, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wk, err := FromPrivateKey(nil, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Address().String() != wk.Address().String() {
+		t.Fatal("address not match")
+	}
+
+	listener.Close()
+	if _, err = signer.Sign(ctx, hash); err == nil {
+		t.Fatal("sign should fail when daemon is not available")
+	}
+}
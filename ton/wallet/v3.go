@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
-	"time"
 
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -27,28 +26,35 @@ func (s *SpecV3) BuildMessage(ctx context.Context, isInitialized bool, block *to
 		return nil, errors.New("for this type of wallet max 4 messages can be sent in the same time")
 	}
 
-	var seq uint64
+	seq, err := s.seqno(ctx, s.wallet, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
 
-	if s.customSeqnoFetcher != nil {
-		seq = uint64(s.customSeqnoFetcher())
-	} else {
-		if isInitialized {
-			resp, err := s.wallet.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, s.wallet.addr, "seqno")
-			if err != nil {
-				return nil, fmt.Errorf("get seqno err: %w", err)
-			}
+	validUntil := s.validUntil()
+	payload, err := buildV3Payload(s.wallet.subwallet, validUntil, seq, messages)
+	if err != nil {
+		return nil, err
+	}
 
-			iSeq, err := resp.Int(0)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse seqno: %w", err)
-			}
-			seq = iSeq.Uint64()
-		}
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
 	}
+	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
 
-	payload := cell.BeginCell().MustStoreUInt(uint64(s.wallet.subwallet), 32).
-		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32).
-		MustStoreUInt(seq, 32)
+	return msg, nil
+}
+
+// buildV3Payload - builds signed part of V3 wallet external message
+func buildV3Payload(subwallet, validUntil, seq uint32, messages []*Message) (*cell.Builder, error) {
+	if len(messages) > 4 {
+		return nil, errors.New("for this type of wallet max 4 messages can be sent in the same time")
+	}
+
+	payload := cell.BeginCell().MustStoreUInt(uint64(subwallet), 32).
+		MustStoreUInt(uint64(validUntil), 32).
+		MustStoreUInt(uint64(seq), 32)
 
 	for i, message := range messages {
 		intMsg, err := tlb.ToCell(message.InternalMessage)
@@ -58,9 +64,5 @@ func (s *SpecV3) BuildMessage(ctx context.Context, isInitialized bool, block *to
 
 		payload.MustStoreUInt(uint64(message.Mode), 8).MustStoreRef(intMsg)
 	}
-
-	sign := payload.EndCell().Sign(s.wallet.key)
-	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
-
-	return msg, nil
+	return payload, nil
 }
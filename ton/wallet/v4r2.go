@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
-	"time"
 
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -27,29 +26,33 @@ func (s *SpecV4R2) BuildMessage(ctx context.Context, isInitialized bool, block *
 		return nil, errors.New("for this type of wallet max 4 messages can be sent in the same time")
 	}
 
-	var seq uint64
+	seq, err := s.seqno(ctx, s.wallet, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
 
-	if s.customSeqnoFetcher != nil {
-		seq = uint64(s.customSeqnoFetcher())
-	} else {
-		if isInitialized {
-			resp, err := s.wallet.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, s.wallet.addr, "seqno")
-			if err != nil {
-				return nil, fmt.Errorf("get seqno err: %w", err)
-			}
+	validUntil := s.validUntil()
+	payload, err := buildV4R2Payload(s.wallet.subwallet, validUntil, seq, messages)
+	if err != nil {
+		return nil, err
+	}
 
-			iSeq, err := resp.Int(0)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse seqno: %w", err)
-			}
-			seq = iSeq.Uint64()
-		}
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
+	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
+
+	return msg, nil
+}
+
+// buildV4R2Payload - builds signed part of V4 wallet external message with simple send op
+func buildV4R2Payload(subwallet, validUntil, seq uint32, messages []*Message) (*cell.Builder, error) {
+	if len(messages) > 4 {
+		return nil, errors.New("for this type of wallet max 4 messages can be sent in the same time")
 	}
 
-	payload := cell.BeginCell().MustStoreUInt(uint64(s.wallet.subwallet), 32).
-		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32).
-		MustStoreUInt(seq, 32).
-		MustStoreInt(0, 8) // op
+	payload := buildV4R2Header(subwallet, validUntil, seq, OpV4R2SimpleSend)
 
 	for i, message := range messages {
 		intMsg, err := tlb.ToCell(message.InternalMessage)
@@ -59,11 +62,13 @@ func (s *SpecV4R2) BuildMessage(ctx context.Context, isInitialized bool, block *
 
 		payload.MustStoreUInt(uint64(message.Mode), 8).MustStoreRef(intMsg)
 	}
-
-	sign := payload.EndCell().Sign(s.wallet.key)
-	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
-
-	return msg, nil
+	return payload, nil
 }
 
-// TODO: implement plugins
+// buildV4R2Header - builds beginning of the signed part of V4 wallet external message, operation data follows it
+func buildV4R2Header(subwallet, validUntil, seq uint32, op uint8) *cell.Builder {
+	return cell.BeginCell().MustStoreUInt(uint64(subwallet), 32).
+		MustStoreUInt(uint64(validUntil), 32).
+		MustStoreUInt(uint64(seq), 32).
+		MustStoreUInt(uint64(op), 8)
+}
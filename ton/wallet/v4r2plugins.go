@@ -0,0 +1,235 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Operations of V4R2 wallet external request
+const (
+	OpV4R2SimpleSend             = 0
+	OpV4R2DeployAndInstallPlugin = 1
+	OpV4R2InstallPlugin          = 2
+	OpV4R2RemovePlugin           = 3
+)
+
+// Opcodes of messages between V4 wallet and its plugins
+const (
+	// OpPluginRequestFunds - plugin requests funds from wallet, wallet responds with the same op | 0x80000000
+	OpPluginRequestFunds = 0x706c7567
+	// OpPluginDestruct - wallet notifies plugin that it was removed, or plugin asks wallet to remove it
+	OpPluginDestruct = 0x64737472
+	// OpPluginInstalled - wallet notifies plugin that it was installed
+	OpPluginInstalled = 0x6e6f7465
+)
+
+// V4R2DeployAndInstallPlugin - deploys plugin contract from the wallet and installs it,
+// plugin address is calculated from the state init in the given workchain.
+type V4R2DeployAndInstallPlugin struct {
+	Workchain int8
+	// Amount - initial balance of plugin
+	Amount    tlb.Coins
+	StateInit *tlb.StateInit
+	// Body - body of the deploy message
+	Body *cell.Cell
+}
+
+// V4R2InstallPlugin - installs already deployed plugin, wallet sends notification with amount to it
+type V4R2InstallPlugin struct {
+	Plugin  *address.Address
+	Amount  tlb.Coins
+	QueryID uint64
+}
+
+// V4R2RemovePlugin - removes plugin, wallet sends destruct message with amount to it
+type V4R2RemovePlugin struct {
+	Plugin  *address.Address
+	Amount  tlb.Coins
+	QueryID uint64
+}
+
+// BuildPluginRequest - builds signed body of the external message with plugin management request,
+// request should be V4R2DeployAndInstallPlugin, V4R2InstallPlugin or V4R2RemovePlugin.
+func (s *SpecV4R2) BuildPluginRequest(ctx context.Context, isInitialized bool, block *ton.BlockIDExt, request any) (*cell.Cell, error) {
+	if s.wallet.ver != V4R2 {
+		return nil, fmt.Errorf("plugins management is supported only by V4R2: %w", ErrUnsupportedWalletVersion)
+	}
+
+	seq, err := s.seqno(ctx, s.wallet, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
+
+	validUntil := s.validUntil()
+	payload, err := buildV4R2PluginPayload(s.wallet.subwallet, validUntil, seq, request)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
+	return cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell(), nil
+}
+
+// BuildExternalPluginRequest - builds external message with plugin management request, see BuildPluginRequest
+func (s *SpecV4R2) BuildExternalPluginRequest(ctx context.Context, request any) (*tlb.ExternalMessage, error) {
+	block, initialized, stateInit, err := s.wallet.prepareExternal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.BuildPluginRequest(ctx, initialized, block, request)
+	if err != nil {
+		return nil, fmt.Errorf("build message err: %w", err)
+	}
+
+	return &tlb.ExternalMessage{
+		DstAddr:   s.wallet.addr,
+		StateInit: stateInit,
+		Body:      body,
+	}, nil
+}
+
+// SendPluginRequest - sends plugin management request, see BuildPluginRequest
+func (s *SpecV4R2) SendPluginRequest(ctx context.Context, request any, waitConfirmation ...bool) (*tlb.Transaction, error) {
+	tx, _, _, err := s.wallet.sendExternal(ctx, func(ctx context.Context) (*tlb.ExternalMessage, error) {
+		return s.BuildExternalPluginRequest(ctx, request)
+	}, waitConfirmation...)
+	return tx, err
+}
+
+func buildV4R2PluginPayload(subwallet, validUntil, seq uint32, request any) (*cell.Builder, error) {
+	switch r := request.(type) {
+	case *V4R2DeployAndInstallPlugin:
+		return buildV4R2PluginPayload(subwallet, validUntil, seq, *r)
+	case *V4R2InstallPlugin:
+		return buildV4R2PluginPayload(subwallet, validUntil, seq, *r)
+	case *V4R2RemovePlugin:
+		return buildV4R2PluginPayload(subwallet, validUntil, seq, *r)
+	case V4R2DeployAndInstallPlugin:
+		if r.StateInit == nil {
+			return nil, errors.New("plugin state init is not set")
+		}
+
+		state, err := tlb.ToCell(r.StateInit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert plugin state init to cell: %w", err)
+		}
+
+		body := r.Body
+		if body == nil {
+			body = cell.BeginCell().EndCell()
+		}
+
+		return buildV4R2Header(subwallet, validUntil, seq, OpV4R2DeployAndInstallPlugin).
+			MustStoreInt(int64(r.Workchain), 8).
+			MustStoreBigCoins(r.Amount.Nano()).
+			MustStoreRef(state).
+			MustStoreRef(body), nil
+	case V4R2InstallPlugin:
+		return buildV4R2PluginAddrPayload(subwallet, validUntil, seq, OpV4R2InstallPlugin, r.Plugin, r.Amount, r.QueryID)
+	case V4R2RemovePlugin:
+		return buildV4R2PluginAddrPayload(subwallet, validUntil, seq, OpV4R2RemovePlugin, r.Plugin, r.Amount, r.QueryID)
+	}
+	return nil, fmt.Errorf("unsupported plugin request type %T", request)
+}
+
+func buildV4R2PluginAddrPayload(subwallet, validUntil, seq uint32, op uint8, plugin *address.Address, amount tlb.Coins, queryID uint64) (*cell.Builder, error) {
+	if plugin == nil {
+		return nil, errors.New("plugin address is not set")
+	}
+
+	return buildV4R2Header(subwallet, validUntil, seq, op).
+		MustStoreInt(int64(plugin.Workchain()), 8).
+		MustStoreSlice(plugin.Data(), 256).
+		MustStoreBigCoins(amount.Nano()).
+		MustStoreUInt(queryID, 64), nil
+}
+
+// PluginAddress - calculates address of the plugin deployed by V4R2DeployAndInstallPlugin request
+func PluginAddress(workchain int8, stateInit *tlb.StateInit) (*address.Address, error) {
+	state, err := tlb.ToCell(stateInit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert plugin state init to cell: %w", err)
+	}
+	return address.NewAddress(0, byte(workchain), state.Hash()), nil
+}
+
+// GetPluginList - returns plugins installed to the wallet, using get_plugin_list get method
+func (s *SpecV4R2) GetPluginList(ctx context.Context, block *ton.BlockIDExt) ([]*address.Address, error) {
+	res, err := s.wallet.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, s.wallet.addr, "get_plugin_list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_plugin_list method: %w", err)
+	}
+
+	stack := res.AsTuple()
+	if len(stack) == 0 {
+		return nil, errors.New("empty result of get_plugin_list method")
+	}
+
+	var list []*address.Address
+	// result is a list of (wc, hash) pairs, built from nested (head, tail) tuples
+	next := stack[0]
+	for next != nil {
+		node, ok := next.([]any)
+		if !ok || len(node) != 2 {
+			return nil, fmt.Errorf("incorrect list node type %T", next)
+		}
+
+		pair, ok := node[0].([]any)
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("incorrect plugin type %T", node[0])
+		}
+
+		wc, ok := pair[0].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("incorrect plugin workchain type %T", pair[0])
+		}
+
+		hash, ok := pair[1].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("incorrect plugin address type %T", pair[1])
+		}
+
+		list = append(list, address.NewAddress(0, byte(wc.Int64()), hash.FillBytes(make([]byte, 32))))
+		next = node[1]
+	}
+
+	return list, nil
+}
+
+// IsPluginInstalled - checks is plugin installed to the wallet, using is_plugin_installed get method
+func (s *SpecV4R2) IsPluginInstalled(ctx context.Context, block *ton.BlockIDExt, plugin *address.Address) (bool, error) {
+	params, err := ton.EncodeParams(struct {
+		Workchain int8     `tvm:"int"`
+		Hash      *big.Int `tvm:"int"`
+	}{
+		Workchain: int8(plugin.Workchain()),
+		Hash:      new(big.Int).SetBytes(plugin.Data()),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	res, err := s.wallet.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, s.wallet.addr, "is_plugin_installed", params...)
+	if err != nil {
+		return false, fmt.Errorf("failed to run is_plugin_installed method: %w", err)
+	}
+
+	var result struct {
+		Installed bool `tvm:"bool"`
+	}
+	if err = res.Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode is_plugin_installed result: %w", err)
+	}
+	return result.Installed, nil
+}
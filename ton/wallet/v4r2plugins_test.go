@@ -0,0 +1,202 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestSpecV4R2_BuildPluginRequest(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status: tlb.AccountStatusActive,
+				},
+			},
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		if method != "seqno" {
+			t.Fatal("incorrect method", method)
+		}
+		return ton.NewExecutionResult([]any{big.NewInt(7)}), nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		m.extMsgSent = msg
+		return nil
+	}
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecV4R2)
+
+	plugin := address.MustParseRawAddr("-1:" + "3333333333333333333333333333333333333333333333333333333333333333")
+	state := &tlb.StateInit{
+		Code: cell.BeginCell().MustStoreUInt(1, 8).EndCell(),
+		Data: cell.BeginCell().MustStoreUInt(2, 8).EndCell(),
+	}
+
+	checkHeader := func(body *cell.Cell, op uint64) *cell.Slice {
+		p := body.BeginParse()
+		sign := p.MustLoadSlice(512)
+		payload := p.MustToCell()
+		if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), payload.Hash(), sign) {
+			t.Fatal("incorrect signature")
+		}
+
+		if p.MustLoadUInt(32) != DefaultSubwallet || p.MustLoadUInt(32) != 1000000+60*3 ||
+			p.MustLoadUInt(32) != 7 || p.MustLoadUInt(8) != op {
+			t.Fatal("incorrect header")
+		}
+		return p
+	}
+
+	_, err = spec.SendPluginRequest(context.Background(), &V4R2DeployAndInstallPlugin{
+		Workchain: -1,
+		Amount:    tlb.MustFromTON("0.5"),
+		StateInit: state,
+		Body:      cell.BeginCell().MustStoreUInt(OpPluginRequestFunds|0x80000000, 32).EndCell(),
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := checkHeader(m.extMsgSent.Body, OpV4R2DeployAndInstallPlugin)
+	if p.MustLoadInt(8) != -1 || p.MustLoadBigCoins().Cmp(tlb.MustFromTON("0.5").Nano()) != 0 {
+		t.Fatal("incorrect deploy request")
+	}
+
+	var stored tlb.StateInit
+	if err = tlb.LoadFromCell(&stored, p.MustLoadRef()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored.Code.Hash(), state.Code.Hash()) || p.MustLoadRef().MustLoadUInt(32) != OpPluginRequestFunds|0x80000000 {
+		t.Fatal("incorrect deploy state or body")
+	}
+
+	deployed, err := PluginAddress(-1, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateCell, _ := tlb.ToCell(state)
+	if deployed.Workchain() != -1 || !bytes.Equal(deployed.Data(), stateCell.Hash()) {
+		t.Fatal("incorrect plugin address")
+	}
+
+	for _, op := range []uint64{OpV4R2InstallPlugin, OpV4R2RemovePlugin} {
+		var req any = V4R2InstallPlugin{Plugin: plugin, Amount: tlb.MustFromTON("0.05"), QueryID: 99}
+		if op == OpV4R2RemovePlugin {
+			req = V4R2RemovePlugin{Plugin: plugin, Amount: tlb.MustFromTON("0.05"), QueryID: 99}
+		}
+
+		ext, err := spec.BuildExternalPluginRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p = checkHeader(ext.Body, op)
+		if p.MustLoadInt(8) != -1 || !bytes.Equal(p.MustLoadSlice(256), plugin.Data()) ||
+			p.MustLoadBigCoins().Cmp(tlb.MustFromTON("0.05").Nano()) != 0 || p.MustLoadUInt(64) != 99 {
+			t.Fatal("incorrect plugin request", op)
+		}
+	}
+
+	if _, err = spec.BuildExternalPluginRequest(context.Background(), V4R2RemovePlugin{}); err == nil {
+		t.Fatal("plugin address should be required")
+	}
+
+	if _, err = spec.BuildExternalPluginRequest(context.Background(), "test"); err == nil {
+		t.Fatal("unknown request should be rejected")
+	}
+
+	w4r1, err := FromPrivateKey(m, pkey, V4R1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w4r1.GetSpec().(*SpecV4R2).BuildExternalPluginRequest(context.Background(), V4R2InstallPlugin{Plugin: plugin})
+	if !errors.Is(err, ErrUnsupportedWalletVersion) {
+		t.Fatal("V4R1 should not support plugins", err)
+	}
+}
+
+func TestSpecV4R2_GetPlugins(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	plugin1 := address.MustParseRawAddr("0:" + "1111111111111111111111111111111111111111111111111111111111111111")
+	plugin2 := address.MustParseRawAddr("-1:" + "2222222222222222222222222222222222222222222222222222222222222222")
+
+	pair := func(a *address.Address) []any {
+		return []any{big.NewInt(int64(a.Workchain())), new(big.Int).SetBytes(a.Data())}
+	}
+
+	m := &MockAPI{}
+	m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+		switch method {
+		case "get_plugin_list":
+			return ton.NewExecutionResult([]any{[]any{pair(plugin1), []any{pair(plugin2), nil}}}), nil
+		case "is_plugin_installed":
+			installed := params[0].(*big.Int).Int64() == 0 && params[1].(*big.Int).Cmp(new(big.Int).SetBytes(plugin1.Data())) == 0
+			if installed {
+				return ton.NewExecutionResult([]any{big.NewInt(-1)}), nil
+			}
+			return ton.NewExecutionResult([]any{big.NewInt(0)}), nil
+		}
+		t.Fatal("unexpected method", method)
+		return nil, nil
+	}
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecV4R2)
+	block := &ton.BlockIDExt{SeqNo: 2}
+
+	list, err := spec.GetPluginList(context.Background(), block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].String() != plugin1.String() || list[1].String() != plugin2.String() {
+		t.Fatal("incorrect plugins list", list)
+	}
+
+	installed, err := spec.IsPluginInstalled(context.Background(), block, plugin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !installed {
+		t.Fatal("plugin should be installed")
+	}
+
+	installed, err = spec.IsPluginInstalled(context.Background(), block, plugin2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if installed {
+		t.Fatal("plugin should not be installed")
+	}
+}
@@ -0,0 +1,261 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// https://github.com/ton-blockchain/wallet-contract-v5/blob/main/contracts/wallet_v5.fc
+const _V5R1CodeHex = "b5ee9c7241021401000281000114ff00f4a413f4bcf2c80b01020120020d020148030402dcd020d749c120915b8f6320d70b1f2082106578746ebd21821073696e74bdb0925f03e082106578746eba8eb48020d72101d074d721fa4030fa44f828fa443058bd915be0ed44d0810141d721f4058307f40e6fa1319130e18040d721707fdb3ce03120d749810280b99130e070e2100f020120050c020120060902016e07080019adce76a2684020eb90eb85ffc00019af1df6a2684010eb90eb858fc00201480a0b0017b325fb51341c75c875c2c7e00011b262fb513435c280200019be5f0f6a2684080a0eb90fa02c0102f20e011e20d70b1f82107369676ebaf2e08a7f0f01e68ef0eda2edfb218308d722028308d723208020d721d31fd31fd31fed44d0d200d31f20d31fd3ffd70a000af90140ccf9109a28945f0adb31e1f2c087df02b35007b0f2d0845125baf2e0855036baf2e086f823bbf2d0882292f800de01a47fc8ca00cb1f01cf16c9ed542092f80fde70db3cd81003f6eda2edfb02f404216e926c218e4c0221d73930709421c700b38e2d01d72820761e436c20d749c008f2e09320d74ac002f2e09320d71d06c712c2005230b0f2d089d74cd7393001a4e86c128407bbf2e093d74ac000f2e093ed55e2d20001c000915be0ebd72c08142091709601d72c081c12e25210b1e30f20d74a111213009601fa4001fa44f828fa443058baf2e091ed44d0810141d718f405049d7fc8ca0040048307f453f2e08b8e14038307f45bf2e08c22d70a00216e01b3b0f2d090e2c85003cf1612f400c9ed54007230d72c08248e2d21f2e092d200ed44d0d2005113baf2d08f54503091319c01810140d721d70a00f2e08ee2c8ca0058cf16c9ed5493f2c08de20010935bdb31e1d74cd0b4d6c35e"
+
+const (
+	MainnetGlobalID int32 = -239
+	TestnetGlobalID int32 = -3
+)
+
+const (
+	OpV5R1ExternalSigned    = 0x7369676e
+	OpV5R1InternalSigned    = 0x73696e74
+	OpV5R1InternalExtension = 0x6578746e
+
+	// c5 register can contain max 255 actions
+	v5r1MaxActions = 255
+)
+
+// DefaultV5R1WalletID - wallet id of the mainnet basechain wallet with subwallet number 0
+var DefaultV5R1WalletID = V5R1WalletID(MainnetGlobalID, 0, 0)
+
+// V5R1WalletID - calculates wallet id of W5 wallet, it is a network global id mixed with
+// the context of the client wallet: workchain, wallet version (0 for V5R1) and subwallet number.
+func V5R1WalletID(networkGlobalID int32, workchain int8, subwalletNumber uint16) uint32 {
+	clientContext := cell.BeginCell().
+		MustStoreUInt(1, 1). // client context
+		MustStoreInt(int64(workchain), 8).
+		MustStoreUInt(0, 8). // wallet version
+		MustStoreUInt(uint64(subwalletNumber), 15).
+		EndCell().BeginParse().MustLoadUInt(32)
+
+	return uint32(networkGlobalID) ^ uint32(clientContext)
+}
+
+// V5R1Data - persistent data of W5 wallet
+type V5R1Data struct {
+	SignatureAllowed bool             `tlb:"bool"`
+	Seqno            uint32           `tlb:"## 32"`
+	WalletID         uint32           `tlb:"## 32"`
+	PublicKey        []byte           `tlb:"bits 256"`
+	Extensions       *cell.Dictionary `tlb:"dict 256"`
+}
+
+// HasExtension - checks is address in the list of wallet extensions
+func (d *V5R1Data) HasExtension(addr *address.Address) bool {
+	if d.Extensions == nil {
+		return false
+	}
+	return d.Extensions.Get(cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell()) != nil
+}
+
+// ExtensionsList - returns addresses of wallet extensions, extensions can be only in the wallet's workchain
+func (d *V5R1Data) ExtensionsList(workchain int32) ([]*address.Address, error) {
+	if d.Extensions == nil {
+		return nil, nil
+	}
+
+	kvs, err := d.Extensions.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extensions dict: %w", err)
+	}
+
+	var list []*address.Address
+	for _, kv := range kvs {
+		list = append(list, address.NewAddress(0, byte(workchain), kv.Key.MustLoadSlice(256)))
+	}
+	return list, nil
+}
+
+// ParseV5R1Data - parses data cell of W5 wallet
+func ParseV5R1Data(data *cell.Cell) (*V5R1Data, error) {
+	var d V5R1Data
+	if err := tlb.LoadFromCell(&d, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse v5r1 data: %w", err)
+	}
+	return &d, nil
+}
+
+// V5R1AddExtension - allows extension contract to send requests on behalf of the wallet
+type V5R1AddExtension struct {
+	_    tlb.Magic        `tlb:"#02"`
+	Addr *address.Address `tlb:"addr"`
+}
+
+// V5R1DeleteExtension - removes extension from the wallet
+type V5R1DeleteExtension struct {
+	_    tlb.Magic        `tlb:"#03"`
+	Addr *address.Address `tlb:"addr"`
+}
+
+// V5R1SetSignatureAllowed - enables or disables signature auth, only extension can send this action.
+// Signature cannot be disabled when wallet has no extensions.
+type V5R1SetSignatureAllowed struct {
+	_       tlb.Magic `tlb:"#04"`
+	Allowed bool      `tlb:"bool"`
+}
+
+type SpecV5R1 struct {
+	SpecRegular
+	SpecSeqno
+}
+
+// BuildMessage - builds body of the external signed request, all messages should have +2 (ignore errors) mode
+func (s *SpecV5R1) BuildMessage(ctx context.Context, isInitialized bool, block *ton.BlockIDExt, messages []*Message) (*cell.Cell, error) {
+	return s.BuildSignedRequest(ctx, isInitialized, block, false, messages, nil)
+}
+
+// BuildInternalMessage - builds body of the signed internal request. It can be sent to the wallet
+// from any contract, so relayer can pay the fees instead of the wallet.
+func (s *SpecV5R1) BuildInternalMessage(ctx context.Context, isInitialized bool, block *ton.BlockIDExt, messages []*Message) (*cell.Cell, error) {
+	return s.BuildSignedRequest(ctx, isInitialized, block, true, messages, nil)
+}
+
+// BuildSignedRequest - builds signed request with messages and extended actions,
+// actions should be V5R1AddExtension, V5R1DeleteExtension or V5R1SetSignatureAllowed.
+// When internal is true request is signed for the delivery by internal message.
+func (s *SpecV5R1) BuildSignedRequest(ctx context.Context, isInitialized bool, block *ton.BlockIDExt, internal bool, messages []*Message, actions []any) (*cell.Cell, error) {
+	if !internal {
+		for i, message := range messages {
+			if message.Mode&2 == 0 {
+				return nil, fmt.Errorf("message %d should have ignore errors (+2) mode to be sent by external request", i)
+			}
+		}
+	}
+
+	for _, action := range actions {
+		if _, ok := action.(V5R1SetSignatureAllowed); ok {
+			return nil, errors.New("signature auth can be changed only by extension")
+		}
+	}
+
+	inner, err := BuildV5R1Actions(messages, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := s.seqno(ctx, s.wallet, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
+
+	var op uint64 = OpV5R1ExternalSigned
+	if internal {
+		op = OpV5R1InternalSigned
+	}
+
+	validUntil := s.validUntil()
+	payload := buildV5R1Payload(op, s.wallet.subwallet, validUntil, seq, inner)
+
+	// signature is in the end of request and signs everything before it
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
+	msg := cell.BeginCell().MustStoreBuilder(payload).MustStoreSlice(sign, 512).EndCell()
+
+	return msg, nil
+}
+
+// buildV5R1Payload - builds signed part of V5R1 request, signature should be stored after it
+func buildV5R1Payload(op uint64, walletID, validUntil, seq uint32, inner *cell.Builder) *cell.Builder {
+	return cell.BeginCell().
+		MustStoreUInt(op, 32).
+		MustStoreUInt(uint64(walletID), 32).
+		MustStoreUInt(uint64(validUntil), 32).
+		MustStoreUInt(uint64(seq), 32).
+		MustStoreBuilder(inner)
+}
+
+// BuildV5R1ExtensionRequest - builds body of the internal message, which extension
+// sends to the wallet to execute messages and actions on its behalf
+func BuildV5R1ExtensionRequest(queryID uint64, messages []*Message, actions []any) (*cell.Cell, error) {
+	inner, err := BuildV5R1Actions(messages, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(OpV5R1InternalExtension, 32).
+		MustStoreUInt(queryID, 64).
+		MustStoreBuilder(inner).
+		EndCell(), nil
+}
+
+// BuildV5R1Actions - builds inner request of W5 wallet: list of out actions to send messages and chain of extended actions
+func BuildV5R1Actions(messages []*Message, actions []any) (*cell.Builder, error) {
+	if len(messages) > v5r1MaxActions {
+		return nil, fmt.Errorf("for this type of wallet max %d messages can be sent in the same time", v5r1MaxActions)
+	}
+
+	b := cell.BeginCell()
+	if len(messages) > 0 {
+		// out list is stored from the last action to the first, each cell refers the previous one
+		list := cell.BeginCell().EndCell()
+		for i, message := range messages {
+			intMsg, err := tlb.ToCell(message.InternalMessage)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert internal message %d to cell: %w", i, err)
+			}
+
+			list = cell.BeginCell().
+				MustStoreRef(list).
+				MustStoreUInt(opActionSendMsg, 32).
+				MustStoreUInt(uint64(message.Mode), 8).
+				MustStoreRef(intMsg).
+				EndCell()
+		}
+		b.MustStoreMaybeRef(list)
+	} else {
+		b.MustStoreMaybeRef(nil)
+	}
+
+	if len(actions) == 0 {
+		return b.MustStoreBoolBit(false), nil
+	}
+
+	// extended actions are stored from the first to the last, each cell refers the next one
+	var next *cell.Cell
+	for i := len(actions) - 1; i >= 0; i-- {
+		switch actions[i].(type) {
+		case V5R1AddExtension, V5R1DeleteExtension, V5R1SetSignatureAllowed:
+		default:
+			return nil, fmt.Errorf("unsupported action %d of type %T", i, actions[i])
+		}
+
+		action, err := tlb.ToCell(actions[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert action %d to cell: %w", i, err)
+		}
+
+		ab := cell.BeginCell().MustStoreBuilder(action.ToBuilder())
+		if next != nil {
+			ab.MustStoreRef(next)
+		}
+		next = ab.EndCell()
+	}
+
+	return b.MustStoreBoolBit(true).MustStoreRef(next), nil
+}
+
+func v5r1StateData(pubKey ed25519.PublicKey, walletID uint32) (*cell.Cell, error) {
+	return tlb.ToCell(V5R1Data{
+		SignatureAllowed: true,
+		Seqno:            0,
+		WalletID:         walletID,
+		PublicKey:        pubKey,
+	})
+}
@@ -0,0 +1,285 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestV5R1WalletID(t *testing.T) {
+	if DefaultV5R1WalletID != 2147483409 {
+		t.Fatal("default wallet id incorrect", DefaultV5R1WalletID)
+	}
+
+	if id := V5R1WalletID(TestnetGlobalID, 0, 0); id != 2147483645 {
+		t.Fatal("testnet wallet id incorrect", id)
+	}
+
+	if id := V5R1WalletID(MainnetGlobalID, -1, 0); id == DefaultV5R1WalletID {
+		t.Fatal("workchain is not used")
+	}
+
+	if id := V5R1WalletID(MainnetGlobalID, 0, 1); id != DefaultV5R1WalletID^1 {
+		t.Fatal("subwallet number incorrect", id)
+	}
+}
+
+func TestV5R1_StateInit(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	w, err := FromPrivateKey(nil, pkey, V5R1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.subwallet != DefaultV5R1WalletID {
+		t.Fatal("default wallet id not used")
+	}
+
+	if _, ok := w.GetSpec().(*SpecV5R1); !ok {
+		t.Fatal("incorrect spec")
+	}
+
+	state, err := GetStateInit(pkey.Public().(ed25519.PublicKey), V5R1, DefaultV5R1WalletID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ParseV5R1Data(state.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !data.SignatureAllowed || data.Seqno != 0 || data.WalletID != DefaultV5R1WalletID ||
+		!bytes.Equal(data.PublicKey, pkey.Public().(ed25519.PublicKey)) || !data.Extensions.IsEmpty() {
+		t.Fatal("incorrect data")
+	}
+
+	stateCell, err := tlb.ToCell(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(w.Address().Data(), stateCell.Hash()) {
+		t.Fatal("incorrect address")
+	}
+
+	acc := &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid: true,
+			AccountStorage: tlb.AccountStorage{
+				Status: tlb.AccountStatusActive,
+			},
+		},
+		Code: state.Code,
+	}
+
+	if ver := GetWalletVersion(acc); ver != V5R1 {
+		t.Fatal("incorrect version", ver.String())
+	}
+}
+
+func TestSpecV5R1_BuildMessage(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		return &ton.BlockIDExt{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{IsActive: false}, nil
+	}
+
+	w, err := FromPrivateKey(m, pkey, V5R1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intMsg := &tlb.InternalMessage{
+		Bounce:  true,
+		DstAddr: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Amount:  tlb.MustFromTON("0.5"),
+		Body:    cell.BeginCell().MustStoreUInt(777, 27).EndCell(),
+	}
+
+	ext, err := w.BuildExternalMessageForMany(context.Background(), []*Message{
+		{Mode: 3, InternalMessage: intMsg},
+		{Mode: 2, InternalMessage: intMsg},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ext.StateInit == nil {
+		t.Fatal("no state init for not deployed wallet")
+	}
+
+	p := ext.Body.BeginParse()
+	if p.MustLoadUInt(32) != OpV5R1ExternalSigned {
+		t.Fatal("op incorrect")
+	}
+	if p.MustLoadUInt(32) != uint64(DefaultV5R1WalletID) {
+		t.Fatal("wallet id incorrect")
+	}
+	if p.MustLoadUInt(32) != uint64(timeNow().Add(60*3*time.Second).Unix()) {
+		t.Fatal("valid until incorrect")
+	}
+	if p.MustLoadUInt(32) != 0 {
+		t.Fatal("seqno incorrect")
+	}
+
+	list := p.MustLoadMaybeRef()
+	if list == nil {
+		t.Fatal("no out list")
+	}
+	if p.MustLoadBoolBit() {
+		t.Fatal("unexpected extended actions")
+	}
+
+	intMsgCell, err := tlb.ToCell(intMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// out list is stored from the last message
+	for i, mode := range []uint64{2, 3} {
+		prev := list.MustLoadRef()
+		if list.MustLoadUInt(32) != opActionSendMsg {
+			t.Fatal("action op incorrect", i)
+		}
+		if list.MustLoadUInt(8) != mode {
+			t.Fatal("mode incorrect", i)
+		}
+
+		msgCell := list.MustLoadRef().MustToCell()
+		if !bytes.Equal(msgCell.Hash(), intMsgCell.Hash()) {
+			t.Fatal("message incorrect", i)
+		}
+		list = prev
+	}
+
+	if list.BitsLeft() != 0 || list.RefsNum() != 0 {
+		t.Fatal("out list is not terminated")
+	}
+
+	sign := p.MustLoadSlice(512)
+	if p.BitsLeft() != 0 {
+		t.Fatal("signature is not in the end")
+	}
+
+	signedBits := ext.Body.BitsSize() - 512
+	unsigned := cell.BeginCell().MustStoreSlice(ext.Body.BeginParse().MustLoadSlice(signedBits), signedBits)
+	for i := 0; i < int(ext.Body.RefsNum()); i++ {
+		unsigned.MustStoreRef(ext.Body.MustPeekRef(i))
+	}
+
+	if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), unsigned.EndCell().Hash(), sign) {
+		t.Fatal("sign incorrect")
+	}
+
+	_, err = w.BuildExternalMessageForMany(context.Background(), []*Message{
+		{Mode: 1, InternalMessage: intMsg},
+	})
+	if err == nil {
+		t.Fatal("external request should require ignore errors mode")
+	}
+}
+
+func TestSpecV5R1_BuildSignedRequest(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	w, err := FromPrivateKey(nil, pkey, V5R1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := w.GetSpec().(*SpecV5R1)
+	spec.SetCustomSeqnoFetcher(func() uint32 {
+		return 7
+	})
+
+	ext := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	body, err := spec.BuildSignedRequest(context.Background(), true, nil, true, nil, []any{
+		V5R1AddExtension{Addr: ext},
+		V5R1DeleteExtension{Addr: ext},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := body.BeginParse()
+	if p.MustLoadUInt(32) != OpV5R1InternalSigned {
+		t.Fatal("op incorrect")
+	}
+	p.MustLoadUInt(64)
+	if p.MustLoadUInt(32) != 7 {
+		t.Fatal("seqno incorrect")
+	}
+
+	if p.MustLoadMaybeRef() != nil {
+		t.Fatal("unexpected out list")
+	}
+	if !p.MustLoadBoolBit() {
+		t.Fatal("no extended actions")
+	}
+
+	action := p.MustLoadRef()
+	if action.MustLoadUInt(8) != 0x02 || action.MustLoadAddr().String() != ext.String() {
+		t.Fatal("add extension action incorrect")
+	}
+
+	action = action.MustLoadRef()
+	if action.MustLoadUInt(8) != 0x03 || action.MustLoadAddr().String() != ext.String() {
+		t.Fatal("delete extension action incorrect")
+	}
+	if action.RefsNum() != 0 {
+		t.Fatal("actions chain is not terminated")
+	}
+
+	_, err = spec.BuildSignedRequest(context.Background(), true, nil, false, nil, []any{
+		V5R1SetSignatureAllowed{Allowed: false},
+	})
+	if err == nil {
+		t.Fatal("signature auth should be changed only by extension")
+	}
+}
+
+func TestBuildV5R1ExtensionRequest(t *testing.T) {
+	body, err := BuildV5R1ExtensionRequest(55, nil, []any{
+		V5R1SetSignatureAllowed{Allowed: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := body.BeginParse()
+	if p.MustLoadUInt(32) != OpV5R1InternalExtension || p.MustLoadUInt(64) != 55 {
+		t.Fatal("header incorrect")
+	}
+
+	if p.MustLoadMaybeRef() != nil || !p.MustLoadBoolBit() {
+		t.Fatal("inner request incorrect")
+	}
+
+	action := p.MustLoadRef()
+	if action.MustLoadUInt(8) != 0x04 || action.MustLoadBoolBit() {
+		t.Fatal("set signature allowed action incorrect")
+	}
+
+	if _, err = BuildV5R1ExtensionRequest(0, nil, []any{"test"}); err == nil {
+		t.Fatal("unsupported action should fail")
+	}
+}
@@ -37,8 +37,10 @@ const (
 	V3                         = V3R2
 	V4R1               Version = 41
 	V4R2               Version = 42
+	V5R1               Version = 51
 	HighloadV2R2       Version = 122
 	HighloadV2Verified Version = 123
+	HighloadV3         Version = 300
 	Lockup             Version = 200
 	Unknown            Version = 0
 )
@@ -53,6 +55,8 @@ func (v Version) String() string {
 		return fmt.Sprintf("highload V2R2")
 	case HighloadV2Verified:
 		return fmt.Sprintf("highload V2R2 verified")
+	case HighloadV3:
+		return fmt.Sprintf("highload V3")
 	}
 
 	if v/100 == 2 {
@@ -70,8 +74,9 @@ var (
 		V2R1: _V2R1CodeHex, V2R2: _V2R2CodeHex,
 		V3R1: _V3R1CodeHex, V3R2: _V3R2CodeHex,
 		V4R1: _V4R1CodeHex, V4R2: _V4R2CodeHex,
+		V5R1: _V5R1CodeHex, Lockup: _LockupCodeHex,
 		HighloadV2R2: _HighloadV2R2CodeHex, HighloadV2Verified: _HighloadV2VerifiedCodeHex,
-		Lockup: _LockupCodeHex,
+		HighloadV3: _HighloadV3CodeHex,
 	}
 	walletCodeBOC = map[Version][]byte{}
 	walletCode    = map[Version]*cell.Cell{}
@@ -123,10 +128,10 @@ type Message struct {
 }
 
 type Wallet struct {
-	api  TonAPI
-	key  ed25519.PrivateKey
-	addr *address.Address
-	ver  Version
+	api    TonAPI
+	signer Signer
+	addr   *address.Address
+	ver    Version
 
 	// Can be used to operate multiple wallets with the same key and version.
 	// use GetSubwallet if you need it.
@@ -136,18 +141,31 @@ type Wallet struct {
 	spec any
 }
 
+// FromPrivateKey - initializes wallet with default subwallet,
+// for V5R1 it is DefaultV5R1WalletID, use GetSubwallet with V5R1WalletID to get wallet for other network or workchain.
 func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version Version) (*Wallet, error) {
-	addr, err := AddressFromPubKey(key.Public().(ed25519.PublicKey), version, DefaultSubwallet)
+	return FromSigner(api, NewKeySigner(key), version)
+}
+
+// FromSigner - same as FromPrivateKey, but messages are signed by signer,
+// so private key can be kept outside of application.
+func FromSigner(api TonAPI, signer Signer, version Version) (*Wallet, error) {
+	subwallet := uint32(DefaultSubwallet)
+	if version == V5R1 {
+		subwallet = DefaultV5R1WalletID
+	}
+
+	addr, err := AddressFromPubKey(signer.PublicKey(), version, subwallet)
 	if err != nil {
 		return nil, err
 	}
 
 	w := &Wallet{
 		api:       api,
-		key:       key,
+		signer:    signer,
 		addr:      addr,
 		ver:       version,
-		subwallet: DefaultSubwallet,
+		subwallet: subwallet,
 	}
 
 	w.spec, err = getSpec(w)
@@ -158,6 +176,12 @@ func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version Version) (*Walle
 	return w, nil
 }
 
+// FromPublicKey - initializes watch-only wallet, it cannot sign messages,
+// but can prepare unsigned bundles to be signed offline.
+func FromPublicKey(api TonAPI, pubKey ed25519.PublicKey, version Version) (*Wallet, error) {
+	return FromSigner(api, NewPublicKeySigner(pubKey), version)
+}
+
 func getSpec(w *Wallet) (any, error) {
 	regular := SpecRegular{
 		wallet:      w,
@@ -165,12 +189,26 @@ func getSpec(w *Wallet) (any, error) {
 	}
 
 	switch w.ver {
+	case V1R1, V1R2, V1R3:
+		return &SpecV1{regular, SpecSeqno{}}, nil
+	case V2R1, V2R2:
+		return &SpecV2{regular, SpecSeqno{}}, nil
 	case V3R1, V3R2:
 		return &SpecV3{regular, SpecSeqno{}}, nil
 	case V4R1, V4R2:
 		return &SpecV4R2{regular, SpecSeqno{}}, nil
+	case V5R1:
+		return &SpecV5R1{regular, SpecSeqno{}}, nil
 	case HighloadV2R2, HighloadV2Verified:
 		return &SpecHighloadV2R2{regular, SpecQuery{}}, nil
+	case HighloadV3:
+		return &SpecHighloadV3{
+			SpecRegular: regular,
+			timeout:     DefaultHighloadV3Timeout,
+			queryIDs:    &memoryQueryIDStorage{},
+		}, nil
+	case Lockup:
+		return &SpecLockup{SpecRegular: regular}, nil
 	}
 
 	return nil, fmt.Errorf("cannot init spec: %w", ErrUnsupportedWalletVersion)
@@ -188,19 +226,38 @@ func (w *Wallet) WalletAddress() *address.Address {
 	return w.addr.Bounce(false)
 }
 
+// PrivateKey - returns private key of the wallet, nil if wallet is not created from the in-process key
 func (w *Wallet) PrivateKey() ed25519.PrivateKey {
-	return w.key
+	if ks, ok := w.signer.(*KeySigner); ok {
+		return ks.PrivateKey()
+	}
+	return nil
+}
+
+func (w *Wallet) PublicKey() ed25519.PublicKey {
+	return w.signer.PublicKey()
+}
+
+func (w *Wallet) Signer() Signer {
+	return w.signer
 }
 
 func (w *Wallet) GetSubwallet(subwallet uint32) (*Wallet, error) {
-	addr, err := AddressFromPubKey(w.key.Public().(ed25519.PublicKey), w.ver, subwallet)
+	if hl, ok := w.spec.(*SpecHighloadV3); ok {
+		return FromSignerHighloadV3(w.api, w.signer, subwallet, hl.timeout)
+	}
+	if l, ok := w.spec.(*SpecLockup); ok {
+		return FromSignerLockup(w.api, w.signer, subwallet, l.config)
+	}
+
+	addr, err := AddressFromPubKey(w.signer.PublicKey(), w.ver, subwallet)
 	if err != nil {
 		return nil, err
 	}
 
 	sub := &Wallet{
 		api:       w.api,
-		key:       w.key,
+		signer:    w.signer,
 		addr:      addr,
 		ver:       w.ver,
 		subwallet: subwallet,
@@ -241,31 +298,14 @@ func (w *Wallet) BuildMessageForMany(ctx context.Context, messages []*Message) (
 }
 
 func (w *Wallet) BuildExternalMessageForMany(ctx context.Context, messages []*Message) (*tlb.ExternalMessage, error) {
-	var stateInit *tlb.StateInit
-
-	block, err := w.api.CurrentMasterchainInfo(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get block: %w", err)
-	}
-
-	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, w.addr)
+	block, initialized, stateInit, err := w.prepareExternal(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get account state: %w", err)
-	}
-
-	initialized := true
-	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
-		initialized = false
-
-		stateInit, err = GetStateInit(w.key.Public().(ed25519.PublicKey), w.ver, w.subwallet)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get state init: %w", err)
-		}
+		return nil, err
 	}
 
 	var msg *cell.Cell
 	switch w.ver {
-	case V3R2, V3R1, V4R2, V4R1:
+	case V1R1, V1R2, V1R3, V2R1, V2R2, V3R2, V3R1, V4R2, V4R1, V5R1, Lockup:
 		msg, err = w.spec.(RegularBuilder).BuildMessage(ctx, initialized, block, messages)
 		if err != nil {
 			return nil, fmt.Errorf("build message err: %w", err)
@@ -275,6 +315,11 @@ func (w *Wallet) BuildExternalMessageForMany(ctx context.Context, messages []*Me
 		if err != nil {
 			return nil, fmt.Errorf("build message err: %w", err)
 		}
+	case HighloadV3:
+		msg, err = w.spec.(*SpecHighloadV3).BuildMessage(ctx, messages)
+		if err != nil {
+			return nil, fmt.Errorf("build message err: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("send is not yet supported: %w", ErrUnsupportedWalletVersion)
 	}
@@ -286,6 +331,83 @@ func (w *Wallet) BuildExternalMessageForMany(ctx context.Context, messages []*Me
 	}, nil
 }
 
+// prepareExternal - returns current master block, is wallet initialized, and its state init when it is not
+func (w *Wallet) prepareExternal(ctx context.Context) (*ton.BlockIDExt, bool, *tlb.StateInit, error) {
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if acc.IsActive && acc.State.Status == tlb.AccountStatusActive {
+		return block, true, nil, nil
+	}
+
+	var stateInit *tlb.StateInit
+	switch spec := w.spec.(type) {
+	case *SpecHighloadV3:
+		stateInit, err = GetHighloadV3StateInit(w.signer.PublicKey(), w.subwallet, spec.timeout)
+	case *SpecLockup:
+		stateInit, err = GetLockupStateInit(w.signer.PublicKey(), w.subwallet, spec.config)
+	default:
+		stateInit, err = GetStateInit(w.signer.PublicKey(), w.ver, w.subwallet)
+	}
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to get state init: %w", err)
+	}
+
+	return block, false, stateInit, nil
+}
+
+// getSeqno - runs seqno get method of the wallet contract,
+// V1R1 has no get methods, so for it seqno is loaded from the beginning of the wallet data
+func getSeqno(ctx context.Context, api TonAPI, block *ton.BlockIDExt, addr *address.Address, ver Version) (uint32, error) {
+	if ver == V1R1 {
+		acc, err := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get account state: %w", err)
+		}
+
+		if !acc.IsActive || acc.Data == nil {
+			return 0, errors.New("wallet is not active")
+		}
+
+		seq, err := acc.Data.BeginParse().LoadUInt(32)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse seqno: %w", err)
+		}
+		return uint32(seq), nil
+	}
+
+	resp, err := api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, addr, "seqno")
+	if err != nil {
+		return 0, fmt.Errorf("get seqno err: %w", err)
+	}
+
+	iSeq, err := resp.Int(0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse seqno: %w", err)
+	}
+	return uint32(iSeq.Uint64()), nil
+}
+
+// sign - signs hash of the cell with wallet signer
+func (w *Wallet) sign(ctx context.Context, c *cell.Cell) ([]byte, error) {
+	sign, err := w.signer.Sign(ctx, c.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	if len(sign) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("incorrect signature size %d", len(sign))
+	}
+	return sign, nil
+}
+
 func (w *Wallet) BuildTransfer(to *address.Address, amount tlb.Coins, bounce bool, comment string) (_ *Message, err error) {
 	var body *cell.Cell
 	if comment != "" {
@@ -315,7 +437,17 @@ func (w *Wallet) BuildTransferEncrypted(ctx context.Context, to *address.Address
 			return nil, fmt.Errorf("failed to get destination contract (wallet) public key")
 		}
 
-		body, err = CreateEncryptedCommentCell(comment, w.WalletAddress(), w.key, key)
+		sk, ok := w.signer.(SharedKeySigner)
+		if !ok {
+			return nil, ErrSharedKeyNotSupported
+		}
+
+		sharedKey, err := sk.SharedKey(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute shared key: %w", err)
+		}
+
+		body, err = createEncryptedCommentCell(comment, w.WalletAddress(), sk.PublicKey(), sharedKey, key)
 		if err != nil {
 			return nil, err
 		}
@@ -369,6 +501,13 @@ func (w *Wallet) SendWaitTransaction(ctx context.Context, message *Message) (*tl
 }
 
 func (w *Wallet) sendMany(ctx context.Context, messages []*Message, waitConfirmation ...bool) (tx *tlb.Transaction, block *ton.BlockIDExt, inMsgHash []byte, err error) {
+	return w.sendExternal(ctx, func(ctx context.Context) (*tlb.ExternalMessage, error) {
+		return w.BuildExternalMessageForMany(ctx, messages)
+	}, waitConfirmation...)
+}
+
+// sendExternal - sends external message created by build, and waits for its transaction if needed
+func (w *Wallet) sendExternal(ctx context.Context, build func(ctx context.Context) (*tlb.ExternalMessage, error), waitConfirmation ...bool) (tx *tlb.Transaction, block *ton.BlockIDExt, inMsgHash []byte, err error) {
 	block, err = w.api.CurrentMasterchainInfo(ctx)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to get block: %w", err)
@@ -379,7 +518,7 @@ func (w *Wallet) sendMany(ctx context.Context, messages []*Message, waitConfirma
 		return nil, nil, nil, fmt.Errorf("failed to get account state: %w", err)
 	}
 
-	ext, err := w.BuildExternalMessageForMany(ctx, messages)
+	ext, err := build(ctx)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -588,14 +727,18 @@ func DecryptCommentCell(commentCell *cell.Cell, sender *address.Address, ourKey
 }
 
 func CreateEncryptedCommentCell(text string, senderAddr *address.Address, ourKey ed25519.PrivateKey, theirKey ed25519.PublicKey) (*cell.Cell, error) {
-	// encrypted comment op code
-	root := cell.BeginCell().MustStoreUInt(EncryptedCommentOpcode, 32)
-
 	sharedKey, err := adnl.SharedKey(ourKey, theirKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute shared key: %w", err)
 	}
 
+	return createEncryptedCommentCell(text, senderAddr, ourKey.Public().(ed25519.PublicKey), sharedKey, theirKey)
+}
+
+func createEncryptedCommentCell(text string, senderAddr *address.Address, ourKey ed25519.PublicKey, sharedKey []byte, theirKey ed25519.PublicKey) (*cell.Cell, error) {
+	// encrypted comment op code
+	root := cell.BeginCell().MustStoreUInt(EncryptedCommentOpcode, 32)
+
 	data := []byte(text)
 
 	pfxSz := 16
@@ -605,7 +748,7 @@ func CreateEncryptedCommentCell(text string, senderAddr *address.Address, ourKey
 
 	pfx := make([]byte, pfxSz)
 	pfx[0] = byte(len(pfx))
-	if _, err = rand.Read(pfx[1:]); err != nil {
+	if _, err := rand.Read(pfx[1:]); err != nil {
 		return nil, fmt.Errorf("rand gen err: %w", err)
 	}
 	data = append(pfx, data...)
@@ -626,7 +769,7 @@ func CreateEncryptedCommentCell(text string, senderAddr *address.Address, ourKey
 	enc := cipher.NewCBCEncrypter(c, x[32:48])
 	enc.CryptBlocks(data, data)
 
-	xorKey := ourKey.Public().(ed25519.PublicKey)
+	xorKey := append(ed25519.PublicKey{}, ourKey...)
 	for i := 0; i < 32; i++ {
 		xorKey[i] ^= theirKey[i]
 	}
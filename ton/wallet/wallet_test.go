@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -69,6 +70,88 @@ func (m MockAPI) ListTransactions(ctx context.Context, addr *address.Address, li
 	return m.listTransactions(ctx, addr, limit, lt, txHash)
 }
 
+// testChain - emulates transactions of multiple accounts and masterchain height,
+// transactions are linked to the previous ones of the same account like in the real chain
+type testChain struct {
+	mx     sync.Mutex
+	master uint32
+	txs    map[string][]*tlb.Transaction
+}
+
+func newTestChain(master uint32) *testChain {
+	return &testChain{master: master, txs: map[string][]*tlb.Transaction{}}
+}
+
+// appendTx - adds transaction with the incoming message to the account, lock should be held by caller
+func (c *testChain) appendTx(addr *address.Address, in *tlb.Message) *tlb.Transaction {
+	list := c.txs[rawAddr(addr)]
+	tx := &tlb.Transaction{
+		LT:   uint64(len(list)+1) * 1000,
+		Hash: []byte{byte(len(list) + 1)},
+	}
+	if len(list) > 0 {
+		tx.PrevTxLT, tx.PrevTxHash = list[len(list)-1].LT, list[len(list)-1].Hash
+	}
+	tx.IO.In = in
+	c.txs[rawAddr(addr)] = append(list, tx)
+	return tx
+}
+
+// accountTxs - returns transactions of the account from the oldest one
+func (c *testChain) accountTxs(addr *address.Address) []*tlb.Transaction {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return append([]*tlb.Transaction{}, c.txs[rawAddr(addr)]...)
+}
+
+func (c *testChain) nextBlocks(n uint32) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.master += n
+}
+
+// api - mock which returns chain height, accounts with their last transactions and transactions lists,
+// get methods and external messages should be set by the test
+func (c *testChain) api() *MockAPI {
+	m := &MockAPI{}
+	m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+
+		return &ton.BlockIDExt{SeqNo: c.master}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+
+		list := c.txs[rawAddr(addr)]
+		if len(list) == 0 {
+			return &tlb.Account{}, nil
+		}
+		return &tlb.Account{
+			IsActive:   true,
+			LastTxLT:   list[len(list)-1].LT,
+			LastTxHash: list[len(list)-1].Hash,
+		}, nil
+	}
+	m.listTransactions = func(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error) {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+
+		all := c.txs[rawAddr(addr)]
+		var list []*tlb.Transaction
+		for i := len(all) - 1; i >= 0 && len(list) < int(limit); i-- {
+			if all[i].LT <= lt {
+				list = append([]*tlb.Transaction{all[i]}, list...)
+			}
+		}
+		return list, nil
+	}
+	return m
+}
+
 // cases
 const (
 	OK = iota
@@ -362,7 +445,7 @@ func checkV4R2(t *testing.T, p *cell.Slice, w *Wallet, flow int, intMsg *tlb.Int
 		t.Fatal("int msg incorrect")
 	}
 
-	if !ed25519.Verify(w.key.Public().(ed25519.PublicKey), payload.EndCell().Hash(), sign) {
+	if !ed25519.Verify(w.PublicKey(), payload.EndCell().Hash(), sign) {
 		t.Fatal("sign incorrect")
 	}
 }
@@ -404,7 +487,7 @@ func checkV3(t *testing.T, p *cell.Slice, w *Wallet, flow int, intMsg *tlb.Inter
 		t.Fatal("int msg incorrect")
 	}
 
-	if !ed25519.Verify(w.key.Public().(ed25519.PublicKey), payload.EndCell().Hash(), sign) {
+	if !ed25519.Verify(w.PublicKey(), payload.EndCell().Hash(), sign) {
 		t.Fatal("sign incorrect")
 	}
 }
@@ -442,7 +525,7 @@ func checkHighloadV2R2(t *testing.T, p *cell.Slice, w *Wallet, intMsg *tlb.Inter
 		MustStoreUInt(qid, 64).
 		MustStoreDict(dict)
 
-	if !ed25519.Verify(w.key.Public().(ed25519.PublicKey), payload.EndCell().Hash(), sign) {
+	if !ed25519.Verify(w.PublicKey(), payload.EndCell().Hash(), sign) {
 		t.Fatal("sign incorrect")
 	}
 }
@@ -472,6 +555,101 @@ func (w WaiterMock) GetLibraries(ctx context.Context, list ...[]byte) ([]*cell.C
 	panic("implement me")
 }
 
+func (w WaiterMock) GetLibrariesWithProof(ctx context.Context, master *ton.BlockIDExt, list ...[]byte) ([]*cell.Cell, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) LookupBlockWithProof(ctx context.Context, master *ton.BlockIDExt, workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) LookupBlockByLTWithProof(ctx context.Context, master *ton.BlockIDExt, workchain int32, shard int64, lt uint64) (*ton.BlockIDExt, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) LookupBlockByUTimeWithProof(ctx context.Context, master *ton.BlockIDExt, workchain int32, shard int64, utime uint32) (*ton.BlockIDExt, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetBlockTransactionsWithMetadata(ctx context.Context, block *ton.BlockIDExt, count uint32, after ...*ton.TransactionID3) ([]ton.TransactionShortInfo, bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetBlockTransactionsExt(ctx context.Context, block *ton.BlockIDExt, count uint32, after ...*ton.TransactionID3) ([]*tlb.Transaction, bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetShardInfo(ctx context.Context, master *ton.BlockIDExt, workchain int32, shard int64, exact bool) (*ton.BlockIDExt, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetShardBlockProof(ctx context.Context, block *ton.BlockIDExt) (*ton.ShardBlockProof, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetOutMsgQueueSizes(ctx context.Context) (*ton.OutMsgQueueSizes, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetShardOutMsgQueueSizes(ctx context.Context, workchain int32, shard int64) (*ton.OutMsgQueueSizes, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetBlockOutMsgQueueSize(ctx context.Context, block *ton.BlockIDExt) (uint64, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetDispatchQueueInfo(ctx context.Context, block *ton.BlockIDExt, afterAddr []byte, maxAccounts int32) ([]ton.AccountDispatchQueueInfo, bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetDispatchQueueMessages(ctx context.Context, block *ton.BlockIDExt, addr *address.Address, afterLT uint64, maxMessages int32, oneAccount bool) ([]ton.DispatchQueueMessage, bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetValidatorStats(ctx context.Context, master *ton.BlockIDExt, limit int32, startAfter []byte, modifiedAfter uint32) ([]*ton.CreatorStats, bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetBlockHeader(ctx context.Context, block *ton.BlockIDExt) (*tlb.BlockHeader, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) VerifyBlockID(ctx context.Context, master, block *ton.BlockIDExt) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetAccountMeta(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*ton.AccountMeta, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) GetShardState(ctx context.Context, block *ton.BlockIDExt) (*ton.ShardState, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) ListTransactionsVerified(ctx context.Context, master *ton.BlockIDExt, addr *address.Address, limit uint32, from *tlb.Transaction) ([]*tlb.Transaction, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (w WaiterMock) SetTrustedBlock(block *ton.BlockIDExt) {
 	//TODO implement me
 	panic("implement me")
@@ -565,6 +743,91 @@ func (w WaiterMock) GetTransaction(ctx context.Context, block *ton.BlockIDExt, a
 	return w.MGetTransaction(ctx, block, addr, lt)
 }
 
+func TestWallet_SendV1V2(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	dst := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	for _, ver := range []Version{V1R1, V1R3, V2R1, V2R2} {
+		for _, initialized := range []bool{true, false} {
+			m := &MockAPI{}
+			m.getBlockInfo = func(ctx context.Context) (*ton.BlockIDExt, error) {
+				return &ton.BlockIDExt{SeqNo: 2}, nil
+			}
+			m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+				if !initialized {
+					return &tlb.Account{}, nil
+				}
+				return &tlb.Account{
+					IsActive: true,
+					State: &tlb.AccountState{
+						IsValid: true,
+						AccountStorage: tlb.AccountStorage{
+							Status: tlb.AccountStatusActive,
+						},
+					},
+					Data: cell.BeginCell().MustStoreUInt(5, 32).MustStoreSlice(pkey.Public().(ed25519.PublicKey), 256).EndCell(),
+				}, nil
+			}
+			m.runGetMethod = func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+				if ver == V1R1 || method != "seqno" {
+					t.Fatal("unexpected method", ver, method)
+				}
+				return ton.NewExecutionResult([]any{big.NewInt(5)}), nil
+			}
+
+			w, err := FromPrivateKey(m, pkey, ver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg := &Message{Mode: 3, InternalMessage: &tlb.InternalMessage{DstAddr: dst, Amount: tlb.MustFromTON("1"), Body: cell.BeginCell().EndCell()}}
+			ext, err := w.BuildExternalMessage(context.Background(), msg)
+			if err != nil {
+				t.Fatal(ver, err)
+			}
+
+			if initialized == (ext.StateInit != nil) {
+				t.Fatal("incorrect state init", ver)
+			}
+			if !initialized && !bytes.Equal(ext.StateInit.Code.Hash(), walletCode[ver].Hash()) {
+				t.Fatal("incorrect state init code", ver)
+			}
+
+			p := ext.Body.BeginParse()
+			sign := p.MustLoadSlice(512)
+			if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), p.MustToCell().Hash(), sign) {
+				t.Fatal("incorrect signature", ver)
+			}
+
+			seq := uint64(5)
+			if !initialized {
+				seq = 0
+			}
+			if p.MustLoadUInt(32) != seq {
+				t.Fatal("incorrect seqno", ver)
+			}
+			if ver >= V2R1 && p.MustLoadUInt(32) != 1000000+60*3 {
+				t.Fatal("incorrect valid until", ver)
+			}
+			if p.MustLoadUInt(8) != 3 || p.MustLoadRef() == nil || p.BitsLeft() != 0 || p.RefsNum() != 0 {
+				t.Fatal("incorrect message", ver)
+			}
+
+			limit := 4
+			if ver < V2R1 {
+				limit = 1
+			}
+			if _, err = w.BuildExternalMessageForMany(context.Background(), []*Message{msg, msg, msg, msg, msg}[:limit+1]); err == nil {
+				t.Fatal("too many messages should be rejected", ver)
+			}
+		}
+	}
+}
+
 func TestCreateEncryptedCommentCell(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		pub1, priv1, err := ed25519.GenerateKey(nil)
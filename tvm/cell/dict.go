@@ -313,19 +313,51 @@ func (d *Dictionary) All() []*HashmapKV {
 }
 
 func (d *Dictionary) LoadAll() ([]DictKV, error) {
+	return d.loadAll(false)
+}
+
+// LoadAllProven - same as LoadAll, but for dictionaries from merkle proofs,
+// pruned branches are skipped, so only keys which are included in proof are returned.
+func (d *Dictionary) LoadAllProven() ([]DictKV, error) {
+	return d.loadAll(true)
+}
+
+// ForEach - calls fn for each key and value in keys order, without loading all of them in memory at once,
+// so it can be used for huge dictionaries. Iteration stops on the first error returned by fn, and this error is returned.
+func (d *Dictionary) ForEach(fn func(key, value *Slice) error) error {
 	if d.root == nil {
-		return []DictKV{}, nil
+		return nil
+	}
+	return d.walk(d.keySz, d.keySz, d.root.BeginParse(), BeginCell(), false, fn)
+}
+
+func (d *Dictionary) loadAll(skipPruned bool) ([]DictKV, error) {
+	list := []DictKV{}
+	if d.root == nil {
+		return list, nil
+	}
+
+	err := d.walk(d.keySz, d.keySz, d.root.BeginParse(), BeginCell(), skipPruned, func(key, value *Slice) error {
+		list = append(list, DictKV{Key: key, Value: value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return d.mapInner(d.keySz, d.keySz, d.root.BeginParse(), BeginCell())
+	return list, nil
 }
 
-func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *Builder) ([]DictKV, error) {
+func (d *Dictionary) walk(keySz, leftKeySz uint, loader *Slice, keyPrefix *Builder, skipPruned bool, fn func(key, value *Slice) error) error {
+	if skipPruned && loader.IsSpecial() {
+		return nil
+	}
+
 	var err error
 	var sz uint
 
 	sz, keyPrefix, err = loadLabel(leftKeySz, loader, keyPrefix)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// until key size is not equals we go deeper
@@ -333,31 +365,22 @@ func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *B
 		// 0 bit branch
 		left, err := loader.LoadRef()
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		keysL, err := d.mapInner(keySz, leftKeySz-(1+sz), left, keyPrefix.Copy().MustStoreUInt(0, 1))
-		if err != nil {
-			return nil, err
+		if err = d.walk(keySz, leftKeySz-(1+sz), left, keyPrefix.Copy().MustStoreUInt(0, 1), skipPruned, fn); err != nil {
+			return err
 		}
 
 		// 1 bit branch
 		right, err := loader.LoadRef()
 		if err != nil {
-			return nil, err
-		}
-		keysR, err := d.mapInner(keySz, leftKeySz-(1+sz), right, keyPrefix.Copy().MustStoreUInt(1, 1))
-		if err != nil {
-			return nil, err
+			return err
 		}
-
-		return append(keysL, keysR...), nil
+		return d.walk(keySz, leftKeySz-(1+sz), right, keyPrefix.Copy().MustStoreUInt(1, 1), skipPruned, fn)
 	}
 
-	return []DictKV{{
-		Key:   keyPrefix.ToSlice(),
-		Value: loader,
-	}}, nil
+	return fn(keyPrefix.ToSlice(), loader)
 }
 
 func (d *Dictionary) findKey(branch *Cell, lookupKey *Cell, at *ProofSkeleton) (*Slice, *ProofSkeleton, error) {
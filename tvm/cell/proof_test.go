@@ -200,4 +200,17 @@ func TestProofDictKey(t *testing.T) {
 	if err == nil {
 		t.Fatal("should not be accessible")
 	}
+
+	all, err := dp.LoadAllProven()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatal("incorrect proven keys num", len(all))
+	}
+	for i, k := range []uint64{111, 333, 777} {
+		if all[i].Key.MustLoadUInt(64) != k {
+			t.Fatal("incorrect proven key", i)
+		}
+	}
 }